@@ -27,6 +27,8 @@ func TweetToProto (t *model.Tweet) *pb.Tweet{
 		*/
 		Timestamp: time.Time(t.Timestamp).UnixMilli(),
 		ReplyingTo: t.ReplyingTo,
+		//the Tweet message has no poll or media field, and there is no GetTweet RPC. ListTweets and SaveTweet send them in
+		//the X-Tweet-Polls and X-Tweet-Media-Layout headers, GET /polls and GET /tweets/media answer them for one tweet
 	}
 }
 
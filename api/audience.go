@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"net/textproto"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	"google.golang.org/grpc/metadata"
+)
+
+//AudienceHeader picks the audience of a new tweet: public(the default), followers or mentioned. The SaveTweet message has
+//no field for it, so it travels next to the message, as the x-tweet-audience metadata over gRPC
+const AudienceHeader = "X-Tweet-Audience"
+
+var audienceMetadata = strings.ToLower(AudienceHeader)
+
+//IncomingHeaderMatcher is a runtime.WithIncomingHeaderMatcher that passes AudienceHeader, PollHeader and MediaHeader to
+//SaveTweet as metadata. Every other header is matched like the gateway does by default
+func IncomingHeaderMatcher(key string) (string, bool) {
+	switch textproto.CanonicalMIMEHeaderKey(key) {
+	case AudienceHeader:
+		return audienceMetadata, true
+	case PollHeader:
+		return pollMetadata, true
+	case MediaHeader:
+		return mediaMetadata, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+//requestedAudience is the audience the client asked for, or nil for a public tweet. The level is checked with the rest of the tweet
+func requestedAudience(ctx context.Context) *model.Audience {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	v := md.Get(audienceMetadata)
+	if len(v) == 0 || strings.TrimSpace(v[0]) == "" {
+		return nil
+	}
+	return &model.Audience{Level: model.AudienceLevel(v[0])}
+}
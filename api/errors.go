@@ -0,0 +1,58 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//toStatusError maps the errors the service layer can explain to the client onto gRPC status codes. The gateway turns them into the matching HTTP status.
+//Anything else is returned as is(the client sees codes.Unknown)
+func toStatusError(err error) error {
+	var invalid *tweetsservice.ValidationError
+	switch {
+	case errors.Is(err, cursor.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &invalid):
+		return validationStatus(invalid)
+	case errors.Is(err, tweetsservice.ErrBlocked):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, tweetsrepo.ErrTweetNotFound):
+		//also what a reply to a tweet the author can't see gets
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, tweetsservice.ErrNoPoll):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, tweetsrepo.ErrAlreadyVoted):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, tweetsrepo.ErrPollClosed):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, tweetsservice.ErrUnsupportedMedia), errors.Is(err, tweetsservice.ErrMediaTooLarge), errors.Is(err, tweetsservice.ErrChecksumMismatch):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, tweetsrepo.ErrMediaUnavailable):
+		//another tweet took the media between the checks and the write
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, tweetsservice.ErrMediaDisabled):
+		return status.Error(codes.Unimplemented, err.Error())
+	default:
+		return err
+	}
+}
+
+//validationStatus lists every invalid field in a BadRequest detail, so clients can show the error next to the field
+func validationStatus(err *tweetsservice.ValidationError) error {
+	st := status.New(codes.InvalidArgument, err.Error())
+	badRequest := &errdetails.BadRequest{}
+	for _, v := range err.Violations {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: v.Field, Description: v.Description})
+	}
+	withDetails, detailsErr := st.WithDetails(badRequest)
+	if detailsErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
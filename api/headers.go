@@ -0,0 +1,67 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+//maxTweetsHeader is the most bytes of JSON sendTweetsHeader puts in a header. Proxies and browsers cap all the headers
+//of a response at 8 to 16 KiB, and a page of tweets may have both the polls and the media layout
+const maxTweetsHeader = 4 << 10
+
+//NextHeaderSuffix names the header that tells a JSON header of tweets was cut short: it holds the id of the first tweet
+//left out. Clients get it and the tweets after it one by one(GET /polls, GET /tweets/media).
+//eg: Grpc-Metadata-X-Tweet-Media-Layout-Next: 0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44
+const NextHeaderSuffix = "-Next"
+
+//sendTweetsHeader sends header with a JSON object of the value of each tweet, keyed by the tweet id, in the order of the
+//tweets. Tweets without a value are left out and nothing is sent when none has one. The object stops before it grows
+//past maxTweetsHeader
+func sendTweetsHeader(ctx context.Context, header string, tweets []*model.Tweet, value func(tweet *model.Tweet) (interface{}, bool)) {
+	var b bytes.Buffer
+	var next string
+	for _, tweet := range tweets {
+		v, ok := value(tweet)
+		if !ok {
+			continue
+		}
+		id, _ := json.Marshal(tweet.Id)
+		entry, err := json.Marshal(v)
+		if err != nil {
+			slog.ErrorContext(ctx, "unable to encode the header", "header", header, "err", err)
+			return
+		}
+		//the separator, the colon and the closing brace
+		if b.Len()+len(id)+len(entry)+3 > maxTweetsHeader {
+			next = tweet.Id
+			break
+		}
+		if b.Len() == 0 {
+			b.WriteByte('{')
+		} else {
+			b.WriteByte(',')
+		}
+		b.Write(id)
+		b.WriteByte(':')
+		b.Write(entry)
+	}
+
+	var kv []string
+	if b.Len() > 0 {
+		b.WriteByte('}')
+		kv = append(kv, strings.ToLower(header), b.String())
+	}
+	if next != "" {
+		kv = append(kv, strings.ToLower(header+NextHeaderSuffix), next)
+	}
+	if len(kv) > 0 {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(kv...))
+	}
+}
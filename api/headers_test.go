@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func Test_sendTweetsHeader(t *testing.T) {
+	//a page of 100 tweets with 4 images each is tens of KiB of layout
+	var tweets []*model.Tweet
+	for i := 0; i < 100; i++ {
+		tweet := &model.Tweet{Id: fmt.Sprintf("tweet-%03d", i)}
+		for j := 0; j < 4; j++ {
+			tweet.Media = append(tweet.Media, &model.Media{Id: fmt.Sprintf("0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2%03d", j), ContentType: "image/jpeg", Width: 1080, Height: 1350, Placeholder: "LEHV6nWB2yk8pyo0adR*.7kCMdnj"})
+		}
+		tweets = append(tweets, tweet)
+	}
+
+	stream := &fakeTransportStream{}
+	sendMediaLayout(grpc.NewContextWithServerTransportStream(context.Background(), stream), tweets)
+	header := strings.Join(stream.header.Get("x-tweet-media-layout"), "")
+	assert.LessOrEqual(t, len(header), maxTweetsHeader)
+
+	var layout map[string][]mediaLayout
+	if assert.NoError(t, json.Unmarshal([]byte(header), &layout)) {
+		assert.NotEmpty(t, layout)
+		for i := 0; i < len(layout); i++ {
+			assert.Len(t, layout[tweets[i].Id], 4, "the first tweets of the page are kept whole")
+		}
+		assert.Equal(t, []string{tweets[len(layout)].Id}, stream.header.Get("x-tweet-media-layout-next"))
+	}
+
+	stream = &fakeTransportStream{}
+	sendMediaLayout(grpc.NewContextWithServerTransportStream(context.Background(), stream), tweets[:2])
+	assert.Empty(t, stream.header.Get("x-tweet-media-layout-next"), "a short page fits")
+	assert.NotEmpty(t, stream.header.Get("x-tweet-media-layout"))
+}
@@ -2,11 +2,15 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
 	health_v1 "google.golang.org/grpc/health/grpc_health_v1"
-	"google.golang.org/grpc/status"
 )
 
 type InProcessHealthClient struct {
@@ -19,16 +23,127 @@ func (client *InProcessHealthClient) Check(ctx context.Context, req *health_v1.H
 	return client.Server.Check(ctx, req)
 }
 
+//HealthCheck is a dependency the service can't work without. eg: the DynamoDB tables
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+//HealthServer reports SERVING for the whole server("") and for every service in services while all the checks pass.
+//The checks run in the background every interval(HEALTH_CHECK_INTERVAL), so Check, Watch and the probes never wait on a dependency
 type HealthServer struct {
-	health_v1.UnimplementedHealthServer
+	//Check, Watch and the per service statuses. Its Shutdown flips every service to NOT_SERVING for good, so the load balancer
+	//stops sending traffic before the servers drain
+	*health.Server
+
+	services []string
+	checks   []HealthCheck
+	interval time.Duration
+	timeout  time.Duration
+
+	mu      sync.Mutex
+	results map[string]error //last result of every check
 }
 
-func (m *HealthServer) Check(ctx context.Context, req *health_v1.HealthCheckRequest) (*health_v1.HealthCheckResponse, error) {
-	// we ignore call options since it is in-process
-	// return m.Server.Check(ctx, req)
-	return &health_v1.HealthCheckResponse{Status: health_v1.HealthCheckResponse_SERVING}, nil
+//NewHealthServer starts NOT_SERVING. Call Run to start probing
+func NewHealthServer(services []string, interval, timeout time.Duration, checks ...HealthCheck) *HealthServer {
+	h := &HealthServer{
+		Server:   health.NewServer(),
+		services: services,
+		checks:   checks,
+		interval: interval,
+		timeout:  timeout,
+		results:  make(map[string]error, len(checks)),
+	}
+	h.setStatus(health_v1.HealthCheckResponse_NOT_SERVING)
+	return h
 }
 
-func (m *HealthServer) Watch(_ *health_v1.HealthCheckRequest, _ health_v1.Health_WatchServer) error {
-	return status.Error(codes.Unimplemented, "unimplemented")
-}
\ No newline at end of file
+//Run probes straight away and then every interval until ctx is done
+func (h *HealthServer) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		h.probe(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *HealthServer) probe(ctx context.Context) {
+	results := make(map[string]error, len(h.checks))
+	status := health_v1.HealthCheckResponse_SERVING
+	for _, c := range h.checks {
+		checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+		err := c.Check(checkCtx)
+		cancel()
+
+		results[c.Name] = err
+		if err != nil {
+			status = health_v1.HealthCheckResponse_NOT_SERVING
+		}
+	}
+
+	h.mu.Lock()
+	for name, err := range results {
+		//only log changes, the probe runs every few seconds
+		if prev, seen := h.results[name]; !seen || (prev == nil) != (err == nil) {
+			if err != nil {
+				slog.Warn("health check failing", "check", name, "err", err)
+			} else {
+				slog.Info("health check ok", "check", name)
+			}
+		}
+	}
+	h.results = results
+	h.mu.Unlock()
+
+	h.setStatus(status)
+}
+
+func (h *HealthServer) setStatus(status health_v1.HealthCheckResponse_ServingStatus) {
+	h.SetServingStatus("", status)
+	for _, s := range h.services {
+		h.SetServingStatus(s, status)
+	}
+}
+
+type readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+//LivenessHandler answers 200 as long as the process can serve http. It does not look at dependencies:
+//restarting the pod would not bring DynamoDB back
+func (h *HealthServer) LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(readiness{Status: "alive"})
+	}
+}
+
+//ReadinessHandler answers 200 while the server is SERVING and 503 otherwise, with the result of every check
+func (h *HealthServer) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, _ := h.Check(r.Context(), &health_v1.HealthCheckRequest{})
+		body := readiness{Status: res.GetStatus().String(), Checks: map[string]string{}}
+
+		h.mu.Lock()
+		for name, err := range h.results {
+			body.Checks[name] = "ok"
+			if err != nil {
+				body.Checks[name] = err.Error()
+			}
+		}
+		h.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if res.GetStatus() != health_v1.HealthCheckResponse_SERVING {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(body)
+	}
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	health_v1 "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const fakeService = "tweet.v1.TweetService"
+
+func TestHealthServer_Check(t *testing.T) {
+	testCases := []struct {
+		name     string
+		checkErr error
+		probe    bool
+		shutdown bool
+
+		expectedStatus health_v1.HealthCheckResponse_ServingStatus
+	}{
+		{
+			name:           "should not serve before the first probe",
+			expectedStatus: health_v1.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:           "should serve when every check passes",
+			probe:          true,
+			expectedStatus: health_v1.HealthCheckResponse_SERVING,
+		},
+		{
+			name:           "should not serve when a check fails",
+			checkErr:       errors.New("ResourceNotFoundException"),
+			probe:          true,
+			expectedStatus: health_v1.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:           "should not serve after shutdown even when the checks pass",
+			probe:          true,
+			shutdown:       true,
+			expectedStatus: health_v1.HealthCheckResponse_NOT_SERVING,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHealthServer([]string{fakeService}, time.Minute, time.Second,
+				HealthCheck{Name: "dynamodb", Check: func(ctx context.Context) error { return tc.checkErr }})
+			if tc.probe {
+				h.probe(context.Background())
+			}
+			if tc.shutdown {
+				h.Shutdown()
+				h.probe(context.Background())
+			}
+
+			for _, service := range []string{"", fakeService} {
+				res, err := h.Check(context.Background(), &health_v1.HealthCheckRequest{Service: service})
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedStatus, res.GetStatus(), service)
+			}
+
+			_, err := h.Check(context.Background(), &health_v1.HealthCheckRequest{Service: "unknown.Service"})
+			assert.Equal(t, codes.NotFound, status.Code(err))
+		})
+	}
+}
+
+func TestHealthServer_Watch(t *testing.T) {
+	var checkErr error
+	h := NewHealthServer([]string{fakeService}, time.Minute, time.Second,
+		HealthCheck{Name: "dynamodb", Check: func(ctx context.Context) error { return checkErr }})
+
+	lis := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	health_v1.RegisterHealthServer(grpcServer, h)
+	go grpcServer.Serve(lis)
+	defer grpcServer.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	stream, err := health_v1.NewHealthClient(conn).Watch(ctx, &health_v1.HealthCheckRequest{Service: fakeService})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() health_v1.HealthCheckResponse_ServingStatus {
+		res, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.GetStatus()
+	}
+
+	assert.Equal(t, health_v1.HealthCheckResponse_NOT_SERVING, next())
+	h.probe(ctx)
+	assert.Equal(t, health_v1.HealthCheckResponse_SERVING, next())
+	checkErr = errors.New("RequestTimeout")
+	h.probe(ctx)
+	assert.Equal(t, health_v1.HealthCheckResponse_NOT_SERVING, next())
+}
+
+func TestHealthServer_Handlers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		checkErr error
+		shutdown bool
+
+		expectedReadyCode int
+		expectedReadyBody string
+	}{
+		{
+			name:              "should be ready when every check passes",
+			expectedReadyCode: http.StatusOK,
+			expectedReadyBody: `{"status":"SERVING","checks":{"dynamodb":"ok"}}`,
+		},
+		{
+			name:              "should not be ready when a check fails",
+			checkErr:          errors.New("table tweets is DELETING"),
+			expectedReadyCode: http.StatusServiceUnavailable,
+			expectedReadyBody: `{"status":"NOT_SERVING","checks":{"dynamodb":"table tweets is DELETING"}}`,
+		},
+		{
+			name:              "should not be ready while shutting down",
+			shutdown:          true,
+			expectedReadyCode: http.StatusServiceUnavailable,
+			expectedReadyBody: `{"status":"NOT_SERVING","checks":{"dynamodb":"ok"}}`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHealthServer([]string{fakeService}, time.Minute, time.Second,
+				HealthCheck{Name: "dynamodb", Check: func(ctx context.Context) error { return tc.checkErr }})
+			h.probe(context.Background())
+			if tc.shutdown {
+				h.Shutdown()
+			}
+
+			ready := httptest.NewRecorder()
+			h.ReadinessHandler()(ready, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tc.expectedReadyCode, ready.Code)
+			assert.JSONEq(t, tc.expectedReadyBody, ready.Body.String())
+
+			//liveness never depends on the checks
+			live := httptest.NewRecorder()
+			h.LivenessHandler()(live, httptest.NewRequest(http.MethodGet, "/livez", nil))
+			assert.Equal(t, http.StatusOK, live.Code)
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package api_http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//draftStatus maps the errors of the drafts to http statuses. Publishing also fails like SaveTweet does
+func draftStatus(err error) int {
+	var invalid *tweetsservice.ValidationError
+	switch {
+	case errors.Is(err, cursor.ErrInvalidCursor), errors.As(err, &invalid):
+		return http.StatusBadRequest
+	case errors.Is(err, tweetsrepo.ErrDraftNotFound), errors.Is(err, tweetsrepo.ErrTweetNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tweetsrepo.ErrDraftVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, tweetsservice.ErrBlocked):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+type draftRequest struct {
+	//Id is empty for a new draft
+	Id         string `json:"id"`
+	Text       string `json:"text"`
+	ReplyingTo string `json:"replyingTo"`
+	Audience   string `json:"audience"`
+	//Version is the version of the draft the client last read
+	Version int64 `json:"version"`
+}
+
+//DraftsHandler saves a draft of the caller(POST) or lists the drafts of the caller(GET). A save answers 409 when the draft
+//was saved from somewhere else since Version; the client reads the drafts again instead of overwriting it.
+//eg: POST /drafts {"id": "0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44", "text": "Half a thought", "version": 3}
+//eg: GET /drafts?limit=20&nextKey=...
+func DraftsHandler(tweetsService tweetsservice.Service) http.HandlerFunc {
+	return requireUser(func(w http.ResponseWriter, r *http.Request, userID string) {
+		switch r.Method {
+		case http.MethodPost:
+			saveDraft(w, r, tweetsService, userID)
+		case http.MethodGet:
+			listDrafts(w, r, tweetsService, userID)
+		default:
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func saveDraft(w http.ResponseWriter, r *http.Request, tweetsService tweetsservice.Service, userID string) {
+	var req draftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		JSONError(w, map[string]interface{}{
+			"message": "invalid body: " + err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	draft := &model.Draft{UserId: userID, Id: req.Id, Text: req.Text, ReplyingTo: req.ReplyingTo, Version: req.Version}
+	if req.Audience != "" {
+		draft.Audience = &model.Audience{Level: model.AudienceLevel(req.Audience)}
+	}
+	saved, err := tweetsService.SaveDraft(r.Context(), draft)
+	if err != nil {
+		JSONError(w, map[string]interface{}{
+			"message": err.Error(),
+		}, draftStatus(err))
+		return
+	}
+
+	status := http.StatusOK
+	if req.Id == "" {
+		status = http.StatusCreated
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(saved)
+}
+
+func listDrafts(w http.ResponseWriter, r *http.Request, tweetsService tweetsservice.Service, userID string) {
+	var limit int64
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.ParseInt(v, 10, 32); err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": "limit must be a number",
+			}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	drafts, nextKey, err := tweetsService.ListDrafts(r.Context(), userID, int32(limit), r.URL.Query().Get("nextKey"))
+	if err != nil {
+		JSONError(w, map[string]interface{}{
+			"message": err.Error(),
+		}, draftStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"drafts":  drafts,
+		"nextKey": nextKey,
+	})
+}
+
+type draftVersionRequest struct {
+	Id string `json:"id"`
+	//Version 0 deletes or publishes the draft whatever was saved since it was read
+	Version int64 `json:"version"`
+}
+
+func decodeDraftVersion(w http.ResponseWriter, r *http.Request) (*draftVersionRequest, bool) {
+	if r.Method != http.MethodPost {
+		JSONError(w, map[string]interface{}{
+			"message": "method not allowed",
+		}, http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	var req draftVersionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		JSONError(w, map[string]interface{}{
+			"message": "invalid body: " + err.Error(),
+		}, http.StatusBadRequest)
+		return nil, false
+	}
+	return &req, true
+}
+
+//DeleteDraftHandler deletes a draft of the caller.
+//eg: POST /drafts/delete {"id": "0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44", "version": 3}
+func DeleteDraftHandler(tweetsService tweetsservice.Service) http.HandlerFunc {
+	return requireUser(func(w http.ResponseWriter, r *http.Request, userID string) {
+		req, ok := decodeDraftVersion(w, r)
+		if !ok {
+			return
+		}
+		if err := tweetsService.DeleteDraft(r.Context(), userID, req.Id, req.Version); err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, draftStatus(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+//PublishDraftHandler publishes a draft of the caller as a tweet and deletes the draft. It answers the tweet.
+//eg: POST /drafts/publish {"id": "0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44", "version": 3}
+func PublishDraftHandler(tweetsService tweetsservice.Service) http.HandlerFunc {
+	return requireUser(func(w http.ResponseWriter, r *http.Request, userID string) {
+		req, ok := decodeDraftVersion(w, r)
+		if !ok {
+			return
+		}
+		tweet, err := tweetsService.PublishDraft(r.Context(), userID, req.Id, req.Version)
+		if err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, draftStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(tweet)
+	})
+}
@@ -0,0 +1,108 @@
+package api_http_handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func Test_DraftsHandler(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		method               string
+		user                 string
+		body                 string
+		buildStubs           func(tweetsService *tweetsservice.MockService)
+		expectedResponseCode int
+	}{
+		{
+			name:   "new draft",
+			method: http.MethodPost,
+			user:   "sarah_edo",
+			body:   `{"text": "Half a thought", "audience": "followers"}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				draft := &model.Draft{UserId: "sarah_edo", Text: "Half a thought", Audience: &model.Audience{Level: model.AudienceFollowers}}
+				tweetsService.EXPECT().SaveDraft(gomock.Any(), draft).Times(1).Return(&model.Draft{UserId: "sarah_edo", Id: "draft-1", Version: 1}, nil)
+			},
+			expectedResponseCode: http.StatusCreated,
+		},
+		{
+			name:   "autosave",
+			method: http.MethodPost,
+			user:   "sarah_edo",
+			body:   `{"id": "draft-1", "text": "Half a thought, and more", "version": 1}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				draft := &model.Draft{UserId: "sarah_edo", Id: "draft-1", Text: "Half a thought, and more", Version: 1}
+				tweetsService.EXPECT().SaveDraft(gomock.Any(), draft).Times(1).Return(&model.Draft{UserId: "sarah_edo", Id: "draft-1", Version: 2}, nil)
+			},
+			expectedResponseCode: http.StatusOK,
+		},
+		{
+			name:   "saved from another device",
+			method: http.MethodPost,
+			user:   "sarah_edo",
+			body:   `{"id": "draft-1", "text": "from the laptop", "version": 1}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().SaveDraft(gomock.Any(), gomock.Any()).Times(1).Return(nil, tweetsrepo.ErrDraftVersionConflict)
+			},
+			expectedResponseCode: http.StatusConflict,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			user:   "sarah_edo",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ListDrafts(gomock.Any(), "sarah_edo", int32(0), "").Times(1).Return([]*model.Draft{}, "", nil)
+			},
+			expectedResponseCode: http.StatusOK,
+		},
+		{
+			name:   "no user",
+			method: http.MethodGet,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ListDrafts(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedResponseCode: http.StatusUnauthorized,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+			tc.buildStubs(tweetsServiceMock)
+
+			req := httptest.NewRequest(tc.method, "/drafts", strings.NewReader(tc.body))
+			if tc.user != "" {
+				req = req.WithContext(identity.WithAuthedUser(req.Context(), tc.user))
+			}
+			rec := httptest.NewRecorder()
+			DraftsHandler(tweetsServiceMock)(rec, req)
+
+			checkResponseCode(t, tc.expectedResponseCode, rec.Code)
+		})
+	}
+}
+
+func Test_PublishDraftHandler(t *testing.T) {
+	tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+	tweetsServiceMock.EXPECT().PublishDraft(gomock.Any(), "sarah_edo", "draft-1", int64(2)).Times(1).Return(&model.Tweet{Id: "tweet-1", Author: "sarah_edo"}, nil)
+	tweetsServiceMock.EXPECT().PublishDraft(gomock.Any(), "sarah_edo", "blocked", int64(1)).Times(1).Return(nil, tweetsservice.ErrBlocked)
+
+	for body, expected := range map[string]int{`{"id": "draft-1", "version": 2}`: http.StatusCreated, `{"id": "blocked", "version": 1}`: http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodPost, "/drafts/publish", strings.NewReader(body))
+		req = req.WithContext(identity.WithAuthedUser(req.Context(), "sarah_edo"))
+		rec := httptest.NewRecorder()
+		PublishDraftHandler(tweetsServiceMock)(rec, req)
+
+		checkResponseCode(t, expected, rec.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package api_http_handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsencoding "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/encoding"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//ExportTweetsHandler streams the whole tweets table(or the part that matches the filters) back to the client.
+//eg: GET /export-tweets?format=csv&author=sarah_edo&author=tylermcginnis&from=1518122597860&to=2023-03-01T00:00:00Z&segments=8
+func ExportTweetsHandler(tweetsService tweetsservice.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		format, err := tweetsencoding.ParseFormat(q.Get("format"))
+		if err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+
+		filter, segments, err := parseExportQuery(q.Get("from"), q.Get("to"), q.Get("segments"), q["author"])
+		if err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+
+		//we only commit to a 200 once the first tweet is ready. That way errors like a missing table can still be returned as JSON
+		var enc tweetsencoding.Encoder
+		flusher, _ := w.(http.Flusher)
+		start := func() {
+			w.Header().Set("Content-Type", format.ContentType())
+			w.Header().Set("Content-Disposition", "attachment; filename=tweets."+string(format))
+			w.WriteHeader(http.StatusOK)
+			enc = tweetsencoding.NewEncoder(w, format)
+		}
+
+		count := 0
+		err = tweetsService.ExportTweets(r.Context(), filter, segments, func(t *model.Tweet) error {
+			if enc == nil {
+				start()
+			}
+			if err := enc.Encode(t); err != nil {
+				return err
+			}
+			count++
+			if flusher != nil && count%100 == 0 {
+				flusher.Flush()
+			}
+			return nil
+		})
+
+		if err != nil && enc == nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, http.StatusInternalServerError)
+			return
+		}
+		if err == nil {
+			if enc == nil {
+				start()
+			}
+			err = enc.Close()
+		}
+		if err != nil {
+			//the status code is already out. The best we can do is abort the connection, so the client gets an error
+			//instead of a body that looks complete
+			slog.ErrorContext(r.Context(), "ExportTweets failed after the response started", "err", err, "exported", count)
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+func parseExportQuery(from, to, segments string, authors []string) (model.TweetFilter, int32, error) {
+	filter := model.TweetFilter{}
+
+	//we allow both ?author=a&author=b and ?author=a,b
+	for _, a := range authors {
+		for _, author := range strings.Split(a, ",") {
+			if author = strings.TrimSpace(author); author != "" {
+				filter.Authors = append(filter.Authors, author)
+			}
+		}
+	}
+
+	var err error
+	if from != "" {
+		if filter.From, err = model.ParseTime(from); err != nil {
+			return filter, 0, err
+		}
+	}
+	if to != "" {
+		if filter.To, err = model.ParseTime(to); err != nil {
+			return filter, 0, err
+		}
+	}
+
+	var nSegments int64
+	if segments != "" {
+		if nSegments, err = strconv.ParseInt(segments, 10, 32); err != nil {
+			return filter, 0, err
+		}
+	}
+	return filter, int32(nSegments), nil
+}
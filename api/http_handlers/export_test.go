@@ -0,0 +1,135 @@
+package api_http_handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ExportTweetsHandler(t *testing.T) {
+	tweet := &model.Tweet{
+		Id:        "8xf0y6ziyjabvozdd253nd",
+		Text:      "Shoutout to all the speakers",
+		Author:    "sarah_edo",
+		Timestamp: model.ChirperAppUnixTime(time.UnixMilli(1518122597860)),
+		Likes:     []string{"tylermcginnis"},
+	}
+
+	testCases := []struct {
+		name                 string
+		query                string
+		buildStubs           func(tweetsService *tweetsservice.MockService)
+		expectedResponseCode int
+		expectedContentType  string
+		expectedBody         string
+	}{
+		{
+			name:  "OK ndjson",
+			query: "?author=sarah_edo&from=1518122597000&segments=2",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				filter := model.TweetFilter{Authors: []string{"sarah_edo"}, From: time.UnixMilli(1518122597000)}
+				tweetsService.EXPECT().
+					ExportTweets(gomock.Any(), gomock.Eq(filter), int32(2), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, _ model.TweetFilter, _ int32, fn func(*model.Tweet) error) error {
+						return fn(tweet)
+					})
+			},
+			expectedResponseCode: http.StatusOK,
+			expectedContentType:  "application/x-ndjson",
+			expectedBody:         `{"author":"sarah_edo","id":"8xf0y6ziyjabvozdd253nd","likes":["tylermcginnis"],"text":"Shoutout to all the speakers","timestamp":1518122597860,"replyingTo":""}` + "\n",
+		},
+		{
+			name:  "OK empty json array",
+			query: "?format=json",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().
+					ExportTweets(gomock.Any(), gomock.Eq(model.TweetFilter{}), int32(0), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			expectedResponseCode: http.StatusOK,
+			expectedContentType:  "application/json",
+			expectedBody:         "[]\n",
+		},
+		{
+			name:  "invalid format",
+			query: "?format=xml",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ExportTweets(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedResponseCode: http.StatusBadRequest,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedBody:         `{"message":"unsupported format \"xml\". It should be one of ndjson, json or csv"}` + "\n",
+		},
+		{
+			name:  "service error before the first tweet",
+			query: "",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().
+					ExportTweets(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("segments cannot be more than 32"))
+			},
+			expectedResponseCode: http.StatusInternalServerError,
+			expectedContentType:  "application/json; charset=utf-8",
+			expectedBody:         `{"message":"segments cannot be more than 32"}` + "\n",
+		},
+	}
+
+	t.Run("service error after the first tweet", func(t *testing.T) {
+		tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+		tweetsServiceMock.EXPECT().
+			ExportTweets(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ interface{}, _ model.TweetFilter, _ int32, fn func(*model.Tweet) error) error {
+				if err := fn(tweet); err != nil {
+					return err
+				}
+				return errors.New("ProvisionedThroughputExceededException")
+			})
+
+		server := httptest.NewServer(ExportTweetsHandler(tweetsServiceMock))
+		defer server.Close()
+
+		//the connection is aborted, so the client can't take the cut off body for the whole export
+		res, err := http.Get(server.URL + "?format=csv")
+		if err == nil {
+			_, err = io.ReadAll(res.Body)
+			res.Body.Close()
+		}
+		require.Error(t, err)
+	})
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+
+			tweetsServiceMock := tweetsservice.NewMockService(ctrl)
+
+			tc.buildStubs(tweetsServiceMock)
+
+			server := httptest.NewServer(ExportTweetsHandler(tweetsServiceMock))
+			defer server.Close()
+
+			res, err := http.Get(server.URL + tc.query)
+			require.NoError(t, err)
+
+			checkResponseCode(t, tc.expectedResponseCode, res.StatusCode)
+			require.Equal(t, tc.expectedContentType, res.Header.Get("Content-Type"))
+
+			body, _ := io.ReadAll(res.Body)
+			require.Equal(t, tc.expectedBody, string(body))
+		})
+	}
+}
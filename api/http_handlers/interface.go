@@ -5,4 +5,19 @@ import "net/http"
 
 type Interface interface{
 	MigrateTweetsHandler() http.HandlerFunc
+	ExportTweetsHandler() http.HandlerFunc
+	ImportTwitterArchiveHandler() http.HandlerFunc
+	ReviewQueueHandler() http.HandlerFunc
+	ReviewTweetHandler() http.HandlerFunc
+	RelationHandler() http.HandlerFunc
+	ListRelationsHandler() http.HandlerFunc
+	ScheduledTweetsHandler() http.HandlerFunc
+	CancelScheduledHandler() http.HandlerFunc
+	DraftsHandler() http.HandlerFunc
+	DeleteDraftHandler() http.HandlerFunc
+	PublishDraftHandler() http.HandlerFunc
+	PollHandler() http.HandlerFunc
+	VotePollHandler() http.HandlerFunc
+	MediaHandler() http.HandlerFunc
+	TweetMediaHandler() http.HandlerFunc
 }
\ No newline at end of file
@@ -0,0 +1,180 @@
+package api_http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//ChecksumHeader is the optional hex SHA-256 of an upload. An upload that does not match it is rejected(400)
+const ChecksumHeader = "X-Checksum-Sha256"
+
+//mediaStatus maps the errors of the media to http statuses
+func mediaStatus(err error) int {
+	var invalid *tweetsservice.ValidationError
+	switch {
+	case errors.As(err, &invalid), errors.Is(err, tweetsservice.ErrChecksumMismatch):
+		return http.StatusBadRequest
+	case errors.Is(err, tweetsrepo.ErrMediaNotFound), errors.Is(err, tweetsrepo.ErrTweetNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tweetsrepo.ErrMediaUnavailable):
+		return http.StatusConflict
+	case errors.Is(err, tweetsservice.ErrMediaTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, tweetsservice.ErrUnsupportedMedia):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, tweetsservice.ErrMediaDisabled):
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
+
+//MediaHandler uploads media and downloads it.
+//
+//POST uploads the "file" part of a multipart/form-data body and answers the media(201). The part is streamed to the
+//store, so uploads are not held in memory. The media stays unattached until a tweet lists its id in X-Tweet-Media and
+//is deleted if no tweet does in time.
+//eg: curl -F file=@cat.jpg -H 'X-Authed-User-Id: sarah_edo' http://localhost:8080/media
+//
+//GET answers the content of the media. The caller is optional, they see the media of the tweets they can see and their
+//own uploads. With thumbnail, it answers the square JPEG thumbnail of that side instead, once the image is processed.
+//eg: GET /media?id=0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44
+//eg: GET /media?id=0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44&thumbnail=150
+func MediaHandler(tweetsService tweetsservice.Service) http.HandlerFunc {
+	upload := requireUser(func(w http.ResponseWriter, r *http.Request, userID string) {
+		mr, err := r.MultipartReader()
+		if err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": "invalid body: " + err.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				JSONError(w, map[string]interface{}{
+					"message": "the file part is required",
+				}, http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				JSONError(w, map[string]interface{}{
+					"message": "invalid body: " + err.Error(),
+				}, http.StatusBadRequest)
+				return
+			}
+			if part.FormName() != "file" {
+				continue
+			}
+
+			media, err := tweetsService.UploadMedia(r.Context(), userID, part, r.Header.Get(ChecksumHeader))
+			if err != nil {
+				JSONError(w, map[string]interface{}{
+					"message": err.Error(),
+				}, mediaStatus(err))
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(media)
+			return
+		}
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			upload(w, r)
+		case http.MethodGet:
+			id := r.URL.Query().Get("id")
+			if id == "" {
+				JSONError(w, map[string]interface{}{
+					"message": "id is required",
+				}, http.StatusBadRequest)
+				return
+			}
+			var side int
+			if v := r.URL.Query().Get("thumbnail"); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 {
+					JSONError(w, map[string]interface{}{
+						"message": "thumbnail must be the side of a thumbnail in pixels",
+					}, http.StatusBadRequest)
+					return
+				}
+				side = n
+			}
+
+			var media *model.Media
+			var body io.ReadCloser
+			var err error
+			if side == 0 {
+				media, body, err = tweetsService.OpenMedia(r.Context(), identity.AuthedUser(r.Context()), id)
+			} else {
+				media, body, err = tweetsService.OpenThumbnail(r.Context(), identity.AuthedUser(r.Context()), id, side)
+			}
+			if err != nil {
+				JSONError(w, map[string]interface{}{
+					"message": err.Error(),
+				}, mediaStatus(err))
+				return
+			}
+			defer body.Close()
+
+			contentType, size, etag := media.ContentType, media.Size, media.Checksum
+			if side != 0 {
+				contentType, size, etag = "image/jpeg", media.Thumbnail(side).Size, media.Checksum+"-"+strconv.Itoa(side)
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+			w.Header().Set("ETag", strconv.Quote(etag))
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.WriteHeader(http.StatusOK)
+			io.Copy(w, body)
+		default:
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+//TweetMediaHandler answers the media attached to a tweet, in the order they are shown. The caller is optional.
+//eg: GET /tweets/media?tweetId=0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44
+func TweetMediaHandler(tweetsService tweetsservice.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+			return
+		}
+		tweetID := r.URL.Query().Get("tweetId")
+		if tweetID == "" {
+			JSONError(w, map[string]interface{}{
+				"message": "tweetId is required",
+			}, http.StatusBadRequest)
+			return
+		}
+
+		media, err := tweetsService.ListTweetMedia(r.Context(), identity.AuthedUser(r.Context()), tweetID)
+		if err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, mediaStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{"media": media})
+	}
+}
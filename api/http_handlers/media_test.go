@@ -0,0 +1,164 @@
+package api_http_handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func multipartBody(t *testing.T, field, content string) (*bytes.Buffer, string) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, err := mw.CreateFormFile(field, "cat.png")
+	assert.NoError(t, err)
+	fw.Write([]byte(content))
+	mw.Close()
+	return &buf, mw.FormDataContentType()
+}
+
+func Test_MediaHandler_Upload(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		user                 string
+		field                string
+		buildStubs           func(tweetsService *tweetsservice.MockService)
+		expectedResponseCode int
+	}{
+		{
+			name:  "upload",
+			user:  "sarah_edo",
+			field: "file",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().UploadMedia(gomock.Any(), "sarah_edo", gomock.Any(), "abc").Times(1).
+					DoAndReturn(func(ctx context.Context, userID string, body io.Reader, checksum string) (*model.Media, error) {
+						content, _ := io.ReadAll(body)
+						assert.Equal(t, "pretend png", string(content))
+						return &model.Media{Id: "media-1"}, nil
+					})
+			},
+			expectedResponseCode: http.StatusCreated,
+		},
+		{
+			name:  "too large",
+			user:  "sarah_edo",
+			field: "file",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().UploadMedia(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+					Return(nil, fmt.Errorf("%w, images can be 5242880 bytes", tweetsservice.ErrMediaTooLarge))
+			},
+			expectedResponseCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:  "unsupported",
+			user:  "sarah_edo",
+			field: "file",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().UploadMedia(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, tweetsservice.ErrUnsupportedMedia)
+			},
+			expectedResponseCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:                 "no file part",
+			user:                 "sarah_edo",
+			field:                "picture",
+			buildStubs:           func(tweetsService *tweetsservice.MockService) {},
+			expectedResponseCode: http.StatusBadRequest,
+		},
+		{
+			name:                 "no user",
+			field:                "file",
+			buildStubs:           func(tweetsService *tweetsservice.MockService) {},
+			expectedResponseCode: http.StatusUnauthorized,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+			tc.buildStubs(tweetsServiceMock)
+
+			body, contentType := multipartBody(t, tc.field, "pretend png")
+			req := httptest.NewRequest(http.MethodPost, "/media", body)
+			req.Header.Set("Content-Type", contentType)
+			req.Header.Set(ChecksumHeader, "abc")
+			if tc.user != "" {
+				req = req.WithContext(identity.WithAuthedUser(req.Context(), tc.user))
+			}
+			rec := httptest.NewRecorder()
+			MediaHandler(tweetsServiceMock)(rec, req)
+
+			checkResponseCode(t, tc.expectedResponseCode, rec.Code)
+		})
+	}
+}
+
+func Test_MediaHandler_Download(t *testing.T) {
+	tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+	tweetsServiceMock.EXPECT().OpenMedia(gomock.Any(), "", "media-1").Times(1).
+		Return(&model.Media{Id: "media-1", ContentType: "image/png", Size: 11, Checksum: "abc"}, io.NopCloser(strings.NewReader("pretend png")), nil)
+	tweetsServiceMock.EXPECT().OpenMedia(gomock.Any(), "", "hidden").Times(1).Return(nil, nil, tweetsrepo.ErrMediaNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/media?id=media-1", nil)
+	rec := httptest.NewRecorder()
+	MediaHandler(tweetsServiceMock)(rec, req)
+	checkResponseCode(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `"abc"`, rec.Header().Get("ETag"))
+	assert.Equal(t, "pretend png", rec.Body.String())
+
+	for url, expected := range map[string]int{"/media?id=hidden": http.StatusNotFound, "/media": http.StatusBadRequest, "/media?id=media-1&thumbnail=big": http.StatusBadRequest} {
+		rec := httptest.NewRecorder()
+		MediaHandler(tweetsServiceMock)(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		checkResponseCode(t, expected, rec.Code)
+	}
+}
+
+func Test_MediaHandler_Thumbnail(t *testing.T) {
+	tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+	tweetsServiceMock.EXPECT().OpenThumbnail(gomock.Any(), "", "media-1", 150).Times(1).
+		Return(&model.Media{Id: "media-1", ContentType: "image/png", Checksum: "abc", Thumbnails: []model.MediaThumbnail{{Side: 150, Size: 9}}}, io.NopCloser(strings.NewReader("thumbnail")), nil)
+	tweetsServiceMock.EXPECT().OpenThumbnail(gomock.Any(), "", "pending", 150).Times(1).Return(nil, nil, tweetsrepo.ErrMediaNotFound)
+
+	rec := httptest.NewRecorder()
+	MediaHandler(tweetsServiceMock)(rec, httptest.NewRequest(http.MethodGet, "/media?id=media-1&thumbnail=150", nil))
+	checkResponseCode(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/jpeg", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "9", rec.Header().Get("Content-Length"))
+	assert.Equal(t, `"abc-150"`, rec.Header().Get("ETag"))
+	assert.Equal(t, "thumbnail", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	MediaHandler(tweetsServiceMock)(rec, httptest.NewRequest(http.MethodGet, "/media?id=pending&thumbnail=150", nil))
+	checkResponseCode(t, http.StatusNotFound, rec.Code)
+}
+
+func Test_TweetMediaHandler(t *testing.T) {
+	tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+	tweetsServiceMock.EXPECT().ListTweetMedia(gomock.Any(), "sarah_edo", "tweet-1").Times(1).Return([]*model.Media{{Id: "media-1"}}, nil)
+	tweetsServiceMock.EXPECT().ListTweetMedia(gomock.Any(), "sarah_edo", "hidden").Times(1).Return(nil, tweetsrepo.ErrTweetNotFound)
+
+	for url, expected := range map[string]int{"/tweets/media?tweetId=tweet-1": http.StatusOK, "/tweets/media?tweetId=hidden": http.StatusNotFound, "/tweets/media": http.StatusBadRequest} {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req = req.WithContext(identity.WithAuthedUser(req.Context(), "sarah_edo"))
+		rec := httptest.NewRecorder()
+		TweetMediaHandler(tweetsServiceMock)(rec, req)
+
+		checkResponseCode(t, expected, rec.Code)
+	}
+}
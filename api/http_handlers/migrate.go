@@ -2,18 +2,20 @@ package api_http_handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
-	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetsencoding "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/encoding"
 )
 
 func MigrateTweetsHandler(tweetsService tweetsservice.Service) http.HandlerFunc{
 	return func (w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		items := make([]*model.Tweet, 0)
 
-		err := json.NewDecoder(r.Body).Decode(&items)
+		//besides the default JSON array we accept whatever /export-tweets can produce. The format is picked from the Content-Type
+		items, err := tweetsencoding.Decode(r.Body, tweetsencoding.FormatFromContentType(r.Header.Get("Content-Type")))
 		if err != nil {
 			JSONError(w, map[string]interface{}{
 				"message": err.Error(),
@@ -21,11 +23,33 @@ func MigrateTweetsHandler(tweetsService tweetsservice.Service) http.HandlerFunc{
 			return
 		}
 
+		//?dryRun=true validates the payload against the same rules as the import and returns a report. Nothing is written
+		if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun")); dryRun {
+			report, err := tweetsService.ValidateBulkTweets(ctx, items)
+			if err != nil {
+				JSONError(w, map[string]interface{}{
+					"message": err.Error(),
+				}, http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(report)
+			return
+		}
+
 		err = tweetsService.BulkSaveTweet(ctx, items)
 		if err != nil {
-			JSONError(w, map[string]interface{}{
+			response := map[string]interface{}{
 				"message": err.Error(),
-			}, http.StatusMultiStatus)
+			}
+			//some of the tweets may be written already
+			var partial *tweetsservice.BulkSaveError
+			if errors.As(err, &partial) {
+				response["saved"] = partial.Saved
+			}
+			JSONError(w, response, http.StatusMultiStatus)
 			return
 			//https://aws.github.io/aws-sdk-go-v2/docs/handling-errors/
 			//https://www.mscharhag.com/api-design/bulk-and-batch-operations#:~:text=Which%20HTTP%20status%20code%20is,simply%20return%20HTTP%20200%20OK.
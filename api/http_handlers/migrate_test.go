@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +12,7 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
 	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
 	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
 	"github.com/stretchr/testify/require"
@@ -20,6 +22,7 @@ import (
 func Test_MigrateTweetsHandler(t *testing.T){
 	testCases := []struct {
 		name          string
+		query         string
 		body          []byte
 		buildStubs    func(tweetsService *tweetsservice.MockService)
 		expectedResponseCode int
@@ -72,6 +75,29 @@ func Test_MigrateTweetsHandler(t *testing.T){
 			expectedResponseCode:  http.StatusMultiStatus,
 			expectedResponse: map[string]interface {}{"message":"cannot perform action on an empty list"},
 		},
+		{
+			name:      "dry run returns the report without saving",
+			query:     "?dryRun=true",
+			body: []byte(`[{"id": "8xf0y6ziyjabvozdd253nd", "text": "hello", "author": "sarah_edo"}]`),
+			buildStubs: func(tweetsservice *tweetsservice.MockService) {
+				tweetsservice.EXPECT().
+				BulkSaveTweet(gomock.Any(), gomock.Any()).
+					Times(0)
+				tweetsservice.EXPECT().
+				ValidateBulkTweets(gomock.Any(), gomock.Len(1)).
+					Times(1).
+					Return(&model.ValidationReport{DryRun: true, Total: 1, Invalid: 1, Errors: []model.RecordIssue{
+						{Index: 0, Id: "8xf0y6ziyjabvozdd253nd", Field: "author", Message: "user sarah_edo does not exist"},
+					}}, nil)
+			},
+			expectedResponseCode: http.StatusOK,
+			expectedResponse: map[string]interface {}{
+				"dryRun": true, "total": float64(1), "valid": float64(0), "invalid": float64(1),
+				"errors": []interface{}{
+					map[string]interface{}{"index": float64(0), "id": "8xf0y6ziyjabvozdd253nd", "field": "author", "message": "user sarah_edo does not exist"},
+				},
+			},
+		},
 		{
 			name:      "EOF",
 			buildStubs: func(tweetsservice *tweetsservice.MockService) {
@@ -97,7 +123,7 @@ func Test_MigrateTweetsHandler(t *testing.T){
 			server := httptest.NewServer(MigrateTweetsHandler(tweetsServiceMock)) //spin up a test sever that runs our handler
 			defer server.Close()
 
-			r, _ := http.NewRequest("POST", server.URL, bytes.NewBuffer(tc.body))
+			r, _ := http.NewRequest("POST", server.URL+tc.query, bytes.NewBuffer(tc.body))
 			r.Header.Add("Content-Type", "application/json")
 
 			client := &http.Client{}
@@ -113,6 +139,28 @@ func Test_MigrateTweetsHandler(t *testing.T){
 	}
 }
 
+func Test_MigrateTweetsHandler_MoreThanABatch(t *testing.T) {
+	var tweets []map[string]interface{}
+	for i := 0; i < 60; i++ {
+		tweets = append(tweets, map[string]interface{}{"id": fmt.Sprintf("tweet-%02d", i), "author": "sarah_edo", "text": "hello"})
+	}
+	body, _ := json.Marshal(tweets)
+
+	//DynamoDB rejects a BatchWriteItem of more than 25 items
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	gomock.InOrder(
+		repoMock.EXPECT().BulkSaveTweetToDynamoDb(gomock.Any(), gomock.Len(model.MaxBatchSize)).Times(2).Return(nil),
+		repoMock.EXPECT().BulkSaveTweetToDynamoDb(gomock.Any(), gomock.Len(10)).Times(1).Return(nil),
+	)
+
+	server := httptest.NewServer(MigrateTweetsHandler(tweetsservice.New(repoMock)))
+	defer server.Close()
+
+	res, err := http.Post(server.URL, "application/json", bytes.NewBuffer(body))
+	require.NoError(t, err)
+	checkResponseCode(t, http.StatusOK, res.StatusCode)
+}
+
 func checkResponseCode(t *testing.T, expected, actual int) {
 	require.Equal(t, expected, actual)
 }
\ No newline at end of file
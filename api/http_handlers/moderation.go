@@ -0,0 +1,147 @@
+package api_http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//Reviewers decides who can use the review queue. The user comes from the X-Authed-User-Id header, see the identity package
+type Reviewers struct {
+	IDs []string
+	//AllowAnyone lets every caller through when IDs is empty. Only meant for local runs
+	AllowAnyone bool
+}
+
+func (r Reviewers) allowed(userID string) bool {
+	if len(r.IDs) == 0 {
+		return r.AllowAnyone
+	}
+	for _, id := range r.IDs {
+		if userID != "" && id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+//requireReviewer answers 403 to callers that are not reviewers
+func requireReviewer(reviewers Reviewers, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !reviewers.allowed(identity.AuthedUser(r.Context())) {
+			JSONError(w, map[string]interface{}{
+				"message": "only reviewers can use the review queue",
+			}, http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+//reviewStatus maps the errors of the review queue to http statuses
+func reviewStatus(err error) int {
+	var invalid *tweetsservice.ValidationError
+	switch {
+	case errors.Is(err, cursor.ErrInvalidCursor), errors.As(err, &invalid):
+		return http.StatusBadRequest
+	case errors.Is(err, tweetsrepo.ErrTweetNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tweetsrepo.ErrNotPendingReview):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+//ReviewQueueHandler lists the tweets moderation held for review, oldest first.
+//eg: GET /admin/moderation/queue?limit=20&nextKey=...
+func ReviewQueueHandler(tweetsService tweetsservice.Service, reviewers Reviewers) http.HandlerFunc {
+	return requireReviewer(reviewers, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+			return
+		}
+
+		var limit int64
+		if v := r.URL.Query().Get("limit"); v != "" {
+			var err error
+			if limit, err = strconv.ParseInt(v, 10, 32); err != nil {
+				JSONError(w, map[string]interface{}{
+					"message": "limit must be a number",
+				}, http.StatusBadRequest)
+				return
+			}
+		}
+
+		tweets, nextKey, err := tweetsService.ListReviewQueue(r.Context(), int32(limit), r.URL.Query().Get("nextKey"))
+		if err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, reviewStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tweets":  tweets,
+			"nextKey": nextKey,
+		})
+	})
+}
+
+type reviewRequest struct {
+	Id       string               `json:"id"`
+	Decision model.ReviewDecision `json:"decision"`
+}
+
+//ReviewTweetHandler approves or removes a tweet held for review. The reviewer is the caller.
+//eg: POST /admin/moderation/review {"id": "8xf0y6ziyjabvozdd253nd", "decision": "approve"}
+func ReviewTweetHandler(tweetsService tweetsservice.Service, reviewers Reviewers) http.HandlerFunc {
+	return requireReviewer(reviewers, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req reviewRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": "invalid body: " + err.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+		if req.Id == "" || (req.Decision != model.ReviewApprove && req.Decision != model.ReviewRemove) {
+			JSONError(w, map[string]interface{}{
+				"message": "id is required and decision must be approve or remove",
+			}, http.StatusBadRequest)
+			return
+		}
+
+		reviewer := identity.AuthedUser(r.Context())
+		if reviewer == "" {
+			reviewer = "local" //only possible when Reviewers.AllowAnyone is set
+		}
+		tweet, err := tweetsService.ReviewTweet(r.Context(), req.Id, req.Decision, reviewer)
+		if err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, reviewStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(tweet)
+	})
+}
@@ -0,0 +1,149 @@
+package api_http_handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReviewQueueHandler(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		user                 string
+		reviewers            Reviewers
+		query                string
+		buildStubs           func(tweetsService *tweetsservice.MockService)
+		expectedResponseCode int
+		expectedBody         string
+	}{
+		{
+			name:      "OK",
+			user:      "admin",
+			reviewers: Reviewers{IDs: []string{"admin"}},
+			query:     "?limit=5",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ListReviewQueue(gomock.Any(), int32(5), "").Times(1).
+					Return([]*model.Tweet{{Id: "flagged", Author: "sarah_edo", Text: "spam", ModerationState: model.ModerationPending}}, "next", nil)
+			},
+			expectedResponseCode: http.StatusOK,
+			expectedBody:         `{"nextKey":"next","tweets":[{"author":"sarah_edo","id":"flagged","text":"spam","timestamp":null,"replyingTo":"","moderationState":"pending"}]}` + "\n",
+		},
+		{
+			name:      "not a reviewer",
+			user:      "sarah_edo",
+			reviewers: Reviewers{IDs: []string{"admin"}, AllowAnyone: true},
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ListReviewQueue(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedResponseCode: http.StatusForbidden,
+			expectedBody:         `{"message":"only reviewers can use the review queue"}` + "\n",
+		},
+		{
+			name: "no reviewers configured",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ListReviewQueue(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedResponseCode: http.StatusForbidden,
+			expectedBody:         `{"message":"only reviewers can use the review queue"}` + "\n",
+		},
+		{
+			name:      "anyone on a local run",
+			reviewers: Reviewers{AllowAnyone: true},
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ListReviewQueue(gomock.Any(), int32(0), "").Times(1).Return([]*model.Tweet{}, "", nil)
+			},
+			expectedResponseCode: http.StatusOK,
+			expectedBody:         `{"nextKey":"","tweets":[]}` + "\n",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+			tc.buildStubs(tweetsServiceMock)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/moderation/queue"+tc.query, nil)
+			if tc.user != "" {
+				req = req.WithContext(identity.WithAuthedUser(req.Context(), tc.user))
+			}
+			rec := httptest.NewRecorder()
+			ReviewQueueHandler(tweetsServiceMock, tc.reviewers)(rec, req)
+
+			checkResponseCode(t, tc.expectedResponseCode, rec.Code)
+			body, _ := io.ReadAll(rec.Body)
+			require.Equal(t, tc.expectedBody, string(body))
+		})
+	}
+}
+
+func Test_ReviewTweetHandler(t *testing.T) {
+	reviewers := Reviewers{IDs: []string{"admin"}}
+
+	testCases := []struct {
+		name                 string
+		body                 string
+		buildStubs           func(tweetsService *tweetsservice.MockService)
+		expectedResponseCode int
+	}{
+		{
+			name: "OK",
+			body: `{"id": "flagged", "decision": "remove"}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ReviewTweet(gomock.Any(), "flagged", model.ReviewRemove, "admin").Times(1).
+					Return(&model.Tweet{Id: "flagged", ModerationState: model.ModerationRemoved}, nil)
+			},
+			expectedResponseCode: http.StatusOK,
+		},
+		{
+			name: "invalid decision",
+			body: `{"id": "flagged", "decision": "ban"}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ReviewTweet(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedResponseCode: http.StatusBadRequest,
+		},
+		{
+			name: "already reviewed",
+			body: `{"id": "flagged", "decision": "approve"}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ReviewTweet(gomock.Any(), "flagged", model.ReviewApprove, "admin").Times(1).Return(nil, tweetsrepo.ErrNotPendingReview)
+			},
+			expectedResponseCode: http.StatusConflict,
+		},
+		{
+			name: "not found",
+			body: `{"id": "unknown", "decision": "approve"}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ReviewTweet(gomock.Any(), "unknown", model.ReviewApprove, "admin").Times(1).Return(nil, tweetsrepo.ErrTweetNotFound)
+			},
+			expectedResponseCode: http.StatusNotFound,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+			tc.buildStubs(tweetsServiceMock)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/moderation/review", strings.NewReader(tc.body))
+			req = req.WithContext(identity.WithAuthedUser(req.Context(), "admin"))
+			rec := httptest.NewRecorder()
+			ReviewTweetHandler(tweetsServiceMock, reviewers)(rec, req)
+
+			checkResponseCode(t, tc.expectedResponseCode, rec.Code)
+		})
+	}
+}
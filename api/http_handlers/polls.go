@@ -0,0 +1,102 @@
+package api_http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+)
+
+//pollStatus maps the errors of the polls to http statuses
+func pollStatus(err error) int {
+	var invalid *tweetsservice.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return http.StatusBadRequest
+	case errors.Is(err, tweetsrepo.ErrTweetNotFound), errors.Is(err, tweetsservice.ErrNoPoll):
+		return http.StatusNotFound
+	case errors.Is(err, tweetsrepo.ErrAlreadyVoted), errors.Is(err, tweetsrepo.ErrPollClosed):
+		return http.StatusConflict
+	case errors.Is(err, tweetsservice.ErrBlocked):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+//PollHandler answers the poll of a tweet, the way the caller sees it: the votes are left out(resultsHidden) until they
+//voted or the poll closed. The caller is optional, anonymous callers see the results once the poll closed.
+//eg: GET /polls?tweetId=0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44
+func PollHandler(tweetsService tweetsservice.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+			return
+		}
+		tweetID := r.URL.Query().Get("tweetId")
+		if tweetID == "" {
+			JSONError(w, map[string]interface{}{
+				"message": "tweetId is required",
+			}, http.StatusBadRequest)
+			return
+		}
+
+		tweet, err := tweetsService.GetTweet(r.Context(), identity.AuthedUser(r.Context()), tweetID)
+		if err == nil && tweet.Poll == nil {
+			err = tweetsservice.ErrNoPoll
+		}
+		if err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, pollStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(tweet.Poll)
+	}
+}
+
+type voteRequest struct {
+	TweetId string `json:"tweetId"`
+	//Option is the index of the option, from 0
+	Option int `json:"option"`
+}
+
+//VotePollHandler votes for an option of the poll of a tweet and answers the poll with its results. A user votes once
+//per poll(409) and only while it is open(409).
+//eg: POST /polls/vote {"tweetId": "0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44", "option": 1}
+func VotePollHandler(tweetsService tweetsservice.Service) http.HandlerFunc {
+	return requireUser(func(w http.ResponseWriter, r *http.Request, userID string) {
+		if r.Method != http.MethodPost {
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+			return
+		}
+		var req voteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": "invalid body: " + err.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+
+		poll, err := tweetsService.VotePoll(r.Context(), userID, req.TweetId, req.Option)
+		if err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, pollStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(poll)
+	})
+}
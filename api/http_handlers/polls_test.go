@@ -0,0 +1,93 @@
+package api_http_handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func Test_PollHandler(t *testing.T) {
+	tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+	tweetsServiceMock.EXPECT().GetTweet(gomock.Any(), "sarah_edo", "poll").Times(1).Return(&model.Tweet{Id: "poll", Poll: &model.Poll{Options: []model.PollOption{{Text: "Tabs"}, {Text: "Spaces"}}}}, nil)
+	tweetsServiceMock.EXPECT().GetTweet(gomock.Any(), "sarah_edo", "no-poll").Times(1).Return(&model.Tweet{Id: "no-poll"}, nil)
+
+	for url, expected := range map[string]int{"/polls?tweetId=poll": http.StatusOK, "/polls?tweetId=no-poll": http.StatusNotFound, "/polls": http.StatusBadRequest} {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req = req.WithContext(identity.WithAuthedUser(req.Context(), "sarah_edo"))
+		rec := httptest.NewRecorder()
+		PollHandler(tweetsServiceMock)(rec, req)
+
+		checkResponseCode(t, expected, rec.Code)
+	}
+}
+
+func Test_VotePollHandler(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		user                 string
+		body                 string
+		buildStubs           func(tweetsService *tweetsservice.MockService)
+		expectedResponseCode int
+	}{
+		{
+			name: "vote",
+			user: "sarah_edo",
+			body: `{"tweetId": "tweet-1", "option": 1}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().VotePoll(gomock.Any(), "sarah_edo", "tweet-1", 1).Times(1).Return(&model.Poll{}, nil)
+			},
+			expectedResponseCode: http.StatusOK,
+		},
+		{
+			name: "second vote",
+			user: "sarah_edo",
+			body: `{"tweetId": "tweet-1", "option": 0}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().VotePoll(gomock.Any(), "sarah_edo", "tweet-1", 0).Times(1).Return(nil, tweetsrepo.ErrAlreadyVoted)
+			},
+			expectedResponseCode: http.StatusConflict,
+		},
+		{
+			name: "closed",
+			user: "sarah_edo",
+			body: `{"tweetId": "tweet-1", "option": 0}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().VotePoll(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(nil, tweetsrepo.ErrPollClosed)
+			},
+			expectedResponseCode: http.StatusConflict,
+		},
+		{
+			name: "no user",
+			body: `{"tweetId": "tweet-1", "option": 0}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().VotePoll(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedResponseCode: http.StatusUnauthorized,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+			tc.buildStubs(tweetsServiceMock)
+
+			req := httptest.NewRequest(http.MethodPost, "/polls/vote", strings.NewReader(tc.body))
+			if tc.user != "" {
+				req = req.WithContext(identity.WithAuthedUser(req.Context(), tc.user))
+			}
+			rec := httptest.NewRecorder()
+			VotePollHandler(tweetsServiceMock)(rec, req)
+
+			checkResponseCode(t, tc.expectedResponseCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package api_http_handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+)
+
+//the relation actions, they are also the last segment of the paths eg: POST /relations/block
+const (
+	BlockAction    = "block"
+	UnblockAction  = "unblock"
+	MuteAction     = "mute"
+	UnmuteAction   = "unmute"
+	FollowAction   = "follow"
+	UnfollowAction = "unfollow"
+)
+
+//relationAction returns the method of the service behind an action
+func relationAction(tweetsService tweetsservice.Service, action string) func(ctx context.Context, userID, target string) error {
+	switch action {
+	case BlockAction:
+		return tweetsService.Block
+	case UnblockAction:
+		return tweetsService.Unblock
+	case MuteAction:
+		return tweetsService.Mute
+	case UnmuteAction:
+		return tweetsService.Unmute
+	case FollowAction:
+		return tweetsService.Follow
+	case UnfollowAction:
+		return tweetsService.Unfollow
+	}
+	return nil
+}
+
+//requireUser answers 401 when the caller is not known, see the identity package. The relations are always the caller's own
+func requireUser(next func(w http.ResponseWriter, r *http.Request, userID string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID := identity.AuthedUser(r.Context())
+		if userID == "" {
+			JSONError(w, map[string]interface{}{
+				"message": "the " + identity.AuthedUserHeader + " header is required",
+			}, http.StatusUnauthorized)
+			return
+		}
+		next(w, r, userID)
+	}
+}
+
+func relationStatus(err error) int {
+	var invalid *tweetsservice.ValidationError
+	if errors.As(err, &invalid) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+type relationRequest struct {
+	Target string `json:"target"`
+}
+
+//RelationHandler blocks, unblocks, mutes, unmutes, follows or unfollows a user for the caller. Doing it twice is not an error.
+//eg: POST /relations/block {"target": "tylermcginnis"}
+func RelationHandler(tweetsService tweetsservice.Service, action string) http.HandlerFunc {
+	do := relationAction(tweetsService, action)
+	if do == nil {
+		panic("unknown relation action " + action)
+	}
+
+	return requireUser(func(w http.ResponseWriter, r *http.Request, userID string) {
+		if r.Method != http.MethodPost {
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req relationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": "invalid body: " + err.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+
+		if err := do(r.Context(), userID, req.Target); err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, relationStatus(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+//ListRelationsHandler lists the blocks, mutes and follows of the caller.
+//eg: GET /relations
+func ListRelationsHandler(tweetsService tweetsservice.Service) http.HandlerFunc {
+	return requireUser(func(w http.ResponseWriter, r *http.Request, userID string) {
+		if r.Method != http.MethodGet {
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+			return
+		}
+
+		relations, err := tweetsService.ListRelations(r.Context(), userID)
+		if err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, relationStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"relations": relations,
+		})
+	})
+}
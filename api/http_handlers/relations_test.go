@@ -0,0 +1,110 @@
+package api_http_handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RelationHandler(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		action               string
+		user                 string
+		body                 string
+		buildStubs           func(tweetsService *tweetsservice.MockService)
+		expectedResponseCode int
+	}{
+		{
+			name:   "block",
+			action: BlockAction,
+			user:   "sarah_edo",
+			body:   `{"target": "tylermcginnis"}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().Block(gomock.Any(), "sarah_edo", "tylermcginnis").Times(1).Return(nil)
+			},
+			expectedResponseCode: http.StatusNoContent,
+		},
+		{
+			name:   "unmute",
+			action: UnmuteAction,
+			user:   "sarah_edo",
+			body:   `{"target": "tylermcginnis"}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().Unmute(gomock.Any(), "sarah_edo", "tylermcginnis").Times(1).Return(nil)
+			},
+			expectedResponseCode: http.StatusNoContent,
+		},
+		{
+			name:   "follow",
+			action: FollowAction,
+			user:   "sarah_edo",
+			body:   `{"target": "tylermcginnis"}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().Follow(gomock.Any(), "sarah_edo", "tylermcginnis").Times(1).Return(nil)
+			},
+			expectedResponseCode: http.StatusNoContent,
+		},
+		{
+			name:   "no user",
+			action: BlockAction,
+			body:   `{"target": "tylermcginnis"}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().Block(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedResponseCode: http.StatusUnauthorized,
+		},
+		{
+			name:   "invalid target",
+			action: MuteAction,
+			user:   "sarah_edo",
+			body:   `{"target": "sarah_edo"}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().Mute(gomock.Any(), "sarah_edo", "sarah_edo").Times(1).
+					Return(&tweetsservice.ValidationError{Violations: []tweetsservice.FieldViolation{{Field: "target", Description: "you can't mute yourself"}}})
+			},
+			expectedResponseCode: http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+			tc.buildStubs(tweetsServiceMock)
+
+			req := httptest.NewRequest(http.MethodPost, "/relations/"+tc.action, strings.NewReader(tc.body))
+			if tc.user != "" {
+				req = req.WithContext(identity.WithAuthedUser(req.Context(), tc.user))
+			}
+			rec := httptest.NewRecorder()
+			RelationHandler(tweetsServiceMock, tc.action)(rec, req)
+
+			checkResponseCode(t, tc.expectedResponseCode, rec.Code)
+		})
+	}
+}
+
+func Test_ListRelationsHandler(t *testing.T) {
+	tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+	tweetsServiceMock.EXPECT().ListRelations(gomock.Any(), "sarah_edo").Times(1).
+		Return([]*model.Relation{{UserId: "sarah_edo", Kind: model.Mute, Target: "tylermcginnis"}}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/relations", nil)
+	req = req.WithContext(identity.WithAuthedUser(req.Context(), "sarah_edo"))
+	rec := httptest.NewRecorder()
+	ListRelationsHandler(tweetsServiceMock)(rec, req)
+
+	checkResponseCode(t, http.StatusOK, rec.Code)
+	body, _ := io.ReadAll(rec.Body)
+	require.Equal(t, `{"relations":[{"userId":"sarah_edo","kind":"mute","target":"tylermcginnis","timestamp":null}]}`+"\n", string(body))
+}
@@ -0,0 +1,161 @@
+package api_http_handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//scheduledStatus maps the errors of the scheduled tweets to http statuses
+func scheduledStatus(err error) int {
+	var invalid *tweetsservice.ValidationError
+	switch {
+	case errors.Is(err, cursor.ErrInvalidCursor), errors.As(err, &invalid):
+		return http.StatusBadRequest
+	case errors.Is(err, tweetsrepo.ErrScheduledTweetNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, tweetsrepo.ErrScheduledTweetNotWaiting), errors.Is(err, tweetsrepo.ErrMediaUnavailable):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+type scheduleRequest struct {
+	Text       string `json:"text"`
+	ReplyingTo string `json:"replyingTo"`
+	//Audience is public(the default), followers or mentioned, like the X-Tweet-Audience header of SaveTweet
+	Audience string `json:"audience"`
+	//Poll is like the X-Tweet-Poll header of SaveTweet
+	Poll *schedulePoll `json:"poll"`
+	//MediaIds are uploads of the caller, like the X-Tweet-Media header of SaveTweet
+	MediaIds  []string                 `json:"mediaIds"`
+	PublishAt model.ChirperAppUnixTime `json:"publishAt"` //unix ms
+}
+
+type schedulePoll struct {
+	Options  []string                 `json:"options"`
+	ClosesAt model.ChirperAppUnixTime `json:"closesAt"`
+}
+
+//ScheduledTweetsHandler schedules a tweet of the caller(POST) or lists the scheduled tweets of the caller(GET).
+//eg: POST /scheduled {"text": "Happy new year!", "publishAt": 1767225600000}
+//eg: POST /scheduled {"text": "Tabs or spaces?", "poll": {"options": ["Tabs", "Spaces"], "closesAt": 1767312000000}, "mediaIds": ["..."], "publishAt": 1767225600000}
+//eg: GET /scheduled?limit=20&nextKey=...
+func ScheduledTweetsHandler(tweetsService tweetsservice.Service) http.HandlerFunc {
+	return requireUser(func(w http.ResponseWriter, r *http.Request, userID string) {
+		switch r.Method {
+		case http.MethodPost:
+			scheduleTweet(w, r, tweetsService, userID)
+		case http.MethodGet:
+			listScheduled(w, r, tweetsService, userID)
+		default:
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func scheduleTweet(w http.ResponseWriter, r *http.Request, tweetsService tweetsservice.Service, userID string) {
+	var req scheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		JSONError(w, map[string]interface{}{
+			"message": "invalid body: " + err.Error(),
+		}, http.StatusBadRequest)
+		return
+	}
+
+	tweet := &model.Tweet{Author: userID, Text: req.Text, ReplyingTo: req.ReplyingTo, MediaIds: req.MediaIds}
+	if req.Audience != "" {
+		tweet.Audience = &model.Audience{Level: model.AudienceLevel(req.Audience)}
+	}
+	if req.Poll != nil {
+		tweet.Poll = &model.Poll{ClosesAt: req.Poll.ClosesAt}
+		for _, text := range req.Poll.Options {
+			tweet.Poll.Options = append(tweet.Poll.Options, model.PollOption{Text: text})
+		}
+	}
+	var publishAt time.Time
+	if !req.PublishAt.IsZero() {
+		publishAt = time.Time(req.PublishAt)
+	}
+	scheduled, err := tweetsService.ScheduleTweet(r.Context(), tweet, publishAt)
+	if err != nil {
+		JSONError(w, map[string]interface{}{
+			"message": err.Error(),
+		}, scheduledStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(scheduled)
+}
+
+func listScheduled(w http.ResponseWriter, r *http.Request, tweetsService tweetsservice.Service, userID string) {
+	var limit int64
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.ParseInt(v, 10, 32); err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": "limit must be a number",
+			}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	scheduled, nextKey, err := tweetsService.ListScheduled(r.Context(), userID, int32(limit), r.URL.Query().Get("nextKey"))
+	if err != nil {
+		JSONError(w, map[string]interface{}{
+			"message": err.Error(),
+		}, scheduledStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"scheduled": scheduled,
+		"nextKey":   nextKey,
+	})
+}
+
+type cancelScheduledRequest struct {
+	Id string `json:"id"`
+}
+
+//CancelScheduledHandler cancels a scheduled tweet of the caller. It is too late once the publisher has it: 409.
+//eg: POST /scheduled/cancel {"id": "0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44"}
+func CancelScheduledHandler(tweetsService tweetsservice.Service) http.HandlerFunc {
+	return requireUser(func(w http.ResponseWriter, r *http.Request, userID string) {
+		if r.Method != http.MethodPost {
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req cancelScheduledRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": "invalid body: " + err.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+
+		if err := tweetsService.CancelScheduled(r.Context(), userID, req.Id); err != nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, scheduledStatus(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
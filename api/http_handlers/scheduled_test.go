@@ -0,0 +1,127 @@
+package api_http_handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func Test_ScheduledTweetsHandler(t *testing.T) {
+	publishAt := time.UnixMilli(1767225600000)
+
+	testCases := []struct {
+		name                 string
+		method               string
+		user                 string
+		body                 string
+		buildStubs           func(tweetsService *tweetsservice.MockService)
+		expectedResponseCode int
+	}{
+		{
+			name:   "schedule",
+			method: http.MethodPost,
+			user:   "sarah_edo",
+			body:   `{"text": "Happy new year!", "audience": "followers", "publishAt": 1767225600000}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweet := &model.Tweet{Author: "sarah_edo", Text: "Happy new year!", Audience: &model.Audience{Level: model.AudienceFollowers}}
+				tweetsService.EXPECT().ScheduleTweet(gomock.Any(), tweet, publishAt).Times(1).
+					Return(&model.ScheduledTweet{Id: "scheduled-1", Author: "sarah_edo", Status: model.ScheduledWaiting}, nil)
+			},
+			expectedResponseCode: http.StatusCreated,
+		},
+		{
+			name:   "schedule a poll with media",
+			method: http.MethodPost,
+			user:   "sarah_edo",
+			body:   `{"text": "Tabs or spaces?", "poll": {"options": ["Tabs", "Spaces"], "closesAt": 1767312000000}, "mediaIds": ["media-1"], "publishAt": 1767225600000}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweet := &model.Tweet{Author: "sarah_edo", Text: "Tabs or spaces?", MediaIds: []string{"media-1"}, Poll: &model.Poll{
+					Options:  []model.PollOption{{Text: "Tabs"}, {Text: "Spaces"}},
+					ClosesAt: model.ChirperAppUnixTime(time.UnixMilli(1767312000000)),
+				}}
+				tweetsService.EXPECT().ScheduleTweet(gomock.Any(), tweet, publishAt).Times(1).
+					Return(&model.ScheduledTweet{Id: "scheduled-1", Author: "sarah_edo", Status: model.ScheduledWaiting}, nil)
+			},
+			expectedResponseCode: http.StatusCreated,
+		},
+		{
+			name:   "media taken in between",
+			method: http.MethodPost,
+			user:   "sarah_edo",
+			body:   `{"text": "Happy new year!", "mediaIds": ["media-1"], "publishAt": 1767225600000}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ScheduleTweet(gomock.Any(), gomock.Any(), publishAt).Times(1).Return(nil, tweetsrepo.ErrMediaUnavailable)
+			},
+			expectedResponseCode: http.StatusConflict,
+		},
+		{
+			name:   "invalid tweet",
+			method: http.MethodPost,
+			user:   "sarah_edo",
+			body:   `{"text": "Happy new year!"}`,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ScheduleTweet(gomock.Any(), gomock.Any(), time.Time{}).Times(1).
+					Return(nil, &tweetsservice.ValidationError{Violations: []tweetsservice.FieldViolation{{Field: "publishAt", Description: "publishAt is required"}}})
+			},
+			expectedResponseCode: http.StatusBadRequest,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			user:   "sarah_edo",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ListScheduled(gomock.Any(), "sarah_edo", int32(0), "").Times(1).Return([]*model.ScheduledTweet{}, "", nil)
+			},
+			expectedResponseCode: http.StatusOK,
+		},
+		{
+			name:   "no user",
+			method: http.MethodGet,
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().ListScheduled(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedResponseCode: http.StatusUnauthorized,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+			tc.buildStubs(tweetsServiceMock)
+
+			req := httptest.NewRequest(tc.method, "/scheduled", strings.NewReader(tc.body))
+			if tc.user != "" {
+				req = req.WithContext(identity.WithAuthedUser(req.Context(), tc.user))
+			}
+			rec := httptest.NewRecorder()
+			ScheduledTweetsHandler(tweetsServiceMock)(rec, req)
+
+			checkResponseCode(t, tc.expectedResponseCode, rec.Code)
+		})
+	}
+}
+
+func Test_CancelScheduledHandler(t *testing.T) {
+	tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+	tweetsServiceMock.EXPECT().CancelScheduled(gomock.Any(), "sarah_edo", "scheduled-1").Times(1).Return(nil)
+	tweetsServiceMock.EXPECT().CancelScheduled(gomock.Any(), "sarah_edo", "publishing").Times(1).Return(tweetsrepo.ErrScheduledTweetNotWaiting)
+
+	for id, expected := range map[string]int{"scheduled-1": http.StatusNoContent, "publishing": http.StatusConflict} {
+		req := httptest.NewRequest(http.MethodPost, "/scheduled/cancel", strings.NewReader(`{"id": "`+id+`"}`))
+		req = req.WithContext(identity.WithAuthedUser(req.Context(), "sarah_edo"))
+		rec := httptest.NewRecorder()
+		CancelScheduledHandler(tweetsServiceMock)(rec, req)
+
+		checkResponseCode(t, expected, rec.Code)
+	}
+}
@@ -0,0 +1,52 @@
+package api_http_handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	twitterarchive "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/twitter_archive"
+)
+
+//ImportTwitterArchiveHandler imports the tweets.js file of a Twitter data archive for a chirper user.
+//eg: POST /import-twitter-archive?author=sarah_edo&dryRun=true with the content of tweets.js as the body
+func ImportTwitterArchiveHandler(importer *twitterarchive.Importer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			JSONError(w, map[string]interface{}{
+				"message": "method not allowed",
+			}, http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		dryRun, _ := strconv.ParseBool(q.Get("dryRun"))
+		includeRetweets, _ := strconv.ParseBool(q.Get("includeRetweets"))
+
+		report, err := importer.Import(r.Context(), r.Body, twitterarchive.ImportOptions{
+			MapOptions: twitterarchive.MapOptions{
+				Author:          q.Get("author"),
+				IncludeRetweets: includeRetweets,
+			},
+			DryRun: dryRun,
+		})
+		if err != nil && report == nil {
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+			}, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			//some batches may have been saved already. We send back the report so the client knows how far we got
+			JSONError(w, map[string]interface{}{
+				"message": err.Error(),
+				"report":  report,
+			}, http.StatusMultiStatus)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(report)
+	}
+}
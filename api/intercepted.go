@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+
+	pb "github.com/okpalaChidiebere/chirper-app-gen-protos/tweet/v1"
+	"google.golang.org/grpc"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+//interceptedTweetServer runs a unary interceptor around every call, the way the grpc server would
+type interceptedTweetServer struct {
+	next        pb.TweetServiceServer
+	interceptor grpc.UnaryServerInterceptor
+}
+
+func (s *interceptedTweetServer) intercept(ctx context.Context, method string, req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+	info := &grpc.UnaryServerInfo{Server: s.next, FullMethod: "/" + pb.TweetService_ServiceDesc.ServiceName + "/" + method}
+	return s.interceptor(ctx, req, info, handler)
+}
+
+func (s *interceptedTweetServer) SaveTweet(ctx context.Context, req *pb.SaveTweetRequest) (*pb.SaveTweetResponse, error) {
+	resp, err := s.intercept(ctx, "SaveTweet", req, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.next.SaveTweet(ctx, req.(*pb.SaveTweetRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.SaveTweetResponse), nil
+}
+
+func (s *interceptedTweetServer) ListTweets(ctx context.Context, req *pb.ListTweetsRequest) (*pb.ListTweetsResponse, error) {
+	resp, err := s.intercept(ctx, "ListTweets", req, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.next.ListTweets(ctx, req.(*pb.ListTweetsRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.ListTweetsResponse), nil
+}
+
+func (s *interceptedTweetServer) SaveLikeToggle(ctx context.Context, req *pb.SaveLikeToggleRequest) (*emptypb.Empty, error) {
+	resp, err := s.intercept(ctx, "SaveLikeToggle", req, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.next.SaveLikeToggle(ctx, req.(*pb.SaveLikeToggleRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*emptypb.Empty), nil
+}
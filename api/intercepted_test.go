@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweet_v1 "github.com/okpalaChidiebere/chirper-app-gen-protos/tweet/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_interceptedTweetServer(t *testing.T) {
+	t.Run("should run the interceptor with the grpc method name", func(t *testing.T) {
+		mockService := tweetsservice.NewMockService(gomock.NewController(t))
+		var seenMethod string
+		server := &interceptedTweetServer{
+			next: NewTweetServer(mockService),
+			interceptor: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				seenMethod = info.FullMethod
+				return nil, status.Error(codes.ResourceExhausted, "slow down")
+			},
+		}
+
+		resp, err := server.SaveTweet(context.TODO(), &tweet_v1.SaveTweetRequest{Author: "alice"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Equal(t, "/tweet.v1.TweetService/SaveTweet", seenMethod)
+	})
+}
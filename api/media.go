@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+//MediaHeader attaches uploads to a new tweet, like AudienceHeader picks its audience. It holds up to 4 media ids
+//separated by commas, in the order they are shown.
+//eg: X-Tweet-Media: 0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44,5d0c1b1e-8a41-4f0e-b8a3-2a7c9e1d6f3b
+const MediaHeader = "X-Tweet-Media"
+
+var mediaMetadata = strings.ToLower(MediaHeader)
+
+//requestedMediaIds are the media the client attached, or nil. They are checked with the rest of the tweet
+func requestedMediaIds(ctx context.Context) []string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+	var ids []string
+	for _, v := range md.Get(mediaMetadata) {
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+//MediaLayoutHeader is the header metadata ListTweets answers with when the page has media, since the Tweet message has
+//no field for them. It is a JSON object of the media of each tweet, keyed by the tweet id, with what clients need to lay
+//them out before they load. Over http the gateway sends it as Grpc-Metadata-X-Tweet-Media-Layout. It is capped like
+//PollsHeader: the tweets left out start at the one in X-Tweet-Media-Layout-Next.
+//eg: {"0b5ba3a6-...": [{"id": "5d0c1b1e-...", "contentType": "image/jpeg", "width": 1080, "height": 1350, "placeholder": "LEHV6nWB2yk8pyo0adR*.7kCMdnj"}]}
+const MediaLayoutHeader = "X-Tweet-Media-Layout"
+
+type mediaLayout struct {
+	Id          string `json:"id"`
+	ContentType string `json:"contentType"`
+	//Width, Height and Placeholder are set once the image is processed
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	Placeholder string `json:"placeholder,omitempty"`
+}
+
+//sendMediaLayout sends the MediaLayoutHeader of a page of tweets, if any of them has media
+func sendMediaLayout(ctx context.Context, tweets []*model.Tweet) {
+	sendTweetsHeader(ctx, MediaLayoutHeader, tweets, func(tweet *model.Tweet) (interface{}, bool) {
+		layout := make([]mediaLayout, 0, len(tweet.Media))
+		for _, m := range tweet.Media {
+			layout = append(layout, mediaLayout{Id: m.Id, ContentType: m.ContentType, Width: m.Width, Height: m.Height, Placeholder: m.Placeholder})
+		}
+		return layout, len(layout) > 0
+	})
+}
+
+//MediaChecksumMetadata is the hex SHA-256 of a whole upload. It is optional; when set, an upload that does not match it is rejected
+const MediaChecksumMetadata = "x-media-checksum"
+
+//UploadMediaMethod is the full name of the UploadMedia rpc, for conn.NewStream
+const UploadMediaMethod = "/tweet.v1.MediaService/UploadMedia"
+
+//MediaServiceServer uploads media over gRPC
+type MediaServiceServer interface {
+	UploadMedia(stream grpc.ServerStream) error
+}
+
+//MediaServiceDesc declares the client streaming UploadMedia rpc. The Tweet service protos don't have it, so it is made of
+//well known types: the client sends the file as google.protobuf.BytesValue chunks, closes its side and gets the media
+//back as a google.protobuf.Struct. Clients use the same desc:
+//
+//	stream, err := conn.NewStream(ctx, &api.MediaServiceDesc.Streams[0], api.UploadMediaMethod)
+var MediaServiceDesc = grpc.ServiceDesc{
+	ServiceName: "tweet.v1.MediaService",
+	HandlerType: (*MediaServiceServer)(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadMedia",
+			Handler:       uploadMediaHandler,
+			ClientStreams: true,
+		},
+	},
+}
+
+func uploadMediaHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MediaServiceServer).UploadMedia(stream)
+}
+
+type MediaServer struct {
+	TweetService tweetsservice.Service
+}
+
+func NewMediaServer(tweetsService tweetsservice.Service) *MediaServer {
+	return &MediaServer{TweetService: tweetsService}
+}
+
+//UploadMedia hands the chunks to the service as they come, so the file is never held in memory
+func (s *MediaServer) UploadMedia(stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	userID := identity.AuthedUser(ctx)
+	if userID == "" {
+		return status.Error(codes.Unauthenticated, "the authed-user-id metadata is required")
+	}
+	var checksum string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(MediaChecksumMetadata); len(v) > 0 {
+			checksum = v[0]
+		}
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		for {
+			chunk := &wrapperspb.BytesValue{}
+			if err := stream.RecvMsg(chunk); err != nil {
+				if err == io.EOF {
+					pw.Close()
+				} else {
+					pw.CloseWithError(err)
+				}
+				return
+			}
+			if _, err := pw.Write(chunk.GetValue()); err != nil {
+				return //the service stopped reading, eg the upload is too large
+			}
+		}
+	}()
+	media, err := s.TweetService.UploadMedia(ctx, userID, pr, checksum)
+	pr.Close()
+	if err != nil {
+		slog.ErrorContext(ctx, "UploadMedia failed", "err", err, "user", userID)
+		return toStatusError(err)
+	}
+
+	res, err := structpb.NewStruct(map[string]interface{}{
+		"id":          media.Id,
+		"contentType": media.ContentType,
+		"size":        float64(media.Size),
+		"checksum":    media.Checksum,
+		"createdAt":   float64(time.Time(media.CreatedAt).UnixMilli()),
+	})
+	if err != nil {
+		return err
+	}
+	return stream.SendMsg(res)
+}
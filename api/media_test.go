@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweet_v1 "github.com/okpalaChidiebere/chirper-app-gen-protos/tweet/v1"
+)
+
+func TestTweetsSever_SaveTweet_Media(t *testing.T) {
+	tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+	tweetsServiceMock.EXPECT().SaveTweet(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(ctx context.Context, tweet *model.Tweet) (*model.Tweet, error) {
+		assert.Equal(t, []string{"media-1", "media-2"}, tweet.MediaIds)
+		return tweet, nil
+	})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tweet-media", "media-1, media-2,"))
+	_, err := NewTweetServer(tweetsServiceMock).SaveTweet(ctx, &tweet_v1.SaveTweetRequest{Author: "sarah_edo", Text: "my cat"})
+	assert.NoError(t, err)
+
+	key, ok := IncomingHeaderMatcher("X-Tweet-Media")
+	assert.True(t, ok)
+	assert.Equal(t, "x-tweet-media", key)
+}
+
+func TestMediaServer_UploadMedia(t *testing.T) {
+	testCases := []struct {
+		name         string
+		user         string
+		buildStubs   func(tweetsService *tweetsservice.MockService)
+		expectedCode codes.Code
+	}{
+		{
+			name: "should upload the chunks",
+			user: "sarah_edo",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().UploadMedia(gomock.Any(), "sarah_edo", gomock.Any(), "abc").Times(1).
+					DoAndReturn(func(ctx context.Context, userID string, body io.Reader, checksum string) (*model.Media, error) {
+						content, err := io.ReadAll(body)
+						assert.NoError(t, err)
+						assert.Equal(t, "pretend png", string(content))
+						return &model.Media{Id: "media-1", ContentType: "image/png", Size: 11, Checksum: "abc", CreatedAt: model.ChirperAppUnixTime(time.UnixMilli(1767225600000))}, nil
+					})
+			},
+		},
+		{
+			name: "should reject an unsupported media",
+			user: "sarah_edo",
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().UploadMedia(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(ctx context.Context, userID string, body io.Reader, checksum string) (*model.Media, error) {
+						return nil, tweetsservice.ErrUnsupportedMedia
+					})
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "should need a user",
+			buildStubs:   func(tweetsService *tweetsservice.MockService) {},
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+			tc.buildStubs(tweetsServiceMock)
+
+			lis := bufconn.Listen(1024 * 1024)
+			grpcServer := grpc.NewServer(grpc.StreamInterceptor(identity.StreamServerInterceptor()))
+			Servers{MediaServer: NewMediaServer(tweetsServiceMock), HealthServer: NewHealthServer(nil, time.Minute, time.Second)}.RegisterAllService(grpcServer)
+			go grpcServer.Serve(lis)
+			defer grpcServer.Stop()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			conn, err := grpc.DialContext(ctx, "bufnet",
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+				grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			md := metadata.Pairs(MediaChecksumMetadata, "abc")
+			if tc.user != "" {
+				md.Set("authed-user-id", tc.user)
+			}
+			stream, err := conn.NewStream(metadata.NewOutgoingContext(ctx, md), &MediaServiceDesc.Streams[0], UploadMediaMethod)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, chunk := range []string{"pretend", " png"} {
+				stream.SendMsg(wrapperspb.Bytes([]byte(chunk)))
+			}
+			stream.CloseSend()
+
+			res := &structpb.Struct{}
+			err = stream.RecvMsg(res)
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode == codes.OK {
+				assert.Equal(t, "media-1", res.GetFields()["id"].GetStringValue())
+				assert.Equal(t, float64(1767225600000), res.GetFields()["createdAt"].GetNumberValue())
+			}
+		})
+	}
+}
+
+//fakeTransportStream records the header metadata of an rpc
+type fakeTransportStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (f *fakeTransportStream) SetHeader(md metadata.MD) error {
+	f.header = metadata.Join(f.header, md)
+	return nil
+}
+
+func TestTweetsSever_ListTweets_MediaLayout(t *testing.T) {
+	tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+	first := tweetsServiceMock.EXPECT().ListTweets(gomock.Any(), "", int32(0), "").Times(1).Return([]*model.Tweet{
+		{Id: "photos", MediaIds: []string{"a", "b"}, Media: []*model.Media{
+			{Id: "a", ContentType: "image/jpeg", Width: 1080, Height: 1350, Placeholder: "LEHV6nWB2yk8pyo0adR*.7kCMdnj"},
+			{Id: "b", ContentType: "video/mp4"},
+		}},
+		{Id: "text"},
+	}, "", nil)
+	tweetsServiceMock.EXPECT().ListTweets(gomock.Any(), "", int32(0), "").Times(1).After(first).Return([]*model.Tweet{{Id: "text"}}, "", nil)
+
+	stream := &fakeTransportStream{}
+	_, err := NewTweetServer(tweetsServiceMock).ListTweets(grpc.NewContextWithServerTransportStream(context.Background(), stream), &tweet_v1.ListTweetsRequest{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"photos": [
+		{"id": "a", "contentType": "image/jpeg", "width": 1080, "height": 1350, "placeholder": "LEHV6nWB2yk8pyo0adR*.7kCMdnj"},
+		{"id": "b", "contentType": "video/mp4"}
+	]}`, strings.Join(stream.header.Get("x-tweet-media-layout"), ""))
+
+	stream = &fakeTransportStream{}
+	_, err = NewTweetServer(tweetsServiceMock).ListTweets(grpc.NewContextWithServerTransportStream(context.Background(), stream), &tweet_v1.ListTweetsRequest{})
+	assert.NoError(t, err)
+	assert.Empty(t, stream.header.Get("x-tweet-media-layout"), "a page without media has no layout")
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	"google.golang.org/grpc/metadata"
+)
+
+//PollHeader attaches a poll to a new tweet, like AudienceHeader picks its audience. It holds JSON with the options and
+//when the poll closes, in unix milliseconds like the timestamp of the tweet.
+//eg: X-Tweet-Poll: {"options": ["Tabs", "Spaces"], "closesAt": 1767225600000}
+const PollHeader = "X-Tweet-Poll"
+
+var pollMetadata = strings.ToLower(PollHeader)
+
+type pollHeader struct {
+	Options  []string                 `json:"options"`
+	ClosesAt model.ChirperAppUnixTime `json:"closesAt"`
+}
+
+//requestedPoll is the poll the client attached, or nil for a tweet without one. The options and closesAt are checked
+//with the rest of the tweet
+func requestedPoll(ctx context.Context) (*model.Poll, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	v := md.Get(pollMetadata)
+	if len(v) == 0 || strings.TrimSpace(v[0]) == "" {
+		return nil, nil
+	}
+
+	var h pollHeader
+	if err := json.Unmarshal([]byte(v[0]), &h); err != nil {
+		return nil, &tweetsservice.ValidationError{Violations: []tweetsservice.FieldViolation{
+			{Field: "poll", Description: PollHeader + " must be JSON like {\"options\": [\"Tabs\", \"Spaces\"], \"closesAt\": 1767225600000}"},
+		}}
+	}
+	poll := &model.Poll{ClosesAt: h.ClosesAt}
+	for _, text := range h.Options {
+		poll.Options = append(poll.Options, model.PollOption{Text: text})
+	}
+	return poll, nil
+}
+
+//PollsHeader is the header metadata ListTweets and SaveTweet answer with when a tweet has a poll, since the Tweet message
+//has no field for it. It is a JSON object of the polls keyed by the tweet id, the way GET /polls answers them: the votes
+//are left out until the caller voted or the poll closed. Over http the gateway sends it as Grpc-Metadata-X-Tweet-Polls.
+//A header has room for a few KiB only(maxTweetsHeader), so the polls of a long page may be cut short: then
+//X-Tweet-Polls-Next holds the id of the first tweet left out, and clients get the polls from there on with GET /polls.
+//eg: {"0b5ba3a6-...": {"options": [{"text": "Tabs", "votes": 0}, {"text": "Spaces", "votes": 0}], "closesAt": 1767225600000, "resultsHidden": true}}
+const PollsHeader = "X-Tweet-Polls"
+
+//sendPollLayout sends the PollsHeader of the tweets, if any of them has a poll. The service already hid the results
+func sendPollLayout(ctx context.Context, tweets []*model.Tweet) {
+	sendTweetsHeader(ctx, PollsHeader, tweets, func(tweet *model.Tweet) (interface{}, bool) {
+		return tweet.Poll, tweet.Poll != nil
+	})
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweet_v1 "github.com/okpalaChidiebere/chirper-app-gen-protos/tweet/v1"
+)
+
+func TestTweetsSever_Polls(t *testing.T) {
+	closesAt := model.ChirperAppUnixTime(time.UnixMilli(1767225600000))
+	voted := 1
+	tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+	tweetsServiceMock.EXPECT().ListTweets(gomock.Any(), "", int32(0), "").Times(1).Return([]*model.Tweet{
+		{Id: "hidden", Poll: &model.Poll{Options: []model.PollOption{{Text: "Tabs"}, {Text: "Spaces"}}, ClosesAt: closesAt, ResultsHidden: true}},
+		{Id: "voted", Poll: &model.Poll{Options: []model.PollOption{{Text: "Tabs", Votes: 3}, {Text: "Spaces", Votes: 4}}, ClosesAt: closesAt, VotedOption: &voted}},
+		{Id: "no-poll"},
+	}, "", nil)
+	tweetsServiceMock.EXPECT().SaveTweet(gomock.Any(), gomock.Any()).Times(1).Return(&model.Tweet{Id: "new"}, nil)
+
+	stream := &fakeTransportStream{}
+	_, err := NewTweetServer(tweetsServiceMock).ListTweets(grpc.NewContextWithServerTransportStream(context.Background(), stream), &tweet_v1.ListTweetsRequest{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"hidden": {"options": [{"text": "Tabs", "votes": 0}, {"text": "Spaces", "votes": 0}], "closesAt": 1767225600000, "resultsHidden": true},
+		"voted": {"options": [{"text": "Tabs", "votes": 3}, {"text": "Spaces", "votes": 4}], "closesAt": 1767225600000, "votedOption": 1}
+	}`, strings.Join(stream.header.Get("x-tweet-polls"), ""))
+
+	stream = &fakeTransportStream{}
+	_, err = NewTweetServer(tweetsServiceMock).SaveTweet(grpc.NewContextWithServerTransportStream(context.Background(), stream), &tweet_v1.SaveTweetRequest{Author: "sarah_edo", Text: "hello"})
+	assert.NoError(t, err)
+	assert.Empty(t, stream.header.Get("x-tweet-polls"), "a tweet without a poll has no header")
+}
@@ -9,38 +9,71 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	http_handlers "github.com/okpalaChidiebere/chirper-app-api-tweet/api/http_handlers"
 	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	twitterarchive "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/twitter_archive"
 	pb "github.com/okpalaChidiebere/chirper-app-gen-protos/tweet/v1"
 	health_v1 "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type Servers struct {
 	TweetServer  pb.TweetServiceServer
+	//MediaServer takes the uploads over gRPC. Over http they go to /media
+	MediaServer MediaServiceServer
 	health_v1.HealthServer 
+	//GatewayInterceptor runs around the calls of the http gateway. The gateway calls TweetServer in process, so the
+	//interceptors of the grpc server never see them
+	GatewayInterceptor grpc.UnaryServerInterceptor
+	//Reviewers can use the moderation review queue endpoints
+	Reviewers http_handlers.Reviewers
 }
 
 type APIServer struct {
 	httpMux *http.ServeMux
+	reviewers http_handlers.Reviewers
 }
 
 func (a Servers) NewAPIServer(httpMux *http.ServeMux) *APIServer{
-	server := &APIServer{ httpMux: httpMux, }
+	server := &APIServer{ httpMux: httpMux, reviewers: a.Reviewers, }
 	return server
 }
 
 func (server *APIServer) RegisterAllEndpoint(tweetsService tweetsservice.Service) error {
 	server.httpMux.HandleFunc("/migrate-tweet", http_handlers.MigrateTweetsHandler(tweetsService))
+	server.httpMux.HandleFunc("/export-tweets", http_handlers.ExportTweetsHandler(tweetsService))
+	server.httpMux.HandleFunc("/import-twitter-archive", http_handlers.ImportTwitterArchiveHandler(twitterarchive.NewImporter(tweetsService)))
+	server.httpMux.HandleFunc("/admin/moderation/queue", http_handlers.ReviewQueueHandler(tweetsService, server.reviewers))
+	server.httpMux.HandleFunc("/admin/moderation/review", http_handlers.ReviewTweetHandler(tweetsService, server.reviewers))
+	server.httpMux.HandleFunc("/relations", http_handlers.ListRelationsHandler(tweetsService))
+	for _, action := range []string{http_handlers.BlockAction, http_handlers.UnblockAction, http_handlers.MuteAction, http_handlers.UnmuteAction, http_handlers.FollowAction, http_handlers.UnfollowAction} {
+		server.httpMux.HandleFunc("/relations/"+action, http_handlers.RelationHandler(tweetsService, action))
+	}
+	server.httpMux.HandleFunc("/scheduled", http_handlers.ScheduledTweetsHandler(tweetsService))
+	server.httpMux.HandleFunc("/scheduled/cancel", http_handlers.CancelScheduledHandler(tweetsService))
+	server.httpMux.HandleFunc("/drafts", http_handlers.DraftsHandler(tweetsService))
+	server.httpMux.HandleFunc("/drafts/delete", http_handlers.DeleteDraftHandler(tweetsService))
+	server.httpMux.HandleFunc("/drafts/publish", http_handlers.PublishDraftHandler(tweetsService))
+	server.httpMux.HandleFunc("/polls", http_handlers.PollHandler(tweetsService))
+	server.httpMux.HandleFunc("/polls/vote", http_handlers.VotePollHandler(tweetsService))
+	server.httpMux.HandleFunc("/media", http_handlers.MediaHandler(tweetsService))
+	server.httpMux.HandleFunc("/tweets/media", http_handlers.TweetMediaHandler(tweetsService))
 	return nil
 }
 
 // Add endpoints to grpc
 func (a Servers) RegisterAllService (s *grpc.Server){
 	pb.RegisterTweetServiceServer(s, a.TweetServer)
+	if a.MediaServer != nil {
+		s.RegisterService(&MediaServiceDesc, a.MediaServer)
+	}
 	health_v1.RegisterHealthServer(s, a.HealthServer)
 }
 
 //Add endpoints to runtime.ServeMux for http
 func (a Servers) RegisterAllServiceHandler (ctx context.Context, mux *runtime.ServeMux) error {
-	err := pb.RegisterTweetServiceHandlerServer(ctx, mux, a.TweetServer)
+	var server pb.TweetServiceServer = a.TweetServer
+	if a.GatewayInterceptor != nil {
+		server = &interceptedTweetServer{next: a.TweetServer, interceptor: a.GatewayInterceptor}
+	}
+	err := pb.RegisterTweetServiceHandlerServer(ctx, mux, server)
 	if err != nil {
 		return err
 	}
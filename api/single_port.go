@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+)
+
+//SinglePortHandler serves gRPC and HTTP from one port(SINGLE_PORT=true). HTTP/2 requests with content-type application/grpc go to
+//grpcServer, everything else(REST gateway, /migrate-tweet, HTTP/1.1) goes to h. Plaintext HTTP/2 is accepted with h2c, so grpc clients don't need TLS.
+//
+//The http server owns the connections in this mode: drain them with http.Server.Shutdown and then call grpcServer.Stop.
+//grpcServer.GracefulStop panics while a request served this way is still running
+func SinglePortHandler(grpcServer *grpc.Server, h http.Handler) http.Handler {
+	return h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}), &http2.Server{})
+}
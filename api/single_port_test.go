@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	health_v1 "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestSinglePortHandler(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	healthServer := NewHealthServer(nil, time.Minute, time.Second)
+	healthServer.probe(context.Background())
+	grpcServer := grpc.NewServer()
+	health_v1.RegisterHealthServer(grpcServer, healthServer)
+	httpMux := http.NewServeMux()
+	httpMux.HandleFunc("/migrate-tweet", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	})
+	httpServer := &http.Server{Handler: SinglePortHandler(grpcServer, httpMux)}
+	go httpServer.Serve(lis)
+
+	addr := lis.Addr().String()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	t.Run("should route grpc calls to the grpc server", func(t *testing.T) {
+		conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		res, err := health_v1.NewHealthClient(conn).Check(ctx, &health_v1.HealthCheckRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, health_v1.HealthCheckResponse_SERVING, res.GetStatus())
+	})
+
+	h2c := &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}
+	testCases := []struct {
+		name          string
+		client        *http.Client
+		expectedProto string
+	}{
+		{
+			name:          "should route http/1.1 requests to the http handler",
+			client:        &http.Client{},
+			expectedProto: "HTTP/1.1",
+		},
+		{
+			name:          "should route h2c requests to the http handler",
+			client:        &http.Client{Transport: h2c},
+			expectedProto: "HTTP/2.0",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			//twice, so a connection that breaks after the first response fails the test
+			for i := 0; i < 2; i++ {
+				res, err := tc.client.Get("http://" + addr + "/migrate-tweet")
+				if err != nil {
+					t.Fatal(err)
+				}
+				body, _ := io.ReadAll(res.Body)
+				res.Body.Close()
+
+				assert.Equal(t, http.StatusOK, res.StatusCode)
+				assert.Equal(t, tc.expectedProto, string(body))
+			}
+		})
+	}
+
+	t.Run("should shut down with requests served by the grpc server", func(t *testing.T) {
+		assert.NoError(t, httpServer.Shutdown(ctx))
+		grpcServer.Stop()
+	})
+}
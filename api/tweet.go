@@ -2,10 +2,11 @@ package api
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"time"
 
 	apiadapters "github.com/okpalaChidiebere/chirper-app-api-tweet/api/adapters"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
 	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
 	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
 	pb "github.com/okpalaChidiebere/chirper-app-gen-protos/tweet/v1"
@@ -24,6 +25,10 @@ func NewTweetServer(tweetsService tweetsservice.Service) pb.TweetServiceServer {
 }
 
 func (s *TweetServer) SaveTweet(ctx context.Context, req *pb.SaveTweetRequest) (*pb.SaveTweetResponse, error) {
+	poll, err := requestedPoll(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
 	t := &model.Tweet{
 		Id: req.GetId(),
 		Author: req.GetAuthor(),
@@ -32,24 +37,31 @@ func (s *TweetServer) SaveTweet(ctx context.Context, req *pb.SaveTweetRequest) (
 		Text: req.GetText(),
 		Timestamp: model.ChirperAppUnixTime(time.UnixMilli(req.GetTimestamp())),
 		ReplyingTo: req.GetReplyingTo(),
+		Audience: requestedAudience(ctx),
+		Poll: poll,
+		MediaIds: requestedMediaIds(ctx),
 	}
 
 	tweet, err := s.TweetService.SaveTweet(ctx, t)
 	if err != nil {
-		log.Printf("SaveTweet Err: %v", err.Error())
-		return nil, err
+		slog.ErrorContext(ctx, "SaveTweet failed", "err", err, "tweet", t)
+		return nil, toStatusError(err)
 	}
 
+	sendPollLayout(ctx, []*model.Tweet{tweet})
 	return &pb.SaveTweetResponse{Tweet: apiadapters.TweetToProto(tweet) } , nil
 }
 
 func (s *TweetServer) ListTweets(ctx context.Context, req *pb.ListTweetsRequest) (*pb.ListTweetsResponse, error){
-	tweets, nk, err := s.TweetService.ListTweets(ctx, req.GetLimit(), req.GetNextKey())
+	//the viewer's blocks and mutes are left out. Anonymous callers see every author
+	tweets, nk, err := s.TweetService.ListTweets(ctx, identity.AuthedUser(ctx), req.GetLimit(), req.GetNextKey())
 	if err != nil {
-		log.Printf("ListTweets Err: %v", err.Error())
-		return nil, err
+		slog.ErrorContext(ctx, "ListTweets failed", "err", err, "limit", req.GetLimit())
+		return nil, toStatusError(err)
 	}
 
+	sendPollLayout(ctx, tweets)
+	sendMediaLayout(ctx, tweets)
 	todos := apiadapters.TweetsToProto(tweets)
 	return &pb.ListTweetsResponse{ Items: todos, NextKey: nk } , nil
 }
@@ -57,8 +69,8 @@ func (s *TweetServer) ListTweets(ctx context.Context, req *pb.ListTweetsRequest)
 func (s *TweetServer) SaveLikeToggle(ctx context.Context, req *pb.SaveLikeToggleRequest) (*emptypb.Empty, error){
 	err := s.TweetService.SaveLikeToggle(ctx, req.GetId(), req.GetAuthor(), req.GetAuthedUserId(), req.GetHasLiked())
 	if err != nil {
-		log.Printf("SaveLikeToggle Err: %v", err.Error())
-		return nil, err
+		slog.ErrorContext(ctx, "SaveLikeToggle failed", "err", err, "tweetId", req.GetId())
+		return nil, toStatusError(err)
 	}
 	
 	return &emptypb.Empty{}, nil
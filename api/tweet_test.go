@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -10,7 +11,13 @@ import (
 	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
 	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
 	tweet_v1 "github.com/okpalaChidiebere/chirper-app-gen-protos/tweet/v1"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestTweetsSever_SaveTweet(t *testing.T){
@@ -78,6 +85,26 @@ func TestTweetsSever_SaveTweet(t *testing.T){
 	}
 }
 
+func TestTweetsSever_SaveTweet_InvalidTweet(t *testing.T){
+	ctrl := gomock.NewController(t)
+	tweetsServiceMock := tweetsservice.NewMockService(ctrl)
+	tweetsServiceMock.EXPECT().SaveTweet(gomock.Any(), gomock.Any()).Return(nil, &tweetsservice.ValidationError{Violations: []tweetsservice.FieldViolation{
+		{Field: "author", Description: "author is required"},
+		{Field: "text", Description: "text is required"},
+	}})
+
+	_, err := NewTweetServer(tweetsServiceMock).SaveTweet(context.Background(), &tweet_v1.SaveTweetRequest{})
+
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "author is required; text is required", st.Message())
+	assert.Len(t, st.Details(), 1)
+	badRequest := st.Details()[0].(*errdetails.BadRequest)
+	assert.Equal(t, "author", badRequest.FieldViolations[0].Field)
+	assert.Equal(t, "text", badRequest.FieldViolations[1].Field)
+	assert.Equal(t, "text is required", badRequest.FieldViolations[1].Description)
+}
+
 func TestTweetsSever_SaveLikeToggle(t *testing.T){
 	testCases := []struct {
 		name          string
@@ -97,6 +124,12 @@ func TestTweetsSever_SaveLikeToggle(t *testing.T){
 			saveLikeToggleError:  errors.New("error"),
 			expectedError:  errors.New("error"),
 		},
+		{
+			name: "returns PermissionDenied when the author blocked the user",
+			inputReq: &tweet_v1.SaveLikeToggleRequest{},
+			saveLikeToggleError:  tweetsservice.ErrBlocked,
+			expectedError:  status.Error(codes.PermissionDenied, tweetsservice.ErrBlocked.Error()),
+		},
 		{
 			name: "returns nil on success",
 			inputReq: &tweet_v1.SaveLikeToggleRequest{},
@@ -127,6 +160,7 @@ func TestTweetsSever_ListTweets(t *testing.T){
 	testCases := []struct {
 		name          string
 		inputReq      *tweet_v1.ListTweetsRequest
+		viewer  string
 
 		limit   int32
 		nextKey  string
@@ -144,6 +178,19 @@ func TestTweetsSever_ListTweets(t *testing.T){
 			listError: errors.New("error"),
 			expectedError: errors.New("error"),
 		},
+		{
+			name: "returns InvalidArgument when the cursor is invalid",
+			inputReq: &tweet_v1.ListTweetsRequest{},
+			listError: fmt.Errorf("%w: bad signature", cursor.ErrInvalidCursor),
+			expectedError: status.Error(codes.InvalidArgument, "invalid cursor: bad signature"),
+		},
+		{
+			name: "lists the tweets for the authed user",
+			inputReq: &tweet_v1.ListTweetsRequest{},
+			viewer: "sarah_edo",
+			expectedTweets: []*model.Tweet{},
+			expectedResponse: &tweet_v1.ListTweetsResponse{},
+		},
 		{
 			name: "properly converst Notes; OK request",
 			inputReq:  &tweet_v1.ListTweetsRequest{},
@@ -184,9 +231,12 @@ func TestTweetsSever_ListTweets(t *testing.T){
 			ctx := context.Background()
 
 			tweetsServiceMock := tweetsservice.NewMockService(ctrl)
-			tweetsServiceMock.EXPECT().ListTweets(gomock.Any(), tc.limit, tc.nextKey).Return(tc.expectedTweets, tc.expectedNextKey, tc.listError).Times(1)
+			tweetsServiceMock.EXPECT().ListTweets(gomock.Any(), tc.viewer, tc.limit, tc.nextKey).Return(tc.expectedTweets, tc.expectedNextKey, tc.listError).Times(1)
 
 			s := NewTweetServer(tweetsServiceMock)
+			if tc.viewer != "" {
+				ctx = identity.WithAuthedUser(ctx, tc.viewer)
+			}
 
 			got, err := s.ListTweets(ctx, tc.inputReq)
 			assert.Equal(t, tc.expectedError, err)
@@ -194,3 +244,62 @@ func TestTweetsSever_ListTweets(t *testing.T){
 		})
 	}
 }
+
+func TestTweetsSever_SaveTweet_Audience(t *testing.T){
+	ctrl := gomock.NewController(t)
+	tweetsServiceMock := tweetsservice.NewMockService(ctrl)
+	tweetsServiceMock.EXPECT().SaveTweet(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, tweet *model.Tweet) (*model.Tweet, error) {
+		assert.Equal(t, &model.Audience{Level: model.AudienceFollowers}, tweet.Audience)
+		return tweet, nil
+	})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tweet-audience", "followers"))
+	_, err := NewTweetServer(tweetsServiceMock).SaveTweet(ctx, &tweet_v1.SaveTweetRequest{Author: "sarah_edo", Text: "hello"})
+	assert.NoError(t, err)
+
+	key, ok := IncomingHeaderMatcher("x-tweet-audience")
+	assert.True(t, ok)
+	assert.Equal(t, "x-tweet-audience", key)
+}
+
+func TestTweetsSever_SaveTweet_Poll(t *testing.T){
+	testCases := []struct {
+		name          string
+		header        string
+		expectedPoll  *model.Poll
+		expectedCode  codes.Code
+	}{
+		{
+			name: "should attach the poll of the header",
+			header: `{"options": ["Tabs", "Spaces"], "closesAt": 1767225600000}`,
+			expectedPoll: &model.Poll{Options: []model.PollOption{{Text: "Tabs"}, {Text: "Spaces"}}, ClosesAt: model.ChirperAppUnixTime(time.UnixMilli(1767225600000))},
+		},
+		{
+			name: "should reject a header that is not JSON",
+			header: "Tabs, Spaces",
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			tweetsServiceMock := tweetsservice.NewMockService(gomock.NewController(t))
+			if tc.expectedPoll != nil {
+				tweetsServiceMock.EXPECT().SaveTweet(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(ctx context.Context, tweet *model.Tweet) (*model.Tweet, error) {
+					assert.Equal(t, tc.expectedPoll, tweet.Poll)
+					return tweet, nil
+				})
+			}
+
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tweet-poll", tc.header))
+			_, err := NewTweetServer(tweetsServiceMock).SaveTweet(ctx, &tweet_v1.SaveTweetRequest{Author: "sarah_edo", Text: "Tabs or spaces?"})
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+		})
+	}
+
+	key, ok := IncomingHeaderMatcher("X-Tweet-Poll")
+	assert.True(t, ok)
+	assert.Equal(t, "x-tweet-poll", key)
+}
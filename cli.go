@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/config"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/logging"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/metrics"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/tracing"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+)
+
+//command is a subcommand of the binary. eg: `./main export -format=csv`. Ops run one-off jobs with the same image as the server
+type command struct {
+	name    string
+	usage   string //the arguments, shown in --help
+	summary string
+	//setup declares the flags of the command and returns what runs once they are parsed. Flags are parsed before the config is loaded so --help always works
+	setup func(fs *flag.FlagSet) func(ctx context.Context, env *environment) error
+}
+
+func commands() []command {
+	return []command{
+		{name: "serve", summary: "run the gRPC and HTTP servers. This is the default when no command is given", setup: serveCommand},
+		{name: "migrate", usage: "--file tweets.json [--dry-run]", summary: "bulk import tweets from a JSON, NDJSON or CSV file", setup: migrateCommand},
+		{name: "export", usage: "[--format csv] [--out tweets.csv]", summary: "stream the tweets table out as NDJSON, JSON or CSV", setup: exportCommand},
+		{name: "import-twitter-archive", usage: "--file tweets.js --author handle", summary: "import the tweets.js file of a Twitter data archive", setup: importTwitterArchiveCommand},
+		{name: "create-tables", summary: "create the tables if they don't exist and wait until they are active", setup: createTablesCommand},
+		{name: "seed", usage: "[--users 25] [--tweets 500] [--seed 1]", summary: "fill the tables with generated users and tweets. The same seed always gives the same data", setup: seedCommand},
+		{name: "reindex", summary: "rebuild the tweets set of every user from the tweets table", setup: reindexCommand},
+		{name: "repair-counters", usage: "[--dry-run]", summary: "make the replies of every tweet match the tweets that reply to it", setup: repairCountersCommand},
+		{name: "get-tweet", usage: "--id tweetID", summary: "print a single tweet", setup: getTweetCommand},
+		{name: "list-tweets", usage: "[--limit 10] [--cursor nextKey]", summary: "print a page of tweets and the cursor of the next page", setup: listTweetsCommand},
+		{name: "review-queue", usage: "[--limit 10] [--cursor nextKey]", summary: "print a page of the tweets moderation held for review", setup: reviewQueueCommand},
+		{name: "review-tweet", usage: "--id tweetID --decision approve|remove", summary: "approve or remove a tweet held for review", setup: reviewTweetCommand},
+		{name: "list-scheduled", usage: "--author handle [--limit 10] [--cursor nextKey]", summary: "print a page of the scheduled tweets of an author", setup: listScheduledCommand},
+		{name: "publish-scheduled", summary: "publish the scheduled tweets that are due, once", setup: publishScheduledCommand},
+		{name: "collect-orphan-media", summary: "delete the uploads no tweet attached within MEDIA_ORPHAN_TTL, once", setup: collectOrphanMediaCommand},
+		{name: "process-media", summary: "make the thumbnails, sizes and placeholders of the images waiting for the worker, once", setup: processMediaCommand},
+	}
+}
+
+func findCommand(name string) (command, bool) {
+	for _, c := range commands() {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands() {
+		fmt.Fprintf(w, "  %-24s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> --help' for the flags of a command. The configuration comes from env vars and CONFIG_FILE, see the README\n", os.Args[0])
+}
+
+//runCommand parses args(without the program name) and runs the matching command
+func runCommand(ctx context.Context, args []string) error {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	} else if len(args) > 0 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+		printUsage(os.Stdout)
+		return nil
+	}
+	if name == "help" {
+		printUsage(os.Stdout)
+		return nil
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		printUsage(os.Stderr)
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s %s\n\n%s\n\nFlags:\n", os.Args[0], cmd.name, cmd.usage, cmd.summary)
+		fs.PrintDefaults()
+	}
+	run := cmd.setup(fs)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	env, err := newEnvironment(ctx)
+	if err != nil {
+		return err
+	}
+	return run(ctx, env)
+}
+
+//environment is what every command shares: the config, the DynamoDB client and the tweets service built on top of it
+type environment struct {
+	config        *config.Config
+	dynamodb      common.DynamoDBAPI
+	tables        tweetsrepo.Tables
+	tweetsService tweetsservice.Service
+}
+
+func newEnvironment(ctx context.Context) (*environment, error) {
+	mConfig, err := config.Load()
+	if err != nil {
+		//the error lists every missing or invalid value
+		return nil, err
+	}
+	//JSON lines on stderr. stdout is kept for the output of the commands, eg `export`
+	slog.SetDefault(logging.New(os.Stderr, logging.Options{Level: mConfig.Log.Level, RedactText: mConfig.Log.RedactText}))
+
+	cfg, err := loadAWSConfig(ctx, mConfig)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load local SDK config, %w", err)
+	}
+
+	//every call is measured(see /metrics) and traced. The metrics ask DynamoDB for the consumed capacity the spans record
+	dynamodbClient := tracing.InstrumentDynamoDB(metrics.InstrumentDynamoDB(dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if mConfig.Aws.DynamoDBEndpoint != "" {
+			o.EndpointResolver = dynamodb.EndpointResolverFromURL(mConfig.Aws.DynamoDBEndpoint)
+		}
+	})))
+
+	cursorSecret := []byte(mConfig.Cursor.Secret)
+	if len(cursorSecret) == 0 {
+		//fine for a single local instance. With more than one replica, a cursor issued by one pod would be rejected by the others
+		slog.Warn("CURSOR_SECRET is not set. Using a random secret; pagination cursors will not survive a restart")
+		cursorSecret = make([]byte, 32)
+		if _, err := rand.Read(cursorSecret); err != nil {
+			return nil, fmt.Errorf("unable to generate a cursor secret: %w", err)
+		}
+	}
+
+	tables := tweetsrepo.Tables{
+		Tweets: mConfig.Tables.Tweets,
+		Users: mConfig.Tables.Users,
+		Relations: mConfig.Tables.Relations,
+		Scheduled: mConfig.Tables.Scheduled,
+		Drafts: mConfig.Tables.Drafts,
+		Votes: mConfig.Tables.Votes,
+		Media: mConfig.Tables.Media,
+	}
+	tweetsRepo := tweetsrepo.NewDynamoDbRepo(dynamodbClient, tables, cursor.NewCodec(cursorSecret, mConfig.Cursor.TTL.Duration))
+	tweetsRepo.SetListScanSegments(mConfig.Limits.ListScanSegments)
+
+	tweetsService := tweetsservice.New(tweetsRepo)
+	tweetsService.SetListLimits(mConfig.Limits.ListDefault, mConfig.Limits.ListMax)
+	tweetsService.SetMaxTextLength(mConfig.Limits.TweetMaxLength)
+	moderator, err := newModerator(mConfig.Moderation, tweetsRepo)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set up moderation: %w", err)
+	}
+	tweetsService.SetModerator(moderator)
+	tweetsService.SetScheduling(mConfig.Scheduler.MaxAttempts, mConfig.Scheduler.Lookback.Duration)
+	mediaStore, err := newMediaStore(mConfig.Media, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set up the media store: %w", err)
+	}
+	tweetsService.SetMedia(mediaStore, tweetsservice.MediaLimits{
+		MaxImageBytes: mConfig.Media.MaxImageBytes,
+		MaxVideoBytes: mConfig.Media.MaxVideoBytes,
+		OrphanTTL: mConfig.Media.OrphanTTL.Duration,
+	})
+
+	return &environment{
+		config:        mConfig,
+		dynamodb:      dynamodbClient,
+		tables:        tables,
+		tweetsService: tweetsservice.WithTracing(tweetsService),
+	}, nil
+}
+
+//printJSON writes reports and tweets to stdout so they can be piped into jq
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
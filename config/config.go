@@ -1,50 +1,974 @@
 package config
 
 import (
-	"log"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
-type env struct {
-	UserTable string
-	TweetTable string
+//Config is loaded in three layers: defaults, then the optional file at CONFIG_FILE(YAML or JSON), then environment variables.
+//Every value can be set either way; the env var names are listed in the README
+type Config struct {
+	Env        string     `yaml:"env" json:"env"` //dev or prod. Picks the default table names
+	Aws        Aws        `yaml:"aws" json:"aws"`
+	Tables     Tables     `yaml:"tables" json:"tables"`
+	Server     Server     `yaml:"server" json:"server"`
+	Limits     Limits     `yaml:"limits" json:"limits"`
+	Cors       Cors       `yaml:"cors" json:"cors"`
+	Cursor     Cursor     `yaml:"cursor" json:"cursor"`
+	Health     Health     `yaml:"health" json:"health"`
+	Tracing    Tracing    `yaml:"tracing" json:"tracing"`
+	Log        Log        `yaml:"log" json:"log"`
+	RateLimit  RateLimit  `yaml:"rateLimit" json:"rateLimit"`
+	Moderation Moderation `yaml:"moderation" json:"moderation"`
+	Scheduler  Scheduler  `yaml:"scheduler" json:"scheduler"`
+	Media      Media      `yaml:"media" json:"media"`
 }
 
-type aws struct {
-	Aws_region       string
-	Aws_profile      string
+type Aws struct {
+	Region  string `yaml:"region" json:"region"`
+	Profile string `yaml:"profile" json:"profile"` //DEPLOYED means we run on AWS and use the instance role
+	//DynamoDBEndpoint points the client somewhere else than AWS. eg: http://localhost:8000 for DynamoDB Local
+	DynamoDBEndpoint string `yaml:"dynamodbEndpoint" json:"dynamodbEndpoint"`
 }
 
-type Config struct {
-	Dev env
-	Aws aws
-	Prod env
+type Tables struct {
+	Tweets string `yaml:"tweets" json:"tweets"`
+	Users  string `yaml:"users" json:"users"`
+	//Relations keeps the blocks, mutes and follows of the users
+	Relations string `yaml:"relations" json:"relations"`
+	//RateLimits keeps the token buckets when RateLimit.Store is dynamodb
+	RateLimits string `yaml:"rateLimits" json:"rateLimits"`
+	//Scheduled keeps the tweets waiting to be published and the lease of the publisher
+	Scheduled string `yaml:"scheduled" json:"scheduled"`
+	//Drafts keeps the unfinished tweets of the users
+	Drafts string `yaml:"drafts" json:"drafts"`
+	//Votes keeps one vote per user on the polls of the tweets
+	Votes string `yaml:"votes" json:"votes"`
+	//Media keeps what we know about the uploads and the lease of the orphan media collector. The files are in Media.Store
+	Media string `yaml:"media" json:"media"`
+	//CreateOnStartup creates the missing tables before serving. Meant for DynamoDB Local; tables on AWS are managed outside the service
+	CreateOnStartup bool `yaml:"createOnStartup" json:"createOnStartup"`
+}
+
+type Server struct {
+	HTTPPort        int      `yaml:"httpPort" json:"httpPort"`
+	GRPCPort        int      `yaml:"grpcPort" json:"grpcPort"` //defaults to HTTPPort + 1. Not used in single port mode
+	//SinglePort serves gRPC, the REST gateway and /migrate-tweet all on HTTPPort. Requests are routed by content-type(application/grpc)
+	SinglePort      bool     `yaml:"singlePort" json:"singlePort"`
+	ReadTimeout     Duration `yaml:"readTimeout" json:"readTimeout"`
+	WriteTimeout    Duration `yaml:"writeTimeout" json:"writeTimeout"`
+	ShutdownTimeout Duration `yaml:"shutdownTimeout" json:"shutdownTimeout"`
+	//ShutdownDelay is how long readiness reports NOT_SERVING before the servers start draining. Give the load balancer
+	//time to notice, eg a bit more than the readiness probe period in k8s
+	ShutdownDelay Duration `yaml:"shutdownDelay" json:"shutdownDelay"`
+	TLS             TLS      `yaml:"tls" json:"tls"`
+}
+
+//TLS is used by both listeners when CertFile and KeyFile are set. The files are read again when they change on disk
+type TLS struct {
+	CertFile string `yaml:"certFile" json:"certFile"`
+	KeyFile  string `yaml:"keyFile" json:"keyFile"`
+	//ClientCAFile turns on mutual TLS. Clients must present a certificate signed by one of the CAs in this bundle
+	ClientCAFile string `yaml:"clientCAFile" json:"clientCAFile"`
+}
+
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+type Limits struct {
+	ListDefault      int32 `yaml:"listDefault" json:"listDefault"`
+	ListMax          int32 `yaml:"listMax" json:"listMax"`
+	ListScanSegments int32 `yaml:"listScanSegments" json:"listScanSegments"`
+	//TweetMaxLength is in characters as users count them: an emoji is one, a link is 23
+	TweetMaxLength int `yaml:"tweetMaxLength" json:"tweetMaxLength"`
+}
+
+type Cors struct {
+	//origins allowed to call the http api. "*" allows any origin
+	AllowedOrigins []string `yaml:"allowedOrigins" json:"allowedOrigins"`
+}
+
+type Health struct {
+	CheckInterval Duration `yaml:"checkInterval" json:"checkInterval"` //how often the dependencies(DynamoDB tables) are probed
+	CheckTimeout  Duration `yaml:"checkTimeout" json:"checkTimeout"`
+}
+
+//Tracing picks where spans go. Incoming requests always continue the W3C trace context(traceparent) of the caller
+type Tracing struct {
+	//Exporter is none, otlp(gRPC), stdout or file. stdout and file write one JSON span per line, handy offline and in tests
+	Exporter string `yaml:"exporter" json:"exporter"`
+	//Endpoint is the host:port of the OTLP collector. When empty the exporter reads OTEL_EXPORTER_OTLP_ENDPOINT or uses localhost:4317
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+	Insecure bool   `yaml:"insecure" json:"insecure"` //plaintext to the collector. eg a sidecar on localhost
+	File     string `yaml:"file" json:"file"`         //where the file exporter appends the spans
+	//SampleRatio is the share of new traces that are recorded, from 0 to 1. A request that comes in sampled stays sampled
+	SampleRatio float64 `yaml:"sampleRatio" json:"sampleRatio"`
+}
+
+type Log struct {
+	Level string `yaml:"level" json:"level"` //debug, info, warn or error
+	//RedactText keeps the text of tweets out of the logs. Ids and authors are still logged
+	RedactText bool `yaml:"redactText" json:"redactText"`
+}
+
+//RateLimit limits the rpcs with token buckets per authenticated user, per client ip and per method
+type RateLimit struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	//Store is memory(every replica limits on its own) or dynamodb(the buckets are shared in the RateLimits table)
+	Store string `yaml:"store" json:"store"`
+	//TrustForwardedFor takes the client ip from the first X-Forwarded-For entry. Only turn it on behind a load balancer
+	//that sets the header, clients could pick any ip otherwise
+	TrustForwardedFor bool `yaml:"trustForwardedFor" json:"trustForwardedFor"`
+	//Rules are keyed by the rpc name, eg SaveTweet. An rpc without a rule is not limited
+	Rules map[string]RateLimitRule `yaml:"rules" json:"rules"`
+}
+
+//RateLimitRule holds the limits of one rpc. A dimension that is not set is not limited
+type RateLimitRule struct {
+	User   Rate `yaml:"user" json:"user"`
+	IP     Rate `yaml:"ip" json:"ip"`
+	Method Rate `yaml:"method" json:"method"` //every caller together
+}
+
+//Rate reads "30/1m"(30 requests per minute) from the config file
+type Rate struct {
+	Requests int
+	Per      time.Duration
 }
 
-func NewConfig() *Config {
+func (r *Rate) UnmarshalText(b []byte) error {
+	v, err := parseRate(string(b))
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
+
+func (r Rate) MarshalText() ([]byte, error) {
+	if r.Requests == 0 {
+		return []byte{}, nil
+	}
+	return []byte(fmt.Sprintf("%d/%s", r.Requests, r.Per)), nil
+}
+
+func parseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Rate{}, nil
+	}
+	requests, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rate{}, fmt.Errorf("rate %q must look like 30/1m", s)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n <= 0 {
+		return Rate{}, fmt.Errorf("rate %q must start with a number of requests greater than 0", s)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || d <= 0 {
+		return Rate{}, fmt.Errorf("rate %q must end with a duration greater than 0 like 1s or 1m", s)
+	}
+	return Rate{Requests: n, Per: d}, nil
+}
+
+//parseRateLimitRules reads RATE_LIMIT_RULES: "SaveTweet:user=30/1m,ip=120/1m;SaveLikeToggle:user=120/1m"
+func parseRateLimitRules(s string) (map[string]RateLimitRule, error) {
+	rules := map[string]RateLimitRule{}
+	for _, entry := range strings.Split(s, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		method, limits, _ := strings.Cut(entry, ":")
+		method = strings.TrimSpace(method)
+		if method == "" {
+			return nil, fmt.Errorf("%q has no rpc name", entry)
+		}
+		rule := RateLimitRule{}
+		for _, limit := range strings.Split(limits, ",") {
+			if limit = strings.TrimSpace(limit); limit == "" {
+				continue
+			}
+			dimension, value, _ := strings.Cut(limit, "=")
+			rate, err := parseRate(value)
+			if err != nil {
+				return nil, err
+			}
+			switch strings.TrimSpace(dimension) {
+			case "user":
+				rule.User = rate
+			case "ip":
+				rule.IP = rate
+			case "method":
+				rule.Method = rate
+			default:
+				return nil, fmt.Errorf("%q must be user, ip or method", dimension)
+			}
+		}
+		rules[method] = rule
+	}
+	return rules, nil
+}
+
+//ModerationRules are the rules Moderation.Rules can pick, in the order they run by default. The cheap ones go first
+var ModerationRules = []string{"banned_words", "blocked_domains", "repeated_characters", "duplicate_text"}
+
+//Moderation runs every tweet of SaveTweet through a chain of rules before it is saved. A rule either rejects the tweet
+//or flags it; flagged tweets are saved but hidden from the public lists until a reviewer approves them
+type Moderation struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	//Rules run in this order. See ModerationRules
+	Rules []string `yaml:"rules" json:"rules"`
+	//Actions is what each rule does with a match: flag or reject. Keyed by the rule name
+	Actions     map[string]string `yaml:"actions" json:"actions"`
+	BannedWords []string          `yaml:"bannedWords" json:"bannedWords"`
+	//BannedWordsFile has one word per line, lines starting with # are comments. The words are added to BannedWords
+	BannedWordsFile string `yaml:"bannedWordsFile" json:"bannedWordsFile"`
+	//BlockedDomains also block their subdomains
+	BlockedDomains []string `yaml:"blockedDomains" json:"blockedDomains"`
+	//MaxRepeatedCharacters is the longest run of the same character a tweet can have
+	MaxRepeatedCharacters int `yaml:"maxRepeatedCharacters" json:"maxRepeatedCharacters"`
+	//DuplicateWindow is how far back duplicate_text looks for the same text from the same author
+	DuplicateWindow Duration `yaml:"duplicateWindow" json:"duplicateWindow"`
+	//Reviewers are the user ids(X-Authed-User-Id) allowed to use the review queue. When empty, only a local run allows it
+	Reviewers []string `yaml:"reviewers" json:"reviewers"`
+}
+
+//parseModerationActions reads MODERATION_ACTIONS: "banned_words=flag,duplicate_text=reject"
+func parseModerationActions(s string) (map[string]string, error) {
+	actions := map[string]string{}
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		rule, action, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q must look like banned_words=flag", entry)
+		}
+		actions[strings.TrimSpace(rule)] = strings.TrimSpace(action)
+	}
+	return actions, nil
+}
+
+//Scheduler publishes the scheduled tweets when they are due. Every replica runs it; a lease in the Scheduled table
+//picks the one that publishes, the others take over when it goes away
+type Scheduler struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	//PollInterval is how often the leader looks for due tweets. A tweet goes out up to this long after its time
+	PollInterval Duration `yaml:"pollInterval" json:"pollInterval"`
+	//LeaseTTL is how long another replica waits before it takes over from a leader that stopped renewing its lease
+	LeaseTTL Duration `yaml:"leaseTTL" json:"leaseTTL"`
+	//Lookback is how far back overdue tweets are looked for, eg after every replica was down
+	Lookback Duration `yaml:"lookback" json:"lookback"`
+	//MaxAttempts is how many times a tweet is tried before it is marked failed
+	MaxAttempts int `yaml:"maxAttempts" json:"maxAttempts"`
+}
+
+//Media is where the uploads are kept and how large they can be. An upload no tweet attached within OrphanTTL is deleted
+type Media struct {
+	//Store is fs(files under Dir, for a single replica or a shared volume) or s3(any S3 compatible bucket)
+	Store string `yaml:"store" json:"store"`
+	Dir   string `yaml:"dir" json:"dir"`
+	S3    S3     `yaml:"s3" json:"s3"`
+	//MaxImageBytes is the largest JPEG, PNG, GIF or WebP upload. MaxVideoBytes the largest MP4
+	MaxImageBytes int64           `yaml:"maxImageBytes" json:"maxImageBytes"`
+	MaxVideoBytes int64           `yaml:"maxVideoBytes" json:"maxVideoBytes"`
+	OrphanTTL     Duration        `yaml:"orphanTTL" json:"orphanTTL"`
+	GC            MediaGC         `yaml:"gc" json:"gc"`
+	Processing    MediaProcessing `yaml:"processing" json:"processing"`
+}
+
+type S3 struct {
+	Bucket string `yaml:"bucket" json:"bucket"`
+	//Region defaults to Aws.Region
+	Region string `yaml:"region" json:"region"`
+	//Endpoint points the client somewhere else than AWS. eg: http://localhost:9000 for MinIO
+	Endpoint string `yaml:"endpoint" json:"endpoint"`
+}
+
+//MediaGC deletes the orphan uploads. Like the Scheduler, a lease(in the Media table) picks the replica that runs it
+type MediaGC struct {
+	Enabled  bool     `yaml:"enabled" json:"enabled"`
+	Interval Duration `yaml:"interval" json:"interval"`
+	LeaseTTL Duration `yaml:"leaseTTL" json:"leaseTTL"`
+}
+
+//MediaProcessing is the worker that makes the thumbnails, sizes and placeholders of the uploaded images. A lease(in the
+//Media table) picks the replica that runs it
+type MediaProcessing struct {
+	Enabled  bool     `yaml:"enabled" json:"enabled"`
+	Interval Duration `yaml:"interval" json:"interval"`
+	LeaseTTL Duration `yaml:"leaseTTL" json:"leaseTTL"`
+}
+
+type Cursor struct {
+	Secret string   `yaml:"secret" json:"secret"` //signs the pagination cursors. Every replica must share the same secret
+	TTL    Duration `yaml:"ttl" json:"ttl"`
+}
+
+//Duration reads "10s", "1m30s" etc from the config file
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+//Error lists every problem found while loading the config, so they can all be fixed in one go
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+//region used with DYNAMODB_ENDPOINT when AWS_REGION is not set
+const localRegion = "us-east-1"
+
+var defaultTables = map[string]Tables{
+	"dev": {Tweets: "chirper-app-tweets-dev", Users: "chirper-app-users-dev", Relations: "chirper-app-relations-dev", RateLimits: "chirper-app-rate-limits-dev", Scheduled: "chirper-app-scheduled-dev", Drafts: "chirper-app-drafts-dev", Votes: "chirper-app-votes-dev", Media: "chirper-app-media-dev"},
+	//there are no defaults for prod. They must be set explicitly so we never write to the wrong tables by accident
+}
+
+func defaults() *Config {
 	return &Config{
-	   Dev: env{
-			UserTable: "chirper-app-users-dev",
-			TweetTable: "chirper-app-tweets-dev",
-	   },
-		Aws: aws{
-			Aws_region:       mustGetenv("AWS_REGION"),
-			Aws_profile:      mustGetenv("AWS_PROFILE"),
+		Env: "dev",
+		Server: Server{
+			HTTPPort:        6060,
+			ReadTimeout:     Duration{10 * time.Second},
+			WriteTimeout:    Duration{20 * time.Second},
+			ShutdownTimeout: Duration{10 * time.Second},
+		},
+		Limits: Limits{
+			ListDefault:      10,
+			ListMax:          30,
+			ListScanSegments: 1,
+			TweetMaxLength:   280,
+		},
+		Cors: Cors{
+			AllowedOrigins: []string{"*"},
 		},
-		Prod: env{
-			UserTable: "",
-	   },
+		Cursor: Cursor{
+			TTL: Duration{24 * time.Hour},
+		},
+		Health: Health{
+			CheckInterval: Duration{10 * time.Second},
+			CheckTimeout:  Duration{2 * time.Second},
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+		Log: Log{
+			Level: "info",
+		},
+		RateLimit: RateLimit{
+			Enabled: true,
+			Store:   "memory",
+			Rules: map[string]RateLimitRule{
+				"SaveTweet":      {User: Rate{30, time.Minute}, IP: Rate{120, time.Minute}},
+				"SaveLikeToggle": {User: Rate{300, time.Minute}, IP: Rate{600, time.Minute}},
+			},
+		},
+		Moderation: Moderation{
+			Enabled: true,
+			Rules:   append([]string{}, ModerationRules...),
+			Actions: map[string]string{
+				"banned_words":        "reject",
+				"blocked_domains":     "reject",
+				"repeated_characters": "flag",
+				"duplicate_text":      "reject",
+			},
+			MaxRepeatedCharacters: 10,
+			DuplicateWindow:       Duration{24 * time.Hour},
+		},
+		Scheduler: Scheduler{
+			Enabled:      true,
+			PollInterval: Duration{15 * time.Second},
+			LeaseTTL:     Duration{30 * time.Second},
+			Lookback:     Duration{7 * 24 * time.Hour},
+			MaxAttempts:  5,
+		},
+		Media: Media{
+			Store:         "fs",
+			Dir:           "data/media",
+			MaxImageBytes: 5 << 20,
+			MaxVideoBytes: 50 << 20,
+			OrphanTTL:     Duration{24 * time.Hour},
+			GC: MediaGC{
+				Enabled:  true,
+				Interval: Duration{time.Hour},
+				LeaseTTL: Duration{time.Minute},
+			},
+			Processing: MediaProcessing{
+				Enabled:  true,
+				Interval: Duration{10 * time.Second},
+				LeaseTTL: Duration{30 * time.Second},
+			},
+		},
+	}
+}
+
+//Load builds the config from the process environment
+func Load() (*Config, error) {
+	return load(os.LookupEnv)
+}
+
+func load(lookupEnv func(string) (string, bool)) (*Config, error) {
+	c := defaults()
+	errs := &Error{}
+
+	if path, ok := lookupEnv("CONFIG_FILE"); ok && path != "" {
+		if err := c.readFile(path); err != nil {
+			//the rest of the config can't be trusted if the file is broken
+			return nil, &Error{Problems: []string{err.Error()}}
+		}
+	}
+
+	env := envReader{lookup: lookupEnv, errs: errs}
+	env.str("APP_ENV", &c.Env)
+	env.str("AWS_REGION", &c.Aws.Region)
+	env.str("AWS_PROFILE", &c.Aws.Profile)
+	env.str("DYNAMODB_ENDPOINT", &c.Aws.DynamoDBEndpoint)
+	env.str("TWEETS_TABLE", &c.Tables.Tweets)
+	env.str("USERS_TABLE", &c.Tables.Users)
+	env.str("RELATIONS_TABLE", &c.Tables.Relations)
+	env.str("RATE_LIMITS_TABLE", &c.Tables.RateLimits)
+	env.str("SCHEDULED_TABLE", &c.Tables.Scheduled)
+	env.str("DRAFTS_TABLE", &c.Tables.Drafts)
+	env.str("VOTES_TABLE", &c.Tables.Votes)
+	env.str("MEDIA_TABLE", &c.Tables.Media)
+	env.boolean("CREATE_TABLES", &c.Tables.CreateOnStartup)
+	env.integer("PORT", &c.Server.HTTPPort)
+	env.integer("GRPC_PORT", &c.Server.GRPCPort)
+	env.boolean("SINGLE_PORT", &c.Server.SinglePort)
+	env.duration("HTTP_READ_TIMEOUT", &c.Server.ReadTimeout)
+	env.duration("HTTP_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	env.duration("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	env.duration("SHUTDOWN_DELAY", &c.Server.ShutdownDelay)
+	env.str("TLS_CERT_FILE", &c.Server.TLS.CertFile)
+	env.str("TLS_KEY_FILE", &c.Server.TLS.KeyFile)
+	env.str("TLS_CLIENT_CA_FILE", &c.Server.TLS.ClientCAFile)
+	env.int32("LIST_DEFAULT_LIMIT", &c.Limits.ListDefault)
+	env.int32("LIST_MAX_LIMIT", &c.Limits.ListMax)
+	env.int32("LIST_SCAN_SEGMENTS", &c.Limits.ListScanSegments)
+	env.integer("TWEET_MAX_LENGTH", &c.Limits.TweetMaxLength)
+	env.list("CORS_ALLOWED_ORIGINS", &c.Cors.AllowedOrigins)
+	env.str("CURSOR_SECRET", &c.Cursor.Secret)
+	env.duration("CURSOR_TTL", &c.Cursor.TTL)
+	env.duration("HEALTH_CHECK_INTERVAL", &c.Health.CheckInterval)
+	env.duration("HEALTH_CHECK_TIMEOUT", &c.Health.CheckTimeout)
+	env.str("TRACING_EXPORTER", &c.Tracing.Exporter)
+	env.str("TRACING_ENDPOINT", &c.Tracing.Endpoint)
+	env.boolean("TRACING_INSECURE", &c.Tracing.Insecure)
+	env.str("TRACING_FILE", &c.Tracing.File)
+	env.float64("TRACING_SAMPLE_RATIO", &c.Tracing.SampleRatio)
+	env.str("LOG_LEVEL", &c.Log.Level)
+	env.boolean("LOG_REDACT_TEXT", &c.Log.RedactText)
+	env.boolean("RATE_LIMIT_ENABLED", &c.RateLimit.Enabled)
+	env.str("RATE_LIMIT_STORE", &c.RateLimit.Store)
+	env.boolean("RATE_LIMIT_TRUST_FORWARDED_FOR", &c.RateLimit.TrustForwardedFor)
+	env.rateLimitRules("RATE_LIMIT_RULES", &c.RateLimit.Rules)
+	env.boolean("MODERATION_ENABLED", &c.Moderation.Enabled)
+	env.list("MODERATION_RULES", &c.Moderation.Rules)
+	env.moderationActions("MODERATION_ACTIONS", &c.Moderation.Actions)
+	env.list("MODERATION_BANNED_WORDS", &c.Moderation.BannedWords)
+	env.str("MODERATION_BANNED_WORDS_FILE", &c.Moderation.BannedWordsFile)
+	env.list("MODERATION_BLOCKED_DOMAINS", &c.Moderation.BlockedDomains)
+	env.integer("MODERATION_MAX_REPEATED_CHARACTERS", &c.Moderation.MaxRepeatedCharacters)
+	env.duration("MODERATION_DUPLICATE_WINDOW", &c.Moderation.DuplicateWindow)
+	env.list("MODERATION_REVIEWERS", &c.Moderation.Reviewers)
+	env.boolean("SCHEDULER_ENABLED", &c.Scheduler.Enabled)
+	env.duration("SCHEDULER_POLL_INTERVAL", &c.Scheduler.PollInterval)
+	env.duration("SCHEDULER_LEASE_TTL", &c.Scheduler.LeaseTTL)
+	env.duration("SCHEDULER_LOOKBACK", &c.Scheduler.Lookback)
+	env.integer("SCHEDULER_MAX_ATTEMPTS", &c.Scheduler.MaxAttempts)
+	env.str("MEDIA_STORE", &c.Media.Store)
+	env.str("MEDIA_DIR", &c.Media.Dir)
+	env.str("MEDIA_S3_BUCKET", &c.Media.S3.Bucket)
+	env.str("MEDIA_S3_REGION", &c.Media.S3.Region)
+	env.str("MEDIA_S3_ENDPOINT", &c.Media.S3.Endpoint)
+	env.int64("MEDIA_MAX_IMAGE_BYTES", &c.Media.MaxImageBytes)
+	env.int64("MEDIA_MAX_VIDEO_BYTES", &c.Media.MaxVideoBytes)
+	env.duration("MEDIA_ORPHAN_TTL", &c.Media.OrphanTTL)
+	env.boolean("MEDIA_GC_ENABLED", &c.Media.GC.Enabled)
+	env.duration("MEDIA_GC_INTERVAL", &c.Media.GC.Interval)
+	env.duration("MEDIA_GC_LEASE_TTL", &c.Media.GC.LeaseTTL)
+	env.boolean("MEDIA_PROCESSING_ENABLED", &c.Media.Processing.Enabled)
+	env.duration("MEDIA_PROCESSING_INTERVAL", &c.Media.Processing.Interval)
+	env.duration("MEDIA_PROCESSING_LEASE_TTL", &c.Media.Processing.LeaseTTL)
+
+	c.applyDerivedDefaults()
+	c.validate(errs)
+
+	if len(errs.Problems) > 0 {
+		return nil, errs
+	}
+	return c, nil
+}
+
+func (c *Config) readFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("CONFIG_FILE: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(b, c)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, c)
+	default:
+		return fmt.Errorf("CONFIG_FILE: unsupported file type %q. Use .yaml, .yml or .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("CONFIG_FILE %s: %w", path, err)
 	}
+	return nil
+}
+
+//values that depend on other values. They only apply when nothing was set explicitly
+func (c *Config) applyDerivedDefaults() {
+	if d, ok := defaultTables[c.Env]; ok {
+		if c.Tables.Tweets == "" {
+			c.Tables.Tweets = d.Tweets
+		}
+		if c.Tables.Users == "" {
+			c.Tables.Users = d.Users
+		}
+		if c.Tables.Relations == "" {
+			c.Tables.Relations = d.Relations
+		}
+		if c.Tables.RateLimits == "" {
+			c.Tables.RateLimits = d.RateLimits
+		}
+		if c.Tables.Scheduled == "" {
+			c.Tables.Scheduled = d.Scheduled
+		}
+		if c.Tables.Drafts == "" {
+			c.Tables.Drafts = d.Drafts
+		}
+		if c.Tables.Votes == "" {
+			c.Tables.Votes = d.Votes
+		}
+		if c.Tables.Media == "" {
+			c.Tables.Media = d.Media
+		}
+	}
+	//DynamoDB Local accepts any region and credentials, so an endpoint override is enough to run offline
+	if c.Aws.DynamoDBEndpoint != "" && c.Aws.Region == "" {
+		c.Aws.Region = localRegion
+	}
+	if c.Media.S3.Region == "" {
+		c.Media.S3.Region = c.Aws.Region
+	}
+	if c.Server.GRPCPort == 0 && c.Server.HTTPPort != 0 {
+		c.Server.GRPCPort = c.Server.HTTPPort + 1
+	}
+}
+
+func (c *Config) validate(errs *Error) {
+	add := func(format string, args ...interface{}) {
+		errs.Problems = append(errs.Problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.Env != "dev" && c.Env != "prod" {
+		add("APP_ENV must be dev or prod, got %q", c.Env)
+	}
+	if c.Aws.Region == "" {
+		add("AWS_REGION is required")
+	}
+	if c.Aws.Profile == "" && c.Aws.DynamoDBEndpoint == "" {
+		add("AWS_PROFILE is required. Use DEPLOYED when running on AWS with an instance role")
+	}
+	if c.Tables.Tweets == "" {
+		add("TWEETS_TABLE is required when APP_ENV=%s", c.Env)
+	}
+	if c.Tables.Users == "" {
+		add("USERS_TABLE is required when APP_ENV=%s", c.Env)
+	}
+	if c.Tables.Relations == "" {
+		add("RELATIONS_TABLE is required when APP_ENV=%s", c.Env)
+	}
+	if c.Tables.Scheduled == "" {
+		add("SCHEDULED_TABLE is required when APP_ENV=%s", c.Env)
+	}
+	if c.Tables.Drafts == "" {
+		add("DRAFTS_TABLE is required when APP_ENV=%s", c.Env)
+	}
+	if c.Tables.Votes == "" {
+		add("VOTES_TABLE is required when APP_ENV=%s", c.Env)
+	}
+	if c.Tables.Media == "" {
+		add("MEDIA_TABLE is required when APP_ENV=%s", c.Env)
+	}
+	ports := []struct {
+		name string
+		port int
+	}{{"PORT", c.Server.HTTPPort}}
+	if !c.Server.SinglePort {
+		ports = append(ports, struct {
+			name string
+			port int
+		}{"GRPC_PORT", c.Server.GRPCPort})
+	}
+	for _, p := range ports {
+		if p.port <= 0 || p.port > 65535 {
+			add("%s must be between 1 and 65535, got %d", p.name, p.port)
+		}
+	}
+	if !c.Server.SinglePort && c.Server.HTTPPort == c.Server.GRPCPort {
+		add("PORT and GRPC_PORT must be different, both are %d", c.Server.HTTPPort)
+	}
+	durations := []struct {
+		name string
+		d    Duration
+	}{
+		{"HTTP_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+		{"CURSOR_TTL", c.Cursor.TTL},
+		{"HEALTH_CHECK_INTERVAL", c.Health.CheckInterval},
+		{"HEALTH_CHECK_TIMEOUT", c.Health.CheckTimeout},
+	}
+	for _, d := range durations {
+		if d.d.Duration <= 0 {
+			add("%s must be greater than 0", d.name)
+		}
+	}
+	if c.Server.ShutdownDelay.Duration < 0 {
+		add("SHUTDOWN_DELAY cannot be negative")
+	}
+	if c.Limits.ListDefault <= 0 {
+		add("LIST_DEFAULT_LIMIT must be greater than 0")
+	}
+	if c.Limits.ListMax < c.Limits.ListDefault {
+		add("LIST_MAX_LIMIT(%d) cannot be less than LIST_DEFAULT_LIMIT(%d)", c.Limits.ListMax, c.Limits.ListDefault)
+	}
+	if c.Limits.ListScanSegments <= 0 || c.Limits.ListScanSegments > 32 {
+		add("LIST_SCAN_SEGMENTS must be between 1 and 32, got %d", c.Limits.ListScanSegments)
+	}
+	if c.Limits.TweetMaxLength <= 0 {
+		add("TWEET_MAX_LENGTH must be greater than 0")
+	}
+	if c.Aws.DynamoDBEndpoint != "" && !strings.HasPrefix(c.Aws.DynamoDBEndpoint, "http://") && !strings.HasPrefix(c.Aws.DynamoDBEndpoint, "https://") {
+		add("DYNAMODB_ENDPOINT must be a http(s) url, got %q", c.Aws.DynamoDBEndpoint)
+	}
+	if c.Env == "prod" && c.Cursor.Secret == "" {
+		add("CURSOR_SECRET is required when APP_ENV=prod")
+	}
+	if (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "") {
+		add("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.Server.TLS.ClientCAFile != "" && !c.Server.TLS.Enabled() {
+		add("TLS_CLIENT_CA_FILE needs TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	switch c.Tracing.Exporter {
+	case "none", "otlp", "stdout":
+	case "file":
+		if c.Tracing.File == "" {
+			add("TRACING_FILE is required when TRACING_EXPORTER=file")
+		}
+	default:
+		add("TRACING_EXPORTER must be none, otlp, stdout or file, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		add("TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		add("LOG_LEVEL must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	if c.RateLimit.Enabled {
+		switch c.RateLimit.Store {
+		case "memory":
+		case "dynamodb":
+			if c.Tables.RateLimits == "" {
+				add("RATE_LIMITS_TABLE is required when RATE_LIMIT_STORE=dynamodb and APP_ENV=%s", c.Env)
+			}
+		default:
+			add("RATE_LIMIT_STORE must be memory or dynamodb, got %q", c.RateLimit.Store)
+		}
+	}
+	if c.Moderation.Enabled {
+		c.validateModeration(add)
+	}
+	if c.Scheduler.Enabled {
+		c.validateScheduler(add)
+	}
+	c.validateMedia(add)
+	tlsFiles := []struct {
+		name string
+		path string
+	}{
+		{"TLS_CERT_FILE", c.Server.TLS.CertFile},
+		{"TLS_KEY_FILE", c.Server.TLS.KeyFile},
+		{"TLS_CLIENT_CA_FILE", c.Server.TLS.ClientCAFile},
+	}
+	for _, f := range tlsFiles {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			add("%s: %v", f.name, err)
+		}
+	}
+}
+
+func (c *Config) validateModeration(add func(format string, args ...interface{})) {
+	known := map[string]bool{}
+	for _, r := range ModerationRules {
+		known[r] = true
+	}
+	for _, r := range c.Moderation.Rules {
+		if !known[r] {
+			add("MODERATION_RULES: unknown rule %q. It should be one of %s", r, strings.Join(ModerationRules, ", "))
+		}
+	}
+	rules := make([]string, 0, len(c.Moderation.Actions))
+	for rule := range c.Moderation.Actions {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules) //a stable order for the problems
+	for _, rule := range rules {
+		action := c.Moderation.Actions[rule]
+		if !known[rule] {
+			add("MODERATION_ACTIONS: unknown rule %q. It should be one of %s", rule, strings.Join(ModerationRules, ", "))
+		}
+		if action != "flag" && action != "reject" {
+			add("MODERATION_ACTIONS: the action of %s must be flag or reject, got %q", rule, action)
+		}
+	}
+	if c.Moderation.MaxRepeatedCharacters <= 0 {
+		add("MODERATION_MAX_REPEATED_CHARACTERS must be greater than 0")
+	}
+	if c.Moderation.DuplicateWindow.Duration <= 0 {
+		add("MODERATION_DUPLICATE_WINDOW must be greater than 0")
+	}
+	if c.Moderation.BannedWordsFile != "" {
+		if _, err := os.Stat(c.Moderation.BannedWordsFile); err != nil {
+			add("MODERATION_BANNED_WORDS_FILE: %v", err)
+		}
+	}
+}
+
+func (c *Config) validateScheduler(add func(format string, args ...interface{})) {
+	durations := []struct {
+		name string
+		d    Duration
+	}{
+		{"SCHEDULER_POLL_INTERVAL", c.Scheduler.PollInterval},
+		{"SCHEDULER_LEASE_TTL", c.Scheduler.LeaseTTL},
+		{"SCHEDULER_LOOKBACK", c.Scheduler.Lookback},
+	}
+	for _, d := range durations {
+		if d.d.Duration <= 0 {
+			add("%s must be greater than 0", d.name)
+		}
+	}
+	//the lease is renewed every third of its TTL. Any shorter and the renewals alone would keep DynamoDB busy
+	if c.Scheduler.LeaseTTL.Duration > 0 && c.Scheduler.LeaseTTL.Duration < 3*time.Second {
+		add("SCHEDULER_LEASE_TTL must be at least 3s, got %s", c.Scheduler.LeaseTTL.Duration)
+	}
+	if c.Scheduler.MaxAttempts <= 0 {
+		add("SCHEDULER_MAX_ATTEMPTS must be greater than 0")
+	}
+}
+
+func (c *Config) validateMedia(add func(format string, args ...interface{})) {
+	switch c.Media.Store {
+	case "fs":
+		if c.Media.Dir == "" {
+			add("MEDIA_DIR is required when MEDIA_STORE=fs")
+		}
+	case "s3":
+		if c.Media.S3.Bucket == "" {
+			add("MEDIA_S3_BUCKET is required when MEDIA_STORE=s3")
+		}
+		if c.Media.S3.Endpoint != "" && !strings.HasPrefix(c.Media.S3.Endpoint, "http://") && !strings.HasPrefix(c.Media.S3.Endpoint, "https://") {
+			add("MEDIA_S3_ENDPOINT must be a http(s) url, got %q", c.Media.S3.Endpoint)
+		}
+	default:
+		add("MEDIA_STORE must be fs or s3, got %q", c.Media.Store)
+	}
+	if c.Media.MaxImageBytes <= 0 {
+		add("MEDIA_MAX_IMAGE_BYTES must be greater than 0")
+	}
+	if c.Media.MaxVideoBytes <= 0 {
+		add("MEDIA_MAX_VIDEO_BYTES must be greater than 0")
+	}
+	if c.Media.OrphanTTL.Duration <= 0 {
+		add("MEDIA_ORPHAN_TTL must be greater than 0")
+	}
+	if c.Media.GC.Enabled {
+		if c.Media.GC.Interval.Duration <= 0 {
+			add("MEDIA_GC_INTERVAL must be greater than 0")
+		}
+		//renewed every third of its TTL, like the lease of the scheduler
+		if c.Media.GC.LeaseTTL.Duration < 3*time.Second {
+			add("MEDIA_GC_LEASE_TTL must be at least 3s, got %s", c.Media.GC.LeaseTTL.Duration)
+		}
+	}
+	if c.Media.Processing.Enabled {
+		if c.Media.Processing.Interval.Duration <= 0 {
+			add("MEDIA_PROCESSING_INTERVAL must be greater than 0")
+		}
+		if c.Media.Processing.LeaseTTL.Duration < 3*time.Second {
+			add("MEDIA_PROCESSING_LEASE_TTL must be at least 3s, got %s", c.Media.Processing.LeaseTTL.Duration)
+		}
+	}
+}
+
+//UsesStaticCredentials is true when we talk to DynamoDB Local(or LocalStack) without an AWS profile. They accept any credentials
+func (c *Config) UsesStaticCredentials() bool {
+	return c.Aws.DynamoDBEndpoint != "" && c.Aws.Profile == ""
 }
 
 func (c *Config) IsLocal() bool {
-	return c.Aws.Aws_profile != "DEPLOYED"
+	return c.Aws.Profile != "DEPLOYED"
 }
 
-func mustGetenv (k string) string {
-	v, ok := os.LookupEnv(k)
-	if !ok {
-		log.Fatalf("Warning: %s environment variable is not set.", k)
+//envReader overrides config values with env vars. Bad values are collected instead of stopping at the first one
+type envReader struct {
+	lookup func(string) (string, bool)
+	errs   *Error
+}
+
+func (e envReader) str(k string, dst *string) {
+	if v, ok := e.lookup(k); ok {
+		*dst = v
+	}
+}
+
+func (e envReader) integer(k string, dst *int) {
+	if v, ok := e.lookup(k); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			e.errs.Problems = append(e.errs.Problems, fmt.Sprintf("%s must be a number, got %q", k, v))
+			return
+		}
+		*dst = n
 	}
-	return v
-}
\ No newline at end of file
+}
+
+func (e envReader) int32(k string, dst *int32) {
+	if v, ok := e.lookup(k); ok {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			e.errs.Problems = append(e.errs.Problems, fmt.Sprintf("%s must be a number, got %q", k, v))
+			return
+		}
+		*dst = int32(n)
+	}
+}
+
+func (e envReader) int64(k string, dst *int64) {
+	if v, ok := e.lookup(k); ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			e.errs.Problems = append(e.errs.Problems, fmt.Sprintf("%s must be a number, got %q", k, v))
+			return
+		}
+		*dst = n
+	}
+}
+
+func (e envReader) float64(k string, dst *float64) {
+	if v, ok := e.lookup(k); ok {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			e.errs.Problems = append(e.errs.Problems, fmt.Sprintf("%s must be a number, got %q", k, v))
+			return
+		}
+		*dst = f
+	}
+}
+
+func (e envReader) boolean(k string, dst *bool) {
+	if v, ok := e.lookup(k); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			e.errs.Problems = append(e.errs.Problems, fmt.Sprintf("%s must be true or false, got %q", k, v))
+			return
+		}
+		*dst = b
+	}
+}
+
+func (e envReader) duration(k string, dst *Duration) {
+	if v, ok := e.lookup(k); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			e.errs.Problems = append(e.errs.Problems, fmt.Sprintf("%s must be a duration like 10s or 24h, got %q", k, v))
+			return
+		}
+		dst.Duration = d
+	}
+}
+
+//rateLimitRules replaces the rules of the rpcs listed in k. The other rpcs keep their rules
+func (e envReader) rateLimitRules(k string, dst *map[string]RateLimitRule) {
+	if v, ok := e.lookup(k); ok {
+		rules, err := parseRateLimitRules(v)
+		if err != nil {
+			e.errs.Problems = append(e.errs.Problems, fmt.Sprintf("%s: %v", k, err))
+			return
+		}
+		if *dst == nil {
+			*dst = map[string]RateLimitRule{}
+		}
+		for method, rule := range rules {
+			(*dst)[method] = rule
+		}
+	}
+}
+
+//moderationActions replaces the actions of the rules listed in k. The other rules keep theirs
+func (e envReader) moderationActions(k string, dst *map[string]string) {
+	if v, ok := e.lookup(k); ok {
+		actions, err := parseModerationActions(v)
+		if err != nil {
+			e.errs.Problems = append(e.errs.Problems, fmt.Sprintf("%s: %v", k, err))
+			return
+		}
+		if *dst == nil {
+			*dst = map[string]string{}
+		}
+		for rule, action := range actions {
+			(*dst)[rule] = action
+		}
+	}
+}
+
+func (e envReader) list(k string, dst *[]string) {
+	if v, ok := e.lookup(k); ok {
+		items := []string{}
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*dst = items
+	}
+}
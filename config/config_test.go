@@ -0,0 +1,441 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeEnv(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_Load(t *testing.T) {
+	testCases := []struct {
+		name string
+		env  map[string]string
+		file func(t *testing.T) string
+
+		check            func(t *testing.T, c *Config)
+		expectedProblems []string
+	}{
+		{
+			name: "should use the defaults for dev",
+			env:  map[string]string{"AWS_REGION": "us-east-1", "AWS_PROFILE": "default"},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, Tables{Tweets: "chirper-app-tweets-dev", Users: "chirper-app-users-dev", Relations: "chirper-app-relations-dev", RateLimits: "chirper-app-rate-limits-dev", Scheduled: "chirper-app-scheduled-dev", Drafts: "chirper-app-drafts-dev", Votes: "chirper-app-votes-dev", Media: "chirper-app-media-dev"}, c.Tables)
+				assert.Equal(t, 6060, c.Server.HTTPPort)
+				assert.Equal(t, 6061, c.Server.GRPCPort)
+				assert.Equal(t, 20*time.Second, c.Server.WriteTimeout.Duration)
+				assert.Equal(t, Limits{ListDefault: 10, ListMax: 30, ListScanSegments: 1, TweetMaxLength: 280}, c.Limits)
+				assert.Equal(t, []string{"*"}, c.Cors.AllowedOrigins)
+				assert.Equal(t, 10*time.Second, c.Health.CheckInterval.Duration)
+				assert.Zero(t, c.Server.ShutdownDelay.Duration)
+				assert.Equal(t, Tracing{Exporter: "none", SampleRatio: 1}, c.Tracing)
+				assert.Equal(t, Log{Level: "info"}, c.Log)
+				assert.True(t, c.RateLimit.Enabled)
+				assert.Equal(t, "memory", c.RateLimit.Store)
+				assert.Equal(t, RateLimitRule{User: Rate{30, time.Minute}, IP: Rate{120, time.Minute}}, c.RateLimit.Rules["SaveTweet"])
+				assert.True(t, c.IsLocal())
+			},
+		},
+		{
+			name: "should override the defaults with env vars",
+			env: map[string]string{
+				"AWS_REGION":           "us-east-1",
+				"AWS_PROFILE":          "DEPLOYED",
+				"APP_ENV":              "prod",
+				"TWEETS_TABLE":         "tweets-prod",
+				"USERS_TABLE":          "users-prod",
+				"RELATIONS_TABLE":      "relations-prod",
+				"SCHEDULED_TABLE":      "scheduled-prod",
+				"DRAFTS_TABLE":         "drafts-prod",
+				"VOTES_TABLE":          "votes-prod",
+				"MEDIA_TABLE":          "media-prod",
+				"PORT":                 "8080",
+				"HTTP_READ_TIMEOUT":    "5s",
+				"LIST_MAX_LIMIT":       "50",
+				"CORS_ALLOWED_ORIGINS": "https://chirper.app, https://admin.chirper.app",
+				"CURSOR_SECRET":        "secret",
+				"DYNAMODB_ENDPOINT":    "http://localhost:8000",
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, Tables{Tweets: "tweets-prod", Users: "users-prod", Relations: "relations-prod", Scheduled: "scheduled-prod", Drafts: "drafts-prod", Votes: "votes-prod", Media: "media-prod"}, c.Tables)
+				assert.Equal(t, 8080, c.Server.HTTPPort)
+				assert.Equal(t, 8081, c.Server.GRPCPort)
+				assert.Equal(t, 5*time.Second, c.Server.ReadTimeout.Duration)
+				assert.Equal(t, int32(50), c.Limits.ListMax)
+				assert.Equal(t, []string{"https://chirper.app", "https://admin.chirper.app"}, c.Cors.AllowedOrigins)
+				assert.Equal(t, "http://localhost:8000", c.Aws.DynamoDBEndpoint)
+				assert.False(t, c.IsLocal())
+			},
+		},
+		{
+			name: "should only need an endpoint to run against DynamoDB Local",
+			env:  map[string]string{"DYNAMODB_ENDPOINT": "http://localhost:8000", "CREATE_TABLES": "true"},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "us-east-1", c.Aws.Region)
+				assert.True(t, c.UsesStaticCredentials())
+				assert.True(t, c.Tables.CreateOnStartup)
+			},
+		},
+		{
+			name: "should not check GRPC_PORT in single port mode",
+			env:  map[string]string{"AWS_REGION": "us-east-1", "AWS_PROFILE": "default", "SINGLE_PORT": "true", "GRPC_PORT": "6060"},
+			check: func(t *testing.T, c *Config) {
+				assert.True(t, c.Server.SinglePort)
+				assert.Equal(t, 6060, c.Server.HTTPPort)
+			},
+		},
+		{
+			name: "should list every problem",
+			env: map[string]string{
+				"APP_ENV":            "prod",
+				"PORT":               "not-a-port",
+				"HTTP_WRITE_TIMEOUT": "20",
+				"LIST_DEFAULT_LIMIT": "40",
+				"LIST_SCAN_SEGMENTS": "64",
+				"TWEET_MAX_LENGTH":   "0",
+				"CREATE_TABLES":      "yes please",
+				"SHUTDOWN_DELAY":     "-5s",
+			},
+			expectedProblems: []string{
+				`CREATE_TABLES must be true or false, got "yes please"`,
+				`PORT must be a number, got "not-a-port"`,
+				`HTTP_WRITE_TIMEOUT must be a duration like 10s or 24h, got "20"`,
+				"AWS_REGION is required",
+				"AWS_PROFILE is required. Use DEPLOYED when running on AWS with an instance role",
+				"TWEETS_TABLE is required when APP_ENV=prod",
+				"USERS_TABLE is required when APP_ENV=prod",
+				"RELATIONS_TABLE is required when APP_ENV=prod",
+				"SCHEDULED_TABLE is required when APP_ENV=prod",
+				"DRAFTS_TABLE is required when APP_ENV=prod",
+				"VOTES_TABLE is required when APP_ENV=prod",
+				"MEDIA_TABLE is required when APP_ENV=prod",
+				"SHUTDOWN_DELAY cannot be negative",
+				"LIST_MAX_LIMIT(30) cannot be less than LIST_DEFAULT_LIMIT(40)",
+				"LIST_SCAN_SEGMENTS must be between 1 and 32, got 64",
+				"TWEET_MAX_LENGTH must be greater than 0",
+				"CURSOR_SECRET is required when APP_ENV=prod",
+			},
+		},
+		{
+			name: "should read a yaml file and let env vars win",
+			env:  map[string]string{"AWS_PROFILE": "default", "GRPC_PORT": "9000"},
+			file: func(t *testing.T) string {
+				return writeFile(t, "config.yaml", `
+aws:
+  region: eu-west-1
+tables:
+  tweets: tweets-from-file
+server:
+  httpPort: 7000
+  grpcPort: 7001
+  shutdownTimeout: 30s
+`)
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "eu-west-1", c.Aws.Region)
+				assert.Equal(t, Tables{Tweets: "tweets-from-file", Users: "chirper-app-users-dev", Relations: "chirper-app-relations-dev", RateLimits: "chirper-app-rate-limits-dev", Scheduled: "chirper-app-scheduled-dev", Drafts: "chirper-app-drafts-dev", Votes: "chirper-app-votes-dev", Media: "chirper-app-media-dev"}, c.Tables)
+				assert.Equal(t, 7000, c.Server.HTTPPort)
+				assert.Equal(t, 9000, c.Server.GRPCPort)
+				assert.Equal(t, 30*time.Second, c.Server.ShutdownTimeout.Duration)
+			},
+		},
+		{
+			name: "should read a json file",
+			env:  map[string]string{"AWS_PROFILE": "default"},
+			file: func(t *testing.T) string {
+				return writeFile(t, "config.json", `{"aws": {"region": "eu-west-1"}, "cursor": {"ttl": "1h"}}`)
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "eu-west-1", c.Aws.Region)
+				assert.Equal(t, time.Hour, c.Cursor.TTL.Duration)
+			},
+		},
+		{
+			name: "should read the tls files",
+			env:  map[string]string{"AWS_REGION": "us-east-1", "AWS_PROFILE": "default"},
+			file: func(t *testing.T) string {
+				cert, key := writeFile(t, "tls.crt", "cert"), writeFile(t, "tls.key", "key")
+				return writeFile(t, "config.yaml", "server:\n  tls:\n    certFile: "+cert+"\n    keyFile: "+key+"\n")
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.True(t, c.Server.TLS.Enabled())
+				assert.Empty(t, c.Server.TLS.ClientCAFile)
+			},
+		},
+		{
+			name: "should list the tls problems",
+			env: map[string]string{
+				"AWS_REGION":         "us-east-1",
+				"AWS_PROFILE":        "default",
+				"TLS_KEY_FILE":       "/does/not/exist.key",
+				"TLS_CLIENT_CA_FILE": "/does/not/exist.crt",
+			},
+			expectedProblems: []string{
+				"TLS_CERT_FILE and TLS_KEY_FILE must be set together",
+				"TLS_CLIENT_CA_FILE needs TLS_CERT_FILE and TLS_KEY_FILE",
+				"TLS_KEY_FILE: stat /does/not/exist.key: no such file or directory",
+				"TLS_CLIENT_CA_FILE: stat /does/not/exist.crt: no such file or directory",
+			},
+		},
+		{
+			name: "should read the rate limits",
+			env: map[string]string{
+				"AWS_REGION":                     "us-east-1",
+				"AWS_PROFILE":                    "default",
+				"RATE_LIMIT_STORE":               "dynamodb",
+				"RATE_LIMIT_TRUST_FORWARDED_FOR": "true",
+				"RATE_LIMIT_RULES":               "SaveTweet:user=10/1m, method=1000/1s;ListTweets:ip=5/1s",
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, "dynamodb", c.RateLimit.Store)
+				assert.True(t, c.RateLimit.TrustForwardedFor)
+				assert.Equal(t, map[string]RateLimitRule{
+					"SaveTweet":      {User: Rate{10, time.Minute}, Method: Rate{1000, time.Second}},
+					"ListTweets":     {IP: Rate{5, time.Second}},
+					"SaveLikeToggle": {User: Rate{300, time.Minute}, IP: Rate{600, time.Minute}},
+				}, c.RateLimit.Rules)
+			},
+		},
+		{
+			name: "should read the rate limits of a yaml file",
+			env:  map[string]string{"AWS_REGION": "us-east-1", "AWS_PROFILE": "default"},
+			file: func(t *testing.T) string {
+				return writeFile(t, "config.yaml", "rateLimit:\n  rules:\n    SaveTweet:\n      user: 5/10s\n")
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, RateLimitRule{User: Rate{5, 10 * time.Second}}, c.RateLimit.Rules["SaveTweet"])
+			},
+		},
+		{
+			name: "should list the rate limit problems",
+			env: map[string]string{
+				"AWS_REGION":       "us-east-1",
+				"AWS_PROFILE":      "DEPLOYED",
+				"APP_ENV":          "prod",
+				"TWEETS_TABLE":     "tweets-prod",
+				"USERS_TABLE":      "users-prod",
+				"RELATIONS_TABLE":  "relations-prod",
+				"SCHEDULED_TABLE":  "scheduled-prod",
+				"DRAFTS_TABLE":     "drafts-prod",
+				"VOTES_TABLE":      "votes-prod",
+				"MEDIA_TABLE":      "media-prod",
+				"CURSOR_SECRET":    "secret",
+				"RATE_LIMIT_STORE": "dynamodb",
+				"RATE_LIMIT_RULES": "SaveTweet:user=30",
+			},
+			expectedProblems: []string{
+				`RATE_LIMIT_RULES: rate "30" must look like 30/1m`,
+				"RATE_LIMITS_TABLE is required when RATE_LIMIT_STORE=dynamodb and APP_ENV=prod",
+			},
+		},
+		{
+			name: "should read the scheduler",
+			env: map[string]string{
+				"AWS_REGION":              "us-east-1",
+				"AWS_PROFILE":             "default",
+				"SCHEDULER_POLL_INTERVAL": "5s",
+				"SCHEDULER_MAX_ATTEMPTS":  "3",
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.True(t, c.Scheduler.Enabled)
+				assert.Equal(t, 5*time.Second, c.Scheduler.PollInterval.Duration)
+				assert.Equal(t, 30*time.Second, c.Scheduler.LeaseTTL.Duration)
+				assert.Equal(t, 3, c.Scheduler.MaxAttempts)
+			},
+		},
+		{
+			name: "should list the scheduler problems",
+			env: map[string]string{
+				"AWS_REGION":             "us-east-1",
+				"AWS_PROFILE":            "default",
+				"SCHEDULER_LEASE_TTL":    "1s",
+				"SCHEDULER_LOOKBACK":     "0s",
+				"SCHEDULER_MAX_ATTEMPTS": "0",
+			},
+			expectedProblems: []string{
+				"SCHEDULER_LOOKBACK must be greater than 0",
+				"SCHEDULER_LEASE_TTL must be at least 3s, got 1s",
+				"SCHEDULER_MAX_ATTEMPTS must be greater than 0",
+			},
+		},
+		{
+			name: "should read the media",
+			env: map[string]string{
+				"AWS_REGION":                "us-east-1",
+				"AWS_PROFILE":               "default",
+				"MEDIA_STORE":               "s3",
+				"MEDIA_S3_BUCKET":           "chirper-media",
+				"MEDIA_S3_ENDPOINT":         "http://localhost:9000",
+				"MEDIA_MAX_IMAGE_BYTES":     "1048576",
+				"MEDIA_ORPHAN_TTL":          "1h",
+				"MEDIA_PROCESSING_INTERVAL": "5s",
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, S3{Bucket: "chirper-media", Region: "us-east-1", Endpoint: "http://localhost:9000"}, c.Media.S3)
+				assert.Equal(t, int64(1<<20), c.Media.MaxImageBytes)
+				assert.Equal(t, int64(50<<20), c.Media.MaxVideoBytes)
+				assert.Equal(t, time.Hour, c.Media.OrphanTTL.Duration)
+				assert.True(t, c.Media.GC.Enabled)
+				assert.Equal(t, time.Hour, c.Media.GC.Interval.Duration)
+				assert.True(t, c.Media.Processing.Enabled)
+				assert.Equal(t, 5*time.Second, c.Media.Processing.Interval.Duration)
+				assert.Equal(t, 30*time.Second, c.Media.Processing.LeaseTTL.Duration)
+			},
+		},
+		{
+			name: "should list the media problems",
+			env: map[string]string{
+				"AWS_REGION":                "us-east-1",
+				"AWS_PROFILE":               "default",
+				"MEDIA_STORE":               "s3",
+				"MEDIA_S3_ENDPOINT":         "localhost:9000",
+				"MEDIA_MAX_VIDEO_BYTES":     "50MB",
+				"MEDIA_MAX_IMAGE_BYTES":     "0",
+				"MEDIA_GC_LEASE_TTL":        "1s",
+				"MEDIA_PROCESSING_INTERVAL": "0s",
+			},
+			expectedProblems: []string{
+				`MEDIA_MAX_VIDEO_BYTES must be a number, got "50MB"`,
+				"MEDIA_S3_BUCKET is required when MEDIA_STORE=s3",
+				`MEDIA_S3_ENDPOINT must be a http(s) url, got "localhost:9000"`,
+				"MEDIA_MAX_IMAGE_BYTES must be greater than 0",
+				"MEDIA_GC_LEASE_TTL must be at least 3s, got 1s",
+				"MEDIA_PROCESSING_INTERVAL must be greater than 0",
+			},
+		},
+		{
+			name: "should read the moderation rules",
+			env: map[string]string{
+				"AWS_REGION":                  "us-east-1",
+				"AWS_PROFILE":                 "default",
+				"MODERATION_RULES":            "blocked_domains, banned_words",
+				"MODERATION_ACTIONS":          "banned_words=flag",
+				"MODERATION_BANNED_WORDS":     "darn,heck",
+				"MODERATION_BLOCKED_DOMAINS":  "spam.example",
+				"MODERATION_DUPLICATE_WINDOW": "1h",
+				"MODERATION_REVIEWERS":        "sarah_edo",
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.True(t, c.Moderation.Enabled)
+				assert.Equal(t, []string{"blocked_domains", "banned_words"}, c.Moderation.Rules)
+				assert.Equal(t, "flag", c.Moderation.Actions["banned_words"])
+				assert.Equal(t, "reject", c.Moderation.Actions["duplicate_text"])
+				assert.Equal(t, []string{"darn", "heck"}, c.Moderation.BannedWords)
+				assert.Equal(t, []string{"spam.example"}, c.Moderation.BlockedDomains)
+				assert.Equal(t, 10, c.Moderation.MaxRepeatedCharacters)
+				assert.Equal(t, time.Hour, c.Moderation.DuplicateWindow.Duration)
+				assert.Equal(t, []string{"sarah_edo"}, c.Moderation.Reviewers)
+			},
+		},
+		{
+			name: "should list the moderation problems",
+			env: map[string]string{
+				"AWS_REGION":                         "us-east-1",
+				"AWS_PROFILE":                        "default",
+				"MODERATION_RULES":                   "banned_words,profanity",
+				"MODERATION_ACTIONS":                 "banned_words=ban,links=reject",
+				"MODERATION_MAX_REPEATED_CHARACTERS": "0",
+			},
+			expectedProblems: []string{
+				`MODERATION_RULES: unknown rule "profanity". It should be one of banned_words, blocked_domains, repeated_characters, duplicate_text`,
+				`MODERATION_ACTIONS: the action of banned_words must be flag or reject, got "ban"`,
+				`MODERATION_ACTIONS: unknown rule "links". It should be one of banned_words, blocked_domains, repeated_characters, duplicate_text`,
+				"MODERATION_MAX_REPEATED_CHARACTERS must be greater than 0",
+			},
+		},
+		{
+			name: "should read the tracing exporter",
+			env: map[string]string{
+				"AWS_REGION":           "us-east-1",
+				"AWS_PROFILE":          "default",
+				"TRACING_EXPORTER":     "otlp",
+				"TRACING_ENDPOINT":     "otel-collector:4317",
+				"TRACING_INSECURE":     "true",
+				"TRACING_SAMPLE_RATIO": "0.25",
+			},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, Tracing{Exporter: "otlp", Endpoint: "otel-collector:4317", Insecure: true, SampleRatio: 0.25}, c.Tracing)
+			},
+		},
+		{
+			name: "should list the tracing problems",
+			env: map[string]string{
+				"AWS_REGION":           "us-east-1",
+				"AWS_PROFILE":          "default",
+				"TRACING_EXPORTER":     "file",
+				"TRACING_SAMPLE_RATIO": "2",
+			},
+			expectedProblems: []string{
+				"TRACING_FILE is required when TRACING_EXPORTER=file",
+				"TRACING_SAMPLE_RATIO must be between 0 and 1, got 2",
+			},
+		},
+		{
+			name: "should reject an unknown tracing exporter",
+			env:  map[string]string{"AWS_REGION": "us-east-1", "AWS_PROFILE": "default", "TRACING_EXPORTER": "jaeger", "TRACING_SAMPLE_RATIO": "half"},
+			expectedProblems: []string{
+				`TRACING_SAMPLE_RATIO must be a number, got "half"`,
+				`TRACING_EXPORTER must be none, otlp, stdout or file, got "jaeger"`,
+			},
+		},
+		{
+			name: "should read the log settings",
+			env:  map[string]string{"AWS_REGION": "us-east-1", "AWS_PROFILE": "default", "LOG_LEVEL": "DEBUG", "LOG_REDACT_TEXT": "true"},
+			check: func(t *testing.T, c *Config) {
+				assert.Equal(t, Log{Level: "DEBUG", RedactText: true}, c.Log)
+			},
+		},
+		{
+			name: "should reject an unknown log level",
+			env:  map[string]string{"AWS_REGION": "us-east-1", "AWS_PROFILE": "default", "LOG_LEVEL": "verbose"},
+			expectedProblems: []string{`LOG_LEVEL must be debug, info, warn or error, got "verbose"`},
+		},
+		{
+			name: "should return error when the file type is not supported",
+			env:  map[string]string{},
+			file: func(t *testing.T) string {
+				return writeFile(t, "config.toml", `region = "eu-west-1"`)
+			},
+			expectedProblems: []string{`CONFIG_FILE: unsupported file type ".toml". Use .yaml, .yml or .json`},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.file != nil {
+				tc.env["CONFIG_FILE"] = tc.file(t)
+			}
+
+			c, err := load(fakeEnv(tc.env))
+
+			if tc.expectedProblems != nil {
+				assert.Nil(t, c)
+				if assert.IsType(t, &Error{}, err) {
+					assert.Equal(t, tc.expectedProblems, err.(*Error).Problems)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			tc.check(t, c)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/ratelimit"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+)
+
+//how long we wait for new tables to become ACTIVE. DynamoDB Local is instant but AWS can take a while with indexes
+const defaultTableWait = 2 * time.Minute
+
+//createTablesCommand creates the tweets and users tables(with their indexes) that don't exist yet, plus the rate limit table with RATE_LIMIT_STORE=dynamodb. eg: `DYNAMODB_ENDPOINT=http://localhost:8000 go run . create-tables`
+func createTablesCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	wait := fs.Duration("wait", defaultTableWait, "how long to wait for the tables to become active")
+
+	return func(ctx context.Context, env *environment) error {
+		return createTables(ctx, env.dynamodb, env.tables, *wait, env.extraTables()...)
+	}
+}
+
+func createTables(ctx context.Context, client common.DynamoDBAPI, tables tweetsrepo.Tables, wait time.Duration, extra ...*dynamodb.CreateTableInput) error {
+	created, err := tweetsrepo.CreateTables(ctx, client, tables, wait, extra...)
+	for _, name := range created {
+		slog.Info("created table", "table", name)
+	}
+	if err != nil {
+		return err
+	}
+	slog.Info("tables are active", "tweets", tables.Tweets, "users", tables.Users)
+	return nil
+}
+
+//extraTables are the tables the config needs on top of tweets and users
+func (env *environment) extraTables() []*dynamodb.CreateTableInput {
+	var extra []*dynamodb.CreateTableInput
+	if env.config.RateLimit.Enabled && env.config.RateLimit.Store == "dynamodb" {
+		extra = append(extra, ratelimit.TableDefinition(env.config.Tables.RateLimits))
+	}
+	return extra
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+)
+
+//getTweetCommand prints a single tweet as JSON. Handy when debugging a report from a client
+func getTweetCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	id := fs.String("id", "", "id of the tweet")
+	viewer := fs.String("viewer", "", "read the tweet as this user. Tweets that are not public need a viewer in their audience")
+
+	return func(ctx context.Context, env *environment) error {
+		if *id == "" {
+			return errors.New("--id is required")
+		}
+		tweet, err := env.tweetsService.GetTweet(ctx, *viewer, *id)
+		if err != nil {
+			return err
+		}
+		return printJSON(tweet)
+	}
+}
+
+//listTweetsCommand prints a page of ListTweets exactly as clients get it, cursor included
+func listTweetsCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	limit := fs.Int("limit", 0, "page size. Defaults to LIST_DEFAULT_LIMIT")
+	cursor := fs.String("cursor", "", "the nextKey of the previous page")
+	viewer := fs.String("viewer", "", "list as this user, leaving out the authors they blocked or muted")
+
+	return func(ctx context.Context, env *environment) error {
+		tweets, nextKey, err := env.tweetsService.ListTweets(ctx, *viewer, int32(*limit), *cursor)
+		if err != nil {
+			return err
+		}
+		return printJSON(map[string]interface{}{
+			"tweets":  tweets,
+			"nextKey": nextKey,
+		})
+	}
+}
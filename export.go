@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	tweetsencoding "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/encoding"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//exportCommand dumps the tweets table to a file or stdout. The output can be POSTed back to /migrate-tweet as is
+func exportCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	formatFlag := fs.String("format", "ndjson", "output format: ndjson, json or csv")
+	out := fs.String("out", "", "file to write to. Defaults to stdout")
+	authors := fs.String("author", "", "comma separated list of authors to export")
+	from := fs.String("from", "", "only export tweets created at or after this time(unix ms or RFC3339)")
+	to := fs.String("to", "", "only export tweets created at or before this time(unix ms or RFC3339)")
+	segments := fs.Int("segments", 4, "number of parallel scan segments")
+
+	return func(ctx context.Context, env *environment) error {
+		format, err := tweetsencoding.ParseFormat(*formatFlag)
+		if err != nil {
+			return err
+		}
+
+		filter := model.TweetFilter{}
+		for _, a := range strings.Split(*authors, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				filter.Authors = append(filter.Authors, a)
+			}
+		}
+		if *from != "" {
+			if filter.From, err = model.ParseTime(*from); err != nil {
+				return fmt.Errorf("invalid -from: %w", err)
+			}
+		}
+		if *to != "" {
+			if filter.To, err = model.ParseTime(*to); err != nil {
+				return fmt.Errorf("invalid -to: %w", err)
+			}
+		}
+
+		var w io.Writer = os.Stdout
+		var file *os.File
+		if *out != "" {
+			if file, err = os.Create(*out); err != nil {
+				return err
+			}
+			//for the early returns. The file written whole is closed below, where the error is checked
+			defer file.Close()
+			w = file
+		}
+		bw := bufio.NewWriter(w)
+
+		enc := tweetsencoding.NewEncoder(bw, format)
+		count := 0
+		err = env.tweetsService.ExportTweets(ctx, filter, int32(*segments), func(t *model.Tweet) error {
+			count++
+			return enc.Encode(t)
+		})
+		if err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		//a full disk or a closed pipe shows up here, not in Encode
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if file != nil {
+			if err := file.Close(); err != nil {
+				return err
+			}
+		}
+
+		slog.Info("exported tweets", "count", count)
+		return nil
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/leader"
+)
+
+//newElector competes for the lease item of a background job in table
+func newElector(env *environment, table, lease string, ttl time.Duration) *leader.Elector {
+	host, _ := os.Hostname()
+	//the pod name on k8s. The random part tells apart two processes on the same host
+	holder := host + "-" + uuid.NewString()[:8]
+	return leader.NewElector(env.dynamodb, table, lease, holder, ttl)
+}
+
+//runLeaderJob runs round every interval while this replica holds the lease of elector. round returns what it did to be
+//logged under name, nil when there was nothing to do. It returns when ctx is done
+func runLeaderJob(ctx context.Context, elector *leader.Elector, interval time.Duration, name string, round func(ctx context.Context) (interface{}, error)) {
+	elector.Run(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			report, err := round(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "background job failed", "job", name, "err", err)
+			} else if report != nil {
+				slog.InfoContext(ctx, name, "report", report)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"flag"
+)
+
+//reindexCommand rebuilds the tweets set the users table keeps for every user. Run it after a bulk import that bypassed SaveTweet
+func reindexCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	segments := fs.Int("segments", 4, "number of parallel scan segments")
+
+	return func(ctx context.Context, env *environment) error {
+		report, err := env.tweetsService.ReindexUserTweets(ctx, int32(*segments))
+		if report != nil {
+			printJSON(report)
+		}
+		return err
+	}
+}
+
+//repairCountersCommand fixes the replies set of every tweet. The reply counts the clients show come from it
+func repairCountersCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	segments := fs.Int("segments", 4, "number of parallel scan segments")
+	dryRun := fs.Bool("dry-run", false, "print what would be fixed without writing anything")
+
+	return func(ctx context.Context, env *environment) error {
+		report, err := env.tweetsService.RepairReplies(ctx, int32(*segments), *dryRun)
+		if report != nil {
+			printJSON(report)
+		}
+		return err
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/config"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/blob"
+)
+
+//ids of the lease items of the orphan media collector and of the processing worker in the media table
+const (
+	mediaGCLease        = "lease#media-gc"
+	mediaProcessorLease = "lease#media-processor"
+)
+
+//newMediaStore opens the store of MEDIA_STORE. S3 uses the same credentials as DynamoDB
+func newMediaStore(c config.Media, cfg aws.Config) (blob.Store, error) {
+	switch c.Store {
+	case "s3":
+		return blob.NewS3Store(blob.S3Options{
+			Bucket:      c.S3.Bucket,
+			Region:      c.S3.Region,
+			Endpoint:    c.S3.Endpoint,
+			Credentials: cfg.Credentials,
+		})
+	case "fs":
+		return blob.NewFileStore(c.Dir)
+	}
+	return nil, fmt.Errorf("unknown media store %q", c.Store)
+}
+
+//runOrphanMediaCollector deletes the uploads no tweet attached every MEDIA_GC_INTERVAL while this replica holds the
+//collector lease. It returns when ctx is done
+func runOrphanMediaCollector(ctx context.Context, env *environment) {
+	c := env.config.Media.GC
+	elector := newElector(env, env.config.Tables.Media, mediaGCLease, c.LeaseTTL.Duration)
+	runLeaderJob(ctx, elector, c.Interval.Duration, "orphan media", func(ctx context.Context) (interface{}, error) {
+		report, err := env.tweetsService.CollectOrphanMedia(ctx, time.Now())
+		if report == nil || report.Orphans == 0 {
+			return nil, err
+		}
+		return report, err
+	})
+}
+
+//collectOrphanMediaCommand runs one round of the collector without the lease. A media is only deleted while no tweet
+//holds it, so it is safe next to a running collector
+func collectOrphanMediaCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	return func(ctx context.Context, env *environment) error {
+		report, err := env.tweetsService.CollectOrphanMedia(ctx, time.Now())
+		if report != nil {
+			if err := printJSON(report); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+}
+
+//runMediaProcessor makes the thumbnails, sizes and placeholders of the pending images every MEDIA_PROCESSING_INTERVAL
+//while this replica holds the processor lease. It returns when ctx is done
+func runMediaProcessor(ctx context.Context, env *environment) {
+	c := env.config.Media.Processing
+	elector := newElector(env, env.config.Tables.Media, mediaProcessorLease, c.LeaseTTL.Duration)
+	runLeaderJob(ctx, elector, c.Interval.Duration, "processed media", func(ctx context.Context) (interface{}, error) {
+		report, err := env.tweetsService.ProcessPendingMedia(ctx)
+		if report == nil || report.Pending == 0 {
+			return nil, err
+		}
+		return report, err
+	})
+}
+
+//processMediaCommand runs one round of the worker without the lease. Saving the result of an image twice is harmless,
+//so it is safe next to a running worker
+func processMediaCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	return func(ctx context.Context, env *environment) error {
+		report, err := env.tweetsService.ProcessPendingMedia(ctx)
+		if report != nil {
+			if err := printJSON(report); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	tweetsencoding "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/encoding"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//migrateCommand is the CLI version of POST /migrate-tweet. It takes whatever `export` writes
+func migrateCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	file := fs.String("file", "", "path to the tweets to import")
+	formatFlag := fs.String("format", "", "ndjson, json or csv. Defaults to the file extension(.ndjson/.jsonl, .json, .csv)")
+	dryRun := fs.Bool("dry-run", false, "validate every record and print the report without writing anything")
+
+	return func(ctx context.Context, env *environment) error {
+		if *file == "" {
+			return errors.New("--file is required")
+		}
+
+		format, err := formatOfFile(*file, *formatFlag)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		tweets, err := tweetsencoding.Decode(f, format)
+		if err != nil {
+			return err
+		}
+
+		if *dryRun {
+			report, err := env.tweetsService.ValidateBulkTweets(ctx, tweets)
+			if err != nil {
+				return err
+			}
+			return printJSON(report)
+		}
+
+		saved := 0
+		for _, batch := range model.Batches(tweets, model.MaxBatchSize) {
+			if err := env.tweetsService.BulkSaveTweet(ctx, batch); err != nil {
+				var partial *tweetsservice.BulkSaveError
+				if errors.As(err, &partial) {
+					saved += partial.Saved
+				}
+				slog.Error("migration stopped", "saved", saved, "total", len(tweets))
+				return err
+			}
+			saved += len(batch)
+		}
+		slog.Info("migrated tweets", "saved", saved)
+		return nil
+	}
+}
+
+func formatOfFile(path, format string) (tweetsencoding.Format, error) {
+	if format != "" {
+		return tweetsencoding.ParseFormat(format)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return tweetsencoding.FormatNDJSON, nil
+	case ".csv":
+		return tweetsencoding.FormatCSV, nil
+	default:
+		return tweetsencoding.FormatJSON, nil
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/config"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetsmoderation "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/moderation"
+)
+
+//how many recent tweets of the author duplicate_text compares a new tweet with
+const duplicateTextLookback = 50
+
+//newModerator builds the rules of MODERATION_RULES in their order. It returns nil when moderation is turned off
+func newModerator(c config.Moderation, repo tweetsrepo.Repository) (*tweetsmoderation.Chain, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	bannedWords := c.BannedWords
+	if c.BannedWordsFile != "" {
+		words, err := readWordList(c.BannedWordsFile)
+		if err != nil {
+			return nil, err
+		}
+		bannedWords = append(append([]string{}, bannedWords...), words...)
+	}
+
+	var rules []tweetsmoderation.Rule
+	for _, name := range c.Rules {
+		//the config validated the actions, a rule without one rejects
+		verdict, err := tweetsmoderation.ParseVerdict(c.Actions[name])
+		if err != nil {
+			verdict = tweetsmoderation.Reject
+		}
+
+		switch name {
+		case tweetsmoderation.BannedWordsRule:
+			rules = append(rules, tweetsmoderation.BannedWords(bannedWords, verdict))
+		case tweetsmoderation.BlockedDomainsRule:
+			rules = append(rules, tweetsmoderation.BlockedDomains(c.BlockedDomains, verdict))
+		case tweetsmoderation.RepeatedCharactersRule:
+			rules = append(rules, tweetsmoderation.RepeatedCharacters(c.MaxRepeatedCharacters, verdict))
+		case tweetsmoderation.DuplicateTextRule:
+			recent := func(ctx context.Context, author string, since time.Time) ([]*model.Tweet, error) {
+				return repo.ListRecentTweetsByAuthorFromDynamoDb(ctx, author, since, duplicateTextLookback)
+			}
+			rules = append(rules, tweetsmoderation.DuplicateText(recent, c.DuplicateWindow.Duration, verdict))
+		default:
+			return nil, fmt.Errorf("unknown moderation rule %q", name)
+		}
+	}
+	return tweetsmoderation.NewChain(rules...), nil
+}
+
+//readWordList reads one word per line. Blank lines and lines starting with # are skipped
+func readWordList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return words, nil
+}
+
+//reviewQueueCommand prints a page of the tweets held for review
+func reviewQueueCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	limit := fs.Int("limit", 0, "page size. Defaults to LIST_DEFAULT_LIMIT")
+	cursor := fs.String("cursor", "", "the nextKey of the previous page")
+
+	return func(ctx context.Context, env *environment) error {
+		tweets, nextKey, err := env.tweetsService.ListReviewQueue(ctx, int32(*limit), *cursor)
+		if err != nil {
+			return err
+		}
+		return printJSON(map[string]interface{}{
+			"tweets":  tweets,
+			"nextKey": nextKey,
+		})
+	}
+}
+
+//reviewTweetCommand approves or removes a tweet held for review. Whoever can run the binary against the tables is trusted as a reviewer
+func reviewTweetCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	id := fs.String("id", "", "id of the tweet")
+	decision := fs.String("decision", "", "approve or remove")
+	reviewer := fs.String("reviewer", "", "who made the decision. Defaults to $USER")
+
+	return func(ctx context.Context, env *environment) error {
+		if *id == "" || *decision == "" {
+			return errors.New("--id and --decision are required")
+		}
+		if *reviewer == "" {
+			*reviewer = os.Getenv("USER")
+		}
+		tweet, err := env.tweetsService.ReviewTweet(ctx, *id, model.ReviewDecision(*decision), *reviewer)
+		if err != nil {
+			return err
+		}
+		return printJSON(tweet)
+	}
+}
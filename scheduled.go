@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+)
+
+//id of the lease item of the publisher in the scheduled table
+const publisherLease = "lease#scheduled-publisher"
+
+//runScheduledPublisher publishes the due scheduled tweets every SCHEDULER_POLL_INTERVAL while this replica holds the
+//publisher lease. It returns when ctx is done
+func runScheduledPublisher(ctx context.Context, env *environment) {
+	c := env.config.Scheduler
+	elector := newElector(env, env.config.Tables.Scheduled, publisherLease, c.LeaseTTL.Duration)
+	runLeaderJob(ctx, elector, c.PollInterval.Duration, "scheduled tweets", func(ctx context.Context) (interface{}, error) {
+		report, err := env.tweetsService.PublishDueTweets(ctx, time.Now())
+		if report == nil || report.Due == 0 {
+			return nil, err
+		}
+		return report, err
+	})
+}
+
+//listScheduledCommand prints a page of the scheduled tweets of an author, with their status and last error
+func listScheduledCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	author := fs.String("author", "", "the author of the scheduled tweets")
+	limit := fs.Int("limit", 0, "page size. Defaults to LIST_DEFAULT_LIMIT")
+	cursor := fs.String("cursor", "", "the nextKey of the previous page")
+
+	return func(ctx context.Context, env *environment) error {
+		if *author == "" {
+			return errors.New("--author is required")
+		}
+		scheduled, nextKey, err := env.tweetsService.ListScheduled(ctx, *author, int32(*limit), *cursor)
+		if err != nil {
+			return err
+		}
+		return printJSON(map[string]interface{}{
+			"scheduled": scheduled,
+			"nextKey":   nextKey,
+		})
+	}
+}
+
+//publishScheduledCommand runs one round of the publisher without the lease, eg while the servers are down. Tweets are
+//still claimed one by one, so it is safe next to a running publisher
+func publishScheduledCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	return func(ctx context.Context, env *environment) error {
+		report, err := env.tweetsService.PublishDueTweets(ctx, time.Now())
+		if report != nil {
+			if err := printJSON(report); err != nil {
+				return err
+			}
+		}
+		return err
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetsseed "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/seed"
+)
+
+//seedCommand fills the tables with generated users and tweets. The same --seed always writes the same data, so demo and load test environments can be rebuilt exactly
+func seedCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	users := fs.Int("users", 25, "number of users to create")
+	tweets := fs.Int("tweets", 500, "number of tweets to create")
+	seed := fs.Int64("seed", 1, "seed of the generator")
+	from := fs.String("from", tweetsseed.DefaultFrom.Format(time.RFC3339), "timestamps start at(unix ms or RFC3339)")
+	to := fs.String("to", tweetsseed.DefaultTo.Format(time.RFC3339), "timestamps end at(unix ms or RFC3339)")
+	replyRatio := fs.Float64("reply-ratio", 0.35, "share of the tweets that are replies")
+	dryRun := fs.Bool("dry-run", false, "print the generated data as JSON instead of writing it")
+
+	return func(ctx context.Context, env *environment) error {
+		opts := tweetsseed.Options{Users: *users, Tweets: *tweets, Seed: *seed, ReplyRatio: *replyRatio}
+
+		var err error
+		if opts.From, err = model.ParseTime(*from); err != nil {
+			return fmt.Errorf("invalid --from: %w", err)
+		}
+		if opts.To, err = model.ParseTime(*to); err != nil {
+			return fmt.Errorf("invalid --to: %w", err)
+		}
+
+		dataset, err := tweetsseed.Generate(opts)
+		if err != nil {
+			return err
+		}
+		if *dryRun {
+			return printJSON(dataset)
+		}
+
+		if err := tweetsseed.Write(ctx, env.tweetsService, dataset); err != nil {
+			return err
+		}
+		slog.Info("saved the seed data", "users", len(dataset.Users), "tweets", len(dataset.Tweets), "seed", *seed)
+		return nil
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	api "github.com/okpalaChidiebere/chirper-app-api-tweet/api"
+	http_handlers "github.com/okpalaChidiebere/chirper-app-api-tweet/api/http_handlers"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/certs"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/logging"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/metrics"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/ratelimit"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/tracing"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	pb "github.com/okpalaChidiebere/chirper-app-gen-protos/tweet/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+)
+
+func serveCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	return func(ctx context.Context, env *environment) error {
+		return serve(ctx, env)
+	}
+}
+
+func serve(ctx context.Context, env *environment) error {
+	mConfig := env.config
+	tweetsService := env.tweetsService
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		ServiceName: "chirper-app-api-tweet",
+		Exporter:    mConfig.Tracing.Exporter,
+		Endpoint:    mConfig.Tracing.Endpoint,
+		Insecure:    mConfig.Tracing.Insecure,
+		File:        mConfig.Tracing.File,
+		SampleRatio: mConfig.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("tracing: %w", err)
+	}
+	defer func() {
+		//flush the spans of the last requests. The shutdown timeout is already used up by the servers at this point
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("unable to flush the traces", "err", err)
+		}
+	}()
+
+	if mConfig.Tables.CreateOnStartup {
+		if err := createTables(ctx, env.dynamodb, env.tables, defaultTableWait, env.extraTables()...); err != nil {
+			return fmt.Errorf("unable to create tables: %w", err)
+		}
+	}
+
+	//every rpc needs the tables, so the whole server and the tweet service share the same status
+	healthServer := api.NewHealthServer([]string{pb.TweetService_ServiceDesc.ServiceName},
+		mConfig.Health.CheckInterval.Duration, mConfig.Health.CheckTimeout.Duration,
+		api.HealthCheck{Name: "dynamodb", Check: func(ctx context.Context) error {
+			return tweetsrepo.CheckTables(ctx, env.dynamodb, env.tables)
+		}})
+	go healthServer.Run(ctx)
+	if mConfig.Scheduler.Enabled {
+		go runScheduledPublisher(ctx, env)
+	}
+	if mConfig.Media.GC.Enabled {
+		go runOrphanMediaCollector(ctx, env)
+	}
+	if mConfig.Media.Processing.Enabled {
+		go runMediaProcessor(ctx, env)
+	}
+
+	//identity first so the rate limits know the user. Only the unary rpcs are limited
+	unaryInterceptors := []grpc.UnaryServerInterceptor{tracing.UnaryServerInterceptor(), logging.UnaryServerInterceptor(), metrics.UnaryServerInterceptor(), identity.UnaryServerInterceptor()}
+	var gatewayInterceptor grpc.UnaryServerInterceptor
+	if mConfig.RateLimit.Enabled {
+		gatewayInterceptor = ratelimit.UnaryServerInterceptor(newRateLimiter(env), mConfig.RateLimit.TrustForwardedFor)
+		unaryInterceptors = append(unaryInterceptors, gatewayInterceptor)
+	}
+
+	s := api.Servers{
+		TweetServer: api.NewTweetServer(tweetsService),
+		MediaServer: api.NewMediaServer(tweetsService),
+		HealthServer: healthServer,
+		GatewayInterceptor: gatewayInterceptor,
+		//without MODERATION_REVIEWERS the review queue is only open on a local run
+		Reviewers: http_handlers.Reviewers{IDs: mConfig.Moderation.Reviewers, AllowAnyone: mConfig.IsLocal()},
+	}
+	grpcMux := runtime.NewServeMux(
+		runtime.WithHealthzEndpoint(&api.InProcessHealthClient{ Server: s.HealthServer }),
+		runtime.WithMetadata(metrics.GatewayRoute),
+		runtime.WithMetadata(tracing.GatewayRoute),
+		//Retry-After and X-Ratelimit-* go out as they are, the rest of the header metadata keeps the Grpc-Metadata- prefix
+		runtime.WithOutgoingHeaderMatcher(ratelimit.OutgoingHeaderMatcher),
+		runtime.WithIncomingHeaderMatcher(api.IncomingHeaderMatcher),
+	)
+	httpMux := http.NewServeMux()
+	httpMux.Handle("/",  allowCORS(grpcMux, mConfig.Cors.AllowedOrigins))
+	httpMux.HandleFunc("/livez", healthServer.LivenessHandler())
+	httpMux.HandleFunc("/readyz", healthServer.ReadinessHandler())
+	httpMux.Handle("/metrics", metrics.Handler())
+
+	creds := insecure.NewCredentials()
+	var tlsConfig *tls.Config
+	if mConfig.Server.TLS.Enabled() {
+		reloader, err := certs.NewReloader(mConfig.Server.TLS.CertFile, mConfig.Server.TLS.KeyFile, mConfig.Server.TLS.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("TLS: %w", err)
+		}
+		tlsConfig = reloader.ServerConfig()
+		//in single port mode the http server terminates TLS for the grpc requests too
+		if !mConfig.Server.SinglePort {
+			creds = credentials.NewTLS(tlsConfig)
+		}
+	}
+	apiServer := s.NewAPIServer(httpMux)
+	grpcServer := grpc.NewServer(
+		grpc.Creds(creds),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(tracing.StreamServerInterceptor(), logging.StreamServerInterceptor(), metrics.StreamServerInterceptor(), identity.StreamServerInterceptor()),
+	)
+
+	apiServer.RegisterAllEndpoint(tweetsService)
+	if mConfig.IsLocal() {
+		//enable reflection to test services in postman. All you need to do is Add a new grpc tab and enter the url of the server with the right port
+		//then you can select the messages
+		reflection.Register(grpcServer)
+	}
+	s.RegisterAllService(grpcServer)
+	if err := s.RegisterAllServiceHandler(ctx, grpcMux); err != nil {
+		return err
+	}
+
+	//outermost first: the span, then the request id(the logs get the trace id), then the user and the metrics
+	httpHandler := tracing.InstrumentHandler(logging.InstrumentHandler(identity.InstrumentHandler(metrics.InstrumentHandler(httpMux))), httpMux)
+	httpServer := http.Server{
+		Handler: httpHandler,
+		ReadTimeout:  mConfig.Server.ReadTimeout.Duration,
+		WriteTimeout: mConfig.Server.WriteTimeout.Duration,
+		TLSConfig: tlsConfig,
+	}
+
+	http.Handle("/favicon.ico", http.NotFoundHandler())
+
+	serveHTTP := func(lis net.Listener) {
+		if tlsConfig != nil {
+			//the certificate comes from TLSConfig, so no files are passed here
+			httpServer.ServeTLS(lis, "", "")
+			return
+		}
+		httpServer.Serve(lis)
+	}
+
+	if mConfig.Server.SinglePort {
+		lis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", mConfig.Server.HTTPPort))
+		if err != nil {
+			return fmt.Errorf("server: failed to listen: error %w", err)
+		}
+		httpServer.Handler = api.SinglePortHandler(grpcServer, httpHandler)
+		httpServer.Addr = lis.Addr().String()
+
+		go func() {
+			slog.Info("grpc and http server listening", "addr", lis.Addr().String())
+			serveHTTP(lis)
+		}()
+	} else {
+		httpLis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", mConfig.Server.HTTPPort))
+		if err != nil {
+			return fmt.Errorf("HTTP server: failed to listen: error %w", err)
+		}
+		grpcLis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", mConfig.Server.GRPCPort))
+		if err != nil {
+			return fmt.Errorf("gRPC server: failed to listen: error %w", err)
+		}
+		httpServer.Addr = httpLis.Addr().String()
+
+		go func() {
+			slog.Info("grpc server listening", "addr", grpcLis.Addr().String())
+			_ = grpcServer.Serve(grpcLis)
+		}()
+
+		go func() {
+			slog.Info("http/1.1 server listening", "addr", httpLis.Addr().String())
+			serveHTTP(httpLis)
+		}()
+	}
+
+	// Listen for the interrupt signal.
+	<-ctx.Done()
+
+	// Restore default behavior on the interrupt signal and notify user of shutdown.
+	signal.Reset(os.Interrupt, syscall.SIGTERM)
+	slog.Info("shutting down gracefully, press Ctrl+C again to force")
+
+	//stop getting new traffic first. Requests that still arrive during the delay are served as usual
+	healthServer.Shutdown()
+	time.Sleep(mConfig.Server.ShutdownDelay.Duration)
+
+	// Perform application shutdown with a maximum timeout of SHUTDOWN_TIMEOUT(10 seconds by default).
+	//we will only keep active http requests for that long before we shutdown the server
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), mConfig.Server.ShutdownTimeout.Duration)
+	defer cancel()
+
+	if mConfig.Server.SinglePort {
+		//the http server owns the grpc connections in this mode. GracefulStop would panic on them, see api.SinglePortHandler
+		err := httpServer.Shutdown(timeoutCtx)
+		grpcServer.Stop()
+		return err
+	}
+
+	grpcServer.GracefulStop()
+	return httpServer.Shutdown(timeoutCtx)
+}
+
+//newRateLimiter builds the limits of RATE_LIMIT_RULES on the store of RATE_LIMIT_STORE
+func newRateLimiter(env *environment) *ratelimit.Limiter {
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if env.config.RateLimit.Store == "dynamodb" {
+		store = ratelimit.NewDynamoDBStore(env.dynamodb, env.config.Tables.RateLimits)
+	}
+	rules := make(map[string]ratelimit.Rule, len(env.config.RateLimit.Rules))
+	for method, r := range env.config.RateLimit.Rules {
+		rules[method] = ratelimit.Rule{
+			User:   ratelimit.Rate{Requests: r.User.Requests, Per: r.User.Per},
+			IP:     ratelimit.Rate{Requests: r.IP.Requests, Per: r.IP.Per},
+			Method: ratelimit.Rate{Requests: r.Method.Requests, Per: r.Method.Per},
+		}
+	}
+	return ratelimit.NewLimiter(store, rules)
+}
+
+//allowCORS only answers CORS for the origins in allowedOrigins(CORS_ALLOWED_ORIGINS). "*" allows any origin
+func allowCORS(h http.Handler, allowedOrigins []string) http.Handler {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	for _, o := range allowedOrigins {
+		allowed[o] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && (allowed["*"] || allowed[origin]) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Access-Control-Allow-Credentials", "true")
+			headers := []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "accept", "origin", "Cache-Control", "X-Requested-With", "traceparent", "tracestate", logging.RequestIDHeader, identity.AuthedUserHeader, api.AudienceHeader, api.PollHeader, api.MediaHeader, http_handlers.ChecksumHeader}
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ","))
+			methods := []string{"get", "patch", "post", "head", "options"}
+			w.Header().Set("Access-Control-Allow-Methods", strings.ToUpper(strings.Join(methods, ",")))
+			exposed := []string{logging.RequestIDHeader, "Retry-After", "X-Ratelimit-Limit", "X-Ratelimit-Remaining", runtime.MetadataHeaderPrefix + api.PollsHeader,
+				runtime.MetadataHeaderPrefix + api.PollsHeader + api.NextHeaderSuffix, runtime.MetadataHeaderPrefix + api.MediaLayoutHeader,
+				runtime.MetadataHeaderPrefix + api.MediaLayoutHeader + api.NextHeaderSuffix}
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(exposed, ","))
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				preflightHandler(w, r)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// preflightHandler adds the necessary headers in order to serve
+// CORS from the allowed origins using the methods "GET", "HEAD", "POST", "PATCH"
+func preflightHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Access-Control-Max-Age", "1728000")
+	w.Header().Add("Content-Type", "text/plain; charset=UTF-8")
+    w.Header().Add("Content-Length", "0")
+	w.WriteHeader(http.StatusNoContent)
+}
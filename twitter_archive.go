@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+
+	twitterarchive "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/twitter_archive"
+)
+
+//importTwitterArchiveCommand imports data/tweets.js from a Twitter archive. The report is printed to stdout as JSON
+func importTwitterArchiveCommand(fs *flag.FlagSet) func(ctx context.Context, env *environment) error {
+	file := fs.String("file", "", "path to the tweets.js file of the archive")
+	author := fs.String("author", "", "the chirper user the tweets will belong to")
+	dryRun := fs.Bool("dry-run", false, "parse and map the archive without saving anything")
+	includeRetweets := fs.Bool("include-retweets", false, "import retweets as well")
+
+	return func(ctx context.Context, env *environment) error {
+		if *file == "" {
+			return errors.New("-file is required")
+		}
+
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		report, err := twitterarchive.NewImporter(env.tweetsService).Import(ctx, f, twitterarchive.ImportOptions{
+			MapOptions: twitterarchive.MapOptions{
+				Author:          *author,
+				IncludeRetweets: *includeRetweets,
+			},
+			DryRun: *dryRun,
+		})
+		if report != nil {
+			printJSON(report)
+		}
+		return err
+	}
+}
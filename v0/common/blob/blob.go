@@ -0,0 +1,44 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//ErrNotFound is returned when there is no blob at a key
+var ErrNotFound = errors.New("blob not found")
+
+//Info describes a blob being stored. Size and SHA256 are known up front because uploads are spooled and checked first
+type Info struct {
+	Size        int64
+	ContentType string
+	//SHA256 is the hex checksum of the content. S3Store sends it so the store checks the upload too
+	SHA256 string
+}
+
+//Store keeps the uploaded files. FileStore writes them to a directory, S3Store to a bucket of S3 or anything that speaks
+//its API(MinIO, LocalStack)
+type Store interface {
+	//Put writes body at key, replacing what was there
+	Put(ctx context.Context, key string, body io.Reader, info Info) error
+	//Open returns ErrNotFound when there is no blob at key. The caller closes the reader
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	//Delete does nothing when there is no blob at key
+	Delete(ctx context.Context, key string) error
+}
+
+//checkKey only lets through keys like media/0b5ba3a6, so a key can't point outside the directory or the bucket
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `\?#%`) {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FileStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, store.Put(ctx, "media/photo-1", strings.NewReader("first"), Info{Size: 5}))
+	assert.NoError(t, store.Put(ctx, "media/photo-1", strings.NewReader("second"), Info{Size: 6}), "a put replaces the blob")
+
+	r, err := store.Open(ctx, "media/photo-1")
+	if assert.NoError(t, err) {
+		b, _ := io.ReadAll(r)
+		r.Close()
+		assert.Equal(t, "second", string(b))
+	}
+
+	assert.NoError(t, store.Delete(ctx, "media/photo-1"))
+	assert.NoError(t, store.Delete(ctx, "media/photo-1"), "deleting a missing blob is not an error")
+	_, err = store.Open(ctx, "media/photo-1")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func Test_FileStore_InvalidKeys(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, key := range []string{"", "/etc/passwd", "media/../../secret", "media//photo", "media/"} {
+		assert.Error(t, store.Put(context.Background(), key, strings.NewReader("x"), Info{Size: 1}), key)
+	}
+}
@@ -0,0 +1,150 @@
+package blob
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+//unsignedPayload is the payload hash of a request whose body is not signed
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+//S3Store keeps the blobs in a bucket. It only needs PUT, GET and DELETE on objects, so it talks to the S3 REST API
+//directly with SigV4 signed requests, and works with anything compatible(MinIO, LocalStack) through Endpoint
+type S3Store struct {
+	client      *http.Client
+	endpoint    *url.URL
+	bucket      string
+	region      string
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+}
+
+type S3Options struct {
+	Bucket string
+	Region string
+	//Endpoint is where the requests go, eg http://localhost:9000 for MinIO. Defaults to https://s3.{Region}.amazonaws.com.
+	//The bucket is always in the path, not in the host name
+	Endpoint    string
+	Credentials aws.CredentialsProvider
+	//Client defaults to http.DefaultClient
+	Client *http.Client
+}
+
+func NewS3Store(o S3Options) (*S3Store, error) {
+	if o.Bucket == "" || o.Region == "" {
+		return nil, fmt.Errorf("S3Store needs a bucket and a region")
+	}
+	endpoint := o.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", o.Region)
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("S3 endpoint must be a http(s) url, got %q", endpoint)
+	}
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3Store{client: client, endpoint: u, bucket: o.Bucket, region: o.Region, credentials: o.Credentials, signer: v4.NewSigner()}, nil
+}
+
+//do signs and sends a request for the object at key
+func (s *S3Store) do(ctx context.Context, method, key string, body io.Reader, size int64, payloadHash string, header http.Header) (*http.Response, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	u := *s.endpoint
+	u.Path = u.Path + "/" + s.bucket + "/" + key
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	if s.credentials != nil {
+		creds, err := s.credentials.Retrieve(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the S3 credentials: %w", err)
+		}
+		if err := s.signer.SignHTTP(ctx, creds, req, payloadHash, "s3", s.region, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	return s.client.Do(req)
+}
+
+//Put sends the checksum of the content as the payload hash, so S3 rejects a body that got corrupted on the way
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, info Info) error {
+	payloadHash := info.SHA256
+	if payloadHash == "" {
+		payloadHash = unsignedPayload
+	}
+	header := http.Header{}
+	if info.ContentType != "" {
+		header.Set("Content-Type", info.ContentType)
+	}
+	//S3 does not take chunked uploads, the length is set up front
+	res, err := s.do(ctx, http.MethodPut, key, body, info.Size, payloadHash, header)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return s3Error(res, http.MethodPut, key)
+}
+
+func (s *S3Store) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	res, err := s.do(ctx, http.MethodGet, key, nil, 0, unsignedPayload, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := s3Error(res, http.MethodGet, key); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	res, err := s.do(ctx, http.MethodDelete, key, nil, 0, unsignedPayload, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := s3Error(res, http.MethodDelete, key); err != ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+//s3Error reads the XML error S3 answers with, eg <Error><Code>AccessDenied</Code><Message>...</Message></Error>
+func s3Error(res *http.Response, method, key string) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	//a PUT answers 404 when the bucket is missing, that is not a missing blob
+	if res.StatusCode == http.StatusNotFound && method != http.MethodPut {
+		return ErrNotFound
+	}
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	xml.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(&body)
+	return fmt.Errorf("S3 %s %s: %d %s %s", method, key, res.StatusCode, body.Code, body.Message)
+}
@@ -0,0 +1,119 @@
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+//fakeS3 is a local stand-in for a bucket. It keeps the objects in memory and checks what S3 checks on a PUT: the
+//request is signed, the length is set and the body matches X-Amz-Content-Sha256
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=local/") {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, "<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>")
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		io.WriteString(w, "<Error><Code>NoSuchBucket</Code><Message>The specified bucket does not exist</Message></Error>")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.ContentLength != int64(len(body)) || r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, "<Error><Code>XAmzContentSHA256Mismatch</Code><Message>The provided 'x-amz-content-sha256' header does not match what was computed.</Message></Error>")
+			return
+		}
+		f.objects[key] = string(body)
+	case http.MethodGet:
+		body, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, body)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func newS3Store(t *testing.T, bucket string) (*S3Store, *fakeS3) {
+	fake := &fakeS3{bucket: "chirper-media", objects: map[string]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := NewS3Store(S3Options{
+		Bucket:      bucket,
+		Region:      "us-east-1",
+		Endpoint:    server.URL,
+		Credentials: credentials.NewStaticCredentialsProvider("local", "local", ""),
+		Client:      server.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store, fake
+}
+
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func Test_S3Store(t *testing.T) {
+	ctx := context.Background()
+	store, fake := newS3Store(t, "chirper-media")
+
+	body := "GIF89a..."
+	assert.NoError(t, store.Put(ctx, "media/photo-1", strings.NewReader(body), Info{Size: int64(len(body)), ContentType: "image/gif", SHA256: checksum(body)}))
+	assert.Equal(t, body, fake.objects["media/photo-1"])
+
+	r, err := store.Open(ctx, "media/photo-1")
+	if assert.NoError(t, err) {
+		b, _ := io.ReadAll(r)
+		r.Close()
+		assert.Equal(t, body, string(b))
+	}
+
+	assert.NoError(t, store.Delete(ctx, "media/photo-1"))
+	_, err = store.Open(ctx, "media/photo-1")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func Test_S3Store_Errors(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newS3Store(t, "chirper-media")
+
+	err := store.Put(ctx, "media/photo-1", strings.NewReader("corrupted"), Info{Size: 9, SHA256: checksum("original!")})
+	assert.EqualError(t, err, "S3 PUT media/photo-1: 400 XAmzContentSHA256Mismatch The provided 'x-amz-content-sha256' header does not match what was computed.")
+
+	missing, _ := newS3Store(t, "no-such-bucket")
+	err = missing.Put(ctx, "media/photo-1", strings.NewReader("x"), Info{Size: 1, SHA256: checksum("x")})
+	assert.NotEqual(t, ErrNotFound, err, "a missing bucket is not a missing blob")
+	assert.Error(t, err)
+}
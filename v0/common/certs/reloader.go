@@ -0,0 +1,155 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+//how often handshakes look at the files on disk. k8s takes up to a minute to project a rotated secret anyway
+const defaultCheckInterval = 10 * time.Second
+
+//Reloader serves the certificate(and client CA bundle) read from disk and picks up new files without a restart.
+//Mounted k8s secrets are swapped in place when they rotate, so the files are checked again during handshakes, at most once per check interval
+type Reloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	interval     time.Duration
+	now          func() time.Time
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	versions  []fileVersion
+	checkedAt time.Time
+}
+
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+//NewReloader loads certFile and keyFile. When clientCAFile is set, clients must present a certificate signed by one of its CAs(mutual TLS)
+func NewReloader(certFile, keyFile, clientCAFile string) (*Reloader, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("cert file and key file are required")
+	}
+	r := &Reloader{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: clientCAFile,
+		interval:     defaultCheckInterval,
+		now:          time.Now,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.checkedAt = r.now()
+	return r, nil
+}
+
+//ServerConfig is shared by the grpc and http listeners. Every handshake gets the certificate that is current at that time
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			return cert, nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, clientCAs := r.current()
+			c := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   []string{"h2", "http/1.1"},
+				Certificates: []tls.Certificate{*cert},
+			}
+			if clientCAs != nil {
+				c.ClientCAs = clientCAs
+				c.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+			return c, nil
+		},
+	}
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := r.now(); now.Sub(r.checkedAt) >= r.interval {
+		r.checkedAt = now
+		if r.changed() {
+			//a half written rotation fails to parse. Keep serving the old certificate and try again on the next check
+			if err := r.load(); err != nil {
+				slog.Error("keeping the current TLS certificate, the reload failed", "err", err)
+			}
+		}
+	}
+	return r.cert, r.clientCAs
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	return files
+}
+
+func (r *Reloader) changed() bool {
+	versions, err := statAll(r.files())
+	if err != nil {
+		return false
+	}
+	for i, v := range versions {
+		if v != r.versions[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) load() error {
+	versions, err := statAll(r.files())
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load key pair: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile)
+		if err != nil {
+			return err
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", r.clientCAFile)
+		}
+	}
+
+	r.cert, r.clientCAs, r.versions = &cert, clientCAs, versions
+	return nil
+}
+
+func statAll(files []string) ([]fileVersion, error) {
+	versions := make([]fileVersion, len(files))
+	for i, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, err
+		}
+		versions[i] = fileVersion{modTime: info.ModTime(), size: info.Size()}
+	}
+	return versions, nil
+}
@@ -0,0 +1,214 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type keyPair struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+//newKeyPair makes a certificate for name signed by parent, or a self signed CA when parent is nil
+func newKeyPair(t *testing.T, name string, serial int64, parent *keyPair) *keyPair {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &keyPair{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeKeyPair(t *testing.T, dir string, kp *keyPair) (string, string) {
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeFile(t, certFile, kp.certPEM)
+	writeFile(t, keyFile, kp.keyPEM)
+	return certFile, keyFile
+}
+
+func writeFile(t *testing.T, path string, b []byte) {
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	//make sure the rewrite is seen even on file systems with a coarse mtime
+	later := time.Now().Add(time.Duration(len(b)) * time.Second)
+	_ = os.Chtimes(path, later, later)
+}
+
+func servedSerial(t *testing.T, r *Reloader) int64 {
+	cert, err := r.ServerConfig().GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.SerialNumber.Int64()
+}
+
+func Test_Reloader(t *testing.T) {
+	ca := newKeyPair(t, "ca", 1, nil)
+
+	testCases := []struct {
+		name    string
+		rewrite func(t *testing.T, dir string)
+		advance time.Duration
+
+		expectedSerial int64
+	}{
+		{
+			name: "should serve the new certificate after the files change",
+			rewrite: func(t *testing.T, dir string) {
+				writeKeyPair(t, dir, newKeyPair(t, "localhost", 3, ca))
+			},
+			advance:        defaultCheckInterval,
+			expectedSerial: 3,
+		},
+		{
+			name: "should not look at the files again before the check interval",
+			rewrite: func(t *testing.T, dir string) {
+				writeKeyPair(t, dir, newKeyPair(t, "localhost", 3, ca))
+			},
+			advance:        time.Second,
+			expectedSerial: 2,
+		},
+		{
+			name: "should keep the current certificate when the new files are broken",
+			rewrite: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "tls.crt"), []byte("half written"))
+			},
+			advance:        defaultCheckInterval,
+			expectedSerial: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			certFile, keyFile := writeKeyPair(t, dir, newKeyPair(t, "localhost", 2, ca))
+
+			r, err := NewReloader(certFile, keyFile, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			now := time.Now()
+			r.now = func() time.Time { return now }
+			assert.Equal(t, int64(2), servedSerial(t, r))
+
+			tc.rewrite(t, dir)
+			now = now.Add(tc.advance)
+			assert.Equal(t, tc.expectedSerial, servedSerial(t, r))
+		})
+	}
+}
+
+func Test_Reloader_ClientCertificates(t *testing.T) {
+	ca := newKeyPair(t, "ca", 1, nil)
+	otherCA := newKeyPair(t, "other-ca", 10, nil)
+
+	dir := t.TempDir()
+	certFile, keyFile := writeKeyPair(t, dir, newKeyPair(t, "localhost", 2, ca))
+	caFile := filepath.Join(dir, "ca.crt")
+	writeFile(t, caFile, ca.certPEM)
+
+	r, err := NewReloader(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	testCases := []struct {
+		name   string
+		client *keyPair
+
+		expectedError bool
+	}{
+		{name: "should accept a client certificate signed by the CA", client: newKeyPair(t, "tweets-client", 20, ca)},
+		{name: "should reject a client without a certificate", expectedError: true},
+		{name: "should reject a client certificate signed by another CA", client: newKeyPair(t, "tweets-client", 21, otherCA), expectedError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clientConfig := &tls.Config{RootCAs: roots, ServerName: "localhost"}
+			if tc.client != nil {
+				pair, err := tls.X509KeyPair(tc.client.certPEM, tc.client.keyPEM)
+				if err != nil {
+					t.Fatal(err)
+				}
+				clientConfig.Certificates = []tls.Certificate{pair}
+			}
+
+			clientConn, serverConn := net.Pipe()
+			go func() {
+				//read until the server closes so its alerts and close_notify never block on the pipe
+				client := tls.Client(clientConn, clientConfig)
+				_, _ = io.Copy(io.Discard, client)
+				client.Close()
+			}()
+			server := tls.Server(serverConn, r.ServerConfig())
+			err := server.Handshake()
+			server.Close()
+
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_NewReloader(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewReloader(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key"), "")
+	assert.Error(t, err)
+
+	_, err = NewReloader("", "", "")
+	assert.EqualError(t, err, "cert file and key file are required")
+}
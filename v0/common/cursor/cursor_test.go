@@ -0,0 +1,63 @@
+package cursor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeKey struct {
+	Id     string `json:"id"`
+	Author string `json:"author"`
+}
+
+func Test_EncodeDecode(t *testing.T) {
+	c := NewCodec([]byte("secret"), time.Hour)
+
+	token, err := c.Encode("tweets", fakeKey{Id: "8xf0y6ziyjabvozdd253nd", Author: "sarah_edo"})
+	require.NoError(t, err)
+	assert.NotContains(t, token, "sarah_edo") //the key schema is not readable in plain text
+
+	var got fakeKey
+	require.NoError(t, c.Decode("tweets", token, &got))
+	assert.Equal(t, fakeKey{Id: "8xf0y6ziyjabvozdd253nd", Author: "sarah_edo"}, got)
+}
+
+func Test_Decode_Rejects(t *testing.T) {
+	c := NewCodec([]byte("secret"), time.Hour)
+	token, err := c.Encode("tweets", fakeKey{Id: "a", Author: "b"})
+	require.NoError(t, err)
+
+	body, sig, _ := strings.Cut(token, ".")
+	otherKey, _ := NewCodec([]byte("another secret"), time.Hour).Encode("tweets", fakeKey{Id: "a", Author: "b"})
+
+	expired := NewCodec([]byte("secret"), time.Hour)
+	expired.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+	expiredToken, _ := expired.Encode("tweets", fakeKey{})
+
+	testCases := []struct {
+		name  string
+		kind  string
+		token string
+	}{
+		{name: "garbage", kind: "tweets", token: "not-a-cursor"},
+		{name: "tampered body", kind: "tweets", token: body + "x." + sig},
+		{name: "tampered signature", kind: "tweets", token: body + "." + sig[1:]},
+		{name: "signed with another secret", kind: "tweets", token: otherKey},
+		{name: "another kind", kind: "tweets-scan", token: token},
+		{name: "expired", kind: "tweets", token: expiredToken},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var got fakeKey
+			err := c.Decode(tc.kind, tc.token, &got)
+			assert.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
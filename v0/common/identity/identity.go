@@ -0,0 +1,101 @@
+package identity
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+//AuthedUserHeader carries the id of the user the request is made for. The service does not authenticate users itself; the
+//auth proxy in front of it sets the header after checking the token and strips it from what clients send
+const AuthedUserHeader = "X-Authed-User-Id"
+
+//authedUserMetadata is the same value over gRPC
+const authedUserMetadata = "authed-user-id"
+
+type authedUserKey struct{}
+
+func WithAuthedUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, authedUserKey{}, userID)
+}
+
+//AuthedUser is the id of the user the request is made for, or "" when the request is anonymous
+func AuthedUser(ctx context.Context) string {
+	id, _ := ctx.Value(authedUserKey{}).(string)
+	return id
+}
+
+//InstrumentHandler puts the user of the X-Authed-User-Id header in the context. Gateway calls get it from there too
+func InstrumentHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := strings.TrimSpace(r.Header.Get(AuthedUserHeader)); id != "" {
+			r = r.WithContext(WithAuthedUser(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+//UnaryServerInterceptor puts the user of the authed-user-id metadata in the context
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(withIncomingUser(ctx), req)
+	}
+}
+
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: withIncomingUser(ss.Context())})
+	}
+}
+
+func withIncomingUser(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(authedUserMetadata); len(v) > 0 && strings.TrimSpace(v[0]) != "" {
+			return WithAuthedUser(ctx, strings.TrimSpace(v[0]))
+		}
+	}
+	return ctx
+}
+
+//ClientIP is the address the request came from. gRPC calls use the peer of the connection. Gateway calls have no peer;
+//the gateway appends the address of the http client to x-forwarded-for, so the last entry is used.
+//With trustForwardedFor(a load balancer in front that sets the header) the first entry of x-forwarded-for wins
+func ClientIP(ctx context.Context, trustForwardedFor bool) string {
+	var forwarded []string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("x-forwarded-for") {
+			for _, ip := range strings.Split(v, ",") {
+				if ip = strings.TrimSpace(ip); ip != "" {
+					forwarded = append(forwarded, ip)
+				}
+			}
+		}
+	}
+	if trustForwardedFor && len(forwarded) > 0 {
+		return forwarded[0]
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return host
+		}
+		return p.Addr.String()
+	}
+	if len(forwarded) > 0 {
+		return forwarded[len(forwarded)-1]
+	}
+	return ""
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,68 @@
+package identity
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func Test_ClientIP(t *testing.T) {
+	withPeer := func(ctx context.Context) context.Context {
+		return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+	}
+	forwarded := func(ctx context.Context) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", "203.0.113.7, 10.0.0.9", "x-forwarded-for", "192.168.1.2"))
+	}
+
+	testCases := []struct {
+		name              string
+		ctx               context.Context
+		trustForwardedFor bool
+		expected          string
+	}{
+		{name: "should use the peer of the connection", ctx: withPeer(forwarded(context.TODO())), expected: "10.0.0.1"},
+		{name: "should use the first forwarded ip when the header is trusted", ctx: withPeer(forwarded(context.TODO())), trustForwardedFor: true, expected: "203.0.113.7"},
+		{name: "should use the ip the gateway appended when there is no peer", ctx: forwarded(context.TODO()), expected: "192.168.1.2"},
+		{name: "should be empty without a peer or a header", ctx: context.TODO(), expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ClientIP(tc.ctx, tc.trustForwardedFor))
+		})
+	}
+}
+
+func Test_InstrumentHandler(t *testing.T) {
+	var seen string
+	h := InstrumentHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = AuthedUser(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/tweets", nil)
+	req.Header.Set(AuthedUserHeader, " alice ")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "alice", seen)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/tweets", nil))
+	assert.Empty(t, seen)
+}
+
+func Test_UnaryServerInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs(authedUserMetadata, "alice"))
+	var seen string
+
+	_, err := UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		seen = AuthedUser(ctx)
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", seen)
+}
@@ -0,0 +1,106 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	tagOrientation = 0x0112
+	tagGPSInfo     = 0x8825
+)
+
+//byte size of each TIFF field type, by type number
+var tiffTypeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+//tiff is the TIFF structure EXIF is stored in. Offsets are from the start of b
+type tiff struct {
+	b     []byte
+	order binary.ByteOrder
+}
+
+func parseTIFF(b []byte) (*tiff, bool) {
+	if len(b) < 8 {
+		return nil, false
+	}
+	t := &tiff{b: b}
+	switch {
+	case bytes.HasPrefix(b, []byte("II*\x00")):
+		t.order = binary.LittleEndian
+	case bytes.HasPrefix(b, []byte("MM\x00*")):
+		t.order = binary.BigEndian
+	default:
+		return nil, false
+	}
+	return t, true
+}
+
+//entries returns the offset of each 12 byte entry of the IFD at off, or nil when it does not fit in b
+func (t *tiff) entries(off uint32) []uint32 {
+	if uint64(off)+2 > uint64(len(t.b)) {
+		return nil
+	}
+	n := uint32(t.order.Uint16(t.b[off:]))
+	if uint64(off)+2+12*uint64(n) > uint64(len(t.b)) {
+		return nil
+	}
+	entries := make([]uint32, n)
+	for i := range entries {
+		entries[i] = off + 2 + 12*uint32(i)
+	}
+	return entries
+}
+
+func (t *tiff) ifd0() uint32 {
+	return t.order.Uint32(t.b[4:])
+}
+
+//orientation is the EXIF Orientation of IFD0, 1(upright) when it is missing or invalid
+func (t *tiff) orientation() int {
+	for _, e := range t.entries(t.ifd0()) {
+		if t.order.Uint16(t.b[e:]) == tagOrientation {
+			if o := int(t.order.Uint16(t.b[e+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+		}
+	}
+	return 1
+}
+
+//clearGPS zeroes the GPS IFD in place and leaves it empty. Nothing moves, so every other offset stays valid and the
+//rest of the EXIF, eg the orientation, is kept. It returns false when the EXIF can't be walked(an IFD or a GPS value
+//is out of b, an unknown GPS type): the location may still be in it, so the caller drops the whole EXIF
+func (t *tiff) clearGPS() bool {
+	ifd0 := t.entries(t.ifd0())
+	if ifd0 == nil {
+		return false
+	}
+	for _, e := range ifd0 {
+		if t.order.Uint16(t.b[e:]) != tagGPSInfo {
+			continue
+		}
+		gps := t.order.Uint32(t.b[e+8:])
+		entries := t.entries(gps)
+		if entries == nil {
+			return false
+		}
+		for _, g := range entries {
+			typeSize, ok := tiffTypeSizes[t.order.Uint16(t.b[g+2:])]
+			if !ok {
+				return false
+			}
+			//values larger than 4 bytes are stored elsewhere, the entry holds their offset
+			size := uint64(typeSize) * uint64(t.order.Uint32(t.b[g+4:]))
+			if size > 4 {
+				off := uint64(t.order.Uint32(t.b[g+8:]))
+				if off+size > uint64(len(t.b)) {
+					return false
+				}
+				clear(t.b[off : off+size])
+			}
+			clear(t.b[g : g+12])
+		}
+		t.order.PutUint16(t.b[gps:], 0)
+	}
+	return true
+}
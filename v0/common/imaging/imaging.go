@@ -0,0 +1,132 @@
+//Package imaging makes the derivatives of the uploaded images with the standard library only: it decodes JPEG, PNG
+//and GIF and makes thumbnails and BlurHash placeholders. It also removes the location from the metadata of the images
+//and of the MP4 videos
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"  //registers the GIF decoder
+	_ "image/jpeg" //registers the JPEG decoder
+	_ "image/png"  //registers the PNG decoder
+	"io"
+)
+
+//MaxPixels is the largest image Decode takes. A small file can claim a huge size, this keeps the memory bounded
+const MaxPixels = 50_000_000
+
+//ErrInvalidImage is returned for content that can't be decoded. Trying again won't help
+var ErrInvalidImage = errors.New("invalid image")
+
+//Decode reads a JPEG, PNG or GIF(its first frame) and turns it upright as the EXIF orientation says, so the width and
+//height are the ones it is shown with. The image is flattened on white; the derivatives are JPEGs
+func Decode(r io.Reader) (*image.RGBA, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d is too large, the limit is %d pixels", ErrInvalidImage, config.Width, config.Height, MaxPixels)
+	}
+	img, format, err := image.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	if opaque, ok := img.(interface{ Opaque() bool }); !ok || !opaque.Opaque() {
+		draw.Draw(rgba, rgba.Bounds(), image.White, image.Point{}, draw.Src)
+	}
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Over)
+
+	switch format {
+	case "jpeg":
+		return orient(rgba, jpegOrientation(b)), nil
+	case "png":
+		return orient(rgba, pngOrientation(b)), nil
+	}
+	return rgba, nil
+}
+
+//jpegOrientation is the EXIF orientation of a JPEG, from its APP1 segment
+func jpegOrientation(b []byte) int {
+	for i := 2; i+4 <= len(b) && b[i] == 0xFF; {
+		marker := b[i+1]
+		n := int(b[i+2])<<8 | int(b[i+3])
+		if marker == 0xDA || n < 2 || i+2+n > len(b) {
+			break
+		}
+		if payload := b[i+4 : i+2+n]; marker == 0xE1 && bytes.HasPrefix(payload, exifHeader) {
+			if t, ok := parseTIFF(payload[len(exifHeader):]); ok {
+				return t.orientation()
+			}
+		}
+		i += 2 + n
+	}
+	return 1
+}
+
+//pngOrientation is the EXIF orientation of a PNG, from its eXIf chunk
+func pngOrientation(b []byte) int {
+	for i := len(pngHeader); i+8 <= len(b); {
+		n := int(b[i])<<24 | int(b[i+1])<<16 | int(b[i+2])<<8 | int(b[i+3])
+		if n < 0 || i+12+n > len(b) {
+			break
+		}
+		switch string(b[i+4 : i+8]) {
+		case "eXIf":
+			if t, ok := parseTIFF(b[i+8 : i+8+n]); ok {
+				return t.orientation()
+			}
+		case "IDAT":
+			//eXIf comes before the image data
+			return 1
+		}
+		i += 12 + n
+	}
+	return 1
+}
+
+//orient applies an EXIF orientation: 2 mirrors, 3 turns 180°, 4 flips, 5 to 8 also swap the sides
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+	return dst
+}
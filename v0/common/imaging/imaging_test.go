@@ -0,0 +1,311 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//51°30'26" as the three rationals of GPSLatitude
+var latitude = []byte{51, 0, 0, 0, 1, 0, 0, 0, 30, 0, 0, 0, 1, 0, 0, 0, 26, 0, 0, 0, 1, 0, 0, 0}
+
+const xmp = "http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta><exif:GPSLatitude>51,30.4N</exif:GPSLatitude></x:xmpmeta>"
+
+//exifTIFF is a little endian TIFF with the orientation in IFD0 and a GPS IFD with GPSLatitudeRef and GPSLatitude
+func exifTIFF(orientation uint16) []byte {
+	le := binary.LittleEndian
+	b := []byte("II*\x00")
+	b = le.AppendUint32(b, 8)
+	//IFD0 at 8: 2 entries and the offset of the next IFD
+	b = le.AppendUint16(b, 2)
+	b = append(b, 0x12, 0x01, 3, 0)
+	b = le.AppendUint32(b, 1)
+	b = le.AppendUint32(b, uint32(orientation))
+	b = append(b, 0x25, 0x88, 4, 0)
+	b = le.AppendUint32(b, 1)
+	b = le.AppendUint32(b, 38) //the GPS IFD follows IFD0
+	b = le.AppendUint32(b, 0)
+	//GPS IFD at 38
+	b = le.AppendUint16(b, 2)
+	b = append(b, 1, 0, 2, 0)
+	b = le.AppendUint32(b, 2)
+	b = append(b, 'N', 0, 0, 0)
+	b = append(b, 2, 0, 5, 0)
+	b = le.AppendUint32(b, 3)
+	b = le.AppendUint32(b, 68) //the rationals follow the GPS IFD
+	b = le.AppendUint32(b, 0)
+	return append(b, latitude...)
+}
+
+func testImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 255 / w), uint8(y * 255 / h), 128, 255})
+		}
+	}
+	return img
+}
+
+func app1(payload []byte) []byte {
+	return append([]byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+}
+
+//testJPEG is a JPEG of w x h with an EXIF segment(see exifTIFF) and an XMP segment after the SOI
+func testJPEG(t *testing.T, w, h int, orientation uint16) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, testImage(w, h), nil))
+	b := buf.Bytes()
+	out := append([]byte{}, b[:2]...)
+	out = append(out, app1(append([]byte("Exif\x00\x00"), exifTIFF(orientation)...))...)
+	out = append(out, app1([]byte(xmp))...)
+	return append(out, b[2:]...)
+}
+
+//withJPEGExif is a JPEG with the EXIF segment tiff
+func withJPEGExif(t *testing.T, tiff []byte) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, testImage(8, 4), nil))
+	b := buf.Bytes()
+	out := append(append([]byte{}, b[:2]...), app1(append([]byte("Exif\x00\x00"), tiff...))...)
+	return append(out, b[2:]...)
+}
+
+//withPNGExif is a PNG with the eXIf chunk tiff
+func withPNGExif(t *testing.T, tiff []byte) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(8, 4)))
+	b := buf.Bytes()
+	ihdrEnd := len(pngHeader) + 8 + 13 + 4
+	return append(append(append([]byte{}, b[:ihdrEnd]...), pngChunk("eXIf", tiff)...), b[ihdrEnd:]...)
+}
+
+func pngChunk(kind string, data []byte) []byte {
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(kind))
+	crc.Write(data)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(append(chunk, kind...), data...)
+	return binary.BigEndian.AppendUint32(chunk, crc.Sum32())
+}
+
+//testPNG is a PNG of w x h with an eXIf chunk and an XMP iTXt chunk after the IHDR
+func testPNG(t *testing.T, w, h int, orientation uint16) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, testImage(w, h)))
+	b := buf.Bytes()
+	ihdrEnd := len(pngHeader) + 8 + 13 + 4
+	out := append([]byte{}, b[:ihdrEnd]...)
+	out = append(out, pngChunk("eXIf", exifTIFF(orientation))...)
+	out = append(out, pngChunk("iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00<x:xmpmeta/>"))...)
+	return append(out, b[ihdrEnd:]...)
+}
+
+//testWebP is a WebP with the EXIF, XMP and alpha flags in VP8X, an EXIF and a XMP chunk, and a 3 byte(so padded) image
+func testWebP() []byte {
+	chunk := func(kind string, data []byte) []byte {
+		c := binary.LittleEndian.AppendUint32([]byte(kind), uint32(len(data)))
+		c = append(c, data...)
+		if len(data)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+	var body []byte
+	body = append(body, chunk("VP8X", []byte{0x10 | 0x08 | 0x04, 0, 0, 0, 7, 0, 0, 3, 0, 0})...)
+	body = append(body, chunk("EXIF", exifTIFF(1))...)
+	body = append(body, chunk("XMP ", []byte("<x:xmpmeta/>"))...)
+	body = append(body, chunk("VP8L", []byte("abc"))...)
+	b := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(4+len(body)))
+	return append(append(b, "WEBP"...), body...)
+}
+
+const iso6709 = "+51.5074-000.1278/"
+
+func mp4Box(kind string, children ...[]byte) []byte {
+	content := bytes.Join(children, nil)
+	return append(binary.BigEndian.AppendUint32(nil, uint32(8+len(content))), append([]byte(kind), content...)...)
+}
+
+//testMP4 has the location in a ©xyz box of udta, in the QuickTime metadata(keys and ilst) and in a XMP uuid box
+func testMP4() []byte {
+	key := func(name string) []byte {
+		return append(binary.BigEndian.AppendUint32(nil, uint32(8+len(name))), append([]byte("mdta"), name...)...)
+	}
+	item := func(index uint32, value string) []byte {
+		return mp4Box(string(binary.BigEndian.AppendUint32(nil, index)), mp4Box("data", []byte("\x00\x00\x00\x01\x00\x00\x00\x00"+value)))
+	}
+	meta := mp4Box("meta",
+		mp4Box("hdlr", make([]byte, 8), []byte("mdta"), make([]byte, 13)),
+		mp4Box("keys", []byte{0, 0, 0, 0, 0, 0, 0, 2}, key("com.apple.quicktime.location.ISO6709"), key("com.apple.quicktime.make")),
+		mp4Box("ilst", item(1, iso6709), item(2, "Apple")),
+	)
+	udta := mp4Box("udta", mp4Box("\xa9xyz", []byte{0, 18, 0x15, 0xc7}, []byte(iso6709)), []byte{0, 0, 0, 0})
+	return bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isom")),
+		mp4Box("moov", mp4Box("mvhd", make([]byte, 100)), udta, meta),
+		mp4Box("uuid", xmpUUID, []byte(xmp)),
+		mp4Box("mdat", []byte("mdat-frames")),
+	}, nil)
+}
+
+func Test_StripLocation(t *testing.T) {
+	testCases := []struct {
+		name        string
+		contentType string
+		content     []byte
+		orientation func(b []byte) int
+	}{
+		{name: "jpeg", contentType: "image/jpeg", content: testJPEG(t, 8, 4, 6), orientation: jpegOrientation},
+		{name: "png", contentType: "image/png", content: testPNG(t, 8, 4, 6), orientation: pngOrientation},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.True(t, bytes.Contains(tc.content, latitude))
+
+			var out bytes.Buffer
+			require.NoError(t, StripLocation(&out, bytes.NewReader(tc.content), tc.contentType))
+			assert.False(t, bytes.Contains(out.Bytes(), latitude), "the GPS values are cleared")
+			assert.False(t, bytes.Contains(out.Bytes(), []byte("xmpmeta")), "the XMP is dropped")
+			assert.Equal(t, 6, tc.orientation(out.Bytes()), "the rest of the EXIF is kept")
+
+			img, err := Decode(bytes.NewReader(out.Bytes()))
+			require.NoError(t, err, "the image is still valid")
+			assert.Equal(t, image.Rect(0, 0, 4, 8), img.Bounds(), "orientation 6 is turned upright")
+		})
+	}
+
+	//the GPS IFD of exifTIFF says it has 2 entries. With 40 it runs past the end of the EXIF
+	broken := exifTIFF(6)
+	broken[38] = 40
+	for name, content := range map[string][]byte{
+		"jpeg with a broken exif": withJPEGExif(t, broken),
+		"png with a broken exif":  withPNGExif(t, broken),
+	} {
+		t.Run(name, func(t *testing.T) {
+			contentType := "image/png"
+			if strings.HasPrefix(name, "jpeg") {
+				contentType = "image/jpeg"
+			}
+			require.True(t, bytes.Contains(content, latitude))
+
+			var out bytes.Buffer
+			require.NoError(t, StripLocation(&out, bytes.NewReader(content), contentType))
+			assert.False(t, bytes.Contains(out.Bytes(), latitude), "an EXIF that can't be walked is dropped")
+			assert.False(t, bytes.Contains(out.Bytes(), broken[:8]), "the whole EXIF is gone")
+			_, err := Decode(bytes.NewReader(out.Bytes()))
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("webp", func(t *testing.T) {
+		content := testWebP()
+		require.True(t, bytes.Contains(content, latitude))
+
+		var out bytes.Buffer
+		require.NoError(t, StripLocation(&out, bytes.NewReader(content), "image/webp"))
+		b := out.Bytes()
+		assert.False(t, bytes.Contains(b, latitude), "the EXIF is dropped")
+		assert.False(t, bytes.Contains(b, []byte("xmpmeta")), "the XMP is dropped")
+		assert.Equal(t, uint32(len(b)-8), binary.LittleEndian.Uint32(b[4:]), "the RIFF size is the new one")
+		assert.Equal(t, byte(0x10), b[20], "only the alpha flag of VP8X is left")
+		assert.True(t, bytes.HasSuffix(b, []byte("VP8L\x03\x00\x00\x00abc\x00")), "the image is kept, with its padding")
+	})
+
+	t.Run("mp4", func(t *testing.T) {
+		content := testMP4()
+		require.True(t, bytes.Contains(content, []byte(iso6709)))
+
+		var out bytes.Buffer
+		require.NoError(t, StripLocation(&out, bytes.NewReader(content), "video/mp4"))
+		b := out.Bytes()
+		assert.False(t, bytes.Contains(b, []byte(iso6709)), "the location boxes and items are cleared")
+		assert.False(t, bytes.Contains(b, []byte("xmpmeta")), "the XMP is cleared")
+		assert.Len(t, b, len(content), "nothing moves")
+		assert.True(t, bytes.Contains(b, []byte("Apple")), "the other metadata is kept")
+		assert.True(t, bytes.HasSuffix(b, []byte("mdat-frames")), "the media data is kept")
+
+		assert.ErrorIs(t, StripLocation(&bytes.Buffer{}, bytes.NewReader(content[:40]), "video/mp4"), ErrInvalidImage, "a truncated moov fails")
+	})
+
+	t.Run("gif is copied", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, StripLocation(&out, strings.NewReader("GIF89a..."), "image/gif"))
+		assert.Equal(t, "GIF89a...", out.String())
+	})
+	t.Run("not a jpeg", func(t *testing.T) {
+		assert.ErrorIs(t, StripLocation(&bytes.Buffer{}, strings.NewReader("not a jpeg"), "image/jpeg"), ErrInvalidImage)
+	})
+}
+
+func Test_Decode(t *testing.T) {
+	for orientation, expected := range map[uint16]image.Rectangle{1: image.Rect(0, 0, 8, 4), 3: image.Rect(0, 0, 8, 4), 8: image.Rect(0, 0, 4, 8)} {
+		img, err := Decode(bytes.NewReader(testJPEG(t, 8, 4, orientation)))
+		require.NoError(t, err)
+		assert.Equal(t, expected, img.Bounds(), "orientation %d", orientation)
+	}
+
+	//orientation 3 turns the image 180°: the top left pixel of the upright image is the bottom right one of the file
+	var buf bytes.Buffer
+	src := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(src, src.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(src, image.Rect(8, 8, 16, 16), image.Black, image.Point{}, draw.Src)
+	require.NoError(t, png.Encode(&buf, src))
+	b := buf.Bytes()
+	ihdrEnd := len(pngHeader) + 8 + 13 + 4
+	b = append(append(append([]byte{}, b[:ihdrEnd]...), pngChunk("eXIf", exifTIFF(3))...), b[ihdrEnd:]...)
+	img, err := Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, color.RGBA{0, 0, 0, 255}, img.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, img.RGBAAt(15, 15))
+
+	_, err = Decode(strings.NewReader("not an image"))
+	assert.ErrorIs(t, err, ErrInvalidImage)
+}
+
+func Test_Thumbnail(t *testing.T) {
+	//red, green and blue thirds. The square in the middle is green
+	img := image.NewRGBA(image.Rect(0, 0, 300, 100))
+	draw.Draw(img, image.Rect(0, 0, 100, 100), &image.Uniform{color.RGBA{255, 0, 0, 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(100, 0, 200, 100), &image.Uniform{color.RGBA{0, 255, 0, 255}}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(200, 0, 300, 100), &image.Uniform{color.RGBA{0, 0, 255, 255}}, image.Point{}, draw.Src)
+
+	thumb := Thumbnail(img, 10)
+	assert.Equal(t, image.Rect(0, 0, 10, 10), thumb.Bounds())
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			assert.Equal(t, color.RGBA{0, 255, 0, 255}, thumb.RGBAAt(x, y))
+		}
+	}
+
+	//a small image is scaled up
+	assert.Equal(t, image.Rect(0, 0, 150, 150), Thumbnail(testImage(20, 10), 150).Bounds())
+}
+
+func Test_Placeholder(t *testing.T) {
+	solid := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	draw.Draw(solid, solid.Bounds(), &image.Uniform{color.RGBA{255, 128, 0, 255}}, image.Point{}, draw.Src)
+
+	hash := Placeholder(solid)
+	assert.Len(t, hash, 4+2*placeholderX*placeholderY)
+	assert.Equal(t, encode83(3+2*9, 1), hash[:1], "the size flag says 4x3 components")
+	assert.Equal(t, encode83(255<<16+128<<8+0, 4), hash[2:6], "the average color is the color")
+	//the basis of BlurHash samples cos(πix/w) from x=0, so only the even components of a solid image are exactly 0
+	assert.Equal(t, encode83(9*19*19+9*19+9, 2), hash[8:10])
+
+	assert.NotEqual(t, hash, Placeholder(testImage(40, 30)))
+	assert.Len(t, Placeholder(testImage(1, 1)), 28)
+}
@@ -0,0 +1,210 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//the boxes of the metadata are read whole. The moov of a long video is a few MiB at most
+const maxMP4Metadata = 16 << 20
+
+var (
+	//the boxes that hold a location: the ISO 6709 string of QuickTime and iTunes(©xyz), the 3GPP location(loci) and XMP
+	mp4LocationBoxes = map[string]bool{"\xa9xyz": true, "loci": true, "XMP_": true}
+	//the boxes the location boxes are found in
+	mp4Containers = map[string]bool{"moov": true, "trak": true, "udta": true, "meta": true, "ilst": true}
+	//the usertype of the uuid box that holds XMP
+	xmpUUID = []byte("\xbe\x7a\xcf\xcb\x97\xa9\x42\xe8\x9c\x71\x99\x94\x91\xe3\xaf\xac")
+)
+
+//stripMP4 copies a MP4 without its location. The metadata boxes(moov, and udta or meta at the top) are read whole and
+//their location boxes are turned into free boxes of the same size with zeroes in them. Nothing moves, so the offsets
+//of the samples in moov stay valid. The media data is copied as it is. A moov that can't be walked fails: it can't be
+//dropped like an EXIF
+func stripMP4(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+	for first := true; ; first = false {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) && !first {
+				return nil
+			}
+			return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		size := uint64(binary.BigEndian.Uint32(header))
+		kind := string(header[4:8])
+		toEnd := size == 0
+		if size == 1 {
+			large := make([]byte, 8)
+			if _, err := io.ReadFull(r, large); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+			}
+			header = append(header, large...)
+			size = binary.BigEndian.Uint64(large)
+		}
+		if !toEnd && (size < uint64(len(header)) || size > 1<<62) {
+			return fmt.Errorf("%w: bad size of the %q box", ErrInvalidImage, kind)
+		}
+		n := int64(size) - int64(len(header))
+
+		switch {
+		case kind == "moov" || kind == "udta" || kind == "meta":
+			var content []byte
+			var err error
+			if toEnd {
+				content, err = io.ReadAll(io.LimitReader(r, maxMP4Metadata+1))
+			} else if n <= maxMP4Metadata {
+				content = make([]byte, n)
+				_, err = io.ReadFull(r, content)
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+			}
+			if len(content) > maxMP4Metadata || (!toEnd && int64(len(content)) != n) {
+				return fmt.Errorf("%w: %q box of more than %d bytes", ErrInvalidImage, kind, maxMP4Metadata)
+			}
+			if !stripMP4Boxes(metaChildren(kind, content), nil) {
+				return fmt.Errorf("%w: unable to read the %q box", ErrInvalidImage, kind)
+			}
+			if _, err := dst.Write(append(header, content...)); err != nil {
+				return err
+			}
+		case kind == "uuid" && (toEnd || n >= int64(len(xmpUUID))):
+			usertype := make([]byte, len(xmpUUID))
+			if _, err := io.ReadFull(r, usertype); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+			}
+			rest := io.Reader(r)
+			if !toEnd {
+				rest = io.LimitReader(r, n-int64(len(usertype)))
+			}
+			if bytes.Equal(usertype, xmpUUID) {
+				//XMP becomes a free box of zeroes
+				copy(header[4:8], "free")
+				clear(usertype)
+				rest = zeroed{rest}
+			}
+			if _, err := dst.Write(append(header, usertype...)); err != nil {
+				return err
+			}
+			copied, err := io.Copy(dst, rest)
+			if err != nil {
+				return err
+			}
+			if !toEnd && copied != n-int64(len(usertype)) {
+				return fmt.Errorf("%w: truncated %q box", ErrInvalidImage, kind)
+			}
+		default:
+			if _, err := dst.Write(header); err != nil {
+				return err
+			}
+			if toEnd {
+				_, err := io.Copy(dst, r)
+				return err
+			}
+			if _, err := io.CopyN(dst, r, n); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+			}
+		}
+		if toEnd {
+			return nil
+		}
+	}
+}
+
+//metaChildren is where the children of a box start. The meta box of MP4 is a full box(4 bytes of version and flags
+//before its children), the one of QuickTime is not: its first child, the hdlr, starts at once
+func metaChildren(kind string, content []byte) []byte {
+	if kind == "meta" && len(content) >= 8 && string(content[4:8]) != "hdlr" {
+		return content[4:]
+	}
+	return content
+}
+
+//stripMP4Boxes frees the location boxes among the boxes of b and in their children, in place. locationKeys are the
+//indexes of the QuickTime metadata keys about the location: the items of an ilst are named by their key index. It
+//returns false when a box runs past the end of b
+func stripMP4Boxes(b []byte, locationKeys map[uint32]bool) bool {
+	type box struct {
+		start, content, end int
+		kind                string
+	}
+	var boxes []box
+	for i := 0; i+8 <= len(b); {
+		size := uint64(binary.BigEndian.Uint32(b[i:]))
+		header := 8
+		switch size {
+		case 0:
+			size = uint64(len(b) - i)
+		case 1:
+			if i+16 > len(b) {
+				return false
+			}
+			size, header = binary.BigEndian.Uint64(b[i+8:]), 16
+		}
+		if size < uint64(header) || size > uint64(len(b)-i) {
+			return false
+		}
+		boxes = append(boxes, box{start: i, content: i + header, end: i + int(size), kind: string(b[i+4 : i+8])})
+		i += int(size)
+	}
+	//the rest is shorter than a box header: the 32 bit terminator QuickTime puts at the end of udta
+
+	for _, bx := range boxes {
+		if bx.kind == "keys" {
+			locationKeys = mp4LocationKeys(b[bx.content:bx.end])
+		}
+	}
+	for _, bx := range boxes {
+		content := b[bx.content:bx.end]
+		switch {
+		case mp4LocationBoxes[bx.kind] || locationKeys[binary.BigEndian.Uint32(b[bx.start+4:])] ||
+			(bx.kind == "uuid" && bytes.HasPrefix(content, xmpUUID)):
+			copy(b[bx.start+4:], "free")
+			clear(content)
+		case mp4Containers[bx.kind]:
+			if !stripMP4Boxes(metaChildren(bx.kind, content), locationKeys) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+//mp4LocationKeys are the indexes(from 1) of the keys of a QuickTime keys box that are about the location, eg
+//com.apple.quicktime.location.ISO6709
+func mp4LocationKeys(content []byte) map[uint32]bool {
+	keys := map[uint32]bool{}
+	if len(content) < 8 {
+		return keys
+	}
+	count := binary.BigEndian.Uint32(content[4:]) //after the version and flags
+	for i, off := uint32(1), 8; i <= count && off+8 <= len(content); i++ {
+		size := int(binary.BigEndian.Uint32(content[off:]))
+		if size < 8 || size > len(content)-off {
+			break
+		}
+		//the size and the namespace(mdta) come before the name
+		if strings.Contains(strings.ToLower(string(content[off+8:off+size])), "location") {
+			keys[i] = true
+		}
+		off += size
+	}
+	return keys
+}
+
+//zeroed reads as many bytes as r, all zeroes
+type zeroed struct {
+	r io.Reader
+}
+
+func (z zeroed) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	clear(p[:n])
+	return n, err
+}
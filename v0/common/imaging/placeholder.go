@@ -0,0 +1,116 @@
+package imaging
+
+import (
+	"image"
+	"math"
+	"strings"
+)
+
+const (
+	//the placeholder has placeholderX x placeholderY components, enough for the rough colors and shapes
+	placeholderX = 4
+	placeholderY = 3
+	//the image is scaled down to this before the components are computed, the placeholder is a blur anyway
+	placeholderSample = 32
+	base83Chars       = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+)
+
+//Placeholder is the BlurHash(https://blurha.sh) of img: a short string clients decode into a blurry preview while
+//the image loads. Clients draw it with the width and height of the image
+func Placeholder(img *image.RGBA) string {
+	b := img.Bounds()
+	w, h := placeholderSample, placeholderSample
+	if b.Dx() < w {
+		w = b.Dx()
+	}
+	if b.Dy() < h {
+		h = b.Dy()
+	}
+	small := scale(img, b, w, h)
+
+	var factors [placeholderY][placeholderX][3]float64
+	for j := 0; j < placeholderY; j++ {
+		for i := 0; i < placeholderX; i++ {
+			var sum [3]float64
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(w)) * math.Cos(math.Pi*float64(j)*float64(y)/float64(h))
+					o := small.PixOffset(x, y)
+					for c := 0; c < 3; c++ {
+						sum[c] += basis * srgbToLinear(small.Pix[o+c])
+					}
+				}
+			}
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			for c := 0; c < 3; c++ {
+				factors[j][i][c] = sum[c] * normalisation / float64(w*h)
+			}
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encode83((placeholderX-1)+(placeholderY-1)*9, 1))
+
+	maximum := 0.0
+	for j := 0; j < placeholderY; j++ {
+		for i := 0; i < placeholderX; i++ {
+			if i == 0 && j == 0 {
+				continue
+			}
+			for c := 0; c < 3; c++ {
+				maximum = math.Max(maximum, math.Abs(factors[j][i][c]))
+			}
+		}
+	}
+	quantisedMaximum := int(math.Max(0, math.Min(82, math.Floor(maximum*166-0.5))))
+	maximumValue := float64(quantisedMaximum+1) / 166
+	hash.WriteString(encode83(quantisedMaximum, 1))
+
+	dc := factors[0][0]
+	hash.WriteString(encode83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+	for j := 0; j < placeholderY; j++ {
+		for i := 0; i < placeholderX; i++ {
+			if i == 0 && j == 0 {
+				continue
+			}
+			ac := factors[j][i]
+			quant := func(v float64) int {
+				return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maximumValue, 0.5)*9+9.5))))
+			}
+			hash.WriteString(encode83(quant(ac[0])*19*19+quant(ac[1])*19+quant(ac[2]), 2))
+		}
+	}
+	return hash.String()
+}
+
+func encode83(value, length int) string {
+	out := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		out[i] = base83Chars[value%83]
+		value /= 83
+	}
+	return string(out)
+}
+
+func srgbToLinear(v uint8) float64 {
+	f := float64(v) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
@@ -0,0 +1,245 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	//XMP can hold the location too. It is dropped whole, we don't parse XML for the rest of it
+	xmpHeaders = [][]byte{[]byte("http://ns.adobe.com/xap/1.0/\x00"), []byte("http://ns.adobe.com/xmp/extension/\x00")}
+	pngHeader  = []byte("\x89PNG\r\n\x1a\n")
+	xmpKeyword = []byte("XML:com.adobe.xmp\x00")
+)
+
+const (
+	//the metadata chunks of a PNG are read whole. Real ones are a few KiB
+	maxMetadataChunk = 8 << 20
+	//a WebP is read whole, its size is in the RIFF header we rewrite. Uploaded images are smaller
+	maxWebP = 64 << 20
+)
+
+//StripLocation copies an image from src to dst without the location the camera recorded: the GPS part of the EXIF is
+//cleared and the XMP packets are dropped. The rest of the metadata, eg the orientation, is kept, unless the EXIF can't
+//be parsed: then it is dropped whole, since the location may be in it. JPEG, PNG, WebP and MP4(see stripMP4) are
+//rewritten. GIF has no EXIF, it is copied as it is; StripsLocation tells the types that are rewritten
+func StripLocation(dst io.Writer, src io.Reader, contentType string) error {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(dst, src)
+	case "image/png":
+		return stripPNG(dst, src)
+	case "image/webp":
+		return stripWebP(dst, src)
+	case "video/mp4":
+		return stripMP4(dst, src)
+	}
+	_, err := io.Copy(dst, src)
+	return err
+}
+
+//StripsLocation is true for the types StripLocation rewrites. The others can't hold a location
+func StripsLocation(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/webp", "video/mp4":
+		return true
+	}
+	return false
+}
+
+//stripJPEG rewrites the segments before the image data(SOS). The image data is copied as it is
+func stripJPEG(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+	soi := make([]byte, 2)
+	if _, err := io.ReadFull(r, soi); err != nil || soi[0] != 0xFF || soi[1] != 0xD8 {
+		return fmt.Errorf("%w: not a JPEG", ErrInvalidImage)
+	}
+	if _, err := dst.Write(soi); err != nil {
+		return err
+	}
+
+	for {
+		marker, err := jpegMarker(r)
+		if err != nil {
+			return err
+		}
+		//markers without a length: TEM and RST0-7
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			if _, err := dst.Write([]byte{0xFF, marker}); err != nil {
+				return err
+			}
+			continue
+		}
+		if marker == 0xD9 { //EOI without image data
+			_, err := dst.Write([]byte{0xFF, marker})
+			return err
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		n := int(binary.BigEndian.Uint16(length[:]))
+		if n < 2 {
+			return fmt.Errorf("%w: bad segment length", ErrInvalidImage)
+		}
+		payload := make([]byte, n-2)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+
+		keep := true
+		if marker == 0xE1 { //APP1 holds EXIF or XMP
+			if bytes.HasPrefix(payload, exifHeader) {
+				t, ok := parseTIFF(payload[len(exifHeader):])
+				keep = ok && t.clearGPS()
+			} else if isXMP(payload) {
+				keep = false
+			}
+		}
+		if keep {
+			if _, err := dst.Write(append([]byte{0xFF, marker, length[0], length[1]}, payload...)); err != nil {
+				return err
+			}
+		}
+		if marker == 0xDA { //SOS, the image data follows
+			_, err := io.Copy(dst, r)
+			return err
+		}
+	}
+}
+
+//jpegMarker reads the next marker, skipping the 0xFF fill bytes
+func jpegMarker(r *bufio.Reader) (byte, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if b != 0xFF {
+		return 0, fmt.Errorf("%w: expected a marker", ErrInvalidImage)
+	}
+	for b == 0xFF {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+	}
+	return b, nil
+}
+
+func isXMP(payload []byte) bool {
+	for _, h := range xmpHeaders {
+		if bytes.HasPrefix(payload, h) {
+			return true
+		}
+	}
+	return false
+}
+
+//stripPNG clears the GPS of the eXIf chunk and drops the XMP text chunk. The other chunks are copied as they are
+func stripPNG(dst io.Writer, src io.Reader) error {
+	header := make([]byte, len(pngHeader))
+	if _, err := io.ReadFull(src, header); err != nil || !bytes.Equal(header, pngHeader) {
+		return fmt.Errorf("%w: not a PNG", ErrInvalidImage)
+	}
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	for {
+		var head [8]byte
+		if _, err := io.ReadFull(src, head[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		n := int64(binary.BigEndian.Uint32(head[:4]))
+		kind := string(head[4:])
+
+		switch kind {
+		case "eXIf", "iTXt", "tEXt", "zTXt":
+			if n > maxMetadataChunk {
+				return fmt.Errorf("%w: %s chunk of %d bytes", ErrInvalidImage, kind, n)
+			}
+			data := make([]byte, n+4) //with the crc
+			if _, err := io.ReadFull(src, data); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+			}
+			data = data[:n]
+			if kind != "eXIf" && bytes.HasPrefix(data, xmpKeyword) {
+				continue
+			}
+			if kind == "eXIf" {
+				if t, ok := parseTIFF(data); !ok || !t.clearGPS() {
+					continue
+				}
+			}
+			crc := crc32.NewIEEE()
+			crc.Write(head[4:])
+			crc.Write(data)
+			chunk := append(append(head[:], data...), binary.BigEndian.AppendUint32(nil, crc.Sum32())...)
+			if _, err := dst.Write(chunk); err != nil {
+				return err
+			}
+		default:
+			if _, err := dst.Write(head[:]); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(dst, src, n+4); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidImage, err)
+			}
+		}
+		if kind == "IEND" {
+			return nil
+		}
+	}
+}
+
+//stripWebP drops the EXIF and XMP chunks of a WebP and their flags in the VP8X chunk. It can't clear the GPS in place
+//like the other types: the EXIF of a WebP is rarely more than the camera wrote, so it goes whole
+func stripWebP(dst io.Writer, src io.Reader) error {
+	b, err := io.ReadAll(io.LimitReader(src, maxWebP+1))
+	if err != nil {
+		return err
+	}
+	if len(b) > maxWebP {
+		return fmt.Errorf("%w: WebP of more than %d bytes", ErrInvalidImage, maxWebP)
+	}
+	if len(b) < 12 || string(b[:4]) != "RIFF" || string(b[8:12]) != "WEBP" {
+		return fmt.Errorf("%w: not a WebP", ErrInvalidImage)
+	}
+
+	out := append([]byte{}, b[:12]...)
+	for i := 12; i < len(b); {
+		if i+8 > len(b) {
+			return fmt.Errorf("%w: truncated chunk", ErrInvalidImage)
+		}
+		kind := string(b[i : i+4])
+		n := int(binary.LittleEndian.Uint32(b[i+4:]))
+		end := i + 8 + n + n%2 //chunks are padded to an even size
+		if n < 0 || end > len(b) || end < i {
+			return fmt.Errorf("%w: truncated %s chunk", ErrInvalidImage, kind)
+		}
+		switch kind {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte{}, b[i:end]...)
+			if n > 0 {
+				chunk[8] &^= 0x08 | 0x04 //the EXIF and XMP flags
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, b[i:end]...)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	_, err = dst.Write(out)
+	return err
+}
@@ -0,0 +1,145 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+)
+
+//Elector picks one replica to run a background job. The replicas compete for a lease item in a DynamoDB table with a
+//conditional write: the holder renews it every TTL/3 and the others take it over once it was not renewed for a whole TTL.
+//A leader can still be cut off from DynamoDB while another replica takes over, so the job must also be safe to run twice
+//for a short while. The lease only keeps the replicas from doing the same work all the time
+type Elector struct {
+	client common.DynamoDBAPI
+	table  string
+	//id of the lease item. The table has a hash key "id" of type S, other items can live next to the lease
+	name   string
+	holder string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+//NewElector competes as holder(eg the host name) for the lease called name
+func NewElector(client common.DynamoDBAPI, table, name, holder string, ttl time.Duration) *Elector {
+	return &Elector{client: client, table: table, name: name, holder: holder, ttl: ttl, now: time.Now}
+}
+
+func (e *Elector) key() map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: e.name}}
+}
+
+//TryAcquire takes the lease when it is free or expired and renews it when we hold it already. It is false while
+//another holder has a lease that did not expire
+func (e *Elector) TryAcquire(ctx context.Context) (bool, error) {
+	now := e.now()
+	_, err := e.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(e.table),
+		Item: map[string]types.AttributeValue{
+			"id":          &types.AttributeValueMemberS{Value: e.name},
+			"holder":      &types.AttributeValueMemberS{Value: e.holder},
+			"lease_until": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(e.ttl).UnixMilli(), 10)}, //unix ms
+		},
+		ConditionExpression: aws.String("attribute_not_exists(id) OR holder = :holder OR lease_until < :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":holder": &types.AttributeValueMemberS{Value: e.holder},
+			":now":    &types.AttributeValueMemberN{Value: strconv.FormatInt(now.UnixMilli(), 10)},
+		},
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+//Release gives the lease up so another replica takes over without waiting for the TTL. It does nothing when we don't hold it
+func (e *Elector) Release(ctx context.Context) error {
+	_, err := e.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           aws.String(e.table),
+		Key:                 e.key(),
+		ConditionExpression: aws.String("holder = :holder"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":holder": &types.AttributeValueMemberS{Value: e.holder},
+		},
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil
+	}
+	return err
+}
+
+//term is one stretch of time this replica leads
+type term struct {
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+func startTerm(ctx context.Context, lead func(ctx context.Context)) *term {
+	leadCtx, stop := context.WithCancel(ctx)
+	t := &term{stop: stop, done: make(chan struct{})}
+	go func() {
+		defer close(t.done)
+		lead(leadCtx)
+	}()
+	return t
+}
+
+//end cancels the context of lead and waits for it to return
+func (t *term) end() {
+	t.stop()
+	<-t.done
+}
+
+//Run calls lead while this replica holds the lease. The context of lead is cancelled when the lease is lost(a renewal
+//failed) or ctx is done; lead must return then. Run blocks until ctx is done and releases the lease on its way out
+func (e *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	var current *term
+	defer func() {
+		if current == nil {
+			return
+		}
+		current.end()
+		//ctx is done already, give the release a moment of its own
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := e.Release(releaseCtx); err != nil {
+			slog.Warn("unable to release the lease", "lease", e.name, "err", err)
+		}
+	}()
+
+	for {
+		leading, err := e.TryAcquire(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("unable to get the lease", "lease", e.name, "err", err)
+		}
+		switch {
+		case leading && current == nil:
+			slog.Info("leading", "lease", e.name, "holder", e.holder)
+			current = startTerm(ctx, lead)
+		case !leading && current != nil:
+			//we can't tell whether another replica took over, so stop as if it did
+			slog.Warn("lost the lease", "lease", e.name, "holder", e.holder)
+			current.end()
+			current = nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
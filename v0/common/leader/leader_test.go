@@ -0,0 +1,125 @@
+package leader
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+)
+
+//leaseMockClient keeps the lease items and checks the conditions of TryAcquire and Release like DynamoDB would
+type leaseMockClient struct {
+	common.DynamoDBAPI
+	mu    sync.Mutex
+	items map[string]map[string]types.AttributeValue
+}
+
+func str(v types.AttributeValue) string {
+	if s, ok := v.(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	if n, ok := v.(*types.AttributeValueMemberN); ok {
+		return n.Value
+	}
+	return ""
+}
+
+func (m *leaseMockClient) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := str(input.Item["id"])
+	if old, ok := m.items[id]; ok {
+		v := input.ExpressionAttributeValues
+		//lease_until and :now have the same number of digits in these tests, so comparing strings is enough
+		if str(old["holder"]) != str(v[":holder"]) && str(old["lease_until"]) >= str(v[":now"]) {
+			return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+		}
+	}
+	m.items[id] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *leaseMockClient) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := str(input.Key["id"])
+	if old, ok := m.items[id]; !ok || str(old["holder"]) != str(input.ExpressionAttributeValues[":holder"]) {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	delete(m.items, id)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func Test_TryAcquire(t *testing.T) {
+	ctx := context.Background()
+	client := &leaseMockClient{items: map[string]map[string]types.AttributeValue{}}
+	now := time.Unix(1518122597, 0)
+	clock := func() time.Time { return now }
+
+	a := NewElector(client, "fake-table", "publisher", "replica-a", 30*time.Second)
+	b := NewElector(client, "fake-table", "publisher", "replica-b", 30*time.Second)
+	a.now, b.now = clock, clock
+
+	leading, err := a.TryAcquire(ctx)
+	assert.NoError(t, err)
+	assert.True(t, leading)
+	leading, err = b.TryAcquire(ctx)
+	assert.NoError(t, err)
+	assert.False(t, leading, "the lease of a did not expire")
+
+	now = now.Add(20 * time.Second)
+	leading, _ = a.TryAcquire(ctx)
+	assert.True(t, leading, "the holder renews its lease")
+
+	now = now.Add(31 * time.Second)
+	leading, _ = b.TryAcquire(ctx)
+	assert.True(t, leading, "b takes over an expired lease")
+	leading, _ = a.TryAcquire(ctx)
+	assert.False(t, leading)
+
+	assert.NoError(t, a.Release(ctx), "releasing a lease we don't hold does nothing")
+	assert.Equal(t, "replica-b", str(client.items["publisher"]["holder"]))
+	assert.NoError(t, b.Release(ctx))
+	leading, _ = a.TryAcquire(ctx)
+	assert.True(t, leading, "a released lease is free straight away")
+}
+
+func Test_Run(t *testing.T) {
+	client := &leaseMockClient{items: map[string]map[string]types.AttributeValue{}}
+	e := NewElector(client, "fake-table", "publisher", "replica-a", 30*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		e.Run(ctx, func(leadCtx context.Context) {
+			close(started)
+			<-leadCtx.Done()
+			close(stopped)
+		})
+		close(finished)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("lead was not called")
+	}
+	cancel()
+	<-finished
+
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("Run returned before lead")
+	}
+	assert.NotContains(t, client.items, "publisher", "the lease is released on the way out")
+}
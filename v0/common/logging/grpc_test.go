@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+//fakeTransportStream records the headers the interceptor sends back
+type fakeTransportStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (f *fakeTransportStream) SetHeader(md metadata.MD) error {
+	f.header = metadata.Join(f.header, md)
+	return nil
+}
+
+func Test_UnaryServerInterceptor(t *testing.T) {
+	testCases := []struct {
+		name      string
+		requestID string
+		err       error
+
+		expectedCode  string
+		expectedLevel string
+	}{
+		{name: "should log the rpc and echo the request id of the client", requestID: "client-id", expectedCode: "OK", expectedLevel: "INFO"},
+		{name: "should log client errors at info level", err: status.Error(codes.InvalidArgument, "id is required"), expectedCode: "InvalidArgument", expectedLevel: "INFO"},
+		{name: "should log server errors at error level", err: status.Error(codes.Internal, "boom"), expectedCode: "Internal", expectedLevel: "ERROR"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			buf := captureDefault(t, Options{Level: "info"})
+			stream := &fakeTransportStream{}
+			ctx := grpc.NewContextWithServerTransportStream(context.TODO(), stream)
+			ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+			if tc.requestID != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-request-id", tc.requestID))
+			}
+			var seenID string
+
+			_, err := UnaryServerInterceptor()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/tweet.v1.TweetService/GetTweet"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				seenID = RequestID(ctx)
+				return nil, tc.err
+			})
+
+			assert.Equal(t, tc.err, err)
+			assert.NotEmpty(t, seenID)
+			if tc.requestID != "" {
+				assert.Equal(t, tc.requestID, seenID)
+			}
+			assert.Equal(t, []string{seenID}, stream.header.Get("x-request-id"))
+			got := lines(t, buf)
+			if assert.Len(t, got, 1) {
+				assert.Equal(t, tc.expectedLevel, got[0]["level"])
+				assert.Equal(t, "/tweet.v1.TweetService/GetTweet", got[0]["method"])
+				assert.Equal(t, tc.expectedCode, got[0]["code"])
+				assert.Equal(t, "10.0.0.1:5000", got[0]["caller"])
+				assert.Equal(t, seenID, got[0]["request_id"])
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_InstrumentHandler(t *testing.T) {
+	testCases := []struct {
+		name      string
+		path      string
+		requestID string
+		status    int
+
+		expectKept  bool
+		expectLevel string
+		expectLines int
+	}{
+		{name: "should keep the request id of the client", path: "/v1/tweets", requestID: "client-id", status: http.StatusOK, expectKept: true, expectLevel: "INFO", expectLines: 1},
+		{name: "should make a request id when there is none", path: "/v1/tweets", status: http.StatusOK, expectLevel: "INFO", expectLines: 1},
+		{name: "should log server errors as errors", path: "/v1/tweets", requestID: "client-id", status: http.StatusInternalServerError, expectKept: true, expectLevel: "ERROR", expectLines: 1},
+		{name: "should only log probes at debug level", path: "/livez", status: http.StatusOK, expectLines: 0},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			buf := captureDefault(t, Options{Level: "info"})
+			var seenID string
+			handler := InstrumentHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seenID = RequestID(r.Context())
+				w.WriteHeader(tc.status)
+			}))
+			r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.requestID != "" {
+				r.Header.Set(RequestIDHeader, tc.requestID)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, r)
+
+			assert.NotEmpty(t, seenID)
+			assert.Equal(t, seenID, w.Header().Get(RequestIDHeader))
+			if tc.expectKept {
+				assert.Equal(t, tc.requestID, seenID)
+			}
+			got := lines(t, buf)
+			if assert.Len(t, got, tc.expectLines) && tc.expectLines > 0 {
+				assert.Equal(t, tc.expectLevel, got[0]["level"])
+				assert.Equal(t, seenID, got[0]["request_id"])
+				assert.Equal(t, tc.path, got[0]["path"])
+				assert.Equal(t, float64(tc.status), got[0]["status"])
+				assert.Contains(t, got[0], "duration")
+				assert.Contains(t, got[0], "caller")
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+//Options come from config.Log
+type Options struct {
+	Level string //debug, info, warn or error
+	//RedactText hides the text of tweets. Anything logged under a "text" key, eg the tweet group of Tweet.LogValue
+	RedactText bool
+}
+
+//New returns a JSON logger writing to w. Every line logged with a context gets the request id and the trace id of that context
+func New(w io.Writer, opts Options) *slog.Logger {
+	handlerOpts := &slog.HandlerOptions{Level: ParseLevel(opts.Level)}
+	if opts.RedactText {
+		handlerOpts.ReplaceAttr = redactText
+	}
+	return slog.New(&contextHandler{Handler: slog.NewJSONHandler(w, handlerOpts)})
+}
+
+//ParseLevel reads debug, info, warn or error. Anything else is info; the config rejects bad values before they get here
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.ToUpper(level))); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+func redactText(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == "text" && a.Value.Kind() == slog.KindString {
+		return slog.String(a.Key, fmt.Sprintf("[redacted %d chars]", utf8.RuneCountInString(a.Value.String())))
+	}
+	return a
+}
+
+type requestIDKey struct{}
+
+//WithRequestID returns a copy of ctx that carries id. The interceptors and InstrumentHandler do it for every request
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+//RequestID is the id of the request ctx belongs to, or "" outside of a request
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+//contextHandler adds the values of the context to the record, so callers only need to use the *Context methods of slog
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//captureDefault makes a logger writing to the returned buffer the default one for the rest of the test
+func captureDefault(t *testing.T, opts Options) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	previous := slog.Default()
+	slog.SetDefault(New(buf, opts))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return buf
+}
+
+//lines decodes the JSON lines of buf
+func lines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var out []map[string]interface{}
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		line := map[string]interface{}{}
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+type fakeTweet struct{ text string }
+
+func (f fakeTweet) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", "tweetID"), slog.String("text", f.text))
+}
+
+func Test_New(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts Options
+		log  func(ctx context.Context)
+
+		expected map[string]interface{}
+	}{
+		{
+			name: "should add the request id and trace of the context",
+			opts: Options{Level: "info"},
+			log: func(ctx context.Context) {
+				sc := trace.NewSpanContext(trace.SpanContextConfig{
+					TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+					SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+				})
+				ctx = trace.ContextWithSpanContext(WithRequestID(ctx, "req-1"), sc)
+				slog.InfoContext(ctx, "hello")
+			},
+			expected: map[string]interface{}{
+				"level":      "INFO",
+				"msg":        "hello",
+				"request_id": "req-1",
+				"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+				"span_id":    "00f067aa0ba902b7",
+			},
+		},
+		{
+			name: "should keep the text of tweets by default",
+			opts: Options{Level: "info"},
+			log: func(ctx context.Context) {
+				slog.InfoContext(ctx, "saved", "tweet", fakeTweet{text: "hello world"})
+			},
+			expected: map[string]interface{}{
+				"level": "INFO",
+				"msg":   "saved",
+				"tweet": map[string]interface{}{"id": "tweetID", "text": "hello world"},
+			},
+		},
+		{
+			name: "should redact the text of tweets when configured",
+			opts: Options{Level: "info", RedactText: true},
+			log: func(ctx context.Context) {
+				slog.InfoContext(ctx, "saved", "tweet", fakeTweet{text: "héllo wörld"})
+			},
+			expected: map[string]interface{}{
+				"level": "INFO",
+				"msg":   "saved",
+				"tweet": map[string]interface{}{"id": "tweetID", "text": "[redacted 11 chars]"},
+			},
+		},
+		{
+			name: "should drop lines below the level",
+			opts: Options{Level: "warn"},
+			log: func(ctx context.Context) {
+				slog.InfoContext(ctx, "dropped")
+				slog.WarnContext(ctx, "kept")
+			},
+			expected: map[string]interface{}{
+				"level": "WARN",
+				"msg":   "kept",
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			buf := captureDefault(t, tc.opts)
+
+			tc.log(context.TODO())
+
+			got := lines(t, buf)
+			if assert.Len(t, got, 1) {
+				delete(got[0], "time")
+				assert.Equal(t, tc.expected, got[0])
+			}
+		})
+	}
+}
+
+func Test_requestID(t *testing.T) {
+	assert.Equal(t, "abc-123", requestID("abc-123"))
+	for _, bad := range []string{"", "with space", "new\nline", `quo"te`, string(make([]byte, 200))} {
+		id := requestID(bad)
+		assert.NotEqual(t, bad, id)
+		assert.Len(t, id, 36)
+	}
+}
@@ -0,0 +1,27 @@
+package logging
+
+import (
+	"github.com/google/uuid"
+)
+
+//RequestIDHeader is read from the request and sent back in the response. gRPC uses the same name in lower case as metadata
+const RequestIDHeader = "X-Request-Id"
+
+const requestIDMetadata = "x-request-id"
+
+//maxRequestIDLength keeps a client from filling the logs through the header
+const maxRequestIDLength = 128
+
+//requestID keeps the id the client sent when it is sane and makes one otherwise
+func requestID(fromClient string) string {
+	if fromClient == "" || len(fromClient) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for _, c := range fromClient {
+		//printable ascii only. No new lines or quotes ending up in the log lines
+		if c < '!' || c > '~' || c == '"' || c == '\\' {
+			return uuid.NewString()
+		}
+	}
+	return fromClient
+}
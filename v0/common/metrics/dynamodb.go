@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+)
+
+var (
+	dynamodbRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "dynamodb_requests_total",
+		Help: "DynamoDB calls, by operation and result. result is ok or the error code.",
+	}, []string{"operation", "result"})
+
+	dynamodbDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dynamodb_request_duration_seconds",
+		Help:    "Time of a DynamoDB call including the SDK retries, by operation.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation"})
+
+	dynamodbThrottles = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "dynamodb_throttles_total",
+		Help: "DynamoDB calls that were still throttled after the SDK retries, by operation.",
+	}, []string{"operation"})
+
+	dynamodbConsumedCapacity = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "dynamodb_consumed_capacity_units_total",
+		Help: "Capacity units consumed, by operation and table.",
+	}, []string{"operation", "table"})
+
+	dynamodbUnprocessed = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "dynamodb_unprocessed_items_total",
+		Help: "Items a batch call handed back unprocessed, by operation and table. The repository retries them.",
+	}, []string{"operation", "table"})
+)
+
+//error codes DynamoDB uses when it throttles a call
+var throttleCodes = map[string]bool{
+	"ProvisionedThroughputExceededException": true,
+	"ThrottlingException":                    true,
+	"RequestLimitExceeded":                   true,
+}
+
+//InstrumentDynamoDB wraps client so every call is measured. Calls that can report consumed capacity ask for it(TOTAL)
+//unless the caller already set ReturnConsumedCapacity. The input of the caller is never modified
+func InstrumentDynamoDB(client common.DynamoDBAPI) common.DynamoDBAPI {
+	return &instrumentedDynamoDB{next: client}
+}
+
+type instrumentedDynamoDB struct {
+	next common.DynamoDBAPI
+}
+
+func returnCapacity(v types.ReturnConsumedCapacity) types.ReturnConsumedCapacity {
+	if v == "" {
+		return types.ReturnConsumedCapacityTotal
+	}
+	return v
+}
+
+func (d *instrumentedDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	p := *params
+	p.ReturnConsumedCapacity = returnCapacity(p.ReturnConsumedCapacity)
+	start := time.Now()
+	out, err := d.next.PutItem(ctx, &p, optFns...)
+	observeDynamoDB("PutItem", start, err)
+	if out != nil && out.ConsumedCapacity != nil {
+		observeCapacity("PutItem", *out.ConsumedCapacity)
+	}
+	return out, err
+}
+
+func (d *instrumentedDynamoDB) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	p := *params
+	p.ReturnConsumedCapacity = returnCapacity(p.ReturnConsumedCapacity)
+	start := time.Now()
+	out, err := d.next.Scan(ctx, &p, optFns...)
+	observeDynamoDB("Scan", start, err)
+	if out != nil && out.ConsumedCapacity != nil {
+		observeCapacity("Scan", *out.ConsumedCapacity)
+	}
+	return out, err
+}
+
+func (d *instrumentedDynamoDB) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	p := *params
+	p.ReturnConsumedCapacity = returnCapacity(p.ReturnConsumedCapacity)
+	start := time.Now()
+	out, err := d.next.UpdateItem(ctx, &p, optFns...)
+	observeDynamoDB("UpdateItem", start, err)
+	if out != nil && out.ConsumedCapacity != nil {
+		observeCapacity("UpdateItem", *out.ConsumedCapacity)
+	}
+	return out, err
+}
+
+func (d *instrumentedDynamoDB) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	p := *params
+	p.ReturnConsumedCapacity = returnCapacity(p.ReturnConsumedCapacity)
+	start := time.Now()
+	out, err := d.next.DeleteItem(ctx, &p, optFns...)
+	observeDynamoDB("DeleteItem", start, err)
+	if out != nil && out.ConsumedCapacity != nil {
+		observeCapacity("DeleteItem", *out.ConsumedCapacity)
+	}
+	return out, err
+}
+
+func (d *instrumentedDynamoDB) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	p := *params
+	p.ReturnConsumedCapacity = returnCapacity(p.ReturnConsumedCapacity)
+	start := time.Now()
+	out, err := d.next.GetItem(ctx, &p, optFns...)
+	observeDynamoDB("GetItem", start, err)
+	if out != nil && out.ConsumedCapacity != nil {
+		observeCapacity("GetItem", *out.ConsumedCapacity)
+	}
+	return out, err
+}
+
+func (d *instrumentedDynamoDB) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	p := *params
+	p.ReturnConsumedCapacity = returnCapacity(p.ReturnConsumedCapacity)
+	start := time.Now()
+	out, err := d.next.Query(ctx, &p, optFns...)
+	observeDynamoDB("Query", start, err)
+	if out != nil && out.ConsumedCapacity != nil {
+		observeCapacity("Query", *out.ConsumedCapacity)
+	}
+	return out, err
+}
+
+func (d *instrumentedDynamoDB) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	p := *params
+	p.ReturnConsumedCapacity = returnCapacity(p.ReturnConsumedCapacity)
+	start := time.Now()
+	out, err := d.next.TransactWriteItems(ctx, &p, optFns...)
+	observeDynamoDB("TransactWriteItems", start, err)
+	if out != nil {
+		observeCapacity("TransactWriteItems", out.ConsumedCapacity...)
+	}
+	return out, err
+}
+
+func (d *instrumentedDynamoDB) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	p := *params
+	p.ReturnConsumedCapacity = returnCapacity(p.ReturnConsumedCapacity)
+	start := time.Now()
+	out, err := d.next.BatchGetItem(ctx, &p, optFns...)
+	observeDynamoDB("BatchGetItem", start, err)
+	if out != nil {
+		observeCapacity("BatchGetItem", out.ConsumedCapacity...)
+		for table, keys := range out.UnprocessedKeys {
+			dynamodbUnprocessed.WithLabelValues("BatchGetItem", table).Add(float64(len(keys.Keys)))
+		}
+	}
+	return out, err
+}
+
+func (d *instrumentedDynamoDB) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	p := *params
+	p.ReturnConsumedCapacity = returnCapacity(p.ReturnConsumedCapacity)
+	start := time.Now()
+	out, err := d.next.BatchWriteItem(ctx, &p, optFns...)
+	observeDynamoDB("BatchWriteItem", start, err)
+	if out != nil {
+		observeCapacity("BatchWriteItem", out.ConsumedCapacity...)
+		for table, requests := range out.UnprocessedItems {
+			dynamodbUnprocessed.WithLabelValues("BatchWriteItem", table).Add(float64(len(requests)))
+		}
+	}
+	return out, err
+}
+
+func (d *instrumentedDynamoDB) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	start := time.Now()
+	out, err := d.next.CreateTable(ctx, params, optFns...)
+	observeDynamoDB("CreateTable", start, err)
+	return out, err
+}
+
+func (d *instrumentedDynamoDB) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	start := time.Now()
+	out, err := d.next.DescribeTable(ctx, params, optFns...)
+	observeDynamoDB("DescribeTable", start, err)
+	return out, err
+}
+
+func observeDynamoDB(operation string, start time.Time, err error) {
+	dynamodbDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+
+	result := "ok"
+	if err != nil {
+		result = "error"
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) {
+			result = apiErr.ErrorCode()
+		} else if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			result = "canceled"
+		}
+		if throttleCodes[result] {
+			dynamodbThrottles.WithLabelValues(operation).Inc()
+		}
+	}
+	dynamodbRequests.WithLabelValues(operation, result).Inc()
+}
+
+func observeCapacity(operation string, consumed ...types.ConsumedCapacity) {
+	for _, c := range consumed {
+		if c.CapacityUnits == nil {
+			continue
+		}
+		dynamodbConsumedCapacity.WithLabelValues(operation, aws.ToString(c.TableName)).Add(*c.CapacityUnits)
+	}
+}
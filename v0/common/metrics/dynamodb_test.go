@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+)
+
+//fakeDynamoDB embeds the interface so only the methods a test calls need to exist
+type fakeDynamoDB struct {
+	common.DynamoDBAPI
+	putInput   *dynamodb.PutItemInput
+	putErr     error
+	batchWrite *dynamodb.BatchWriteItemOutput
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.putInput = params
+	if f.putErr != nil {
+		return nil, f.putErr
+	}
+	return &dynamodb.PutItemOutput{
+		ConsumedCapacity: &types.ConsumedCapacity{TableName: params.TableName, CapacityUnits: aws.Float64(2)},
+	}, nil
+}
+
+func (f *fakeDynamoDB) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return f.batchWrite, nil
+}
+
+func Test_InstrumentDynamoDB(t *testing.T) {
+	t.Run("asks for the consumed capacity without changing the input", func(t *testing.T) {
+		fake := &fakeDynamoDB{}
+		client := InstrumentDynamoDB(fake)
+		input := &dynamodb.PutItemInput{TableName: aws.String("capacity-table")}
+		before := testutil.ToFloat64(dynamodbRequests.WithLabelValues("PutItem", "ok"))
+
+		_, err := client.PutItem(context.TODO(), input)
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.ReturnConsumedCapacity(""), input.ReturnConsumedCapacity)
+		assert.Equal(t, types.ReturnConsumedCapacityTotal, fake.putInput.ReturnConsumedCapacity)
+		assert.Equal(t, float64(2), testutil.ToFloat64(dynamodbConsumedCapacity.WithLabelValues("PutItem", "capacity-table")))
+		assert.Equal(t, before+1, testutil.ToFloat64(dynamodbRequests.WithLabelValues("PutItem", "ok")))
+	})
+
+	t.Run("keeps the ReturnConsumedCapacity of the caller", func(t *testing.T) {
+		fake := &fakeDynamoDB{}
+		client := InstrumentDynamoDB(fake)
+
+		_, err := client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+			TableName:              aws.String("indexes-table"),
+			ReturnConsumedCapacity: types.ReturnConsumedCapacityIndexes,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.ReturnConsumedCapacityIndexes, fake.putInput.ReturnConsumedCapacity)
+	})
+
+	t.Run("counts throttles by error code", func(t *testing.T) {
+		fake := &fakeDynamoDB{putErr: &smithy.GenericAPIError{Code: "ProvisionedThroughputExceededException"}}
+		client := InstrumentDynamoDB(fake)
+		before := testutil.ToFloat64(dynamodbThrottles.WithLabelValues("PutItem"))
+
+		_, err := client.PutItem(context.TODO(), &dynamodb.PutItemInput{TableName: aws.String("throttled-table")})
+
+		assert.Error(t, err)
+		assert.Equal(t, before+1, testutil.ToFloat64(dynamodbThrottles.WithLabelValues("PutItem")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(dynamodbRequests.WithLabelValues("PutItem", "ProvisionedThroughputExceededException")))
+	})
+
+	t.Run("labels canceled calls", func(t *testing.T) {
+		fake := &fakeDynamoDB{putErr: context.Canceled}
+		client := InstrumentDynamoDB(fake)
+		before := testutil.ToFloat64(dynamodbRequests.WithLabelValues("PutItem", "canceled"))
+
+		_, err := client.PutItem(context.TODO(), &dynamodb.PutItemInput{TableName: aws.String("canceled-table")})
+
+		assert.True(t, errors.Is(err, context.Canceled))
+		assert.Equal(t, before+1, testutil.ToFloat64(dynamodbRequests.WithLabelValues("PutItem", "canceled")))
+	})
+
+	t.Run("counts unprocessed items per table", func(t *testing.T) {
+		fake := &fakeDynamoDB{batchWrite: &dynamodb.BatchWriteItemOutput{
+			UnprocessedItems: map[string][]types.WriteRequest{
+				"unprocessed-table": {{}, {}, {}},
+			},
+		}}
+		client := InstrumentDynamoDB(fake)
+
+		_, err := client.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, float64(3), testutil.ToFloat64(dynamodbUnprocessed.WithLabelValues("BatchWriteItem", "unprocessed-table")))
+	})
+}
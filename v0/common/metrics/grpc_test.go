@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func Test_UnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{name: "ok", code: "OK"},
+		{name: "status error", err: status.Error(codes.InvalidArgument, "id is required"), code: "InvalidArgument"},
+		{name: "plain error", err: context.DeadlineExceeded, code: "Unknown"},
+	}
+	interceptor := UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/tweet.v1.TweetService/MetricsTest"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := testutil.ToFloat64(grpcHandled.WithLabelValues(info.FullMethod, tt.code))
+
+			_, err := interceptor(context.TODO(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, tt.err
+			})
+
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, before+1, testutil.ToFloat64(grpcHandled.WithLabelValues(info.FullMethod, tt.code)))
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests completed, by route, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time to serve an HTTP request, by route and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+)
+
+type routeKey struct{}
+
+//route is filled in while the request is served. It starts as the ServeMux pattern and GatewayRoute replaces it with the gateway path pattern
+type route struct {
+	pattern string
+}
+
+//InstrumentHandler records the rate, errors and duration of every request served by mux. Requests are labelled with the
+//pattern that matched, never the raw path, so ids in urls don't create a time series each
+func InstrumentHandler(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rt := &route{pattern: "unmatched"}
+		if _, pattern := mux.Handler(r); pattern != "" {
+			rt.pattern = pattern
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		mux.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), routeKey{}, rt)))
+
+		httpRequests.WithLabelValues(rt.pattern, r.Method, strconv.Itoa(rec.status)).Inc()
+		httpDuration.WithLabelValues(rt.pattern, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+//GatewayRoute is a runtime.WithMetadata annotator. Every gateway route is served from "/" of the ServeMux, so this
+//labels the request with the path pattern of the rpc instead. eg: /v1/tweets
+func GatewayRoute(ctx context.Context, r *http.Request) metadata.MD {
+	rt, ok := ctx.Value(routeKey{}).(*route)
+	if !ok {
+		return nil
+	}
+	if pattern, ok := runtime.HTTPPathPattern(ctx); ok {
+		rt.pattern = pattern
+	}
+	return nil
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = code, true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+//Flush keeps streaming responses(eg /export-tweets) streaming
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
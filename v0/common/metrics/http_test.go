@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_InstrumentHandler(t *testing.T) {
+	gateway := runtime.NewServeMux(runtime.WithMetadata(GatewayRoute))
+	//the same call the generated handlers of RegisterTweetServiceHandlerServer make before the rpc runs
+	err := gateway.HandlePath(http.MethodGet, "/v1/metrics-test/{id}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		_, err := runtime.AnnotateIncomingContext(r.Context(), gateway, r, "/tweet.v1.TweetService/MetricsTest", runtime.WithHTTPPathPattern("/v1/metrics-test/{id}"))
+		assert.NoError(t, err)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", gateway)
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {})
+	handler := InstrumentHandler(mux)
+
+	tests := []struct {
+		name   string
+		path   string
+		route  string
+		code   string
+	}{
+		{name: "ServeMux pattern", path: "/livez", route: "/livez", code: "200"},
+		{name: "gateway path pattern instead of the raw path", path: "/v1/metrics-test/1234", route: "/v1/metrics-test/{id}", code: "404"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := testutil.ToFloat64(httpRequests.WithLabelValues(tt.route, http.MethodGet, tt.code))
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, before+1, testutil.ToFloat64(httpRequests.WithLabelValues(tt.route, http.MethodGet, tt.code)))
+		})
+	}
+}
+
+func Test_GatewayRoute(t *testing.T) {
+	//outside of InstrumentHandler there is nothing to label
+	assert.Nil(t, GatewayRoute(context.TODO(), httptest.NewRequest(http.MethodGet, "/", nil)))
+}
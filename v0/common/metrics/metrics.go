@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+//Registry holds every metric of the service plus the go runtime and process collectors. It is served at /metrics
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+//Handler serves the metrics in the prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+//Business counters. They are counted in the service, so every transport(grpc, the gateway, /migrate-tweet and the CLI) adds to them
+var (
+	TweetsCreated = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "chirper_tweets_created_total",
+		Help: "Tweets saved through SaveTweet. kind is tweet or reply.",
+	}, []string{"kind"})
+
+	LikesToggled = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "chirper_likes_toggled_total",
+		Help: "Likes added or removed. action is like or unlike.",
+	}, []string{"action"})
+
+	ImportRows = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "chirper_import_rows_total",
+		Help: "Rows of bulk imports. result is saved, rejected(failed validation) or failed(the write failed).",
+	}, []string{"result"})
+
+	RateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "chirper_rate_limited_total",
+		Help: "Requests rejected by the rate limits, by operation and dimension(user, ip or method).",
+	}, []string{"operation", "dimension"})
+
+	ModerationDecisions = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "chirper_moderation_decisions_total",
+		Help: "Tweets flagged or rejected by a moderation rule, and reviews. verdict is flag, reject, approve or remove.",
+	}, []string{"rule", "verdict"})
+
+	ScheduledTweets = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "chirper_scheduled_tweets_total",
+		Help: "Scheduled tweets by outcome. outcome is scheduled, cancelled, published, retried or failed.",
+	}, []string{"outcome"})
+)
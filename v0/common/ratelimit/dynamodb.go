@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+)
+
+//maxAttempts bounds the retries when other replicas update the same bucket at the same time
+const maxAttempts = 5
+
+//DynamoDBStore keeps the buckets in a DynamoDB table so every replica shares them. A bucket is updated with a conditional
+//write on its version, so concurrent requests on different replicas can't both spend the last token
+type DynamoDBStore struct {
+	client common.DynamoDBAPI
+	table  string
+}
+
+func NewDynamoDBStore(client common.DynamoDBAPI, table string) *DynamoDBStore {
+	return &DynamoDBStore{client: client, table: table}
+}
+
+//TableDefinition is the table of DynamoDBStore. Turn on TTL on expires_at so the buckets that are full again are deleted;
+//it can't be set with CreateTable
+func TableDefinition(table string) *dynamodb.CreateTableInput {
+	return &dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	}
+}
+
+type bucketItem struct {
+	Id        string  `dynamodbav:"id"`
+	Tokens    float64 `dynamodbav:"tokens"`
+	UpdatedAt int64   `dynamodbav:"updated_at"` //unix ms
+	Version   int64   `dynamodbav:"version"`
+	ExpiresAt int64   `dynamodbav:"expires_at"` //unix seconds, for the TTL
+}
+
+func (s *DynamoDBStore) Take(ctx context.Context, key string, rate Rate, now time.Time) (Result, error) {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(s.table),
+			Key:            map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: key}},
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return Result{}, err
+		}
+
+		item := bucketItem{Id: key, Tokens: float64(rate.Requests), UpdatedAt: now.UnixMilli()}
+		exists := len(out.Item) > 0
+		if exists {
+			if err := attributevalue.UnmarshalMap(out.Item, &item); err != nil {
+				return Result{}, fmt.Errorf("unable to read the bucket %s: %w", key, err)
+			}
+		}
+
+		res, tokens := take(item.Tokens, time.UnixMilli(item.UpdatedAt), now, rate)
+		if !res.Allowed {
+			//nothing to write. The refill is worked out again on the next request
+			return res, nil
+		}
+
+		prevVersion := item.Version
+		missing := float64(rate.Requests) - tokens
+		full := now.Add(time.Duration(missing / rate.tokensPerSecond() * float64(time.Second)))
+		item.Tokens, item.UpdatedAt, item.Version, item.ExpiresAt = tokens, now.UnixMilli(), prevVersion+1, full.Unix()+1
+		av, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			return Result{}, err
+		}
+
+		input := &dynamodb.PutItemInput{TableName: aws.String(s.table), Item: av}
+		if exists {
+			input.ConditionExpression = aws.String("version = :version")
+			input.ExpressionAttributeValues = map[string]types.AttributeValue{
+				":version": &types.AttributeValueMemberN{Value: strconv.FormatInt(prevVersion, 10)},
+			}
+		} else {
+			input.ConditionExpression = aws.String("attribute_not_exists(id)")
+		}
+		_, err = s.client.PutItem(ctx, input)
+		var conflict *types.ConditionalCheckFailedException
+		if errors.As(err, &conflict) {
+			continue //another replica took a token in between. Read the bucket again
+		}
+		if err != nil {
+			return Result{}, err
+		}
+		return res, nil
+	}
+	return Result{}, fmt.Errorf("the bucket %s kept changing after %d attempts", key, maxAttempts)
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+)
+
+//fakeDynamoDB keeps the items in a map and checks the conditions DynamoDBStore uses. beforePut runs between the read
+//and the write, like another replica would
+type fakeDynamoDB struct {
+	common.DynamoDBAPI
+	items     map[string]map[string]types.AttributeValue
+	beforePut func(f *fakeDynamoDB)
+	puts      int
+}
+
+func (f *fakeDynamoDB) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	id := params.Key["id"].(*types.AttributeValueMemberS).Value
+	return &dynamodb.GetItemOutput{Item: f.items[id]}, nil
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if f.beforePut != nil {
+		f.beforePut(f)
+	}
+	f.puts++
+	id := params.Item["id"].(*types.AttributeValueMemberS).Value
+	current, exists := f.items[id]
+	switch aws.ToString(params.ConditionExpression) {
+	case "attribute_not_exists(id)":
+		if exists {
+			return nil, &types.ConditionalCheckFailedException{}
+		}
+	case "version = :version":
+		prev := params.ExpressionAttributeValues[":version"].(*types.AttributeValueMemberN).Value
+		if !exists || current["version"].(*types.AttributeValueMemberN).Value != prev {
+			return nil, &types.ConditionalCheckFailedException{}
+		}
+	}
+	f.items[id] = params.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func Test_DynamoDBStore(t *testing.T) {
+	rate := Rate{Requests: 2, Per: 2 * time.Second}
+
+	t.Run("should share the bucket between stores", func(t *testing.T) {
+		fake := &fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}
+		replicaA, replicaB := NewDynamoDBStore(fake, "rate-limits"), NewDynamoDBStore(fake, "rate-limits")
+
+		res, err := replicaA.Take(context.TODO(), "SaveTweet|user:alice", rate, start)
+		assert.NoError(t, err)
+		assert.Equal(t, Result{Allowed: true, Remaining: 1}, res)
+		res, _ = replicaB.Take(context.TODO(), "SaveTweet|user:alice", rate, start)
+		assert.Equal(t, Result{Allowed: true, Remaining: 0}, res)
+		res, _ = replicaA.Take(context.TODO(), "SaveTweet|user:alice", rate, start.Add(500*time.Millisecond))
+		assert.Equal(t, Result{RetryAfter: 500 * time.Millisecond}, res)
+
+		item := fake.items["SaveTweet|user:alice"]
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "2"}, item["version"])
+		//empty at start, full again 2s later
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "1677672003"}, item["expires_at"])
+	})
+
+	t.Run("should read the bucket again when another replica wrote it first", func(t *testing.T) {
+		fake := &fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}
+		store := NewDynamoDBStore(fake, "rate-limits")
+		store.Take(context.TODO(), "SaveTweet|user:alice", rate, start)
+
+		other := NewDynamoDBStore(&fakeDynamoDB{items: fake.items}, "rate-limits")
+		fake.beforePut = func(f *fakeDynamoDB) {
+			f.beforePut = nil
+			other.Take(context.TODO(), "SaveTweet|user:alice", rate, start)
+		}
+
+		res, err := store.Take(context.TODO(), "SaveTweet|user:alice", rate, start)
+		assert.NoError(t, err)
+		assert.False(t, res.Allowed, "the other replica took the last token")
+		assert.Equal(t, 2, fake.puts)
+	})
+
+	t.Run("should give up when the bucket keeps changing", func(t *testing.T) {
+		fake := &fakeDynamoDB{items: map[string]map[string]types.AttributeValue{}}
+		version := 0
+		fake.beforePut = func(f *fakeDynamoDB) {
+			version++
+			f.items["SaveTweet|user:alice"] = map[string]types.AttributeValue{
+				"id":      &types.AttributeValueMemberS{Value: "SaveTweet|user:alice"},
+				"tokens":  &types.AttributeValueMemberN{Value: "2"},
+				"version": &types.AttributeValueMemberN{Value: strconv.Itoa(version)},
+			}
+		}
+		_, err := NewDynamoDBStore(fake, "rate-limits").Take(context.TODO(), "SaveTweet|user:alice", rate, start)
+
+		assert.EqualError(t, err, "the bucket SaveTweet|user:alice kept changing after 5 attempts")
+	})
+}
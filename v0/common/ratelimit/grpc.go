@@ -0,0 +1,101 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+)
+
+//the headers sent with every limited rpc. The gateway passes them on as http headers, see OutgoingHeaderMatcher
+var rateLimitHeaders = map[string]bool{
+	"retry-after":           true,
+	"x-ratelimit-limit":     true,
+	"x-ratelimit-remaining": true,
+}
+
+//UnaryServerInterceptor applies the limits of the rpc. A rejected call fails with codes.ResourceExhausted, a
+//QuotaFailure and a RetryInfo detail, plus the retry-after header(HTTP 429 with Retry-After through the gateway).
+//The user comes from identity.AuthedUser. Until an auth proxy sets it, the user named in the request is used instead
+func UnaryServerInterceptor(l *Limiter, trustForwardedFor bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		operation := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+		if !l.Limits(operation) {
+			return handler(ctx, req)
+		}
+
+		user := identity.AuthedUser(ctx)
+		if user == "" {
+			user = requestUser(req)
+		}
+		d := l.Allow(ctx, operation, user, identity.ClientIP(ctx, trustForwardedFor))
+
+		header := metadata.MD{}
+		if !d.Limit.IsZero() {
+			header.Set("x-ratelimit-limit", strconv.Itoa(d.Limit.Requests))
+			header.Set("x-ratelimit-remaining", strconv.Itoa(d.Remaining))
+		}
+		if d.Allowed {
+			if len(header) > 0 {
+				_ = grpc.SetHeader(ctx, header)
+			}
+			return handler(ctx, req)
+		}
+
+		v := d.Violation
+		header.Set("retry-after", strconv.Itoa(retryAfterSeconds(v.RetryAfter)))
+		_ = grpc.SetHeader(ctx, header)
+		return nil, rejection(operation, v)
+	}
+}
+
+//requestUser is the user the request says it is made for: SaveLikeToggle has authedUserId, SaveTweet the author
+func requestUser(req interface{}) string {
+	if r, ok := req.(interface{ GetAuthedUserId() string }); ok && r.GetAuthedUserId() != "" {
+		return r.GetAuthedUserId()
+	}
+	if r, ok := req.(interface{ GetAuthor() string }); ok {
+		return r.GetAuthor()
+	}
+	return ""
+}
+
+func rejection(operation string, v *Violation) error {
+	description := fmt.Sprintf("%s allows %s per %s", operation, v.Rate, v.Dimension)
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("rate limit exceeded: %s. Retry in %ds", description, retryAfterSeconds(v.RetryAfter)))
+	withDetails, err := st.WithDetails(
+		&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{Subject: v.Subject, Description: description}}},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(v.RetryAfter)},
+	)
+	if err != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+//retryAfterSeconds rounds up. Retry-After only takes whole seconds and retrying early would be rejected again
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Max(1, math.Ceil(d.Seconds())))
+}
+
+//OutgoingHeaderMatcher is a runtime.WithOutgoingHeaderMatcher that passes the rate limit headers on to http clients as
+//is(Retry-After, X-Ratelimit-Limit...). Other headers keep the Grpc-Metadata- prefix of the gateway
+func OutgoingHeaderMatcher(key string) (string, bool) {
+	if rateLimitHeaders[strings.ToLower(key)] {
+		return textproto.CanonicalMIMEHeaderKey(key), true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/okpalaChidiebere/chirper-app-gen-protos/tweet/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/identity"
+)
+
+//fakeTransportStream records the headers the interceptor sends back
+type fakeTransportStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (f *fakeTransportStream) SetHeader(md metadata.MD) error {
+	f.header = metadata.Join(f.header, md)
+	return nil
+}
+
+func Test_UnaryServerInterceptor(t *testing.T) {
+	rules := map[string]Rule{"SaveTweet": {User: Rate{1, time.Minute}}}
+	info := &grpc.UnaryServerInfo{FullMethod: "/tweet.v1.TweetService/SaveTweet"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "saved", nil
+	}
+	newContext := func(stream *fakeTransportStream) context.Context {
+		ctx := grpc.NewContextWithServerTransportStream(context.TODO(), stream)
+		return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+	}
+
+	t.Run("should reject with the quota and the retry delay", func(t *testing.T) {
+		l := NewLimiter(NewMemoryStore(), rules)
+		l.now = func() time.Time { return start }
+		interceptor := UnaryServerInterceptor(l, false)
+		req := &pb.SaveTweetRequest{Author: "alice"}
+
+		stream := &fakeTransportStream{}
+		resp, err := interceptor(newContext(stream), req, info, handler)
+		assert.NoError(t, err)
+		assert.Equal(t, "saved", resp)
+		assert.Equal(t, []string{"1"}, stream.header.Get("x-ratelimit-limit"))
+		assert.Equal(t, []string{"0"}, stream.header.Get("x-ratelimit-remaining"))
+
+		stream = &fakeTransportStream{}
+		l.now = func() time.Time { return start.Add(500 * time.Millisecond) }
+		_, err = interceptor(newContext(stream), req, info, handler)
+		st := status.Convert(err)
+		assert.Equal(t, codes.ResourceExhausted, st.Code())
+		assert.Equal(t, "rate limit exceeded: SaveTweet allows 1 requests per 1m0s per user. Retry in 60s", st.Message())
+		assert.Len(t, st.Details(), 2)
+		quota := st.Details()[0].(*errdetails.QuotaFailure)
+		assert.Equal(t, "user:alice", quota.Violations[0].Subject)
+		retry := st.Details()[1].(*errdetails.RetryInfo)
+		assert.Equal(t, 59500*time.Millisecond, retry.RetryDelay.AsDuration())
+		assert.Equal(t, []string{"60"}, stream.header.Get("retry-after"))
+	})
+
+	t.Run("should prefer the authenticated user to the one of the request", func(t *testing.T) {
+		l := NewLimiter(NewMemoryStore(), rules)
+		interceptor := UnaryServerInterceptor(l, false)
+		ctx := identity.WithAuthedUser(newContext(&fakeTransportStream{}), "alice")
+
+		_, err := interceptor(ctx, &pb.SaveTweetRequest{Author: "bob"}, info, handler)
+		assert.NoError(t, err)
+		_, err = interceptor(ctx, &pb.SaveTweetRequest{Author: "carol"}, info, handler)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("should not touch the rpcs without limits", func(t *testing.T) {
+		interceptor := UnaryServerInterceptor(NewLimiter(NewMemoryStore(), rules), false)
+		stream := &fakeTransportStream{}
+
+		resp, err := interceptor(newContext(stream), &pb.ListTweetsRequest{}, &grpc.UnaryServerInfo{FullMethod: "/tweet.v1.TweetService/ListTweets"}, handler)
+		assert.NoError(t, err)
+		assert.Equal(t, "saved", resp)
+		assert.Empty(t, stream.header)
+	})
+}
+
+func Test_OutgoingHeaderMatcher(t *testing.T) {
+	testCases := []struct {
+		key      string
+		expected string
+	}{
+		{key: "retry-after", expected: "Retry-After"},
+		{key: "x-ratelimit-remaining", expected: "X-Ratelimit-Remaining"},
+		{key: "x-request-id", expected: "Grpc-Metadata-x-request-id"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			header, ok := OutgoingHeaderMatcher(tc.key)
+			assert.True(t, ok)
+			assert.Equal(t, tc.expected, header)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//sweepInterval is how often MemoryStore drops the buckets that are full again. A full bucket is the same as no bucket
+const sweepInterval = time.Minute
+
+//MemoryStore keeps the buckets in the process. With more than one replica every replica allows the full rate
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time //when the bucket is full again if nothing is taken
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}}
+}
+
+func (s *MemoryStore) Take(ctx context.Context, key string, rate Rate, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rate.Requests), updated: now}
+		s.buckets[key] = b
+	}
+	res, tokens := take(b.tokens, b.updated, now, rate)
+	if res.Allowed {
+		b.tokens, b.updated = tokens, now
+		missing := float64(rate.Requests) - tokens
+		b.full = now.Add(time.Duration(missing / rate.tokensPerSecond() * float64(time.Second)))
+	}
+	return res, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/metrics"
+)
+
+//Rate is how many requests a token bucket allows per period. The bucket holds Requests tokens, so a client that was quiet
+//can send that many at once
+type Rate struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (r Rate) IsZero() bool {
+	return r.Requests <= 0 || r.Per <= 0
+}
+
+func (r Rate) String() string {
+	return fmt.Sprintf("%d requests per %s", r.Requests, r.Per)
+}
+
+func (r Rate) tokensPerSecond() float64 {
+	return float64(r.Requests) / r.Per.Seconds()
+}
+
+//Rule holds the limits of one operation(the rpc name, eg SaveTweet). A zero Rate means no limit on that dimension
+type Rule struct {
+	User   Rate //per authenticated user
+	IP     Rate //per client ip
+	Method Rate //every caller of the operation together
+}
+
+//Result is what a Store answers for one bucket
+type Result struct {
+	Allowed   bool
+	Remaining int
+	//RetryAfter is how long until the next token when the request is not allowed
+	RetryAfter time.Duration
+}
+
+//Store keeps the token buckets. MemoryStore limits each replica on its own, DynamoDBStore shares the buckets across replicas
+type Store interface {
+	//Take removes a token from the bucket of key if there is one. A bucket seen for the first time is full
+	Take(ctx context.Context, key string, rate Rate, now time.Time) (Result, error)
+}
+
+//Violation describes the bucket that ran out
+type Violation struct {
+	Dimension  string //user, ip or method
+	Subject    string //eg user:alice or ip:10.0.0.1
+	Rate       Rate
+	RetryAfter time.Duration
+}
+
+//Decision is the answer of the Limiter for one request
+type Decision struct {
+	Allowed bool
+	//Limit and Remaining are for the bucket closest to running out, for the x-ratelimit-* headers. Limit is zero when
+	//the operation has no limit
+	Limit     Rate
+	Remaining int
+	Violation *Violation //set when the request is not allowed
+}
+
+type Limiter struct {
+	store Store
+	rules map[string]Rule
+	now   func() time.Time
+}
+
+func NewLimiter(store Store, rules map[string]Rule) *Limiter {
+	return &Limiter{store: store, rules: rules, now: time.Now}
+}
+
+//Limits is true when operation has at least one limit
+func (l *Limiter) Limits(operation string) bool {
+	rule, ok := l.rules[operation]
+	return ok && !(rule.User.IsZero() && rule.IP.IsZero() && rule.Method.IsZero())
+}
+
+//Allow takes a token from every bucket of the request: the user(when known), the ip(when known) and the operation.
+//A store that fails lets the request through; the limits protect the service, they are not worth an outage
+func (l *Limiter) Allow(ctx context.Context, operation, user, ip string) Decision {
+	rule := l.rules[operation]
+	checks := []struct {
+		dimension string
+		subject   string
+		rate      Rate
+	}{
+		{"user", user, rule.User},
+		{"ip", ip, rule.IP},
+		{"method", operation, rule.Method},
+	}
+
+	now := l.now()
+	decision := Decision{Allowed: true, Remaining: math.MaxInt}
+	for _, c := range checks {
+		if c.rate.IsZero() || c.subject == "" {
+			continue
+		}
+		subject := c.dimension + ":" + c.subject
+		res, err := l.store.Take(ctx, operation+"|"+subject, c.rate, now)
+		if err != nil {
+			slog.WarnContext(ctx, "rate limit store failed, letting the request through", "err", err, "operation", operation, "subject", subject)
+			continue
+		}
+		if !res.Allowed {
+			metrics.RateLimited.WithLabelValues(operation, c.dimension).Inc()
+			return Decision{
+				Limit:     c.rate,
+				Violation: &Violation{Dimension: c.dimension, Subject: subject, Rate: c.rate, RetryAfter: res.RetryAfter},
+			}
+		}
+		if res.Remaining < decision.Remaining {
+			decision.Limit, decision.Remaining = c.rate, res.Remaining
+		}
+	}
+	if decision.Limit.IsZero() {
+		decision.Remaining = 0
+	}
+	return decision
+}
+
+//take is the token bucket both stores share. tokens is what the bucket held at updated
+func take(tokens float64, updated, now time.Time, rate Rate) (Result, float64) {
+	capacity := float64(rate.Requests)
+	if elapsed := now.Sub(updated); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed.Seconds()*rate.tokensPerSecond())
+	}
+	if tokens < 1 {
+		wait := time.Duration((1 - tokens) / rate.tokensPerSecond() * float64(time.Second))
+		return Result{RetryAfter: wait}, tokens
+	}
+	tokens--
+	return Result{Allowed: true, Remaining: int(tokens)}, tokens
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func Test_MemoryStore(t *testing.T) {
+	rate := Rate{Requests: 3, Per: 3 * time.Second} //a token every second
+
+	testCases := []struct {
+		name string
+		//offsets of the requests from start
+		requests []time.Duration
+		expected []Result
+	}{
+		{
+			name:     "should allow a burst of the full rate",
+			requests: []time.Duration{0, 0, 0},
+			expected: []Result{{Allowed: true, Remaining: 2}, {Allowed: true, Remaining: 1}, {Allowed: true, Remaining: 0}},
+		},
+		{
+			name:     "should tell when the next token comes once the bucket is empty",
+			requests: []time.Duration{0, 0, 0, 250 * time.Millisecond},
+			expected: []Result{{Allowed: true, Remaining: 2}, {Allowed: true, Remaining: 1}, {Allowed: true, Remaining: 0}, {RetryAfter: 750 * time.Millisecond}},
+		},
+		{
+			name:     "should refill over time without going over the rate",
+			requests: []time.Duration{0, 0, 0, time.Second, time.Hour},
+			expected: []Result{{Allowed: true, Remaining: 2}, {Allowed: true, Remaining: 1}, {Allowed: true, Remaining: 0}, {Allowed: true, Remaining: 0}, {Allowed: true, Remaining: 2}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			for i, offset := range tc.requests {
+				res, err := store.Take(context.TODO(), "SaveTweet|user:alice", rate, start.Add(offset))
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected[i], res, "request %d", i)
+			}
+		})
+	}
+
+	t.Run("should drop the buckets that are full again", func(t *testing.T) {
+		store := NewMemoryStore()
+		store.Take(context.TODO(), "a", rate, start)
+		store.Take(context.TODO(), "b", rate, start.Add(59500*time.Millisecond))
+		store.Take(context.TODO(), "c", rate, start.Add(time.Minute))
+
+		assert.NotContains(t, store.buckets, "a")
+		assert.Contains(t, store.buckets, "b")
+		assert.Contains(t, store.buckets, "c")
+	})
+}
+
+//failingStore fails every Take
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, rate Rate, now time.Time) (Result, error) {
+	return Result{}, errors.New("store is down")
+}
+
+func Test_Limiter(t *testing.T) {
+	rules := map[string]Rule{
+		"SaveTweet":  {User: Rate{2, time.Minute}, IP: Rate{3, time.Minute}},
+		"ListTweets": {Method: Rate{1, time.Second}},
+	}
+
+	t.Run("should reject once the user runs out, even from another ip", func(t *testing.T) {
+		l := NewLimiter(NewMemoryStore(), rules)
+		l.now = func() time.Time { return start }
+
+		first := l.Allow(context.TODO(), "SaveTweet", "alice", "10.0.0.1")
+		assert.True(t, first.Allowed)
+		assert.Equal(t, Rate{2, time.Minute}, first.Limit)
+		assert.Equal(t, 1, first.Remaining)
+
+		assert.True(t, l.Allow(context.TODO(), "SaveTweet", "alice", "10.0.0.2").Allowed)
+		d := l.Allow(context.TODO(), "SaveTweet", "alice", "10.0.0.3")
+		assert.False(t, d.Allowed)
+		assert.Equal(t, &Violation{Dimension: "user", Subject: "user:alice", Rate: Rate{2, time.Minute}, RetryAfter: 30 * time.Second}, d.Violation)
+
+		assert.True(t, l.Allow(context.TODO(), "SaveTweet", "bob", "10.0.0.3").Allowed)
+	})
+
+	t.Run("should reject once the ip runs out, whatever the user", func(t *testing.T) {
+		l := NewLimiter(NewMemoryStore(), rules)
+		l.now = func() time.Time { return start }
+
+		for _, user := range []string{"alice", "bob", "carol"} {
+			assert.True(t, l.Allow(context.TODO(), "SaveTweet", user, "10.0.0.1").Allowed)
+		}
+		d := l.Allow(context.TODO(), "SaveTweet", "dave", "10.0.0.1")
+		assert.False(t, d.Allowed)
+		assert.Equal(t, "ip:10.0.0.1", d.Violation.Subject)
+	})
+
+	t.Run("should limit every caller of a method together", func(t *testing.T) {
+		l := NewLimiter(NewMemoryStore(), rules)
+		l.now = func() time.Time { return start }
+
+		assert.True(t, l.Allow(context.TODO(), "ListTweets", "", "").Allowed)
+		assert.Equal(t, "method:ListTweets", l.Allow(context.TODO(), "ListTweets", "", "").Violation.Subject)
+	})
+
+	t.Run("should not limit an operation without a rule", func(t *testing.T) {
+		l := NewLimiter(NewMemoryStore(), rules)
+
+		assert.False(t, l.Limits("SaveLikeToggle"))
+		assert.Equal(t, Decision{Allowed: true}, l.Allow(context.TODO(), "SaveLikeToggle", "alice", "10.0.0.1"))
+	})
+
+	t.Run("should let the request through when the store fails", func(t *testing.T) {
+		l := NewLimiter(failingStore{}, rules)
+
+		assert.True(t, l.Allow(context.TODO(), "SaveTweet", "alice", "10.0.0.1").Allowed)
+	})
+}
@@ -0,0 +1,226 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+)
+
+//consumedCapacityUnitsKey is the sum of the capacity units of the call. The semconv attribute(aws.dynamodb.consumed_capacity)
+//is JSON, which is hard to query on
+const consumedCapacityUnitsKey = attribute.Key("aws.dynamodb.consumed_capacity_units")
+
+//InstrumentDynamoDB wraps client so every call is a client span named after the operation(eg DynamoDB.PutItem) with the
+//tables it touched. The consumed capacity is only recorded when the call returns it; wrap a client that asks for it, eg
+//metrics.InstrumentDynamoDB
+func InstrumentDynamoDB(client common.DynamoDBAPI) common.DynamoDBAPI {
+	return &tracedDynamoDB{next: client, tracer: otel.Tracer(instrumentationName)}
+}
+
+type tracedDynamoDB struct {
+	next   common.DynamoDBAPI
+	tracer trace.Tracer
+}
+
+func (d *tracedDynamoDB) start(ctx context.Context, operation string, tables ...string) (context.Context, trace.Span) {
+	return d.tracer.Start(ctx, "DynamoDB."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemDynamoDB,
+			semconv.DBOperationKey.String(operation),
+			semconv.AWSDynamoDBTableNamesKey.StringSlice(tables),
+		),
+	)
+}
+
+//end records the error and the consumed capacity then ends the span
+func end(span trace.Span, err error, consumed ...types.ConsumedCapacity) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	if len(consumed) > 0 {
+		var units float64
+		values := make([]string, 0, len(consumed))
+		for _, c := range consumed {
+			units += aws.ToFloat64(c.CapacityUnits)
+			if b, err := json.Marshal(c); err == nil {
+				values = append(values, string(b))
+			}
+		}
+		span.SetAttributes(
+			consumedCapacityUnitsKey.Float64(units),
+			semconv.AWSDynamoDBConsumedCapacityKey.StringSlice(values),
+		)
+	}
+	span.End()
+}
+
+//consumedCapacity turns the optional capacity of the single item operations into the slice end expects
+func consumedCapacity(c *types.ConsumedCapacity) []types.ConsumedCapacity {
+	if c == nil {
+		return nil
+	}
+	return []types.ConsumedCapacity{*c}
+}
+
+func tableNames[T any](items map[string]T) []string {
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (d *tracedDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	ctx, span := d.start(ctx, "PutItem", aws.ToString(params.TableName))
+	out, err := d.next.PutItem(ctx, params, optFns...)
+	if out != nil {
+		end(span, err, consumedCapacity(out.ConsumedCapacity)...)
+	} else {
+		end(span, err)
+	}
+	return out, err
+}
+
+func (d *tracedDynamoDB) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	ctx, span := d.start(ctx, "Scan", aws.ToString(params.TableName))
+	if params.Segment != nil {
+		span.SetAttributes(
+			semconv.AWSDynamoDBSegmentKey.Int(int(*params.Segment)),
+			semconv.AWSDynamoDBTotalSegmentsKey.Int(int(aws.ToInt32(params.TotalSegments))),
+		)
+	}
+	out, err := d.next.Scan(ctx, params, optFns...)
+	if out != nil {
+		span.SetAttributes(semconv.AWSDynamoDBCountKey.Int(int(out.Count)))
+		end(span, err, consumedCapacity(out.ConsumedCapacity)...)
+	} else {
+		end(span, err)
+	}
+	return out, err
+}
+
+func (d *tracedDynamoDB) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	ctx, span := d.start(ctx, "UpdateItem", aws.ToString(params.TableName))
+	out, err := d.next.UpdateItem(ctx, params, optFns...)
+	if out != nil {
+		end(span, err, consumedCapacity(out.ConsumedCapacity)...)
+	} else {
+		end(span, err)
+	}
+	return out, err
+}
+
+func (d *tracedDynamoDB) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	ctx, span := d.start(ctx, "DeleteItem", aws.ToString(params.TableName))
+	out, err := d.next.DeleteItem(ctx, params, optFns...)
+	if out != nil {
+		end(span, err, consumedCapacity(out.ConsumedCapacity)...)
+	} else {
+		end(span, err)
+	}
+	return out, err
+}
+
+func (d *tracedDynamoDB) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	ctx, span := d.start(ctx, "GetItem", aws.ToString(params.TableName))
+	out, err := d.next.GetItem(ctx, params, optFns...)
+	if out != nil {
+		end(span, err, consumedCapacity(out.ConsumedCapacity)...)
+	} else {
+		end(span, err)
+	}
+	return out, err
+}
+
+func (d *tracedDynamoDB) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	ctx, span := d.start(ctx, "Query", aws.ToString(params.TableName))
+	if params.IndexName != nil {
+		span.SetAttributes(semconv.AWSDynamoDBIndexNameKey.String(*params.IndexName))
+	}
+	out, err := d.next.Query(ctx, params, optFns...)
+	if out != nil {
+		span.SetAttributes(semconv.AWSDynamoDBCountKey.Int(int(out.Count)))
+		end(span, err, consumedCapacity(out.ConsumedCapacity)...)
+	} else {
+		end(span, err)
+	}
+	return out, err
+}
+
+func (d *tracedDynamoDB) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	tables := map[string]bool{}
+	for _, item := range params.TransactItems {
+		switch {
+		case item.Put != nil:
+			tables[aws.ToString(item.Put.TableName)] = true
+		case item.Update != nil:
+			tables[aws.ToString(item.Update.TableName)] = true
+		case item.Delete != nil:
+			tables[aws.ToString(item.Delete.TableName)] = true
+		case item.ConditionCheck != nil:
+			tables[aws.ToString(item.ConditionCheck.TableName)] = true
+		}
+	}
+	ctx, span := d.start(ctx, "TransactWriteItems", tableNames(tables)...)
+	out, err := d.next.TransactWriteItems(ctx, params, optFns...)
+	if out != nil {
+		end(span, err, out.ConsumedCapacity...)
+	} else {
+		end(span, err)
+	}
+	return out, err
+}
+
+func (d *tracedDynamoDB) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	ctx, span := d.start(ctx, "BatchGetItem", tableNames(params.RequestItems)...)
+	out, err := d.next.BatchGetItem(ctx, params, optFns...)
+	if out != nil {
+		end(span, err, out.ConsumedCapacity...)
+	} else {
+		end(span, err)
+	}
+	return out, err
+}
+
+func (d *tracedDynamoDB) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	ctx, span := d.start(ctx, "BatchWriteItem", tableNames(params.RequestItems)...)
+	out, err := d.next.BatchWriteItem(ctx, params, optFns...)
+	if out != nil {
+		end(span, err, out.ConsumedCapacity...)
+	} else {
+		end(span, err)
+	}
+	return out, err
+}
+
+func (d *tracedDynamoDB) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	ctx, span := d.start(ctx, "CreateTable", aws.ToString(params.TableName))
+	out, err := d.next.CreateTable(ctx, params, optFns...)
+	end(span, err)
+	return out, err
+}
+
+func (d *tracedDynamoDB) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	//the health probes describe the tables every few seconds outside of any request. They would be a trace each
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return d.next.DescribeTable(ctx, params, optFns...)
+	}
+	ctx, span := d.start(ctx, "DescribeTable", aws.ToString(params.TableName))
+	out, err := d.next.DescribeTable(ctx, params, optFns...)
+	end(span, err)
+	return out, err
+}
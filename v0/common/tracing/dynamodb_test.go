@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+)
+
+//fakeDynamoDB embeds the interface so only the methods a test calls need to exist
+type fakeDynamoDB struct {
+	common.DynamoDBAPI
+	err error
+	//parent is the span the wrapped client was called with
+	parent trace.SpanContext
+}
+
+func (f *fakeDynamoDB) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	f.parent = trace.SpanContextFromContext(ctx)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dynamodb.PutItemOutput{
+		ConsumedCapacity: &types.ConsumedCapacity{TableName: params.TableName, CapacityUnits: aws.Float64(1)},
+	}, nil
+}
+
+func (f *fakeDynamoDB) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return &dynamodb.TransactWriteItemsOutput{
+		ConsumedCapacity: []types.ConsumedCapacity{
+			{TableName: aws.String("tweets"), CapacityUnits: aws.Float64(2)},
+			{TableName: aws.String("users"), CapacityUnits: aws.Float64(2)},
+		},
+	}, nil
+}
+
+func (f *fakeDynamoDB) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{}, nil
+}
+
+func Test_InstrumentDynamoDB(t *testing.T) {
+	t.Run("should record the operation, table and consumed capacity", func(t *testing.T) {
+		recorder := useRecorder(t)
+		fake := &fakeDynamoDB{}
+		client := InstrumentDynamoDB(fake)
+		ctx, parent := otel.Tracer("test").Start(context.TODO(), "TweetsService.SaveTweet")
+
+		_, err := client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String("tweets")})
+		parent.End()
+
+		assert.NoError(t, err)
+		spans := recorder.Ended()
+		if assert.Len(t, spans, 2) {
+			span := spans[0]
+			assert.Equal(t, "DynamoDB.PutItem", span.Name())
+			assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+			assert.Equal(t, span.SpanContext().SpanID(), fake.parent.SpanID())
+			assert.Contains(t, span.Attributes(), attribute.String("db.system", "dynamodb"))
+			assert.Contains(t, span.Attributes(), attribute.String("db.operation", "PutItem"))
+			assert.Contains(t, span.Attributes(), attribute.StringSlice("aws.dynamodb.table_names", []string{"tweets"}))
+			assert.Contains(t, span.Attributes(), attribute.Float64("aws.dynamodb.consumed_capacity_units", 1))
+		}
+	})
+
+	t.Run("should sum the capacity of every table of a transaction", func(t *testing.T) {
+		recorder := useRecorder(t)
+		client := InstrumentDynamoDB(&fakeDynamoDB{})
+
+		_, err := client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{
+			TransactItems: []types.TransactWriteItem{
+				{Put: &types.Put{TableName: aws.String("users")}},
+				{Update: &types.Update{TableName: aws.String("tweets")}},
+				{Update: &types.Update{TableName: aws.String("tweets")}},
+			},
+		})
+
+		assert.NoError(t, err)
+		span := recorder.Ended()[0]
+		assert.Contains(t, span.Attributes(), attribute.StringSlice("aws.dynamodb.table_names", []string{"tweets", "users"}))
+		assert.Contains(t, span.Attributes(), attribute.Float64("aws.dynamodb.consumed_capacity_units", 4))
+	})
+
+	t.Run("should record the error", func(t *testing.T) {
+		recorder := useRecorder(t)
+		client := InstrumentDynamoDB(&fakeDynamoDB{err: errors.New("ConditionalCheckFailedException")})
+
+		_, err := client.PutItem(context.TODO(), &dynamodb.PutItemInput{TableName: aws.String("tweets")})
+
+		assert.Error(t, err)
+		span := recorder.Ended()[0]
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Equal(t, "ConditionalCheckFailedException", span.Status().Description)
+		assert.Len(t, span.Events(), 1)
+	})
+
+	t.Run("should not trace the health probes", func(t *testing.T) {
+		recorder := useRecorder(t)
+		client := InstrumentDynamoDB(&fakeDynamoDB{})
+
+		_, err := client.DescribeTable(context.TODO(), &dynamodb.DescribeTableInput{TableName: aws.String("tweets")})
+
+		assert.NoError(t, err)
+		assert.Empty(t, recorder.Ended())
+	})
+}
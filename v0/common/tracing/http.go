@@ -0,0 +1,60 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+//paths probed every few seconds by k8s and prometheus. A span each would drown the real traffic
+var untracedPaths = map[string]bool{
+	"/livez":   true,
+	"/readyz":  true,
+	"/healthz": true,
+	"/metrics": true,
+}
+
+//InstrumentHandler starts a server span for every request served by next, continuing the trace of the caller when the
+//request has a traceparent header. Spans are named by the mux pattern that matched; GatewayRoute names the gateway ones
+func InstrumentHandler(next http.Handler, mux *http.ServeMux) http.Handler {
+	return otelhttp.NewHandler(next, "",
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			return !untracedPaths[r.URL.Path]
+		}),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				return r.Method + " " + pattern
+			}
+			return r.Method
+		}),
+	)
+}
+
+//GatewayRoute is a runtime.WithMetadata annotator. It renames the span of the request after the path pattern of the
+//rpc(eg GET /v1/tweets/{id}) and records which rpc of TweetServer the gateway calls
+func GatewayRoute(ctx context.Context, r *http.Request) metadata.MD {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return nil
+	}
+	if pattern, ok := runtime.HTTPPathPattern(ctx); ok {
+		span.SetName(r.Method + " " + pattern)
+		span.SetAttributes(semconv.HTTPRouteKey.String(pattern))
+	}
+	//eg /tweet.v1.TweetService/GetTweet
+	if method, ok := runtime.RPCMethod(ctx); ok {
+		if i := strings.LastIndex(method, "/"); i > 0 {
+			span.SetAttributes(
+				semconv.RPCServiceKey.String(strings.TrimPrefix(method[:i], "/")),
+				semconv.RPCMethodKey.String(method[i+1:]),
+			)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Test_InstrumentHandler(t *testing.T) {
+	recorder := useRecorder(t)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var rpcSpan trace.SpanContext
+	gateway := runtime.NewServeMux(runtime.WithMetadata(GatewayRoute))
+	err := gateway.HandlePath(http.MethodGet, "/v1/tracing-test/{id}", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		//the same call the generated handlers of RegisterTweetServiceHandlerServer make before the rpc runs
+		ctx, err := runtime.AnnotateIncomingContext(r.Context(), gateway, r, "/tweet.v1.TweetService/GetTweet", runtime.WithHTTPPathPattern("/v1/tracing-test/{id}"))
+		assert.NoError(t, err)
+		rpcSpan = trace.SpanContextFromContext(ctx)
+	})
+	assert.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", gateway)
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/migrate-tweet", func(w http.ResponseWriter, r *http.Request) {})
+	handler := InstrumentHandler(mux, mux)
+
+	t.Run("should continue the trace of the caller and name the span after the rpc route", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/v1/tracing-test/1234", nil)
+		r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := recorder.Ended()
+		if assert.Len(t, spans, 1) {
+			span := spans[0]
+			assert.Equal(t, "GET /v1/tracing-test/{id}", span.Name())
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+			assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+			assert.True(t, span.Parent().IsRemote())
+			assert.Contains(t, span.Attributes(), attribute.String("http.route", "/v1/tracing-test/{id}"))
+			assert.Contains(t, span.Attributes(), attribute.String("rpc.service", "tweet.v1.TweetService"))
+			assert.Contains(t, span.Attributes(), attribute.String("rpc.method", "GetTweet"))
+			//the rpc runs inside the span, so the service and DynamoDB spans become its children
+			assert.Equal(t, span.SpanContext().SpanID(), rpcSpan.SpanID())
+		}
+	})
+
+	t.Run("should name the other routes after the mux pattern", func(t *testing.T) {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/migrate-tweet", nil))
+
+		spans := recorder.Ended()
+		assert.Equal(t, "POST /migrate-tweet", spans[len(spans)-1].Name())
+	})
+
+	t.Run("should not trace the probes", func(t *testing.T) {
+		before := len(recorder.Ended())
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+		assert.Len(t, recorder.Ended(), before)
+	})
+}
+
+func Test_GatewayRoute(t *testing.T) {
+	//without a recording span there is nothing to name
+	assert.Nil(t, GatewayRoute(context.TODO(), httptest.NewRequest(http.MethodGet, "/", nil)))
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+//useRecorder makes a recorder the global tracer provider for the rest of the test
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func Test_Setup(t *testing.T) {
+	t.Run("should write the spans to the file as json lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "traces.json")
+		previous := otel.GetTracerProvider()
+		t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+		shutdown, err := Setup(context.TODO(), Options{ServiceName: "chirper-test", Exporter: "file", File: path, SampleRatio: 1})
+		assert.NoError(t, err)
+
+		_, span := otel.Tracer("test").Start(context.TODO(), "first")
+		span.End()
+		_, span = otel.Tracer("test").Start(context.TODO(), "second")
+		span.End()
+		assert.NoError(t, shutdown(context.TODO()))
+
+		f, err := os.Open(path)
+		assert.NoError(t, err)
+		defer f.Close()
+		var names []string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var s struct {
+				Name     string
+				Resource []struct {
+					Key   string
+					Value struct{ Value interface{} }
+				}
+			}
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &s))
+			names = append(names, s.Name)
+			assert.Contains(t, s.Resource, struct {
+				Key   string
+				Value struct{ Value interface{} }
+			}{Key: "service.name", Value: struct{ Value interface{} }{Value: "chirper-test"}})
+		}
+		assert.Equal(t, []string{"first", "second"}, names)
+	})
+
+	t.Run("should propagate the w3c trace context even without an exporter", func(t *testing.T) {
+		shutdown, err := Setup(context.TODO(), Options{Exporter: "none"})
+		assert.NoError(t, err)
+		assert.NoError(t, shutdown(context.TODO()))
+		assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, otel.GetTextMapPropagator().Fields())
+	})
+
+	t.Run("should reject an unknown exporter", func(t *testing.T) {
+		_, err := Setup(context.TODO(), Options{Exporter: "jaeger"})
+		assert.EqualError(t, err, `unknown trace exporter "jaeger"`)
+	})
+}
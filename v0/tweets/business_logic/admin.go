@@ -0,0 +1,130 @@
+package tweetsservice
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	repo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//ReindexUserTweets rebuilds the tweets set of every user in the users table from the tweets table.
+//Ids are only ever added, so it is safe to run while the service takes writes
+func (s *ServiceImpl) ReindexUserTweets(ctx context.Context, segments int32) (*model.ReindexReport, error) {
+	report := &model.ReindexReport{}
+
+	byAuthor := make(map[string][]string)
+	err := s.ExportTweets(ctx, model.TweetFilter{}, segments, func(tweet *model.Tweet) error {
+		report.Tweets++
+		byAuthor[tweet.Author] = append(byAuthor[tweet.Author], tweet.Id)
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	authors := make([]string, 0, len(byAuthor))
+	for author := range byAuthor {
+		authors = append(authors, author)
+	}
+	sort.Strings(authors)
+
+	for _, author := range authors {
+		ids := byAuthor[author]
+		sort.Strings(ids)
+
+		err := s.repo.AddTweetsToUserInDynamoDb(ctx, author, ids)
+		if errors.Is(err, repo.ErrUserNotFound) {
+			report.MissingUsers = append(report.MissingUsers, author)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Users++
+	}
+	return report, nil
+}
+
+//RepairReplies makes the replies set of every tweet match the tweets that reply to it. The sets drift when a transaction
+//was only partly replayed by an import or a parent was replaced. The whole table is held in memory while we work out the fixes
+func (s *ServiceImpl) RepairReplies(ctx context.Context, segments int32, dryRun bool) (*model.RepairReport, error) {
+	report := &model.RepairReport{DryRun: dryRun}
+
+	tweets := make(map[string]*model.Tweet)
+	children := make(map[string][]string)
+	err := s.ExportTweets(ctx, model.TweetFilter{}, segments, func(tweet *model.Tweet) error {
+		report.Tweets++
+		tweets[tweet.Id] = tweet
+		if tweet.ReplyingTo != "" {
+			children[tweet.ReplyingTo] = append(children[tweet.ReplyingTo], tweet.Id)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	ids := make([]string, 0, len(tweets))
+	for id := range tweets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		tweet := tweets[id]
+		fix := replyFix(tweet, children[id])
+		if len(fix.Missing) == 0 && len(fix.Dangling) == 0 {
+			continue
+		}
+		report.Fixes = append(report.Fixes, fix)
+		if dryRun {
+			continue
+		}
+
+		err := s.repo.RepairRepliesInDynamoDb(ctx, tweet.Id, tweet.Author, fix.Missing, fix.Dangling)
+		if errors.Is(err, repo.ErrTweetNotFound) {
+			continue //deleted since the scan. Nothing left to repair
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Repaired++
+	}
+	return report, nil
+}
+
+func replyFix(tweet *model.Tweet, replies []string) model.ReplyFix {
+	fix := model.ReplyFix{Id: tweet.Id, Author: tweet.Author}
+
+	expected := make(map[string]bool, len(replies))
+	for _, r := range replies {
+		expected[r] = true
+	}
+	actual := make(map[string]bool, len(tweet.Replies))
+	for _, r := range tweet.Replies {
+		actual[r] = true
+		if !expected[r] {
+			fix.Dangling = append(fix.Dangling, r)
+		}
+	}
+	for _, r := range replies {
+		if !actual[r] {
+			fix.Missing = append(fix.Missing, r)
+		}
+	}
+	sort.Strings(fix.Missing)
+	sort.Strings(fix.Dangling)
+	return fix
+}
+
+//BulkSaveUsers writes users to the users table. The users service owns that table, so this is only for seeding demo and load test environments
+func (s *ServiceImpl) BulkSaveUsers(ctx context.Context, users []*model.User) error {
+	for _, user := range users {
+		if user.Id == "" {
+			return errors.New("user id is required")
+		}
+	}
+	return s.repo.BulkSaveUsersToDynamoDb(ctx, users)
+}
@@ -0,0 +1,125 @@
+package tweetsservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func scanOf(tweets ...*model.Tweet) <-chan model.ScanPage {
+	ch := make(chan model.ScanPage, 1)
+	ch <- model.ScanPage{Tweets: tweets}
+	close(ch)
+	return ch
+}
+
+func Test_ReindexUserTweets(t *testing.T) {
+	testCases := []struct {
+		name       string
+		buildStubs func(repoMock *tweetsrepo.MockRepository)
+
+		expectedReport *model.ReindexReport
+		expectedError  error
+	}{
+		{
+			name: "should add the tweets of every author to the users table",
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().ParallelScanTweetsFromDynamoDb(gomock.Any(), gomock.Any()).Times(1).Return(scanOf(
+					&model.Tweet{Id: "b", Author: "sarah_edo"},
+					&model.Tweet{Id: "a", Author: "sarah_edo"},
+					&model.Tweet{Id: "c", Author: "tylermcginnis"},
+					&model.Tweet{Id: "d", Author: "deleted_user"},
+				), nil)
+				repoMock.EXPECT().AddTweetsToUserInDynamoDb(gomock.Any(), "sarah_edo", []string{"a", "b"}).Times(1).Return(nil)
+				repoMock.EXPECT().AddTweetsToUserInDynamoDb(gomock.Any(), "tylermcginnis", []string{"c"}).Times(1).Return(nil)
+				repoMock.EXPECT().AddTweetsToUserInDynamoDb(gomock.Any(), "deleted_user", []string{"d"}).Times(1).Return(tweetsrepo.ErrUserNotFound)
+			},
+			expectedReport: &model.ReindexReport{Tweets: 4, Users: 2, MissingUsers: []string{"deleted_user"}},
+		},
+		{
+			name: "should return the repo error",
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().ParallelScanTweetsFromDynamoDb(gomock.Any(), gomock.Any()).Times(1).Return(scanOf(
+					&model.Tweet{Id: "a", Author: "sarah_edo"},
+				), nil)
+				repoMock.EXPECT().AddTweetsToUserInDynamoDb(gomock.Any(), "sarah_edo", []string{"a"}).Times(1).Return(errors.New("ProvisionedThroughputExceededException"))
+			},
+			expectedReport: &model.ReindexReport{Tweets: 1},
+			expectedError:  errors.New("ProvisionedThroughputExceededException"),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repoMock := tweetsrepo.NewMockRepository(ctrl)
+			tc.buildStubs(repoMock)
+
+			report, err := New(repoMock).ReindexUserTweets(context.Background(), 2)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedReport, report)
+		})
+	}
+}
+
+func Test_RepairReplies(t *testing.T) {
+	tweets := func() <-chan model.ScanPage {
+		return scanOf(
+			&model.Tweet{Id: "root", Author: "sarah_edo", Replies: []string{"reply1", "gone"}},
+			&model.Tweet{Id: "reply1", Author: "tylermcginnis", ReplyingTo: "root"},
+			&model.Tweet{Id: "reply2", Author: "dan_abramov", ReplyingTo: "root"},
+			&model.Tweet{Id: "fine", Author: "dan_abramov"},
+		)
+	}
+	fixes := []model.ReplyFix{{Id: "root", Author: "sarah_edo", Missing: []string{"reply2"}, Dangling: []string{"gone"}}}
+
+	testCases := []struct {
+		name       string
+		dryRun     bool
+		buildStubs func(repoMock *tweetsrepo.MockRepository)
+
+		expectedReport *model.RepairReport
+	}{
+		{
+			name: "should fix the replies that don't match",
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().ParallelScanTweetsFromDynamoDb(gomock.Any(), gomock.Any()).Times(1).Return(tweets(), nil)
+				repoMock.EXPECT().RepairRepliesInDynamoDb(gomock.Any(), "root", "sarah_edo", []string{"reply2"}, []string{"gone"}).Times(1).Return(nil)
+			},
+			expectedReport: &model.RepairReport{Tweets: 4, Repaired: 1, Fixes: fixes},
+		},
+		{
+			name:   "should not write anything on a dry run",
+			dryRun: true,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().ParallelScanTweetsFromDynamoDb(gomock.Any(), gomock.Any()).Times(1).Return(tweets(), nil)
+				repoMock.EXPECT().RepairRepliesInDynamoDb(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedReport: &model.RepairReport{DryRun: true, Tweets: 4, Fixes: fixes},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repoMock := tweetsrepo.NewMockRepository(ctrl)
+			tc.buildStubs(repoMock)
+
+			report, err := New(repoMock).RepairReplies(context.Background(), 2, tc.dryRun)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedReport, report)
+		})
+	}
+}
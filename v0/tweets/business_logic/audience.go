@@ -0,0 +1,109 @@
+package tweetsservice
+
+import (
+	"context"
+
+	repo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetstext "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/text"
+)
+
+//audienceCheck decides which tweets a viewer can see. Every read path(ListTweets, GetTweet, replying) goes through canSee,
+//so the audience rules live in this one place
+type audienceCheck struct {
+	viewer string
+	//follows tells whether the viewer follows a user. Lists answer from the relations they already read, single reads with a GetItem
+	follows func(ctx context.Context, owner string) (bool, error)
+}
+
+//audienceFor checks one tweet at a time, asking DynamoDB about follows when a tweet needs it
+func (s *ServiceImpl) audienceFor(viewer string) audienceCheck {
+	return audienceCheck{viewer: viewer, follows: func(ctx context.Context, owner string) (bool, error) {
+		return s.repo.HasRelationInDynamoDb(ctx, viewer, model.Follow, owner)
+	}}
+}
+
+//canSee is true when the viewer is in the audience of the tweet. Anonymous viewers(viewer "") only see public tweets.
+//A tweet held for review or removed by moderation is only seen by its author
+func (c audienceCheck) canSee(ctx context.Context, tweet *model.Tweet) (bool, error) {
+	if !tweet.Visible() {
+		return c.viewer != "" && c.viewer == tweet.Author, nil
+	}
+	if !tweet.Restricted() {
+		return true, nil
+	}
+	if c.viewer == "" {
+		return false, nil
+	}
+	owner := tweet.AudienceOwner()
+	if c.viewer == owner || c.viewer == tweet.Author {
+		return true, nil
+	}
+
+	switch tweet.Audience.Level {
+	case model.AudienceFollowers:
+		return c.follows(ctx, owner)
+	case model.AudienceMentioned:
+		for _, handle := range tweet.Audience.Mentioned {
+			if handle == c.viewer {
+				return true, nil
+			}
+		}
+	}
+	//unknown levels fail closed
+	return false, nil
+}
+
+//setAudience fills the audience of a tweet about to be saved. A reply takes the audience of its parent when the parent is
+//restricted(the parent got it from the root, so it is the audience of the conversation), otherwise it keeps its own.
+//It returns repo.ErrTweetNotFound when the author can't see the tweet they reply to
+func (s *ServiceImpl) setAudience(ctx context.Context, tweet *model.Tweet) error {
+	if tweet.ReplyingTo != "" {
+		parent, err := s.repo.GetTweetFromDynamoDb(ctx, tweet.ReplyingTo)
+		if err != nil {
+			return err
+		}
+		visible, err := s.audienceFor(tweet.Author).canSee(ctx, parent)
+		if err != nil {
+			return err
+		}
+		if !visible {
+			return repo.ErrTweetNotFound
+		}
+		if parent.Restricted() {
+			inherited := *parent.Audience
+			inherited.Owner = parent.AudienceOwner()
+			tweet.Audience = &inherited
+			return nil
+		}
+	}
+
+	ownAudience(tweet)
+	return nil
+}
+
+//ownAudience sets the audience a tweet picks for itself: the author owns it and a mentioned only tweet is for the users
+//its text mentions
+func ownAudience(tweet *model.Tweet) {
+	if tweet.Audience == nil {
+		return
+	}
+	switch tweet.Audience.Level {
+	case model.AudienceFollowers:
+		tweet.Audience = &model.Audience{Level: model.AudienceFollowers, Owner: tweet.Author}
+	case model.AudienceMentioned:
+		tweet.Audience = &model.Audience{Level: model.AudienceMentioned, Owner: tweet.Author, Mentioned: tweetstext.Mentions(tweet.Text)}
+	default:
+		//public tweets are saved without an audience, like the tweets from before audiences existed
+		tweet.Audience = nil
+	}
+}
+
+//Follow lets userID see the followers only tweets of target
+func (s *ServiceImpl) Follow(ctx context.Context, userID, target string) error {
+	return s.saveRelation(ctx, userID, model.Follow, target)
+}
+
+func (s *ServiceImpl) Unfollow(ctx context.Context, userID, target string) error {
+	return s.deleteRelation(ctx, userID, model.Follow, target)
+}
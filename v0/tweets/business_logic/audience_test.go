@@ -0,0 +1,157 @@
+package tweetsservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func Test_CanSee(t *testing.T) {
+	followersOnly := &model.Tweet{Id: "1", Author: "sarah_edo", Audience: &model.Audience{Level: model.AudienceFollowers}}
+	mentionedOnly := &model.Tweet{Id: "2", Author: "sarah_edo", Audience: &model.Audience{Level: model.AudienceMentioned, Mentioned: []string{"dan_abramov"}}}
+	//a reply of tylermcginnis in the conversation of followersOnly
+	inheritedReply := &model.Tweet{Id: "3", Author: "tylermcginnis", Audience: &model.Audience{Level: model.AudienceFollowers, Owner: "sarah_edo"}}
+
+	testCases := []struct {
+		name     string
+		viewer   string
+		tweet    *model.Tweet
+		expected bool
+	}{
+		{name: "everybody sees a public tweet", tweet: &model.Tweet{Author: "sarah_edo"}, expected: true},
+		{name: "a public audience is public", tweet: &model.Tweet{Author: "sarah_edo", Audience: &model.Audience{Level: model.AudiencePublic}}, expected: true},
+		{name: "anonymous viewers only see public tweets", tweet: followersOnly},
+		{name: "the author sees their tweet", viewer: "sarah_edo", tweet: followersOnly, expected: true},
+		{name: "a follower sees a followers only tweet", viewer: "tylermcginnis", tweet: followersOnly, expected: true},
+		{name: "others don't see a followers only tweet", viewer: "dan_abramov", tweet: followersOnly},
+		{name: "a mentioned user sees a mentioned only tweet", viewer: "dan_abramov", tweet: mentionedOnly, expected: true},
+		{name: "others don't see a mentioned only tweet", viewer: "tylermcginnis", tweet: mentionedOnly},
+		{name: "a reply follows the audience of the root", viewer: "dan_abramov", tweet: inheritedReply},
+		{name: "the owner of the conversation sees the replies", viewer: "sarah_edo", tweet: inheritedReply, expected: true},
+		{name: "unknown levels are not visible", viewer: "tylermcginnis", tweet: &model.Tweet{Author: "sarah_edo", Audience: &model.Audience{Level: "friends"}}},
+		{name: "others don't see a tweet held for review", viewer: "tylermcginnis", tweet: &model.Tweet{Author: "sarah_edo", ModerationState: model.ModerationPending}},
+		{name: "anonymous viewers don't see a removed tweet", tweet: &model.Tweet{Author: "sarah_edo", ModerationState: model.ModerationRemoved}},
+		{name: "the author sees their tweet held for review", viewer: "sarah_edo", tweet: &model.Tweet{Author: "sarah_edo", ModerationState: model.ModerationPending}, expected: true},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			check := audienceCheck{viewer: tc.viewer, follows: func(ctx context.Context, owner string) (bool, error) {
+				//tylermcginnis follows sarah_edo
+				return tc.viewer == "tylermcginnis" && owner == "sarah_edo", nil
+			}}
+			visible, err := check.canSee(context.Background(), tc.tweet)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, visible)
+		})
+	}
+}
+
+func Test_SaveTweet_Audience(t *testing.T) {
+	restrictedRoot := &model.Tweet{Id: "root", Author: "sarah_edo", Audience: &model.Audience{Level: model.AudienceMentioned, Owner: "sarah_edo", Mentioned: []string{"tylermcginnis"}}}
+
+	testCases := []struct {
+		name  string
+		tweet *model.Tweet
+
+		expectedAudience *model.Audience
+		expectedError    error
+	}{
+		{
+			name:  "should save a public tweet without an audience",
+			tweet: &model.Tweet{Author: "tylermcginnis", Text: "hello", Audience: &model.Audience{Level: "PUBLIC"}},
+		},
+		{
+			name:             "should save the mentions of a mentioned only tweet",
+			tweet:            &model.Tweet{Author: "tylermcginnis", Text: "@sarah_edo @dan_abramov lunch?", Audience: &model.Audience{Level: model.AudienceMentioned, Mentioned: []string{"someone_else"}}},
+			expectedAudience: &model.Audience{Level: model.AudienceMentioned, Owner: "tylermcginnis", Mentioned: []string{"sarah_edo", "dan_abramov"}},
+		},
+		{
+			name:             "should make a reply inherit the audience of the conversation",
+			tweet:            &model.Tweet{Author: "tylermcginnis", Text: "sure", ReplyingTo: "root:sarah_edo"},
+			expectedAudience: &model.Audience{Level: model.AudienceMentioned, Owner: "sarah_edo", Mentioned: []string{"tylermcginnis"}},
+		},
+		{
+			name:          "should not reply to a tweet the author can't see",
+			tweet:         &model.Tweet{Author: "dan_abramov", Text: "me too", ReplyingTo: "root:sarah_edo"},
+			expectedError: tweetsrepo.ErrTweetNotFound,
+		},
+		{
+			name:          "should reject an unknown audience",
+			tweet:         &model.Tweet{Author: "tylermcginnis", Text: "hello", Audience: &model.Audience{Level: "friends"}},
+			expectedError: &ValidationError{Violations: []FieldViolation{{"audience", `invalid audience "friends". It should be public, followers or mentioned`}}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			repoMock.EXPECT().HasRelationInDynamoDb(gomock.Any(), gomock.Any(), model.Block, gomock.Any()).AnyTimes().Return(false, nil)
+			repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), "root").AnyTimes().Return(restrictedRoot, nil)
+			var saved *model.Tweet
+			repoMock.EXPECT().SaveTweetToDynamoDb(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, replyingToAuthor string, tweet *model.Tweet) (*model.Tweet, error) {
+				saved = tweet
+				return tweet, nil
+			})
+
+			_, err := New(repoMock).SaveTweet(context.Background(), tc.tweet)
+			assert.Equal(t, tc.expectedError, err)
+			if tc.expectedError == nil {
+				assert.Equal(t, tc.expectedAudience, saved.Audience)
+			}
+		})
+	}
+}
+
+func Test_GetTweet_Audience(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), "1").AnyTimes().Return(&model.Tweet{Id: "1", Author: "sarah_edo", Audience: &model.Audience{Level: model.AudienceFollowers}}, nil)
+	repoMock.EXPECT().HasRelationInDynamoDb(gomock.Any(), "tylermcginnis", model.Follow, "sarah_edo").Times(1).Return(true, nil)
+	repoMock.EXPECT().HasRelationInDynamoDb(gomock.Any(), "dan_abramov", model.Follow, "sarah_edo").Times(1).Return(false, nil)
+	service := New(repoMock)
+
+	tweet, err := service.GetTweet(context.Background(), "tylermcginnis", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", tweet.Id)
+
+	_, err = service.GetTweet(context.Background(), "dan_abramov", "1")
+	assert.ErrorIs(t, err, tweetsrepo.ErrTweetNotFound)
+	_, err = service.GetTweet(context.Background(), "", "1")
+	assert.ErrorIs(t, err, tweetsrepo.ErrTweetNotFound, "anonymous viewers don't ask DynamoDB")
+
+	repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), "pending").AnyTimes().Return(&model.Tweet{Id: "pending", Author: "sarah_edo", ModerationState: model.ModerationPending}, nil)
+	_, err = service.GetTweet(context.Background(), "tylermcginnis", "pending")
+	assert.ErrorIs(t, err, tweetsrepo.ErrTweetNotFound, "a tweet held for review is hidden from the others")
+	tweet, err = service.GetTweet(context.Background(), "sarah_edo", "pending")
+	assert.NoError(t, err, "the author keeps access")
+	assert.Equal(t, "pending", tweet.Id)
+}
+
+func Test_ListTweets_Audience(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().ListRelationsFromDynamoDb(gomock.Any(), "tylermcginnis").Times(1).Return([]*model.Relation{
+		{UserId: "tylermcginnis", Kind: model.Follow, Target: "sarah_edo"},
+	}, nil)
+	repoMock.EXPECT().ScanTweetsFromDynamoDb(gomock.Any(), int32(10), "").Times(1).Return([]*model.Tweet{
+		{Id: "1", Author: "sarah_edo", Audience: &model.Audience{Level: model.AudienceFollowers}},
+		{Id: "2", Author: "dan_abramov", Audience: &model.Audience{Level: model.AudienceFollowers}},
+		{Id: "3", Author: "dan_abramov"},
+	}, "", nil)
+
+	tweets, _, err := New(repoMock).ListTweets(context.Background(), "tylermcginnis", 0, "")
+	assert.NoError(t, err)
+	ids := []string{}
+	for _, tweet := range tweets {
+		ids = append(ids, tweet.Id)
+	}
+	assert.Equal(t, []string{"1", "3"}, ids, "following is not blocking or muting")
+}
@@ -0,0 +1,123 @@
+package tweetsservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	repo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetsmoderation "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/moderation"
+)
+
+//prepareImport applies the rules of SaveTweet that an import can't be trusted with, in place:
+//  - the poll votes are reset. The votes table has no votes for them, and a payload could make up any tally
+//  - the moderation rules run like on a new tweet. A payload can keep a tweet held for review or removed, but it can't
+//    take a tweet out of review or keep it out of the rules
+//  - the audience is set the way setAudience sets it: replies take the restricted audience of their parent, from the
+//    payload or the table, and the other tweets get an audience of their author
+//
+//rewrites says what changed, for the dry run. rejected are the tweets the moderation rules reject
+func (s *ServiceImpl) prepareImport(ctx context.Context, tweets []*model.Tweet) (rewrites, rejected []model.RecordIssue, err error) {
+	byId := make(map[string]*model.Tweet, len(tweets))
+	for _, tweet := range tweets {
+		if _, ok := byId[tweet.Id]; tweet.Id != "" && !ok {
+			byId[tweet.Id] = tweet
+		}
+	}
+	rewrite := func(i int, field, message string) {
+		rewrites = append(rewrites, model.RecordIssue{Index: i, Id: tweets[i].Id, Field: field, Message: message})
+	}
+
+	for i, tweet := range tweets {
+		if tweet.Poll != nil {
+			reset := false
+			for j := range tweet.Poll.Options {
+				reset = reset || tweet.Poll.Options[j].Votes != 0
+				tweet.Poll.Options[j].Votes = 0
+			}
+			if reset {
+				rewrite(i, "poll", "the poll votes are reset. Votes can't be imported")
+			}
+		}
+
+		switch tweet.ModerationState {
+		case "", model.ModerationPending, model.ModerationRemoved:
+		default:
+			rejected = append(rejected, model.RecordIssue{Index: i, Id: tweet.Id, Field: "moderationState", Message: fmt.Sprintf("invalid moderationState %q. It should be empty, %s or %s", tweet.ModerationState, model.ModerationPending, model.ModerationRemoved)})
+			continue
+		}
+		if tweet.ModerationState == "" {
+			tweet.ModerationReasons = nil
+		}
+		if s.moderator != nil {
+			result := s.moderator.Moderate(ctx, tweet)
+			switch result.Verdict {
+			case tweetsmoderation.Reject:
+				rejected = append(rejected, model.RecordIssue{Index: i, Id: tweet.Id, Field: "text", Message: "text was rejected by moderation, " + result.Reasons[0]})
+				continue
+			case tweetsmoderation.Flag:
+				if tweet.ModerationState == "" {
+					tweet.ModerationState = model.ModerationPending
+					rewrite(i, "moderationState", "the tweet is held for review by moderation, "+strings.Join(result.Reasons, ", "))
+				}
+				if tweet.ModerationState == model.ModerationPending {
+					tweet.ModerationReasons = result.Reasons
+				}
+			}
+		}
+	}
+
+	//a reply needs the audience of its parent first. resolving guards against replies that loop
+	done := make(map[*model.Tweet]bool, len(tweets))
+	resolving := map[*model.Tweet]bool{}
+	var resolve func(tweet *model.Tweet) error
+	resolve = func(tweet *model.Tweet) error {
+		if done[tweet] || resolving[tweet] {
+			return nil
+		}
+		resolving[tweet] = true
+		defer func() { done[tweet] = true }()
+
+		if parentId, _ := splitReplyingTo(tweet.ReplyingTo); parentId != "" {
+			parent, inPayload := byId[parentId]
+			if inPayload {
+				if err := resolve(parent); err != nil {
+					return err
+				}
+			} else {
+				var err error
+				if parent, err = s.repo.GetTweetFromDynamoDb(ctx, parentId); err != nil && !errors.Is(err, repo.ErrTweetNotFound) {
+					return err
+				}
+			}
+			if parent != nil && parent.Restricted() {
+				inherited := *parent.Audience
+				inherited.Owner = parent.AudienceOwner()
+				tweet.Audience = &inherited
+				return nil
+			}
+		}
+		ownAudience(tweet)
+		return nil
+	}
+	before := make([]*model.Audience, len(tweets))
+	for i, tweet := range tweets {
+		if tweet.Restricted() {
+			before[i] = tweet.Audience
+		}
+	}
+	for _, tweet := range tweets {
+		if err := resolve(tweet); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i, tweet := range tweets {
+		if !reflect.DeepEqual(before[i], tweet.Audience) {
+			rewrite(i, "audience", "the audience is set from the author, the mentions and the parent tweet like for a new tweet")
+		}
+	}
+	return rewrites, rejected, nil
+}
@@ -0,0 +1,89 @@
+package tweetsservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetsmoderation "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/moderation"
+)
+
+func importedTweets() []*model.Tweet {
+	return []*model.Tweet{
+		//a reply that comes before its root, to a root that is in the payload
+		{Id: "reply", Author: "tylermcginnis", Text: "agreed", ReplyingTo: "root"},
+		{Id: "root", Author: "sarah_edo", Text: "@tylermcginnis only for you", Audience: &model.Audience{Level: model.AudienceMentioned, Owner: "dan_abramov", Mentioned: []string{"everybody"}}},
+		{Id: "poll", Author: "sarah_edo", Text: "Tabs or spaces?", Poll: &model.Poll{Options: []model.PollOption{{Text: "Tabs", Votes: 1000}, {Text: "Spaces"}}, ClosesAt: model.ChirperAppUnixTime(time.UnixMilli(1767225600000))}},
+		{Id: "flagged", Author: "sarah_edo", Text: "helloooo"},
+		{Id: "removed", Author: "sarah_edo", Text: "hello", ModerationState: model.ModerationRemoved, ModerationReasons: []string{"reviewer"}},
+		{Id: "reply-to-table", Author: "sarah_edo", Text: "hello", ReplyingTo: "followers-only"},
+	}
+}
+
+func Test_BulkSaveTweet_Rewrites(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), "followers-only").AnyTimes().Return(&model.Tweet{Id: "followers-only", Author: "dan_abramov", Audience: &model.Audience{Level: model.AudienceFollowers}}, nil)
+	var saved []*model.Tweet
+	repoMock.EXPECT().BulkSaveTweetToDynamoDb(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(ctx context.Context, tweets []*model.Tweet) error {
+		saved = tweets
+		return nil
+	})
+	service := New(repoMock)
+	service.SetModerator(tweetsmoderation.NewChain(tweetsmoderation.RepeatedCharacters(3, tweetsmoderation.Flag)))
+
+	if !assert.NoError(t, service.BulkSaveTweet(context.Background(), importedTweets())) {
+		return
+	}
+	byId := map[string]*model.Tweet{}
+	for _, tweet := range saved {
+		byId[tweet.Id] = tweet
+	}
+
+	mentioned := &model.Audience{Level: model.AudienceMentioned, Owner: "sarah_edo", Mentioned: []string{"tylermcginnis"}}
+	assert.Equal(t, mentioned, byId["root"].Audience, "the audience is the one of a new tweet")
+	assert.Equal(t, mentioned, byId["reply"].Audience, "a reply takes the audience of its root")
+	assert.Equal(t, &model.Audience{Level: model.AudienceFollowers, Owner: "dan_abramov"}, byId["reply-to-table"].Audience)
+	assert.Equal(t, []model.PollOption{{Text: "Tabs"}, {Text: "Spaces"}}, byId["poll"].Poll.Options, "the votes are not imported")
+	assert.Equal(t, model.ModerationPending, byId["flagged"].ModerationState, "imports are moderated")
+	assert.Equal(t, model.ModerationRemoved, byId["removed"].ModerationState, "a payload can keep a tweet removed")
+
+	service.SetModerator(tweetsmoderation.NewChain(tweetsmoderation.BannedWords([]string{"darn"}, tweetsmoderation.Reject)))
+	err := service.BulkSaveTweet(context.Background(), []*model.Tweet{{Id: "banned", Author: "sarah_edo", Text: "darn"}})
+	assert.Equal(t, errors.New(`text was rejected by moderation, banned_words: contains the banned word "darn" for tweetID: banned`), err)
+}
+
+func Test_ValidateBulkTweets_Rewrites(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().FindExistingUsersInDynamoDb(gomock.Any(), gomock.Any()).AnyTimes().Return(map[string]bool{"sarah_edo": true, "tylermcginnis": true}, nil)
+	repoMock.EXPECT().FindExistingTweetsInDynamoDb(gomock.Any(), gomock.Any()).AnyTimes().Return(map[string]bool{"followers-only": true}, nil)
+	repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), "followers-only").AnyTimes().Return(&model.Tweet{Id: "followers-only", Author: "dan_abramov", Audience: &model.Audience{Level: model.AudienceFollowers}}, nil)
+	repoMock.EXPECT().BulkSaveTweetToDynamoDb(gomock.Any(), gomock.Any()).Times(0)
+	service := New(repoMock)
+	service.SetModerator(tweetsmoderation.NewChain(tweetsmoderation.RepeatedCharacters(3, tweetsmoderation.Flag)))
+
+	tweets := append(importedTweets(), &model.Tweet{Id: "bad-state", Author: "sarah_edo", Text: "hello", ModerationState: "approved"})
+	report, err := service.ValidateBulkTweets(context.Background(), tweets)
+	assert.NoError(t, err)
+
+	fields := map[string][]string{}
+	for _, w := range report.Warnings {
+		fields[w.Id] = append(fields[w.Id], w.Field)
+	}
+	assert.Equal(t, map[string][]string{
+		"reply":          {"audience"},
+		"root":           {"audience"},
+		"poll":           {"poll"},
+		"flagged":        {"moderationState"},
+		"reply-to-table": {"audience"},
+	}, fields)
+	assert.Equal(t, 1, report.Invalid)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, "moderationState", report.Errors[0].Field)
+	}
+}
@@ -0,0 +1,103 @@
+package tweetsservice
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	repo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetstext "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/text"
+)
+
+//SaveDraft creates a draft(no id) or saves a new version of one. draft.Version is the version the client last read; the
+//save fails with repo.ErrDraftVersionConflict when another device saved the draft since, so it is not overwritten.
+//Drafts are unfinished, so the text is only checked for size: the checks of SaveTweet run when the draft is published
+func (s *ServiceImpl) SaveDraft(ctx context.Context, draft *model.Draft) (*model.Draft, error) {
+	var errs []FieldViolation
+	if draft.UserId == "" {
+		errs = append(errs, FieldViolation{"userId", "userId is required"})
+	}
+	if draft.Id != "" && draft.Version <= 0 {
+		errs = append(errs, FieldViolation{"version", "version is required to save a draft that exists"})
+	}
+	if len(draft.Text) > s.maxTextLength*maxBytesPerCharacter {
+		errs = append(errs, FieldViolation{"text", fmt.Sprintf("text cannot be more than %d characters", s.maxTextLength)})
+	}
+	if draft.Audience != nil {
+		if level, err := model.ParseAudienceLevel(string(draft.Audience.Level)); err != nil {
+			errs = append(errs, FieldViolation{"audience", err.Error()})
+		} else {
+			draft.Audience.Level = level
+		}
+	}
+	if len(errs) > 0 {
+		return nil, &ValidationError{Violations: errs}
+	}
+
+	now := model.ChirperAppUnixTime(time.Now())
+	readVersion := draft.Version
+	if draft.Id == "" {
+		draft.Id = uuid.NewString()
+		draft.CreatedAt = now
+		readVersion = 0
+	}
+	draft.Text = tweetstext.Normalize(draft.Text)
+	draft.Version = readVersion + 1
+	draft.UpdatedAt = now
+	return s.repo.SaveDraftToDynamoDb(ctx, draft, readVersion)
+}
+
+//ListDrafts returns the drafts of a user, the last edited first. It pages like ListTweets
+func (s *ServiceImpl) ListDrafts(ctx context.Context, userID string, limit int32, nextKey string) ([]*model.Draft, string, error) {
+	if userID == "" {
+		return nil, "", &ValidationError{Violations: []FieldViolation{{"userId", "userId is required"}}}
+	}
+	limit, err := s.pageLimit(limit)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.repo.ListDraftsFromDynamoDb(ctx, userID, nextKey, limit)
+}
+
+//DeleteDraft deletes a draft the client read at version. version 0 deletes it whatever was saved since
+func (s *ServiceImpl) DeleteDraft(ctx context.Context, userID, id string, version int64) error {
+	if userID == "" || id == "" {
+		return &ValidationError{Violations: []FieldViolation{{"id", "userId and id are required"}}}
+	}
+	return s.repo.DeleteDraftFromDynamoDb(ctx, userID, id, version)
+}
+
+//PublishDraft saves a draft as a tweet through the checks of SaveTweet, and deletes the draft in the same transaction: the
+//tweet is saved once, and the draft never outlives it. version works like for DeleteDraft
+func (s *ServiceImpl) PublishDraft(ctx context.Context, userID, id string, version int64) (*model.Tweet, error) {
+	if userID == "" || id == "" {
+		return nil, &ValidationError{Violations: []FieldViolation{{"id", "userId and id are required"}}}
+	}
+	draft, err := s.repo.GetDraftFromDynamoDb(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && draft.Version != version {
+		return nil, repo.ErrDraftVersionConflict
+	}
+
+	tweet := &model.Tweet{
+		Author:     draft.UserId,
+		Text:       draft.Text,
+		ReplyingTo: draft.ReplyingTo,
+		Audience:   draft.Audience,
+	}
+	replyingToAuthor, err := s.prepareTweet(ctx, tweet)
+	if err != nil {
+		return nil, err
+	}
+	//the version that was read, so an edit between the read and the transaction is not lost
+	newTweet, err := s.repo.PublishDraftToDynamoDb(ctx, replyingToAuthor, tweet, draft.UserId, draft.Id, draft.Version)
+	if err != nil {
+		return nil, err
+	}
+	countCreated(newTweet)
+	return newTweet, nil
+}
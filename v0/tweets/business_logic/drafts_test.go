@@ -0,0 +1,126 @@
+package tweetsservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func Test_SaveDraft(t *testing.T) {
+	testCases := []struct {
+		name                string
+		draft               *model.Draft
+		expectedReadVersion int64
+		expectedVersion     int64
+		expectedError       error
+	}{
+		{
+			name:            "should create a draft",
+			draft:           &model.Draft{UserId: "sarah_edo"},
+			expectedVersion: 1,
+		},
+		{
+			name:                "should save a new version of a draft",
+			draft:               &model.Draft{UserId: "sarah_edo", Id: "draft-1", Text: "half a thought", Version: 4},
+			expectedReadVersion: 4,
+			expectedVersion:     5,
+		},
+		{
+			name:          "should not save a draft that exists without its version",
+			draft:         &model.Draft{UserId: "sarah_edo", Id: "draft-1", Audience: &model.Audience{Level: "friends"}},
+			expectedError: &ValidationError{Violations: []FieldViolation{{"version", "version is required to save a draft that exists"}, {"audience", `invalid audience "friends". It should be public, followers or mentioned`}}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			repoMock.EXPECT().SaveDraftToDynamoDb(gomock.Any(), gomock.Any(), tc.expectedReadVersion).AnyTimes().DoAndReturn(func(ctx context.Context, draft *model.Draft, readVersion int64) (*model.Draft, error) {
+				return draft, nil
+			})
+
+			saved, err := New(repoMock).SaveDraft(context.Background(), tc.draft)
+			assert.Equal(t, tc.expectedError, err)
+			if tc.expectedError == nil {
+				assert.NotEqual(t, "", saved.Id)
+				assert.Equal(t, tc.expectedVersion, saved.Version)
+				assert.False(t, saved.UpdatedAt.IsZero())
+			}
+		})
+	}
+}
+
+func Test_PublishDraft(t *testing.T) {
+	draft := &model.Draft{UserId: "sarah_edo", Id: "draft-1", Text: " done writing ", Version: 3}
+
+	testCases := []struct {
+		name          string
+		version       int64
+		buildStubs    func(repoMock *tweetsrepo.MockRepository)
+		expectedError error
+	}{
+		{
+			name:    "should publish the draft and delete it",
+			version: 3,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().PublishDraftToDynamoDb(gomock.Any(), "", gomock.Any(), "sarah_edo", "draft-1", int64(3)).Times(1).
+					DoAndReturn(func(ctx context.Context, replyingToAuthor string, tweet *model.Tweet, userID, id string, version int64) (*model.Tweet, error) {
+						assert.Equal(t, "sarah_edo", tweet.Author)
+						assert.Equal(t, "done writing", tweet.Text, "the draft goes through the checks of SaveTweet")
+						return tweet, nil
+					})
+			},
+		},
+		{
+			name:    "should publish the version that was read",
+			version: 0,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().PublishDraftToDynamoDb(gomock.Any(), "", gomock.Any(), "sarah_edo", "draft-1", int64(3)).Times(1).Return(nil, tweetsrepo.ErrDraftVersionConflict)
+			},
+			expectedError: tweetsrepo.ErrDraftVersionConflict,
+		},
+		{
+			name:    "should not publish a draft saved since",
+			version: 2,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().PublishDraftToDynamoDb(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedError: tweetsrepo.ErrDraftVersionConflict,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			repoMock.EXPECT().GetDraftFromDynamoDb(gomock.Any(), "sarah_edo", "draft-1").Times(1).DoAndReturn(func(ctx context.Context, userID, id string) (*model.Draft, error) {
+				d := *draft
+				return &d, nil
+			})
+			tc.buildStubs(repoMock)
+
+			tweet, err := New(repoMock).PublishDraft(context.Background(), "sarah_edo", "draft-1", tc.version)
+			assert.ErrorIs(t, err, tc.expectedError)
+			if tc.expectedError == nil {
+				assert.NotEqual(t, "", tweet.Id)
+			}
+		})
+	}
+}
+
+func Test_PublishDraft_Invalid(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().GetDraftFromDynamoDb(gomock.Any(), "sarah_edo", "empty").Times(1).Return(&model.Draft{UserId: "sarah_edo", Id: "empty", Version: 1}, nil)
+	repoMock.EXPECT().PublishDraftToDynamoDb(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := New(repoMock).PublishDraft(context.Background(), "sarah_edo", "empty", 1)
+	assert.Equal(t, &ValidationError{Violations: []FieldViolation{{"text", "text is required"}}}, err, "the draft stays until it can be published")
+}
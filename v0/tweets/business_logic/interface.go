@@ -2,6 +2,8 @@ package tweetsservice
 
 import (
 	"context"
+	"io"
+	"time"
 
 	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
 )
@@ -10,6 +12,37 @@ import (
 type Service interface {
 	SaveTweet(ctx context.Context, tweet *model.Tweet) (*model.Tweet, error)
 	BulkSaveTweet(ctx context.Context, tweets []*model.Tweet) error
-	ListTweets(ctx context.Context, limit int32, nextKey string) ([]*model.Tweet, string, error)
+	ValidateBulkTweets(ctx context.Context, tweets []*model.Tweet) (*model.ValidationReport, error)
+	ListTweets(ctx context.Context, viewer string, limit int32, nextKey string) ([]*model.Tweet, string, error)
+	GetTweet(ctx context.Context, viewer, tweetID string) (*model.Tweet, error)
 	SaveLikeToggle(ctx context.Context, tweetID, author, authedUserID string, hasLiked bool) error
+	ExportTweets(ctx context.Context, filter model.TweetFilter, segments int32, fn func(*model.Tweet) error) error
+	ParallelScanTweets(ctx context.Context, input model.ParallelScanInput) (<-chan model.ScanPage, error)
+	ReindexUserTweets(ctx context.Context, segments int32) (*model.ReindexReport, error)
+	RepairReplies(ctx context.Context, segments int32, dryRun bool) (*model.RepairReport, error)
+	BulkSaveUsers(ctx context.Context, users []*model.User) error
+	ListReviewQueue(ctx context.Context, limit int32, nextKey string) ([]*model.Tweet, string, error)
+	ReviewTweet(ctx context.Context, tweetID string, decision model.ReviewDecision, reviewer string) (*model.Tweet, error)
+	Block(ctx context.Context, userID, target string) error
+	Unblock(ctx context.Context, userID, target string) error
+	Mute(ctx context.Context, userID, target string) error
+	Unmute(ctx context.Context, userID, target string) error
+	ListRelations(ctx context.Context, userID string) ([]*model.Relation, error)
+	Follow(ctx context.Context, userID, target string) error
+	Unfollow(ctx context.Context, userID, target string) error
+	ScheduleTweet(ctx context.Context, tweet *model.Tweet, publishAt time.Time) (*model.ScheduledTweet, error)
+	ListScheduled(ctx context.Context, author string, limit int32, nextKey string) ([]*model.ScheduledTweet, string, error)
+	CancelScheduled(ctx context.Context, author, id string) error
+	PublishDueTweets(ctx context.Context, now time.Time) (*model.PublishReport, error)
+	SaveDraft(ctx context.Context, draft *model.Draft) (*model.Draft, error)
+	ListDrafts(ctx context.Context, userID string, limit int32, nextKey string) ([]*model.Draft, string, error)
+	DeleteDraft(ctx context.Context, userID, id string, version int64) error
+	PublishDraft(ctx context.Context, userID, id string, version int64) (*model.Tweet, error)
+	VotePoll(ctx context.Context, userID, tweetID string, option int) (*model.Poll, error)
+	UploadMedia(ctx context.Context, userID string, body io.Reader, checksum string) (*model.Media, error)
+	OpenMedia(ctx context.Context, viewer, id string) (*model.Media, io.ReadCloser, error)
+	ListTweetMedia(ctx context.Context, viewer, tweetID string) ([]*model.Media, error)
+	CollectOrphanMedia(ctx context.Context, now time.Time) (*model.MediaGCReport, error)
+	OpenThumbnail(ctx context.Context, viewer, id string, side int) (*model.Media, io.ReadCloser, error)
+	ProcessPendingMedia(ctx context.Context) (*model.MediaProcessingReport, error)
 }
\ No newline at end of file
@@ -0,0 +1,484 @@
+package tweetsservice
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/blob"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/imaging"
+	repo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+const (
+	//http.DetectContentType looks at most at this many bytes
+	sniffLength = 512
+	//how many orphans one round of the collector deletes. The rest waits for the next round
+	orphanBatchSize = 100
+	//how many images one round of the processing worker decodes. Decoding is the slow part, a round stays short
+	processingBatchSize = 20
+	//an image the worker could not process this many times is marked failed
+	maxProcessingAttempts = 3
+	thumbnailQuality      = 80
+)
+
+var (
+	ErrMediaDisabled    = errors.New("media uploads are not set up")
+	ErrUnsupportedMedia = errors.New("unsupported media type. Upload a JPEG, PNG, GIF or WebP image or an MP4 video")
+	ErrMediaTooLarge    = errors.New("media is too large")
+	//returned when the content does not match the checksum the client sent, eg it was cut off on the way
+	ErrChecksumMismatch = errors.New("checksum does not match the content")
+)
+
+//MediaLimits bound the uploads. Images and videos have their own size limit
+type MediaLimits struct {
+	MaxImageBytes int64
+	MaxVideoBytes int64
+	//OrphanTTL is how long an upload waits for a tweet before the collector deletes it
+	OrphanTTL time.Duration
+}
+
+//SetMedia sets where the uploads are stored. Without a store(the default) UploadMedia returns ErrMediaDisabled
+func (s *ServiceImpl) SetMedia(store blob.Store, limits MediaLimits) {
+	s.media = store
+	if limits.MaxImageBytes > 0 {
+		s.mediaLimits.MaxImageBytes = limits.MaxImageBytes
+	}
+	if limits.MaxVideoBytes > 0 {
+		s.mediaLimits.MaxVideoBytes = limits.MaxVideoBytes
+	}
+	if limits.OrphanTTL > 0 {
+		s.mediaLimits.OrphanTTL = limits.OrphanTTL
+	}
+}
+
+//processed are the types the processing worker decodes. The standard library has no WebP encoder or video decoder
+func processed(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+//maxMediaBytes is the size limit of a content type, 0 for the types we don't take
+func (s *ServiceImpl) maxMediaBytes(contentType string) int64 {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return s.mediaLimits.MaxImageBytes
+	case "video/mp4":
+		return s.mediaLimits.MaxVideoBytes
+	}
+	return 0
+}
+
+//UploadMedia stores the upload of userID, read from body, and returns what we know about it. The content type is sniffed
+//from the content. When checksum(the hex SHA-256 of the content) is set, an upload that does not match it is rejected.
+//The location is removed from the images and videos before they are stored, so Checksum and Size are the ones of the stored
+//content. The upload is an orphan until a tweet references it in MediaIds
+func (s *ServiceImpl) UploadMedia(ctx context.Context, userID string, body io.Reader, checksum string) (*model.Media, error) {
+	if s.media == nil {
+		return nil, ErrMediaDisabled
+	}
+	if userID == "" {
+		return nil, &ValidationError{Violations: []FieldViolation{{"userId", "userId is required"}}}
+	}
+	checksum = strings.ToLower(strings.TrimSpace(checksum))
+	if _, err := hex.DecodeString(checksum); err != nil || (checksum != "" && len(checksum) != 2*sha256.Size) {
+		return nil, &ValidationError{Violations: []FieldViolation{{"checksum", "checksum must be the hex SHA-256 of the content"}}}
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+	if n == 0 {
+		return nil, &ValidationError{Violations: []FieldViolation{{"file", "file is empty"}}}
+	}
+	contentType := http.DetectContentType(head)
+	limit := s.maxMediaBytes(contentType)
+	if limit == 0 {
+		return nil, ErrUnsupportedMedia
+	}
+
+	//spooled to disk so the size and the checksum are known before anything is stored
+	spool, err := os.CreateTemp("", "chirper-upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}()
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(spool, hash), io.LimitReader(io.MultiReader(bytes.NewReader(head), body), limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > limit {
+		return nil, fmt.Errorf("%w, %s files can be up to %d bytes", ErrMediaTooLarge, contentType, limit)
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if checksum != "" && checksum != sum {
+		return nil, ErrChecksumMismatch
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	content := io.Reader(spool)
+
+	//the location is gone before anybody can read the upload, the processing worker comes later
+	if imaging.StripsLocation(contentType) {
+		stripped, err := os.CreateTemp("", "chirper-upload-*")
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			stripped.Close()
+			os.Remove(stripped.Name())
+		}()
+		hash.Reset()
+		if err := imaging.StripLocation(io.MultiWriter(stripped, hash), spool, contentType); err != nil {
+			if errors.Is(err, imaging.ErrInvalidImage) {
+				return nil, fmt.Errorf("%w, %v", ErrUnsupportedMedia, err)
+			}
+			return nil, err
+		}
+		if size, err = stripped.Seek(0, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		if _, err := stripped.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		sum = hex.EncodeToString(hash.Sum(nil))
+		content = stripped
+	}
+
+	media := &model.Media{
+		Id:          uuid.NewString(),
+		UserId:      userID,
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    sum,
+		CreatedAt:   model.ChirperAppUnixTime(time.Now()),
+		PendingGC:   model.MediaOrphan,
+	}
+	if processed(contentType) {
+		media.Status = model.MediaPending
+		media.PendingProcessing = model.MediaPending
+	}
+	key := model.MediaKey(media.Id)
+	if err := s.media.Put(ctx, key, content, blob.Info{Size: size, ContentType: contentType, SHA256: sum}); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveMediaToDynamoDb(ctx, media); err != nil {
+		//nothing knows about the blob, the collector would never find it
+		if delErr := s.media.Delete(ctx, key); delErr != nil {
+			slog.ErrorContext(ctx, "unable to delete an upload that was not recorded", "key", key, "err", delErr)
+		}
+		return nil, err
+	}
+	return media, nil
+}
+
+//OpenMedia returns a media and its content for viewer. The media of a tweet can be seen by whoever can see the tweet, an
+//orphan only by its uploader. Everybody else gets repo.ErrMediaNotFound. The caller closes the reader
+func (s *ServiceImpl) OpenMedia(ctx context.Context, viewer, id string) (*model.Media, io.ReadCloser, error) {
+	media, err := s.visibleMedia(ctx, viewer, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.media.Open(ctx, model.MediaKey(id))
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil, repo.ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, content, nil
+}
+
+//OpenThumbnail returns the thumbnail of side pixels of an image, for the viewers OpenMedia lets see the image. Images
+//the worker did not process yet have no thumbnails: they are repo.ErrMediaNotFound. The caller closes the reader
+func (s *ServiceImpl) OpenThumbnail(ctx context.Context, viewer, id string, side int) (*model.Media, io.ReadCloser, error) {
+	media, err := s.visibleMedia(ctx, viewer, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if media.Thumbnail(side) == nil {
+		return nil, nil, repo.ErrMediaNotFound
+	}
+	content, err := s.media.Open(ctx, model.MediaThumbnailKey(id, side))
+	if errors.Is(err, blob.ErrNotFound) {
+		return nil, nil, repo.ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, content, nil
+}
+
+//visibleMedia returns the media when viewer can see it, repo.ErrMediaNotFound otherwise
+func (s *ServiceImpl) visibleMedia(ctx context.Context, viewer, id string) (*model.Media, error) {
+	if s.media == nil {
+		return nil, ErrMediaDisabled
+	}
+	if id == "" {
+		return nil, &ValidationError{Violations: []FieldViolation{{"id", "id is required"}}}
+	}
+	media, err := s.repo.GetMediaFromDynamoDb(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if media.TweetId == "" && media.UserId != viewer {
+		return nil, repo.ErrMediaNotFound
+	}
+	if media.TweetId != "" {
+		tweet, err := s.repo.GetTweetFromDynamoDb(ctx, media.TweetId)
+		if errors.Is(err, repo.ErrTweetNotFound) {
+			return nil, repo.ErrMediaNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		visible, err := s.audienceFor(viewer).canSee(ctx, tweet)
+		if err != nil {
+			return nil, err
+		}
+		if !visible {
+			return nil, repo.ErrMediaNotFound
+		}
+	}
+	return media, nil
+}
+
+//ListTweetMedia returns the media of a tweet in the order of its MediaIds. The Tweet message has no field for them
+func (s *ServiceImpl) ListTweetMedia(ctx context.Context, viewer, tweetID string) ([]*model.Media, error) {
+	tweet, err := s.GetTweet(ctx, viewer, tweetID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tweet.MediaIds) == 0 {
+		return []*model.Media{}, nil
+	}
+	return s.repo.ListMediaFromDynamoDb(ctx, tweet.MediaIds)
+}
+
+//showMedia fills the Media of the tweets of a page with one read, so clients can lay out the images before they load
+func (s *ServiceImpl) showMedia(ctx context.Context, tweets []*model.Tweet) error {
+	var ids []string
+	for _, tweet := range tweets {
+		ids = append(ids, tweet.MediaIds...)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	media, err := s.repo.ListMediaFromDynamoDb(ctx, ids)
+	if err != nil {
+		return err
+	}
+	found := make(map[string]*model.Media, len(media))
+	for _, m := range media {
+		found[m.Id] = m
+	}
+	for _, tweet := range tweets {
+		for _, id := range tweet.MediaIds {
+			if m, ok := found[id]; ok {
+				tweet.Media = append(tweet.Media, m)
+			}
+		}
+	}
+	return nil
+}
+
+//validateMediaIds are the checks of MediaIds that don't need the media
+func validateMediaIds(ids []string) []FieldViolation {
+	var errs []FieldViolation
+	if len(ids) > model.MaxMediaPerTweet {
+		errs = append(errs, FieldViolation{"mediaIds", fmt.Sprintf("a tweet can have up to %d media, got %d", model.MaxMediaPerTweet, len(ids))})
+	}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if id == "" {
+			errs = append(errs, FieldViolation{"mediaIds", "media ids cannot be empty"})
+		} else if seen[id] {
+			errs = append(errs, FieldViolation{"mediaIds", fmt.Sprintf("media %s is attached twice", id)})
+		}
+		seen[id] = true
+	}
+	return errs
+}
+
+//checkMedia makes sure the author of a new tweet uploaded its media and no other tweet has them. The write checks it
+//again(repo.ErrMediaUnavailable), this is for the errors the client can act on
+func (s *ServiceImpl) checkMedia(ctx context.Context, tweet *model.Tweet) error {
+	if len(tweet.MediaIds) == 0 {
+		return nil
+	}
+	media, err := s.repo.ListMediaFromDynamoDb(ctx, tweet.MediaIds)
+	if err != nil {
+		return err
+	}
+	found := make(map[string]*model.Media, len(media))
+	for _, m := range media {
+		found[m.Id] = m
+	}
+
+	var errs []FieldViolation
+	for _, id := range tweet.MediaIds {
+		m, ok := found[id]
+		switch {
+		case !ok || m.UserId != tweet.Author:
+			//the uploads of other users are not found either, so they can't be probed for
+			errs = append(errs, FieldViolation{"mediaIds", fmt.Sprintf("media %s was not found", id)})
+		case m.TweetId != "" && m.TweetId != tweet.Id:
+			errs = append(errs, FieldViolation{"mediaIds", fmt.Sprintf("media %s is attached to another tweet", id)})
+		}
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Violations: errs}
+	}
+	return nil
+}
+
+//CollectOrphanMedia deletes the uploads no tweet referenced within the orphan TTL, at most orphanBatchSize per round.
+//The record goes first with a condition, so an upload attached in the meantime is kept
+func (s *ServiceImpl) CollectOrphanMedia(ctx context.Context, now time.Time) (*model.MediaGCReport, error) {
+	if s.media == nil {
+		return nil, ErrMediaDisabled
+	}
+	report := &model.MediaGCReport{}
+	orphans, err := s.repo.ListOrphanMediaFromDynamoDb(ctx, now.Add(-s.mediaLimits.OrphanTTL), orphanBatchSize)
+	if err != nil {
+		return report, err
+	}
+	for _, media := range orphans {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		report.Orphans++
+		err := s.repo.DeleteMediaFromDynamoDb(ctx, media.Id)
+		if errors.Is(err, repo.ErrMediaAttached) {
+			report.Attached++
+			continue
+		}
+		if err == nil {
+			err = s.media.Delete(ctx, model.MediaKey(media.Id))
+			s.deleteThumbnails(ctx, media.Id)
+		}
+		if err != nil {
+			//a blob whose record is gone is not listed again, the log is what is left of it
+			report.Failed++
+			slog.ErrorContext(ctx, "unable to delete orphan media", "mediaId", media.Id, "key", model.MediaKey(media.Id), "err", err)
+			continue
+		}
+		report.Deleted++
+	}
+	return report, nil
+}
+
+//ProcessPendingMedia decodes the images waiting for the worker, at most processingBatchSize per round, and records
+//their size and placeholder and makes their thumbnails. Images that can't be decoded fail at once, the other errors
+//are retried next round up to maxProcessingAttempts times
+func (s *ServiceImpl) ProcessPendingMedia(ctx context.Context) (*model.MediaProcessingReport, error) {
+	if s.media == nil {
+		return nil, ErrMediaDisabled
+	}
+	report := &model.MediaProcessingReport{}
+	pending, err := s.repo.ListPendingMediaFromDynamoDb(ctx, processingBatchSize)
+	if err != nil {
+		return report, err
+	}
+	for _, media := range pending {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		report.Pending++
+		media.Attempts++
+		err := s.processImage(ctx, media)
+		switch {
+		case err == nil:
+			media.Status = model.MediaReady
+		case errors.Is(err, imaging.ErrInvalidImage) || media.Attempts >= maxProcessingAttempts:
+			slog.ErrorContext(ctx, "unable to process an image", "mediaId", media.Id, "attempts", media.Attempts, "err", err)
+			media.Status = model.MediaFailed
+			media.Width, media.Height, media.Placeholder, media.Thumbnails = 0, 0, "", nil
+			s.deleteThumbnails(ctx, media.Id)
+		default:
+			slog.WarnContext(ctx, "unable to process an image, it is tried again next round", "mediaId", media.Id, "attempts", media.Attempts, "err", err)
+		}
+
+		if err := s.repo.SaveMediaProcessingToDynamoDb(ctx, media); err != nil {
+			if errors.Is(err, repo.ErrMediaNotFound) {
+				//the collector deleted it while it was processed
+				s.deleteThumbnails(ctx, media.Id)
+				continue
+			}
+			return report, err
+		}
+		switch media.Status {
+		case model.MediaReady:
+			report.Ready++
+		case model.MediaFailed:
+			report.Failed++
+		default:
+			report.Retried++
+		}
+	}
+	return report, nil
+}
+
+//processImage decodes an image and sets its size, placeholder and thumbnails. The thumbnails are stored as it goes
+func (s *ServiceImpl) processImage(ctx context.Context, media *model.Media) error {
+	content, err := s.media.Open(ctx, model.MediaKey(media.Id))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	//the content was checked against the limits when it was uploaded, it should not have grown since
+	img, err := imaging.Decode(io.LimitReader(content, media.Size))
+	if err != nil {
+		return err
+	}
+
+	media.Width, media.Height = img.Bounds().Dx(), img.Bounds().Dy()
+	media.Placeholder = imaging.Placeholder(img)
+	media.Thumbnails = nil
+	for _, side := range model.ThumbnailSizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, imaging.Thumbnail(img, side), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+			return err
+		}
+		sum := sha256.Sum256(buf.Bytes())
+		info := blob.Info{Size: int64(buf.Len()), ContentType: "image/jpeg", SHA256: hex.EncodeToString(sum[:])}
+		if err := s.media.Put(ctx, model.MediaThumbnailKey(media.Id, side), bytes.NewReader(buf.Bytes()), info); err != nil {
+			return err
+		}
+		media.Thumbnails = append(media.Thumbnails, model.MediaThumbnail{Side: side, Size: info.Size})
+	}
+	return nil
+}
+
+//deleteThumbnails deletes the thumbnails a media may have. A thumbnail that is left behind is only logged, nothing
+//points at it anymore
+func (s *ServiceImpl) deleteThumbnails(ctx context.Context, id string) {
+	for _, side := range model.ThumbnailSizes {
+		if err := s.media.Delete(ctx, model.MediaThumbnailKey(id, side)); err != nil {
+			slog.ErrorContext(ctx, "unable to delete a thumbnail", "mediaId", id, "key", model.MediaThumbnailKey(id, side), "err", err)
+		}
+	}
+}
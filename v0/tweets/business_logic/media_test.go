@@ -0,0 +1,405 @@
+package tweetsservice
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/blob"
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//pngFile is a PNG of one gray pixel
+var pngFile = encodePNG(image.NewGray(image.Rect(0, 0, 1, 1)))
+
+func encodePNG(img image.Image) string {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func newMediaService(t *testing.T, repoMock *tweetsrepo.MockRepository) (*ServiceImpl, *blob.FileStore) {
+	store, err := blob.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(repoMock)
+	s.SetMedia(store, MediaLimits{MaxImageBytes: 100, OrphanTTL: time.Hour})
+	return s, store
+}
+
+func Test_UploadMedia(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          string
+		checksum      string
+		repoError     error
+		expectedError error
+	}{
+		{
+			name:     "should store the upload",
+			body:     pngFile,
+			checksum: strings.ToUpper(sha256Hex(pngFile)),
+		},
+		{
+			name:          "should not store what is not an image or a video",
+			body:          "#!/bin/sh\nrm -rf /",
+			expectedError: ErrUnsupportedMedia,
+		},
+		{
+			name:          "should not store an upload over the limit",
+			body:          pngFile + strings.Repeat("\x00", 100),
+			expectedError: ErrMediaTooLarge,
+		},
+		{
+			name:          "should not store an upload that does not match its checksum",
+			body:          pngFile,
+			checksum:      sha256Hex("something else"),
+			expectedError: ErrChecksumMismatch,
+		},
+		{
+			name:          "should not keep the blob when the upload can't be recorded",
+			body:          pngFile,
+			repoError:     errors.New("throttled"),
+			expectedError: errors.New("throttled"),
+		},
+		{
+			name:          "should not store an empty file",
+			expectedError: &ValidationError{Violations: []FieldViolation{{"file", "file is empty"}}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			var saved *model.Media
+			repoMock.EXPECT().SaveMediaToDynamoDb(gomock.Any(), gomock.Any()).MaxTimes(1).DoAndReturn(func(ctx context.Context, media *model.Media) error {
+				saved = media
+				return tc.repoError
+			})
+			s, store := newMediaService(t, repoMock)
+
+			media, err := s.UploadMedia(context.Background(), "sarah_edo", strings.NewReader(tc.body), tc.checksum)
+			if tc.expectedError != nil {
+				if errors.Is(tc.expectedError, ErrMediaTooLarge) {
+					assert.ErrorIs(t, err, ErrMediaTooLarge)
+				} else {
+					assert.Equal(t, tc.expectedError, err)
+				}
+				if saved != nil {
+					_, err := store.Open(context.Background(), model.MediaKey(saved.Id))
+					assert.Equal(t, blob.ErrNotFound, err)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "image/png", media.ContentType)
+			assert.Equal(t, int64(len(pngFile)), media.Size)
+			assert.Equal(t, sha256Hex(pngFile), media.Checksum)
+			content, err := store.Open(context.Background(), model.MediaKey(media.Id))
+			if assert.NoError(t, err) {
+				b, _ := io.ReadAll(content)
+				content.Close()
+				assert.Equal(t, pngFile, string(b))
+			}
+		})
+	}
+}
+
+func Test_SaveTweet_Media(t *testing.T) {
+	uploads := []*model.Media{
+		{Id: "photo-1", UserId: "sarah_edo"},
+		{Id: "attached", UserId: "sarah_edo", TweetId: "other-tweet"},
+		{Id: "theirs", UserId: "tylermcginnis"},
+	}
+
+	testCases := []struct {
+		name          string
+		mediaIds      []string
+		expectedError error
+	}{
+		{
+			name:     "should save a tweet with the media of its author",
+			mediaIds: []string{"photo-1"},
+		},
+		{
+			name:     "should list every media that can't be attached",
+			mediaIds: []string{"attached", "theirs", "missing"},
+			expectedError: &ValidationError{Violations: []FieldViolation{
+				{"mediaIds", "media attached is attached to another tweet"},
+				{"mediaIds", "media theirs was not found"},
+				{"mediaIds", "media missing was not found"},
+			}},
+		},
+		{
+			name:     "should not save more than 4 media",
+			mediaIds: []string{"1", "2", "3", "4", "4"},
+			expectedError: &ValidationError{Violations: []FieldViolation{
+				{"mediaIds", "a tweet can have up to 4 media, got 5"},
+				{"mediaIds", "media 4 is attached twice"},
+			}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			repoMock.EXPECT().ListMediaFromDynamoDb(gomock.Any(), tc.mediaIds).AnyTimes().DoAndReturn(func(ctx context.Context, ids []string) ([]*model.Media, error) {
+				var found []*model.Media
+				for _, id := range ids {
+					for _, m := range uploads {
+						if m.Id == id {
+							found = append(found, m)
+						}
+					}
+				}
+				return found, nil
+			})
+			repoMock.EXPECT().SaveTweetToDynamoDb(gomock.Any(), "", gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, replyingToAuthor string, tweet *model.Tweet) (*model.Tweet, error) {
+				return tweet, nil
+			})
+
+			_, err := New(repoMock).SaveTweet(context.Background(), &model.Tweet{Author: "sarah_edo", Text: "look", MediaIds: tc.mediaIds})
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func Test_OpenMedia(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().GetMediaFromDynamoDb(gomock.Any(), "orphan").AnyTimes().Return(&model.Media{Id: "orphan", UserId: "sarah_edo"}, nil)
+	s, store := newMediaService(t, repoMock)
+	store.Put(context.Background(), model.MediaKey("orphan"), strings.NewReader(pngFile), blob.Info{})
+
+	_, content, err := s.OpenMedia(context.Background(), "sarah_edo", "orphan")
+	if assert.NoError(t, err, "the uploader sees the upload before it is attached") {
+		content.Close()
+	}
+	_, _, err = s.OpenMedia(context.Background(), "tylermcginnis", "orphan")
+	assert.Equal(t, tweetsrepo.ErrMediaNotFound, err)
+
+	repoMock.EXPECT().GetMediaFromDynamoDb(gomock.Any(), "held").AnyTimes().Return(&model.Media{Id: "held", UserId: "sarah_edo", TweetId: "pending"}, nil)
+	repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), "pending").AnyTimes().Return(&model.Tweet{Id: "pending", Author: "sarah_edo", ModerationState: model.ModerationPending}, nil)
+	store.Put(context.Background(), model.MediaKey("held"), strings.NewReader(pngFile), blob.Info{})
+	_, _, err = s.OpenMedia(context.Background(), "tylermcginnis", "held")
+	assert.Equal(t, tweetsrepo.ErrMediaNotFound, err, "the media of a tweet held for review is hidden from the others")
+	_, content, err = s.OpenMedia(context.Background(), "sarah_edo", "held")
+	if assert.NoError(t, err, "the author keeps access") {
+		content.Close()
+	}
+	_, err = s.ListTweetMedia(context.Background(), "tylermcginnis", "pending")
+	assert.Equal(t, tweetsrepo.ErrTweetNotFound, err)
+}
+
+func Test_CollectOrphanMedia(t *testing.T) {
+	now := time.Now()
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().ListOrphanMediaFromDynamoDb(gomock.Any(), now.Add(-time.Hour), orphanBatchSize).Times(1).
+		Return([]*model.Media{{Id: "orphan"}, {Id: "attached-since"}}, nil)
+	repoMock.EXPECT().DeleteMediaFromDynamoDb(gomock.Any(), "orphan").Times(1).Return(nil)
+	repoMock.EXPECT().DeleteMediaFromDynamoDb(gomock.Any(), "attached-since").Times(1).Return(tweetsrepo.ErrMediaAttached)
+	s, store := newMediaService(t, repoMock)
+	for _, id := range []string{"orphan", "attached-since"} {
+		store.Put(context.Background(), model.MediaKey(id), strings.NewReader(pngFile), blob.Info{})
+	}
+
+	report, err := s.CollectOrphanMedia(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.MediaGCReport{Orphans: 2, Deleted: 1, Attached: 1}, report)
+	_, err = store.Open(context.Background(), model.MediaKey("orphan"))
+	assert.Equal(t, blob.ErrNotFound, err)
+	content, err := store.Open(context.Background(), model.MediaKey("attached-since"))
+	if assert.NoError(t, err) {
+		content.Close()
+	}
+}
+
+//pngWithLocation is a PNG with an eXIf chunk that holds a GPS latitude of 51,30,26
+func pngWithLocation() (string, []byte) {
+	latitude := []byte{51, 0, 0, 0, 1, 0, 0, 0, 30, 0, 0, 0, 1, 0, 0, 0, 26, 0, 0, 0, 1, 0, 0, 0}
+	le := binary.LittleEndian
+	exif := le.AppendUint32([]byte("II*\x00"), 8)
+	exif = le.AppendUint16(exif, 1) //IFD0 with the GPSInfo pointer
+	exif = append(exif, 0x25, 0x88, 4, 0, 1, 0, 0, 0)
+	exif = le.AppendUint32(exif, 26)
+	exif = le.AppendUint32(exif, 0)
+	exif = le.AppendUint16(exif, 1) //the GPS IFD with GPSLatitude
+	exif = append(exif, 2, 0, 5, 0, 3, 0, 0, 0)
+	exif = le.AppendUint32(exif, 44)
+	exif = le.AppendUint32(exif, 0)
+	exif = append(exif, latitude...)
+
+	crc := crc32.NewIEEE()
+	crc.Write([]byte("eXIf"))
+	crc.Write(exif)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(exif)))
+	chunk = binary.BigEndian.AppendUint32(append(append(chunk, "eXIf"...), exif...), crc.Sum32())
+
+	img := encodePNG(image.NewGray(image.Rect(0, 0, 4, 4)))
+	ihdrEnd := 8 + 8 + 13 + 4
+	return img[:ihdrEnd] + string(chunk) + img[ihdrEnd:], latitude
+}
+
+func Test_UploadMedia_Location(t *testing.T) {
+	body, latitude := pngWithLocation()
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().SaveMediaToDynamoDb(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+	s, store := newMediaService(t, repoMock)
+	s.SetMedia(store, MediaLimits{MaxImageBytes: 1 << 20})
+
+	media, err := s.UploadMedia(context.Background(), "sarah_edo", strings.NewReader(body), sha256Hex(body))
+	assert.NoError(t, err, "the checksum is the one of the upload")
+	assert.Equal(t, model.MediaPending, media.Status)
+	assert.Equal(t, model.MediaPending, media.PendingProcessing, "in the queue of the worker")
+
+	content, err := store.Open(context.Background(), model.MediaKey(media.Id))
+	if assert.NoError(t, err) {
+		b, _ := io.ReadAll(content)
+		content.Close()
+		assert.False(t, bytes.Contains(b, latitude), "the location is not stored")
+		assert.Equal(t, int64(len(b)), media.Size)
+		assert.Equal(t, sha256Hex(string(b)), media.Checksum, "the checksum is the one of the stored content")
+		_, err := png.Decode(bytes.NewReader(b))
+		assert.NoError(t, err)
+	}
+
+	//a video with the ISO 6709 location of its camera in moov/udta/©xyz
+	box := func(kind string, content string) string {
+		return string(binary.BigEndian.AppendUint32(nil, uint32(8+len(content)))) + kind + content
+	}
+	video := box("ftyp", "mp42\x00\x00\x00\x00mp42isom") + box("moov", box("udta", box("\xa9xyz", "\x00\x12\x15\xc7+51.5074-000.1278/"))) + box("mdat", "frames")
+	repoMock.EXPECT().SaveMediaToDynamoDb(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+	s.SetMedia(store, MediaLimits{MaxImageBytes: 1 << 20, MaxVideoBytes: 1 << 20})
+	media, err = s.UploadMedia(context.Background(), "sarah_edo", strings.NewReader(video), "")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "video/mp4", media.ContentType)
+		assert.Empty(t, media.Status, "videos are not processed")
+		content, err := store.Open(context.Background(), model.MediaKey(media.Id))
+		if assert.NoError(t, err) {
+			b, _ := io.ReadAll(content)
+			content.Close()
+			assert.NotContains(t, string(b), "+51.5074", "the location of the video is not stored")
+			assert.Len(t, b, len(video))
+		}
+	}
+}
+
+func Test_ProcessPendingMedia(t *testing.T) {
+	photo := image.NewRGBA(image.Rect(0, 0, 64, 32))
+	for i := range photo.Pix {
+		photo.Pix[i] = uint8(i)
+	}
+	photoFile := encodePNG(photo)
+
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().ListPendingMediaFromDynamoDb(gomock.Any(), processingBatchSize).Times(1).Return([]*model.Media{
+		{Id: "photo", ContentType: "image/png", Size: int64(len(photoFile)), Status: model.MediaPending},
+		{Id: "broken", ContentType: "image/png", Size: 12, Status: model.MediaPending},
+		{Id: "missing", ContentType: "image/png", Size: 12, Status: model.MediaPending},
+		{Id: "missing-again", ContentType: "image/png", Size: 12, Status: model.MediaPending, Attempts: maxProcessingAttempts - 1},
+	}, nil)
+	saved := map[string]*model.Media{}
+	repoMock.EXPECT().SaveMediaProcessingToDynamoDb(gomock.Any(), gomock.Any()).Times(4).DoAndReturn(func(ctx context.Context, media *model.Media) error {
+		saved[media.Id] = media
+		return nil
+	})
+	s, store := newMediaService(t, repoMock)
+	store.Put(context.Background(), model.MediaKey("photo"), strings.NewReader(photoFile), blob.Info{})
+	store.Put(context.Background(), model.MediaKey("broken"), strings.NewReader("not an image"), blob.Info{})
+
+	report, err := s.ProcessPendingMedia(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &model.MediaProcessingReport{Pending: 4, Ready: 1, Retried: 1, Failed: 2}, report)
+
+	processed := saved["photo"]
+	assert.Equal(t, model.MediaReady, processed.Status)
+	assert.Equal(t, []int{64, 32}, []int{processed.Width, processed.Height})
+	assert.Len(t, processed.Placeholder, 28)
+	if assert.Len(t, processed.Thumbnails, len(model.ThumbnailSizes)) {
+		for i, side := range model.ThumbnailSizes {
+			assert.Equal(t, side, processed.Thumbnails[i].Side)
+			content, err := store.Open(context.Background(), model.MediaThumbnailKey("photo", side))
+			if assert.NoError(t, err) {
+				thumbnail, format, err := image.Decode(content)
+				content.Close()
+				assert.NoError(t, err)
+				assert.Equal(t, "jpeg", format)
+				assert.Equal(t, image.Rect(0, 0, side, side), thumbnail.Bounds())
+			}
+		}
+	}
+
+	assert.Equal(t, model.MediaFailed, saved["broken"].Status, "an image that can't be decoded is not tried again")
+	assert.Equal(t, model.MediaPending, saved["missing"].Status, "the blob store may be back next round")
+	assert.Equal(t, 1, saved["missing"].Attempts)
+	assert.Equal(t, model.MediaFailed, saved["missing-again"].Status)
+}
+
+func Test_OpenThumbnail(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().GetMediaFromDynamoDb(gomock.Any(), "ready").AnyTimes().Return(&model.Media{Id: "ready", UserId: "sarah_edo", Status: model.MediaReady, Thumbnails: []model.MediaThumbnail{{Side: 150, Size: 3}}}, nil)
+	repoMock.EXPECT().GetMediaFromDynamoDb(gomock.Any(), "pending").AnyTimes().Return(&model.Media{Id: "pending", UserId: "sarah_edo", Status: model.MediaPending}, nil)
+	s, store := newMediaService(t, repoMock)
+	store.Put(context.Background(), model.MediaThumbnailKey("ready", 150), strings.NewReader("jpg"), blob.Info{})
+
+	_, content, err := s.OpenThumbnail(context.Background(), "sarah_edo", "ready", 150)
+	if assert.NoError(t, err) {
+		content.Close()
+	}
+	_, _, err = s.OpenThumbnail(context.Background(), "sarah_edo", "ready", 480)
+	assert.Equal(t, tweetsrepo.ErrMediaNotFound, err, "only the sizes the media has")
+	_, _, err = s.OpenThumbnail(context.Background(), "sarah_edo", "pending", 150)
+	assert.Equal(t, tweetsrepo.ErrMediaNotFound, err)
+	_, _, err = s.OpenThumbnail(context.Background(), "tylermcginnis", "ready", 150)
+	assert.Equal(t, tweetsrepo.ErrMediaNotFound, err, "like the media, an orphan is only seen by its uploader")
+}
+
+func Test_ListTweets_Media(t *testing.T) {
+	tweets := []*model.Tweet{
+		{Id: "photos", Author: "tylermcginnis", MediaIds: []string{"b", "a"}},
+		{Id: "text", Author: "tylermcginnis"},
+	}
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().ListRelationsFromDynamoDb(gomock.Any(), "sarah_edo").Times(1).Return(nil, nil)
+	repoMock.EXPECT().ScanTweetsFromDynamoDb(gomock.Any(), int32(10), "").Times(1).Return(tweets, "", nil)
+	repoMock.EXPECT().ListMediaFromDynamoDb(gomock.Any(), []string{"b", "a"}).Times(1).Return([]*model.Media{
+		{Id: "b", Width: 640, Height: 480, Placeholder: "LEHV6nWB2yk8pyo0adR*.7kCMdnj"},
+		{Id: "a", Width: 1080, Height: 1920},
+	}, nil)
+
+	list, _, err := New(repoMock).ListTweets(context.Background(), "sarah_edo", 0, "")
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) && assert.Len(t, list[0].Media, 2) {
+		assert.Equal(t, "b", list[0].Media[0].Id)
+		assert.Equal(t, 640, list[0].Media[0].Width)
+		assert.Equal(t, "a", list[0].Media[1].Id)
+		assert.Empty(t, list[1].Media)
+	}
+}
@@ -6,7 +6,9 @@ package tweetsservice
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	tweetmodel "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
@@ -35,6 +37,20 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// Block mocks base method.
+func (m *MockService) Block(ctx context.Context, userID, target string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Block", ctx, userID, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Block indicates an expected call of Block.
+func (mr *MockServiceMockRecorder) Block(ctx, userID, target interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Block", reflect.TypeOf((*MockService)(nil).Block), ctx, userID, target)
+}
+
 // BulkSaveTweet mocks base method.
 func (m *MockService) BulkSaveTweet(ctx context.Context, tweets []*tweetmodel.Tweet) error {
 	m.ctrl.T.Helper()
@@ -49,10 +65,188 @@ func (mr *MockServiceMockRecorder) BulkSaveTweet(ctx, tweets interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkSaveTweet", reflect.TypeOf((*MockService)(nil).BulkSaveTweet), ctx, tweets)
 }
 
+// BulkSaveUsers mocks base method.
+func (m *MockService) BulkSaveUsers(ctx context.Context, users []*tweetmodel.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkSaveUsers", ctx, users)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BulkSaveUsers indicates an expected call of BulkSaveUsers.
+func (mr *MockServiceMockRecorder) BulkSaveUsers(ctx, users interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkSaveUsers", reflect.TypeOf((*MockService)(nil).BulkSaveUsers), ctx, users)
+}
+
+// CancelScheduled mocks base method.
+func (m *MockService) CancelScheduled(ctx context.Context, author, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduled", ctx, author, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelScheduled indicates an expected call of CancelScheduled.
+func (mr *MockServiceMockRecorder) CancelScheduled(ctx, author, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduled", reflect.TypeOf((*MockService)(nil).CancelScheduled), ctx, author, id)
+}
+
+// CollectOrphanMedia mocks base method.
+func (m *MockService) CollectOrphanMedia(ctx context.Context, now time.Time) (*tweetmodel.MediaGCReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CollectOrphanMedia", ctx, now)
+	ret0, _ := ret[0].(*tweetmodel.MediaGCReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CollectOrphanMedia indicates an expected call of CollectOrphanMedia.
+func (mr *MockServiceMockRecorder) CollectOrphanMedia(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CollectOrphanMedia", reflect.TypeOf((*MockService)(nil).CollectOrphanMedia), ctx, now)
+}
+
+// DeleteDraft mocks base method.
+func (m *MockService) DeleteDraft(ctx context.Context, userID, id string, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDraft", ctx, userID, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDraft indicates an expected call of DeleteDraft.
+func (mr *MockServiceMockRecorder) DeleteDraft(ctx, userID, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDraft", reflect.TypeOf((*MockService)(nil).DeleteDraft), ctx, userID, id, version)
+}
+
+// ExportTweets mocks base method.
+func (m *MockService) ExportTweets(ctx context.Context, filter tweetmodel.TweetFilter, segments int32, fn func(*tweetmodel.Tweet) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportTweets", ctx, filter, segments, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportTweets indicates an expected call of ExportTweets.
+func (mr *MockServiceMockRecorder) ExportTweets(ctx, filter, segments, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportTweets", reflect.TypeOf((*MockService)(nil).ExportTweets), ctx, filter, segments, fn)
+}
+
+// Follow mocks base method.
+func (m *MockService) Follow(ctx context.Context, userID, target string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Follow", ctx, userID, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Follow indicates an expected call of Follow.
+func (mr *MockServiceMockRecorder) Follow(ctx, userID, target interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Follow", reflect.TypeOf((*MockService)(nil).Follow), ctx, userID, target)
+}
+
+// GetTweet mocks base method.
+func (m *MockService) GetTweet(ctx context.Context, viewer, tweetID string) (*tweetmodel.Tweet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTweet", ctx, viewer, tweetID)
+	ret0, _ := ret[0].(*tweetmodel.Tweet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTweet indicates an expected call of GetTweet.
+func (mr *MockServiceMockRecorder) GetTweet(ctx, viewer, tweetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTweet", reflect.TypeOf((*MockService)(nil).GetTweet), ctx, viewer, tweetID)
+}
+
+// ListDrafts mocks base method.
+func (m *MockService) ListDrafts(ctx context.Context, userID string, limit int32, nextKey string) ([]*tweetmodel.Draft, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDrafts", ctx, userID, limit, nextKey)
+	ret0, _ := ret[0].([]*tweetmodel.Draft)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListDrafts indicates an expected call of ListDrafts.
+func (mr *MockServiceMockRecorder) ListDrafts(ctx, userID, limit, nextKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDrafts", reflect.TypeOf((*MockService)(nil).ListDrafts), ctx, userID, limit, nextKey)
+}
+
+// ListRelations mocks base method.
+func (m *MockService) ListRelations(ctx context.Context, userID string) ([]*tweetmodel.Relation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRelations", ctx, userID)
+	ret0, _ := ret[0].([]*tweetmodel.Relation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRelations indicates an expected call of ListRelations.
+func (mr *MockServiceMockRecorder) ListRelations(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRelations", reflect.TypeOf((*MockService)(nil).ListRelations), ctx, userID)
+}
+
+// ListReviewQueue mocks base method.
+func (m *MockService) ListReviewQueue(ctx context.Context, limit int32, nextKey string) ([]*tweetmodel.Tweet, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReviewQueue", ctx, limit, nextKey)
+	ret0, _ := ret[0].([]*tweetmodel.Tweet)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListReviewQueue indicates an expected call of ListReviewQueue.
+func (mr *MockServiceMockRecorder) ListReviewQueue(ctx, limit, nextKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReviewQueue", reflect.TypeOf((*MockService)(nil).ListReviewQueue), ctx, limit, nextKey)
+}
+
+// ListScheduled mocks base method.
+func (m *MockService) ListScheduled(ctx context.Context, author string, limit int32, nextKey string) ([]*tweetmodel.ScheduledTweet, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduled", ctx, author, limit, nextKey)
+	ret0, _ := ret[0].([]*tweetmodel.ScheduledTweet)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListScheduled indicates an expected call of ListScheduled.
+func (mr *MockServiceMockRecorder) ListScheduled(ctx, author, limit, nextKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduled", reflect.TypeOf((*MockService)(nil).ListScheduled), ctx, author, limit, nextKey)
+}
+
+// ListTweetMedia mocks base method.
+func (m *MockService) ListTweetMedia(ctx context.Context, viewer, tweetID string) ([]*tweetmodel.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTweetMedia", ctx, viewer, tweetID)
+	ret0, _ := ret[0].([]*tweetmodel.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTweetMedia indicates an expected call of ListTweetMedia.
+func (mr *MockServiceMockRecorder) ListTweetMedia(ctx, viewer, tweetID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTweetMedia", reflect.TypeOf((*MockService)(nil).ListTweetMedia), ctx, viewer, tweetID)
+}
+
 // ListTweets mocks base method.
-func (m *MockService) ListTweets(ctx context.Context, limit int32, nextKey string) ([]*tweetmodel.Tweet, string, error) {
+func (m *MockService) ListTweets(ctx context.Context, viewer string, limit int32, nextKey string) ([]*tweetmodel.Tweet, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTweets", ctx, limit, nextKey)
+	ret := m.ctrl.Call(m, "ListTweets", ctx, viewer, limit, nextKey)
 	ret0, _ := ret[0].([]*tweetmodel.Tweet)
 	ret1, _ := ret[1].(string)
 	ret2, _ := ret[2].(error)
@@ -60,9 +254,175 @@ func (m *MockService) ListTweets(ctx context.Context, limit int32, nextKey strin
 }
 
 // ListTweets indicates an expected call of ListTweets.
-func (mr *MockServiceMockRecorder) ListTweets(ctx, limit, nextKey interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) ListTweets(ctx, viewer, limit, nextKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTweets", reflect.TypeOf((*MockService)(nil).ListTweets), ctx, viewer, limit, nextKey)
+}
+
+// Mute mocks base method.
+func (m *MockService) Mute(ctx context.Context, userID, target string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Mute", ctx, userID, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Mute indicates an expected call of Mute.
+func (mr *MockServiceMockRecorder) Mute(ctx, userID, target interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Mute", reflect.TypeOf((*MockService)(nil).Mute), ctx, userID, target)
+}
+
+// OpenMedia mocks base method.
+func (m *MockService) OpenMedia(ctx context.Context, viewer, id string) (*tweetmodel.Media, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenMedia", ctx, viewer, id)
+	ret0, _ := ret[0].(*tweetmodel.Media)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OpenMedia indicates an expected call of OpenMedia.
+func (mr *MockServiceMockRecorder) OpenMedia(ctx, viewer, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTweets", reflect.TypeOf((*MockService)(nil).ListTweets), ctx, limit, nextKey)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenMedia", reflect.TypeOf((*MockService)(nil).OpenMedia), ctx, viewer, id)
+}
+
+// OpenThumbnail mocks base method.
+func (m *MockService) OpenThumbnail(ctx context.Context, viewer, id string, side int) (*tweetmodel.Media, io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenThumbnail", ctx, viewer, id, side)
+	ret0, _ := ret[0].(*tweetmodel.Media)
+	ret1, _ := ret[1].(io.ReadCloser)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OpenThumbnail indicates an expected call of OpenThumbnail.
+func (mr *MockServiceMockRecorder) OpenThumbnail(ctx, viewer, id, side interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenThumbnail", reflect.TypeOf((*MockService)(nil).OpenThumbnail), ctx, viewer, id, side)
+}
+
+// ParallelScanTweets mocks base method.
+func (m *MockService) ParallelScanTweets(ctx context.Context, input tweetmodel.ParallelScanInput) (<-chan tweetmodel.ScanPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParallelScanTweets", ctx, input)
+	ret0, _ := ret[0].(<-chan tweetmodel.ScanPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParallelScanTweets indicates an expected call of ParallelScanTweets.
+func (mr *MockServiceMockRecorder) ParallelScanTweets(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParallelScanTweets", reflect.TypeOf((*MockService)(nil).ParallelScanTweets), ctx, input)
+}
+
+// ProcessPendingMedia mocks base method.
+func (m *MockService) ProcessPendingMedia(ctx context.Context) (*tweetmodel.MediaProcessingReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessPendingMedia", ctx)
+	ret0, _ := ret[0].(*tweetmodel.MediaProcessingReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProcessPendingMedia indicates an expected call of ProcessPendingMedia.
+func (mr *MockServiceMockRecorder) ProcessPendingMedia(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessPendingMedia", reflect.TypeOf((*MockService)(nil).ProcessPendingMedia), ctx)
+}
+
+// PublishDraft mocks base method.
+func (m *MockService) PublishDraft(ctx context.Context, userID, id string, version int64) (*tweetmodel.Tweet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishDraft", ctx, userID, id, version)
+	ret0, _ := ret[0].(*tweetmodel.Tweet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishDraft indicates an expected call of PublishDraft.
+func (mr *MockServiceMockRecorder) PublishDraft(ctx, userID, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishDraft", reflect.TypeOf((*MockService)(nil).PublishDraft), ctx, userID, id, version)
+}
+
+// PublishDueTweets mocks base method.
+func (m *MockService) PublishDueTweets(ctx context.Context, now time.Time) (*tweetmodel.PublishReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishDueTweets", ctx, now)
+	ret0, _ := ret[0].(*tweetmodel.PublishReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishDueTweets indicates an expected call of PublishDueTweets.
+func (mr *MockServiceMockRecorder) PublishDueTweets(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishDueTweets", reflect.TypeOf((*MockService)(nil).PublishDueTweets), ctx, now)
+}
+
+// ReindexUserTweets mocks base method.
+func (m *MockService) ReindexUserTweets(ctx context.Context, segments int32) (*tweetmodel.ReindexReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReindexUserTweets", ctx, segments)
+	ret0, _ := ret[0].(*tweetmodel.ReindexReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReindexUserTweets indicates an expected call of ReindexUserTweets.
+func (mr *MockServiceMockRecorder) ReindexUserTweets(ctx, segments interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReindexUserTweets", reflect.TypeOf((*MockService)(nil).ReindexUserTweets), ctx, segments)
+}
+
+// RepairReplies mocks base method.
+func (m *MockService) RepairReplies(ctx context.Context, segments int32, dryRun bool) (*tweetmodel.RepairReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairReplies", ctx, segments, dryRun)
+	ret0, _ := ret[0].(*tweetmodel.RepairReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RepairReplies indicates an expected call of RepairReplies.
+func (mr *MockServiceMockRecorder) RepairReplies(ctx, segments, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairReplies", reflect.TypeOf((*MockService)(nil).RepairReplies), ctx, segments, dryRun)
+}
+
+// ReviewTweet mocks base method.
+func (m *MockService) ReviewTweet(ctx context.Context, tweetID string, decision tweetmodel.ReviewDecision, reviewer string) (*tweetmodel.Tweet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviewTweet", ctx, tweetID, decision, reviewer)
+	ret0, _ := ret[0].(*tweetmodel.Tweet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviewTweet indicates an expected call of ReviewTweet.
+func (mr *MockServiceMockRecorder) ReviewTweet(ctx, tweetID, decision, reviewer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviewTweet", reflect.TypeOf((*MockService)(nil).ReviewTweet), ctx, tweetID, decision, reviewer)
+}
+
+// SaveDraft mocks base method.
+func (m *MockService) SaveDraft(ctx context.Context, draft *tweetmodel.Draft) (*tweetmodel.Draft, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDraft", ctx, draft)
+	ret0, _ := ret[0].(*tweetmodel.Draft)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveDraft indicates an expected call of SaveDraft.
+func (mr *MockServiceMockRecorder) SaveDraft(ctx, draft interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDraft", reflect.TypeOf((*MockService)(nil).SaveDraft), ctx, draft)
 }
 
 // SaveLikeToggle mocks base method.
@@ -93,3 +453,105 @@ func (mr *MockServiceMockRecorder) SaveTweet(ctx, tweet interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTweet", reflect.TypeOf((*MockService)(nil).SaveTweet), ctx, tweet)
 }
+
+// ScheduleTweet mocks base method.
+func (m *MockService) ScheduleTweet(ctx context.Context, tweet *tweetmodel.Tweet, publishAt time.Time) (*tweetmodel.ScheduledTweet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleTweet", ctx, tweet, publishAt)
+	ret0, _ := ret[0].(*tweetmodel.ScheduledTweet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleTweet indicates an expected call of ScheduleTweet.
+func (mr *MockServiceMockRecorder) ScheduleTweet(ctx, tweet, publishAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleTweet", reflect.TypeOf((*MockService)(nil).ScheduleTweet), ctx, tweet, publishAt)
+}
+
+// Unblock mocks base method.
+func (m *MockService) Unblock(ctx context.Context, userID, target string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unblock", ctx, userID, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unblock indicates an expected call of Unblock.
+func (mr *MockServiceMockRecorder) Unblock(ctx, userID, target interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unblock", reflect.TypeOf((*MockService)(nil).Unblock), ctx, userID, target)
+}
+
+// Unfollow mocks base method.
+func (m *MockService) Unfollow(ctx context.Context, userID, target string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unfollow", ctx, userID, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unfollow indicates an expected call of Unfollow.
+func (mr *MockServiceMockRecorder) Unfollow(ctx, userID, target interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unfollow", reflect.TypeOf((*MockService)(nil).Unfollow), ctx, userID, target)
+}
+
+// Unmute mocks base method.
+func (m *MockService) Unmute(ctx context.Context, userID, target string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unmute", ctx, userID, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unmute indicates an expected call of Unmute.
+func (mr *MockServiceMockRecorder) Unmute(ctx, userID, target interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unmute", reflect.TypeOf((*MockService)(nil).Unmute), ctx, userID, target)
+}
+
+// UploadMedia mocks base method.
+func (m *MockService) UploadMedia(ctx context.Context, userID string, body io.Reader, checksum string) (*tweetmodel.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadMedia", ctx, userID, body, checksum)
+	ret0, _ := ret[0].(*tweetmodel.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UploadMedia indicates an expected call of UploadMedia.
+func (mr *MockServiceMockRecorder) UploadMedia(ctx, userID, body, checksum interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadMedia", reflect.TypeOf((*MockService)(nil).UploadMedia), ctx, userID, body, checksum)
+}
+
+// ValidateBulkTweets mocks base method.
+func (m *MockService) ValidateBulkTweets(ctx context.Context, tweets []*tweetmodel.Tweet) (*tweetmodel.ValidationReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateBulkTweets", ctx, tweets)
+	ret0, _ := ret[0].(*tweetmodel.ValidationReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateBulkTweets indicates an expected call of ValidateBulkTweets.
+func (mr *MockServiceMockRecorder) ValidateBulkTweets(ctx, tweets interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateBulkTweets", reflect.TypeOf((*MockService)(nil).ValidateBulkTweets), ctx, tweets)
+}
+
+// VotePoll mocks base method.
+func (m *MockService) VotePoll(ctx context.Context, userID, tweetID string, option int) (*tweetmodel.Poll, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VotePoll", ctx, userID, tweetID, option)
+	ret0, _ := ret[0].(*tweetmodel.Poll)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VotePoll indicates an expected call of VotePoll.
+func (mr *MockServiceMockRecorder) VotePoll(ctx, userID, tweetID, option interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VotePoll", reflect.TypeOf((*MockService)(nil).VotePoll), ctx, userID, tweetID, option)
+}
@@ -0,0 +1,52 @@
+package tweetsservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/metrics"
+	repo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//ListReviewQueue returns the tweets the moderation rules flagged, oldest first. It pages like ListTweets
+func (s *ServiceImpl) ListReviewQueue(ctx context.Context, limit int32, nextKey string) ([]*model.Tweet, string, error) {
+	limit, err := s.pageLimit(limit)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.repo.ListModerationQueueFromDynamoDb(ctx, nextKey, limit)
+}
+
+//ReviewTweet approves or removes a tweet held for review. It returns repo.ErrNotPendingReview when the tweet is not in the review queue
+func (s *ServiceImpl) ReviewTweet(ctx context.Context, tweetID string, decision model.ReviewDecision, reviewer string) (*model.Tweet, error) {
+	if tweetID == "" {
+		return nil, errors.New("id is required")
+	}
+	if reviewer == "" {
+		return nil, errors.New("reviewer is required")
+	}
+	if decision != model.ReviewApprove && decision != model.ReviewRemove {
+		return nil, fmt.Errorf("invalid decision %q. It should be %s or %s", decision, model.ReviewApprove, model.ReviewRemove)
+	}
+
+	//the table key needs the author
+	tweet, err := s.repo.GetTweetFromDynamoDb(ctx, tweetID)
+	if err != nil {
+		return nil, err
+	}
+	if tweet.ModerationState != model.ModerationPending {
+		return nil, repo.ErrNotPendingReview
+	}
+
+	reviewed, err := s.repo.ReviewTweetInDynamoDb(ctx, tweet.Id, tweet.Author, decision == model.ReviewApprove, reviewer, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	metrics.ModerationDecisions.WithLabelValues("review", string(decision)).Inc()
+	slog.InfoContext(ctx, "tweet reviewed", "tweet", reviewed, "decision", decision, "reviewer", reviewer)
+	return reviewed, nil
+}
@@ -0,0 +1,127 @@
+package tweetsservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetsmoderation "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/moderation"
+)
+
+func Test_SaveTweet_Moderation(t *testing.T) {
+	moderator := tweetsmoderation.NewChain(
+		tweetsmoderation.BannedWords([]string{"darn"}, tweetsmoderation.Reject),
+		tweetsmoderation.RepeatedCharacters(3, tweetsmoderation.Flag),
+	)
+
+	testCases := []struct {
+		name  string
+		tweet *model.Tweet
+
+		expectedState   string
+		expectedReasons []string
+		expectedError   error
+	}{
+		{name: "should save a tweet the rules allow", tweet: &model.Tweet{Author: "sarah_edo", Text: "hello"}},
+		{
+			name: "should hold a flagged tweet for review", tweet: &model.Tweet{Author: "sarah_edo", Text: "helloooo"},
+			expectedState: model.ModerationPending, expectedReasons: []string{`repeated_characters: repeats "o" more than 3 times in a row`},
+		},
+		{
+			name: "should not let the client pick the moderation state", tweet: &model.Tweet{Author: "sarah_edo", Text: "hello", ModerationState: model.ModerationPending},
+		},
+		{
+			name: "should reject a tweet with a banned word", tweet: &model.Tweet{Author: "sarah_edo", Text: "d4rn"},
+			expectedError: &ValidationError{Violations: []FieldViolation{{"text", `text was rejected by moderation, banned_words: contains the banned word "darn"`}}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			var saved *model.Tweet
+			repoMock.EXPECT().SaveTweetToDynamoDb(gomock.Any(), "", gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, replyingToAuthor string, tweet *model.Tweet) (*model.Tweet, error) {
+				saved = tweet
+				return tweet, nil
+			})
+
+			service := New(repoMock)
+			service.SetModerator(moderator)
+			_, err := service.SaveTweet(context.Background(), tc.tweet)
+
+			assert.Equal(t, tc.expectedError, err)
+			if tc.expectedError == nil {
+				assert.Equal(t, tc.expectedState, saved.ModerationState)
+				assert.Equal(t, tc.expectedReasons, saved.ModerationReasons)
+			}
+		})
+	}
+}
+
+func Test_ReviewTweet(t *testing.T) {
+	pending := &model.Tweet{Id: "flagged", Author: "sarah_edo", ModerationState: model.ModerationPending}
+
+	testCases := []struct {
+		name       string
+		tweetID    string
+		decision   model.ReviewDecision
+		buildStubs func(repoMock *tweetsrepo.MockRepository)
+
+		expectedError error
+	}{
+		{
+			name: "should approve a pending tweet", tweetID: "flagged", decision: model.ReviewApprove,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), "flagged").Times(1).Return(pending, nil)
+				repoMock.EXPECT().ReviewTweetInDynamoDb(gomock.Any(), "flagged", "sarah_edo", true, "admin", gomock.Any()).Times(1).Return(&model.Tweet{Id: "flagged"}, nil)
+			},
+		},
+		{
+			name: "should remove a pending tweet", tweetID: "flagged", decision: model.ReviewRemove,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), "flagged").Times(1).Return(pending, nil)
+				repoMock.EXPECT().ReviewTweetInDynamoDb(gomock.Any(), "flagged", "sarah_edo", false, "admin", gomock.Any()).Times(1).Return(&model.Tweet{Id: "flagged"}, nil)
+			},
+		},
+		{
+			name: "should not review a tweet that is not in the queue", tweetID: "visible", decision: model.ReviewRemove,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), "visible").Times(1).Return(&model.Tweet{Id: "visible", Author: "sarah_edo"}, nil)
+				repoMock.EXPECT().ReviewTweetInDynamoDb(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedError: tweetsrepo.ErrNotPendingReview,
+		},
+		{
+			name: "should reject an unknown decision", tweetID: "flagged", decision: "ban",
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedError: assert.AnError,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			tc.buildStubs(repoMock)
+
+			_, err := New(repoMock).ReviewTweet(context.Background(), tc.tweetID, tc.decision, "admin")
+			switch tc.expectedError {
+			case nil:
+				assert.NoError(t, err)
+			case assert.AnError:
+				assert.Error(t, err)
+			default:
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package tweetsservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	repo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetstext "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/text"
+)
+
+const (
+	//how long a new poll stays open at least and at most
+	minPollDuration = 5 * time.Minute
+	maxPollDuration = 7 * 24 * time.Hour
+)
+
+//ErrNoPoll is returned when a vote is for a tweet without a poll
+var ErrNoPoll = errors.New("tweet has no poll")
+
+//validatePoll checks the options of a poll. Their text is normalized like the text of the tweet
+func validatePoll(poll *model.Poll) []FieldViolation {
+	if poll == nil {
+		return nil
+	}
+	var errs []FieldViolation
+	if n := len(poll.Options); n < model.MinPollOptions || n > model.MaxPollOptions {
+		errs = append(errs, FieldViolation{"poll.options", fmt.Sprintf("a poll has %d to %d options, got %d", model.MinPollOptions, model.MaxPollOptions, n)})
+	}
+	for i := range poll.Options {
+		option := &poll.Options[i]
+		if len(option.Text) > model.MaxPollOptionLength*maxBytesPerCharacter {
+			errs = append(errs, FieldViolation{"poll.options", fmt.Sprintf("option %d cannot be more than %d characters", i+1, model.MaxPollOptionLength)})
+			continue
+		}
+		option.Text = tweetstext.Normalize(option.Text)
+		if option.Text == "" {
+			errs = append(errs, FieldViolation{"poll.options", fmt.Sprintf("option %d is empty", i+1)})
+		} else if tweetstext.Length(option.Text) > model.MaxPollOptionLength {
+			errs = append(errs, FieldViolation{"poll.options", fmt.Sprintf("option %d cannot be more than %d characters", i+1, model.MaxPollOptionLength)})
+		}
+	}
+	if poll.ClosesAt.IsZero() {
+		errs = append(errs, FieldViolation{"poll.closesAt", "poll.closesAt is required"})
+	}
+	return errs
+}
+
+//newPollViolations are the checks of the poll of a new tweet on top of validatePoll. Imported tweets keep their closed polls
+func newPollViolations(poll *model.Poll, now time.Time) []FieldViolation {
+	if poll == nil || poll.ClosesAt.IsZero() {
+		return nil
+	}
+	open := time.Time(poll.ClosesAt).Sub(now)
+	if open < minPollDuration || open > maxPollDuration {
+		return []FieldViolation{{"poll.closesAt", fmt.Sprintf("a poll stays open from %s to %s", minPollDuration, maxPollDuration)}}
+	}
+	return nil
+}
+
+//VotePoll records the vote of userID for option(counted from 0) of the poll of a tweet and returns the poll with its
+//results. A user votes once per poll(repo.ErrAlreadyVoted) and only while it is open(repo.ErrPollClosed)
+func (s *ServiceImpl) VotePoll(ctx context.Context, userID, tweetID string, option int) (*model.Poll, error) {
+	if userID == "" || tweetID == "" {
+		return nil, &ValidationError{Violations: []FieldViolation{{"tweetId", "userId and tweetId are required"}}}
+	}
+	tweet, err := s.repo.GetTweetFromDynamoDb(ctx, tweetID)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := s.audienceFor(userID).canSee(ctx, tweet)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, repo.ErrTweetNotFound
+	}
+	if tweet.Poll == nil {
+		return nil, ErrNoPoll
+	}
+	if option < 0 || option >= len(tweet.Poll.Options) {
+		return nil, &ValidationError{Violations: []FieldViolation{{"option", fmt.Sprintf("option must be between 0 and %d", len(tweet.Poll.Options)-1)}}}
+	}
+	now := time.Now()
+	if tweet.Poll.Closed(now) {
+		return nil, repo.ErrPollClosed
+	}
+	if err := s.checkNotBlocked(ctx, tweet.Author, userID); err != nil {
+		return nil, err
+	}
+
+	vote := &model.PollVote{TweetId: tweet.Id, UserId: userID, Option: option, Timestamp: model.ChirperAppUnixTime(now)}
+	if err := s.repo.VotePollInDynamoDb(ctx, vote, tweet.Author, now); err != nil {
+		return nil, err
+	}
+
+	//read again for the votes of the others since
+	voted, err := s.repo.GetTweetFromDynamoDb(ctx, tweet.Id)
+	if err != nil {
+		return nil, err
+	}
+	voted.Poll.VotedOption = &vote.Option
+	return voted.Poll, nil
+}
+
+//showPolls hides the results of the open polls the viewer did not vote on, and tells which option they voted for
+func (s *ServiceImpl) showPolls(ctx context.Context, viewer string, tweets []*model.Tweet, now time.Time) error {
+	var polls []string
+	for _, tweet := range tweets {
+		if tweet.Poll != nil {
+			polls = append(polls, tweet.Id)
+		}
+	}
+	if len(polls) == 0 {
+		return nil
+	}
+
+	voted := map[string]int{}
+	if viewer != "" {
+		var err error
+		if voted, err = s.repo.ListPollVotesFromDynamoDb(ctx, viewer, polls); err != nil {
+			return err
+		}
+	}
+	for _, tweet := range tweets {
+		if tweet.Poll == nil {
+			continue
+		}
+		if option, ok := voted[tweet.Id]; ok {
+			tweet.Poll.VotedOption = &option
+		} else if !tweet.Poll.Closed(now) {
+			tweet.Poll.HideResults()
+		}
+	}
+	return nil
+}
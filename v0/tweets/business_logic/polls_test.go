@@ -0,0 +1,193 @@
+package tweetsservice
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func newPoll(closesAt time.Time, votes ...int64) *model.Poll {
+	poll := &model.Poll{ClosesAt: model.ChirperAppUnixTime(closesAt)}
+	for i, v := range votes {
+		poll.Options = append(poll.Options, model.PollOption{Text: string(rune('A' + i)), Votes: v})
+	}
+	return poll
+}
+
+func Test_SaveTweet_Poll(t *testing.T) {
+	tomorrow := time.Now().Add(24 * time.Hour)
+
+	testCases := []struct {
+		name          string
+		poll          *model.Poll
+		expectedError error
+	}{
+		{
+			name: "should save a poll without votes",
+			poll: newPoll(tomorrow, 10, 20),
+		},
+		{
+			name: "should not save a poll with one option",
+			poll: newPoll(tomorrow, 0),
+			expectedError: &ValidationError{Violations: []FieldViolation{
+				{"poll.options", "a poll has 2 to 4 options, got 1"},
+			}},
+		},
+		{
+			name: "should list every problem of the poll",
+			poll: &model.Poll{Options: []model.PollOption{{Text: " "}, {Text: "an option that is far too long to read"}, {Text: "C"}, {Text: "D"}, {Text: "E"}}},
+			expectedError: &ValidationError{Violations: []FieldViolation{
+				{"poll.options", "a poll has 2 to 4 options, got 5"},
+				{"poll.options", "option 1 is empty"},
+				{"poll.options", "option 2 cannot be more than 25 characters"},
+				{"poll.closesAt", "poll.closesAt is required"},
+			}},
+		},
+		{
+			name: "should not save a poll that closes in a month",
+			poll: newPoll(tomorrow.Add(30*24*time.Hour), 0, 0),
+			expectedError: &ValidationError{Violations: []FieldViolation{
+				{"poll.closesAt", "a poll stays open from 5m0s to 168h0m0s"},
+			}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			repoMock.EXPECT().SaveTweetToDynamoDb(gomock.Any(), "", gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, replyingToAuthor string, tweet *model.Tweet) (*model.Tweet, error) {
+				return tweet, nil
+			})
+
+			tweet, err := New(repoMock).SaveTweet(context.Background(), &model.Tweet{Author: "sarah_edo", Text: "Tabs or spaces?", Poll: tc.poll})
+			assert.Equal(t, tc.expectedError, err)
+			if tc.expectedError == nil {
+				assert.Equal(t, int64(0), tweet.Poll.Options[0].Votes+tweet.Poll.Options[1].Votes, "the votes of a new poll are not taken from the client")
+				assert.True(t, tweet.Poll.ResultsHidden)
+			}
+		})
+	}
+}
+
+func Test_VotePoll(t *testing.T) {
+	open := &model.Tweet{Id: "open", Author: "tylermcginnis", Poll: newPoll(time.Now().Add(time.Hour), 3, 4)}
+	closed := &model.Tweet{Id: "closed", Author: "tylermcginnis", Poll: newPoll(time.Now().Add(-time.Hour), 3, 4)}
+	noPoll := &model.Tweet{Id: "no-poll", Author: "tylermcginnis"}
+	removed := &model.Tweet{Id: "removed", Author: "tylermcginnis", ModerationState: model.ModerationRemoved, Poll: newPoll(time.Now().Add(time.Hour), 3, 4)}
+
+	testCases := []struct {
+		name          string
+		tweet         *model.Tweet
+		option        int
+		buildStubs    func(repoMock *tweetsrepo.MockRepository)
+		expectedError error
+	}{
+		{
+			name:   "should count the vote",
+			tweet:  open,
+			option: 1,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().HasRelationInDynamoDb(gomock.Any(), "tylermcginnis", model.Block, "sarah_edo").Times(1).Return(false, nil)
+				repoMock.EXPECT().VotePollInDynamoDb(gomock.Any(), gomock.Any(), "tylermcginnis", gomock.Any()).Times(1).
+					DoAndReturn(func(ctx context.Context, vote *model.PollVote, author string, now time.Time) error {
+						assert.Equal(t, model.PollVote{TweetId: "open", UserId: "sarah_edo", Option: 1, Timestamp: model.ChirperAppUnixTime(now)}, *vote)
+						return nil
+					})
+			},
+		},
+		{
+			name:   "should vote once",
+			tweet:  open,
+			option: 0,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().HasRelationInDynamoDb(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+				repoMock.EXPECT().VotePollInDynamoDb(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1).Return(tweetsrepo.ErrAlreadyVoted)
+			},
+			expectedError: tweetsrepo.ErrAlreadyVoted,
+		},
+		{
+			name:          "should not vote on a closed poll",
+			tweet:         closed,
+			expectedError: tweetsrepo.ErrPollClosed,
+		},
+		{
+			name:          "should not vote for an option the poll does not have",
+			tweet:         open,
+			option:        2,
+			expectedError: &ValidationError{Violations: []FieldViolation{{"option", "option must be between 0 and 1"}}},
+		},
+		{
+			name:          "should not vote on a tweet without a poll",
+			tweet:         noPoll,
+			expectedError: ErrNoPoll,
+		},
+		{
+			name:          "should not vote on a tweet removed by moderation",
+			tweet:         removed,
+			expectedError: tweetsrepo.ErrTweetNotFound,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), tc.tweet.Id).MinTimes(1).DoAndReturn(func(ctx context.Context, tweetID string) (*model.Tweet, error) {
+				tweet := *tc.tweet
+				if tweet.Poll != nil {
+					poll := *tweet.Poll
+					poll.Options = append([]model.PollOption{}, poll.Options...)
+					tweet.Poll = &poll
+				}
+				return &tweet, nil
+			})
+			if tc.buildStubs != nil {
+				tc.buildStubs(repoMock)
+			}
+
+			poll, err := New(repoMock).VotePoll(context.Background(), "sarah_edo", tc.tweet.Id, tc.option)
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.option, *poll.VotedOption)
+			assert.False(t, poll.ResultsHidden)
+		})
+	}
+}
+
+func Test_ListTweets_Polls(t *testing.T) {
+	now := time.Now()
+	tweets := []*model.Tweet{
+		{Id: "voted", Author: "tylermcginnis", Poll: newPoll(now.Add(time.Hour), 3, 4)},
+		{Id: "not-voted", Author: "tylermcginnis", Poll: newPoll(now.Add(time.Hour), 3, 4)},
+		{Id: "closed", Author: "tylermcginnis", Poll: newPoll(now.Add(-time.Hour), 3, 4)},
+		{Id: "no-poll", Author: "tylermcginnis"},
+	}
+
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().ListRelationsFromDynamoDb(gomock.Any(), "sarah_edo").Times(1).Return(nil, nil)
+	repoMock.EXPECT().ScanTweetsFromDynamoDb(gomock.Any(), int32(10), "").Times(1).Return(tweets, "", nil)
+	repoMock.EXPECT().ListPollVotesFromDynamoDb(gomock.Any(), "sarah_edo", []string{"voted", "not-voted", "closed"}).Times(1).Return(map[string]int{"voted": 1}, nil)
+
+	list, _, err := New(repoMock).ListTweets(context.Background(), "sarah_edo", 0, "")
+	assert.NoError(t, err)
+	if assert.Len(t, list, 4) {
+		assert.Equal(t, 1, *list[0].Poll.VotedOption)
+		assert.Equal(t, int64(4), list[0].Poll.Options[1].Votes, "voters see the results")
+		assert.True(t, list[1].Poll.ResultsHidden)
+		assert.Equal(t, int64(0), list[1].Poll.Options[1].Votes, "the results are hidden until the viewer votes")
+		assert.False(t, list[2].Poll.ResultsHidden, "everybody sees the results of a closed poll")
+		assert.Equal(t, int64(4), list[2].Poll.Options[1].Votes)
+	}
+}
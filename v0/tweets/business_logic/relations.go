@@ -0,0 +1,117 @@
+package tweetsservice
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//ErrBlocked is returned when a user replies to, likes or votes on a tweet of someone who blocked them
+var ErrBlocked = errors.New("you can't reply to, like or vote on the tweets of a user who blocked you")
+
+const (
+	//how many pages ListTweets reads at most to fill a page the viewer's blocks and mutes emptied.
+	//It bounds the cost of a viewer who muted most of the active authors
+	maxListRounds = 10
+)
+
+//Block stops target from replying to or liking the tweets of userID, and hides the tweets of target from the lists of userID
+func (s *ServiceImpl) Block(ctx context.Context, userID, target string) error {
+	return s.saveRelation(ctx, userID, model.Block, target)
+}
+
+func (s *ServiceImpl) Unblock(ctx context.Context, userID, target string) error {
+	return s.deleteRelation(ctx, userID, model.Block, target)
+}
+
+//Mute hides the tweets of target from the lists of userID. Unlike Block, target can still reply and like
+func (s *ServiceImpl) Mute(ctx context.Context, userID, target string) error {
+	return s.saveRelation(ctx, userID, model.Mute, target)
+}
+
+func (s *ServiceImpl) Unmute(ctx context.Context, userID, target string) error {
+	return s.deleteRelation(ctx, userID, model.Mute, target)
+}
+
+//ListRelations returns every block, mute and follow of a user
+func (s *ServiceImpl) ListRelations(ctx context.Context, userID string) ([]*model.Relation, error) {
+	if userID == "" {
+		return nil, errors.New("userID is required")
+	}
+	return s.repo.ListRelationsFromDynamoDb(ctx, userID)
+}
+
+func (s *ServiceImpl) saveRelation(ctx context.Context, userID string, kind model.RelationKind, target string) error {
+	if err := validateRelation(userID, kind, target); err != nil {
+		return err
+	}
+	return s.repo.SaveRelationInDynamoDb(ctx, &model.Relation{
+		UserId:    userID,
+		Kind:      kind,
+		Target:    target,
+		Timestamp: model.ChirperAppUnixTime(time.Now()),
+	})
+}
+
+func (s *ServiceImpl) deleteRelation(ctx context.Context, userID string, kind model.RelationKind, target string) error {
+	if err := validateRelation(userID, kind, target); err != nil {
+		return err
+	}
+	return s.repo.DeleteRelationFromDynamoDb(ctx, userID, kind, target)
+}
+
+func validateRelation(userID string, kind model.RelationKind, target string) error {
+	var errs []FieldViolation
+	if userID == "" {
+		errs = append(errs, FieldViolation{"userId", "userId is required"})
+	}
+	if target == "" {
+		errs = append(errs, FieldViolation{"target", "target is required"})
+	}
+	if userID != "" && userID == target {
+		errs = append(errs, FieldViolation{"target", "you can't " + string(kind) + " yourself"})
+	}
+	if len(errs) > 0 {
+		return &ValidationError{Violations: errs}
+	}
+	return nil
+}
+
+//checkNotBlocked returns ErrBlocked when owner blocked userID
+func (s *ServiceImpl) checkNotBlocked(ctx context.Context, owner, userID string) error {
+	if owner == "" || owner == userID {
+		return nil
+	}
+	blocked, err := s.repo.HasRelationInDynamoDb(ctx, owner, model.Block, userID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrBlocked
+	}
+	return nil
+}
+
+//viewerRelations reads the relations of the viewer once per list: the authors they blocked or muted, who are left out,
+//and the users they follow, for the followers only tweets. An anonymous viewer has none
+func (s *ServiceImpl) viewerRelations(ctx context.Context, viewer string) (hidden, follows map[string]bool, err error) {
+	if viewer == "" {
+		return nil, nil, nil
+	}
+	relations, err := s.repo.ListRelationsFromDynamoDb(ctx, viewer)
+	if err != nil {
+		return nil, nil, err
+	}
+	hidden, follows = map[string]bool{}, map[string]bool{}
+	for _, rel := range relations {
+		switch rel.Kind {
+		case model.Block, model.Mute:
+			hidden[rel.Target] = true
+		case model.Follow:
+			follows[rel.Target] = true
+		}
+	}
+	return hidden, follows, nil
+}
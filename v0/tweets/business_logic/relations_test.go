@@ -0,0 +1,136 @@
+package tweetsservice
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func Test_Block(t *testing.T) {
+	testCases := []struct {
+		name   string
+		userID string
+		target string
+
+		expectedRepoCallTimes int
+		expectedError         error
+	}{
+		{name: "should save the block", userID: "sarah_edo", target: "tylermcginnis", expectedRepoCallTimes: 1},
+		{
+			name: "should not block yourself", userID: "sarah_edo", target: "sarah_edo",
+			expectedError: &ValidationError{Violations: []FieldViolation{{"target", "you can't block yourself"}}},
+		},
+		{
+			name: "should require both users",
+			expectedError: &ValidationError{Violations: []FieldViolation{{"userId", "userId is required"}, {"target", "target is required"}}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			repoMock.EXPECT().SaveRelationInDynamoDb(gomock.Any(), gomock.Any()).Times(tc.expectedRepoCallTimes).DoAndReturn(func(ctx context.Context, rel *model.Relation) error {
+				assert.Equal(t, model.Block, rel.Kind)
+				assert.Equal(t, tc.userID, rel.UserId)
+				assert.Equal(t, tc.target, rel.Target)
+				assert.False(t, isTimestampUnset(rel.Timestamp))
+				return nil
+			})
+
+			err := New(repoMock).Block(context.Background(), tc.userID, tc.target)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func Test_Unmute(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().DeleteRelationFromDynamoDb(gomock.Any(), "sarah_edo", model.Mute, "tylermcginnis").Times(1).Return(nil)
+
+	assert.NoError(t, New(repoMock).Unmute(context.Background(), "sarah_edo", "tylermcginnis"))
+}
+
+func Test_SaveTweet_Blocked(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().HasRelationInDynamoDb(gomock.Any(), "sarah_edo", model.Block, "tylermcginnis").Times(1).Return(true, nil)
+	repoMock.EXPECT().SaveTweetToDynamoDb(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := New(repoMock).SaveTweet(context.Background(), &model.Tweet{Author: "tylermcginnis", Text: "hi", ReplyingTo: "8xf0y6ziyjabvozdd253nd:sarah_edo"})
+	assert.ErrorIs(t, err, ErrBlocked)
+}
+
+func Test_SaveLikeToggle_Blocked(t *testing.T) {
+	testCases := []struct {
+		name     string
+		hasLiked bool
+		blocked  bool
+
+		expectedHasRelationCallTimes int
+		expectedRepoCallTimes        int
+		expectedError                error
+	}{
+		{name: "should not let a blocked user like", blocked: true, expectedHasRelationCallTimes: 1, expectedError: ErrBlocked},
+		{name: "should let a user like", expectedHasRelationCallTimes: 1, expectedRepoCallTimes: 1},
+		{name: "should let a blocked user take a like back", hasLiked: true, blocked: true, expectedRepoCallTimes: 1},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			repoMock.EXPECT().HasRelationInDynamoDb(gomock.Any(), "sarah_edo", model.Block, "tylermcginnis").Times(tc.expectedHasRelationCallTimes).Return(tc.blocked, nil)
+			repoMock.EXPECT().SaveLikeToggleInDynamoDb(gomock.Any(), "8xf0y6ziyjabvozdd253nd", "sarah_edo", "tylermcginnis", tc.hasLiked).Times(tc.expectedRepoCallTimes).Return(nil)
+
+			err := New(repoMock).SaveLikeToggle(context.Background(), "8xf0y6ziyjabvozdd253nd", "sarah_edo", "tylermcginnis", tc.hasLiked)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func Test_ListTweets_Relations(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().ListRelationsFromDynamoDb(gomock.Any(), "sarah_edo").Times(1).Return([]*model.Relation{
+		{UserId: "sarah_edo", Kind: model.Block, Target: "spammer"},
+		{UserId: "sarah_edo", Kind: model.Mute, Target: "loud"},
+	}, nil)
+	gomock.InOrder(
+		repoMock.EXPECT().ScanTweetsFromDynamoDb(gomock.Any(), int32(3), "").Times(1).Return([]*model.Tweet{
+			{Id: "1", Author: "spammer"}, {Id: "2", Author: "tylermcginnis"}, {Id: "3", Author: "loud"},
+		}, "page-2", nil),
+		//only what is missing from the page is asked for
+		repoMock.EXPECT().ScanTweetsFromDynamoDb(gomock.Any(), int32(2), "page-2").Times(1).Return([]*model.Tweet{
+			{Id: "4", Author: "loud"}, {Id: "5", Author: "dan_abramov"},
+		}, "page-3", nil),
+		repoMock.EXPECT().ScanTweetsFromDynamoDb(gomock.Any(), int32(1), "page-3").Times(1).Return([]*model.Tweet{
+			{Id: "6", Author: "sarah_edo"},
+		}, "page-4", nil),
+	)
+
+	tweets, next, err := New(repoMock).ListTweets(context.Background(), "sarah_edo", 3, "")
+	assert.NoError(t, err)
+	ids := []string{}
+	for _, tweet := range tweets {
+		ids = append(ids, tweet.Id)
+	}
+	assert.Equal(t, []string{"2", "5", "6"}, ids)
+	assert.Equal(t, "page-4", next)
+}
+
+func Test_ListTweets_EndOfTable(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().ListRelationsFromDynamoDb(gomock.Any(), "sarah_edo").Times(1).Return([]*model.Relation{{UserId: "sarah_edo", Kind: model.Mute, Target: "loud"}}, nil)
+	repoMock.EXPECT().ScanTweetsFromDynamoDb(gomock.Any(), int32(10), "").Times(1).Return([]*model.Tweet{{Id: "1", Author: "loud"}}, "", nil)
+
+	tweets, next, err := New(repoMock).ListTweets(context.Background(), "sarah_edo", 0, "")
+	assert.NoError(t, err)
+	assert.Empty(t, tweets)
+	assert.Equal(t, "", next, "a short last page ends the list")
+}
@@ -0,0 +1,229 @@
+package tweetsservice
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/metrics"
+	repo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+const (
+	//how far ahead a tweet can be scheduled
+	maxScheduleAhead = 365 * 24 * time.Hour
+	//how long a publisher holds a claimed tweet. Another publisher takes it over after that, eg when the first one died
+	scheduledClaimTTL = 2 * time.Minute
+	//how long a published scheduled tweet stays listed before the TTL deletes it
+	publishedRetention = 7 * 24 * time.Hour
+	//how many due tweets of a day bucket one round publishes. The rest waits for the next round
+	publishBatchSize int32 = 25
+	//the first retry waits retryBaseDelay, every other one twice as long as the one before, up to retryMaxDelay
+	retryBaseDelay = time.Minute
+	retryMaxDelay  = time.Hour
+)
+
+//SetScheduling sets how many times the publisher tries a scheduled tweet before it fails for good, and how far back it
+//looks for due tweets. Tweets overdue by more than lookback(eg the publisher was down for longer) are not published
+func (s *ServiceImpl) SetScheduling(maxAttempts int, lookback time.Duration) {
+	if maxAttempts > 0 {
+		s.scheduleMaxAttempts = maxAttempts
+	}
+	if lookback > 0 {
+		s.scheduleLookback = lookback
+	}
+}
+
+//ScheduleTweet saves a tweet to publish at publishAt. The tweet is checked like SaveTweet checks it, and again when it is
+//published: moderation, blocks and the audience of a reply are only decided then. The poll closes after publishAt
+//like for a tweet published then, and the media are attached to the scheduled tweet right away
+func (s *ServiceImpl) ScheduleTweet(ctx context.Context, tweet *model.Tweet, publishAt time.Time) (*model.ScheduledTweet, error) {
+	now := time.Now()
+	//the tweet gets its timestamp when it is published
+	tweet.Timestamp = model.ChirperAppUnixTime{}
+	errs := s.validateTweet(tweet, true, now)
+	if !publishAt.IsZero() {
+		errs = append(errs, newPollViolations(tweet.Poll, publishAt)...)
+	}
+	switch {
+	case publishAt.IsZero():
+		errs = append(errs, FieldViolation{"publishAt", "publishAt is required"})
+	case publishAt.Before(now):
+		errs = append(errs, FieldViolation{"publishAt", "publishAt must be in the future"})
+	case publishAt.After(now.Add(maxScheduleAhead)):
+		errs = append(errs, FieldViolation{"publishAt", "publishAt cannot be more than a year ahead"})
+	}
+	if len(errs) > 0 {
+		return nil, &ValidationError{Violations: errs}
+	}
+	if tweet.Poll != nil {
+		for i := range tweet.Poll.Options {
+			tweet.Poll.Options[i].Votes = 0
+		}
+	}
+	//the media are checked with the id the published tweet gets
+	tweet.Id = uuid.NewString()
+	if err := s.checkMedia(ctx, tweet); err != nil {
+		return nil, err
+	}
+
+	scheduled := &model.ScheduledTweet{
+		Id:         tweet.Id,
+		Author:     tweet.Author,
+		Text:       tweet.Text,
+		ReplyingTo: tweet.ReplyingTo,
+		Audience:   tweet.Audience,
+		Poll:       tweet.Poll,
+		MediaIds:   tweet.MediaIds,
+		PublishAt:  model.ChirperAppUnixTime(publishAt),
+		Status:     model.ScheduledWaiting,
+		DueBucket:  model.DueBucket(publishAt),
+		DueAt:      model.ChirperAppUnixTime(publishAt),
+		CreatedAt:  model.ChirperAppUnixTime(now),
+	}
+	if err := s.repo.SaveScheduledTweetToDynamoDb(ctx, scheduled); err != nil {
+		return nil, err
+	}
+	metrics.ScheduledTweets.WithLabelValues("scheduled").Inc()
+	return scheduled, nil
+}
+
+//ListScheduled returns the scheduled tweets of an author, the next one to go out first. It pages like ListTweets
+func (s *ServiceImpl) ListScheduled(ctx context.Context, author string, limit int32, nextKey string) ([]*model.ScheduledTweet, string, error) {
+	if author == "" {
+		return nil, "", &ValidationError{Violations: []FieldViolation{{"author", "author is required"}}}
+	}
+	limit, err := s.pageLimit(limit)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.repo.ListScheduledTweetsFromDynamoDb(ctx, author, nextKey, limit)
+}
+
+//CancelScheduled deletes a scheduled tweet of author that was not published. It returns repo.ErrScheduledTweetNotFound
+//for the tweets of other authors too, and repo.ErrScheduledTweetNotWaiting once the publisher has the tweet
+func (s *ServiceImpl) CancelScheduled(ctx context.Context, author, id string) error {
+	if author == "" || id == "" {
+		return &ValidationError{Violations: []FieldViolation{{"id", "author and id are required"}}}
+	}
+	scheduled, err := s.repo.GetScheduledTweetFromDynamoDb(ctx, id)
+	if err != nil {
+		return err
+	}
+	if scheduled.Author != author {
+		return repo.ErrScheduledTweetNotFound
+	}
+	//the delete checks the status again, the publisher may claim the tweet in between
+	if err := s.repo.DeleteScheduledTweetFromDynamoDb(ctx, scheduled); err != nil {
+		return err
+	}
+	metrics.ScheduledTweets.WithLabelValues("cancelled").Inc()
+	return nil
+}
+
+//PublishDueTweets is one round of the publisher: every tweet due at now is claimed and published through SaveTweet.
+//Several publishers can run at the same time, each tweet is claimed by one of them. A failed tweet is retried later,
+//unless it can never be published(eg moderation rejects it) or it ran out of attempts
+func (s *ServiceImpl) PublishDueTweets(ctx context.Context, now time.Time) (*model.PublishReport, error) {
+	report := &model.PublishReport{}
+	day := 24 * time.Hour
+	for d := now.Add(-s.scheduleLookback).UTC().Truncate(day); !d.After(now); d = d.Add(day) {
+		due, err := s.repo.ListDueScheduledTweetsFromDynamoDb(ctx, model.DueBucket(d), now, publishBatchSize)
+		if err != nil {
+			return report, err
+		}
+		for _, scheduled := range due {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			report.Due++
+			if err := s.publishScheduled(ctx, scheduled.Id, now, report); err != nil {
+				//the claim runs out and the tweet is picked up again
+				slog.ErrorContext(ctx, "unable to publish a scheduled tweet", "scheduledId", scheduled.Id, "err", err)
+			}
+		}
+	}
+	return report, nil
+}
+
+//publishScheduled publishes one scheduled tweet if this publisher gets the claim on it, and records how it went
+func (s *ServiceImpl) publishScheduled(ctx context.Context, id string, now time.Time, report *model.PublishReport) error {
+	claim := uuid.NewString()
+	scheduled, err := s.repo.ClaimScheduledTweetInDynamoDb(ctx, id, claim, now, now.Add(scheduledClaimTTL))
+	if errors.Is(err, repo.ErrScheduledTweetNotWaiting) {
+		report.Skipped++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	tweetID, err := s.publish(ctx, scheduled)
+	if err == nil {
+		report.Published++
+		metrics.ScheduledTweets.WithLabelValues("published").Inc()
+		slog.InfoContext(ctx, "scheduled tweet published", "scheduledId", id, "tweetId", tweetID, "author", scheduled.Author)
+		return s.repo.CompleteScheduledTweetInDynamoDb(ctx, id, claim, tweetID, now.Add(publishedRetention))
+	}
+
+	attempts := scheduled.Attempts + 1
+	var retryAt time.Time
+	if !permanentPublishError(err) && attempts < s.scheduleMaxAttempts {
+		retryAt = now.Add(retryDelay(attempts))
+		report.Retried++
+		metrics.ScheduledTweets.WithLabelValues("retried").Inc()
+	} else {
+		report.Failed++
+		metrics.ScheduledTweets.WithLabelValues("failed").Inc()
+	}
+	slog.WarnContext(ctx, "scheduled tweet not published", "scheduledId", id, "author", scheduled.Author, "attempts", attempts, "retryAt", retryAt, "err", err)
+	return s.repo.FailScheduledTweetInDynamoDb(ctx, id, claim, err.Error(), retryAt)
+}
+
+//publish saves the scheduled tweet through SaveTweet and returns the id of the tweet
+func (s *ServiceImpl) publish(ctx context.Context, scheduled *model.ScheduledTweet) (string, error) {
+	//the tweet has the id of the scheduled tweet. When it exists, a publisher saved it and died before recording it, so we
+	//don't save it again(that would reset its likes and replies)
+	existing, err := s.repo.GetTweetFromDynamoDb(ctx, scheduled.Id)
+	if err == nil {
+		return existing.Id, nil
+	}
+	if !errors.Is(err, repo.ErrTweetNotFound) {
+		return "", err
+	}
+
+	tweet, err := s.SaveTweet(ctx, &model.Tweet{
+		Id:         scheduled.Id,
+		Author:     scheduled.Author,
+		Text:       scheduled.Text,
+		ReplyingTo: scheduled.ReplyingTo,
+		Audience:   scheduled.Audience,
+		Poll:       scheduled.Poll,
+		MediaIds:   scheduled.MediaIds,
+	})
+	if err != nil {
+		return "", err
+	}
+	return tweet.Id, nil
+}
+
+//permanentPublishError is true for the errors another attempt would get again
+func permanentPublishError(err error) bool {
+	var invalid *ValidationError
+	//ErrTweetNotFound: the tweet it replies to is gone or the author can't see it anymore
+	return errors.As(err, &invalid) || errors.Is(err, ErrBlocked) || errors.Is(err, repo.ErrTweetNotFound)
+}
+
+func retryDelay(attempt int) time.Duration {
+	d := retryBaseDelay
+	for i := 1; i < attempt && d < retryMaxDelay; i++ {
+		d *= 2
+	}
+	if d > retryMaxDelay {
+		return retryMaxDelay
+	}
+	return d
+}
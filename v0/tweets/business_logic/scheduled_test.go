@@ -0,0 +1,181 @@
+package tweetsservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	tweetsrepo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func Test_ScheduleTweet(t *testing.T) {
+	publishAt := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		name      string
+		tweet     *model.Tweet
+		publishAt time.Time
+
+		expectedError error
+	}{
+		{
+			name:      "should schedule a tweet",
+			tweet:     &model.Tweet{Author: "sarah_edo", Text: "Happy new year!", Audience: &model.Audience{Level: "Followers"}},
+			publishAt: publishAt,
+		},
+		{
+			name:          "should not schedule a tweet in the past",
+			tweet:         &model.Tweet{Author: "sarah_edo", Text: "Happy new year!"},
+			publishAt:     time.Now().Add(-time.Hour),
+			expectedError: &ValidationError{Violations: []FieldViolation{{"publishAt", "publishAt must be in the future"}}},
+		},
+		{
+			name:  "should list every problem",
+			tweet: &model.Tweet{Author: "sarah_edo", ReplyingTo: "no-author"},
+			expectedError: &ValidationError{Violations: []FieldViolation{
+				{"replyingTo", "invalid format for replyingTo. It should be eg {reply_tweet_id}:{reply_tweet_author}"},
+				{"text", "text is required"},
+				{"publishAt", "publishAt is required"},
+			}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			var saved *model.ScheduledTweet
+			repoMock.EXPECT().SaveScheduledTweetToDynamoDb(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, scheduled *model.ScheduledTweet) error {
+				saved = scheduled
+				return nil
+			})
+
+			scheduled, err := New(repoMock).ScheduleTweet(context.Background(), tc.tweet, tc.publishAt)
+			assert.Equal(t, tc.expectedError, err)
+			if tc.expectedError == nil {
+				assert.Equal(t, saved, scheduled)
+				assert.Equal(t, model.ScheduledWaiting, saved.Status)
+				assert.Equal(t, model.DueBucket(publishAt), saved.DueBucket)
+				assert.Equal(t, model.AudienceFollowers, saved.Audience.Level)
+			}
+		})
+	}
+}
+
+func Test_ScheduleTweet_PollAndMedia(t *testing.T) {
+	publishAt := time.Now().Add(24 * time.Hour)
+	newTweet := func(closesAt time.Time, mediaIds ...string) *model.Tweet {
+		return &model.Tweet{Author: "sarah_edo", Text: "Tabs or spaces?", MediaIds: mediaIds, Poll: &model.Poll{
+			Options:  []model.PollOption{{Text: "Tabs", Votes: 10}, {Text: "Spaces"}},
+			ClosesAt: model.ChirperAppUnixTime(closesAt),
+		}}
+	}
+
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().ListMediaFromDynamoDb(gomock.Any(), gomock.Any()).AnyTimes().Return([]*model.Media{
+		{Id: "mine", UserId: "sarah_edo"},
+		{Id: "attached", UserId: "sarah_edo", TweetId: "another-tweet"},
+	}, nil)
+	var saved *model.ScheduledTweet
+	repoMock.EXPECT().SaveScheduledTweetToDynamoDb(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(ctx context.Context, scheduled *model.ScheduledTweet) error {
+		saved = scheduled
+		return nil
+	})
+	service := New(repoMock)
+
+	_, err := service.ScheduleTweet(context.Background(), newTweet(publishAt.Add(time.Hour), "mine"), publishAt)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []model.PollOption{{Text: "Tabs"}, {Text: "Spaces"}}, saved.Poll.Options, "the votes start when it is published")
+	assert.Equal(t, []string{"mine"}, saved.MediaIds)
+
+	_, err = service.ScheduleTweet(context.Background(), newTweet(publishAt.Add(-time.Hour)), publishAt)
+	assert.Equal(t, &ValidationError{Violations: []FieldViolation{{"poll.closesAt", "a poll stays open from 5m0s to 168h0m0s"}}}, err, "the poll closes after it is published")
+	_, err = service.ScheduleTweet(context.Background(), newTweet(publishAt.Add(time.Hour), "attached"), publishAt)
+	assert.Equal(t, &ValidationError{Violations: []FieldViolation{{"mediaIds", "media attached is attached to another tweet"}}}, err)
+
+	//the publisher passes them on to SaveTweet
+	now := time.Time(saved.PublishAt)
+	repoMock.EXPECT().ListDueScheduledTweetsFromDynamoDb(gomock.Any(), model.DueBucket(now), now, publishBatchSize).Times(1).Return([]*model.ScheduledTweet{saved}, nil)
+	repoMock.EXPECT().ClaimScheduledTweetInDynamoDb(gomock.Any(), saved.Id, gomock.Any(), now, now.Add(scheduledClaimTTL)).Return(saved, nil)
+	repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), saved.Id).Return(nil, tweetsrepo.ErrTweetNotFound)
+	repoMock.EXPECT().SaveTweetToDynamoDb(gomock.Any(), "", gomock.Any()).Times(1).DoAndReturn(func(ctx context.Context, replyingToAuthor string, tweet *model.Tweet) (*model.Tweet, error) {
+		assert.Equal(t, saved.Id, tweet.Id)
+		assert.Equal(t, saved.Poll, tweet.Poll)
+		assert.Equal(t, []string{"mine"}, tweet.MediaIds)
+		return tweet, nil
+	})
+	repoMock.EXPECT().CompleteScheduledTweetInDynamoDb(gomock.Any(), saved.Id, gomock.Any(), saved.Id, now.Add(publishedRetention)).Return(nil)
+	//only the day bucket of now
+	service.SetScheduling(3, time.Nanosecond)
+	report, err := service.PublishDueTweets(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Published)
+}
+
+func Test_PublishDueTweets(t *testing.T) {
+	now := time.Date(2026, time.January, 1, 0, 10, 0, 0, time.UTC)
+	throttled := errors.New("ProvisionedThroughputExceededException")
+	due := []*model.ScheduledTweet{
+		{Id: "ok", Author: "sarah_edo", Text: "Happy new year!"},
+		{Id: "taken", Author: "sarah_edo", Text: "another publisher has it"},
+		{Id: "saved-before", Author: "sarah_edo", Text: "a publisher died after saving it"},
+		{Id: "flaky", Author: "sarah_edo", Text: "throttled"},
+		{Id: "last-attempt", Author: "sarah_edo", Text: "throttled", Attempts: 2},
+		{Id: "rejected", Author: "sarah_edo", Text: " "},
+	}
+
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().ListDueScheduledTweetsFromDynamoDb(gomock.Any(), "2025-12-31", now, publishBatchSize).Times(1).Return(nil, nil)
+	repoMock.EXPECT().ListDueScheduledTweetsFromDynamoDb(gomock.Any(), "2026-01-01", now, publishBatchSize).Times(1).Return(due, nil)
+	for _, scheduled := range due {
+		scheduled := scheduled
+		if scheduled.Id == "taken" {
+			repoMock.EXPECT().ClaimScheduledTweetInDynamoDb(gomock.Any(), "taken", gomock.Any(), now, now.Add(scheduledClaimTTL)).Return(nil, tweetsrepo.ErrScheduledTweetNotWaiting)
+			continue
+		}
+		repoMock.EXPECT().ClaimScheduledTweetInDynamoDb(gomock.Any(), scheduled.Id, gomock.Any(), now, now.Add(scheduledClaimTTL)).Return(scheduled, nil)
+	}
+	repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), "saved-before").Return(&model.Tweet{Id: "saved-before"}, nil)
+	repoMock.EXPECT().GetTweetFromDynamoDb(gomock.Any(), gomock.Any()).AnyTimes().Return(nil, tweetsrepo.ErrTweetNotFound)
+	repoMock.EXPECT().SaveTweetToDynamoDb(gomock.Any(), "", gomock.Any()).Times(3).DoAndReturn(func(ctx context.Context, replyingToAuthor string, tweet *model.Tweet) (*model.Tweet, error) {
+		if tweet.Text == "throttled" {
+			return nil, throttled
+		}
+		return tweet, nil
+	})
+	repoMock.EXPECT().CompleteScheduledTweetInDynamoDb(gomock.Any(), "ok", gomock.Any(), "ok", now.Add(publishedRetention)).Return(nil)
+	repoMock.EXPECT().CompleteScheduledTweetInDynamoDb(gomock.Any(), "saved-before", gomock.Any(), "saved-before", now.Add(publishedRetention)).Return(nil)
+	repoMock.EXPECT().FailScheduledTweetInDynamoDb(gomock.Any(), "flaky", gomock.Any(), throttled.Error(), now.Add(retryBaseDelay)).Return(nil)
+	repoMock.EXPECT().FailScheduledTweetInDynamoDb(gomock.Any(), "last-attempt", gomock.Any(), throttled.Error(), time.Time{}).Return(nil)
+	repoMock.EXPECT().FailScheduledTweetInDynamoDb(gomock.Any(), "rejected", gomock.Any(), "text is required", time.Time{}).Return(nil)
+
+	service := New(repoMock)
+	service.SetScheduling(3, 24*time.Hour)
+	report, err := service.PublishDueTweets(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.PublishReport{Due: 6, Published: 2, Retried: 1, Failed: 2, Skipped: 1}, report)
+}
+
+func Test_RetryDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, retryDelay(1))
+	assert.Equal(t, 4*time.Minute, retryDelay(3))
+	assert.Equal(t, time.Hour, retryDelay(20))
+}
+
+func Test_CancelScheduled(t *testing.T) {
+	repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+	repoMock.EXPECT().GetScheduledTweetFromDynamoDb(gomock.Any(), "scheduled-1").AnyTimes().Return(&model.ScheduledTweet{Id: "scheduled-1", Author: "sarah_edo"}, nil)
+	repoMock.EXPECT().DeleteScheduledTweetFromDynamoDb(gomock.Any(), &model.ScheduledTweet{Id: "scheduled-1", Author: "sarah_edo"}).Times(1).Return(nil)
+	service := New(repoMock)
+
+	assert.ErrorIs(t, service.CancelScheduled(context.Background(), "tylermcginnis", "scheduled-1"), tweetsrepo.ErrScheduledTweetNotFound, "the tweets of others look like they don't exist")
+	assert.NoError(t, service.CancelScheduled(context.Background(), "sarah_edo", "scheduled-1"))
+}
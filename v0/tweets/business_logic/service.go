@@ -4,89 +4,378 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/blob"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/metrics"
 	repo "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/data_access"
 	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetsmoderation "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/moderation"
+	tweetstext "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/text"
 )
 
 type ServiceImpl struct {
 	repo repo.Repository
+	listDefaultLimit int32
+	listMaxLimit int32
+	maxTextLength int
+	moderator *tweetsmoderation.Chain
+	//see SetScheduling
+	scheduleMaxAttempts int
+	scheduleLookback time.Duration
+	//see SetMedia
+	media blob.Store
+	mediaLimits MediaLimits
 }
 
 func New(repo repo.Repository) *ServiceImpl {
-	return &ServiceImpl{repo}
+	return &ServiceImpl{repo: repo, listDefaultLimit: 10, listMaxLimit: 30, maxTextLength: tweetstext.DefaultMaxLength,
+		scheduleMaxAttempts: 5, scheduleLookback: 7 * 24 * time.Hour,
+		mediaLimits: MediaLimits{MaxImageBytes: 5 << 20, MaxVideoBytes: 50 << 20, OrphanTTL: 24 * time.Hour}}
+}
+
+//SetMaxTextLength sets how long the text of a tweet can be, in characters as tweetstext.Length counts them
+func (s *ServiceImpl) SetMaxTextLength(n int) {
+	if n > 0 {
+		s.maxTextLength = n
+	}
+}
+
+//SetModerator sets the moderation rules every tweet of SaveTweet and BulkSaveTweet goes through. nil(the default) turns
+//moderation off
+func (s *ServiceImpl) SetModerator(m *tweetsmoderation.Chain) {
+	s.moderator = m
+}
+
+//SetListLimits sets the page size ListTweets uses when the client does not ask for one, and the biggest page a client can ask for
+func (s *ServiceImpl) SetListLimits(defaultLimit, maxLimit int32) {
+	if defaultLimit > 0 && maxLimit >= defaultLimit {
+		s.listDefaultLimit = defaultLimit
+		s.listMaxLimit = maxLimit
+	}
 }
 
 func (s *ServiceImpl) SaveTweet(ctx context.Context, tweet *model.Tweet) (*model.Tweet, error){
+	replyingToAuthor, err := s.prepareTweet(ctx, tweet)
+	if err != nil {
+		return nil, err
+	}
+
+	newTweet, err := s.repo.SaveTweetToDynamoDb(ctx, replyingToAuthor,tweet)
+	if err != nil {
+		return nil, err
+	}
+	countCreated(newTweet)
+	//nobody voted on a new poll yet: its results are hidden the way showPolls hides them from viewers who did not vote
+	if err := s.showPolls(ctx, "", []*model.Tweet{newTweet}, time.Now()); err != nil {
+		return nil, err
+	}
+	return newTweet, nil
+}
+
+//prepareTweet runs every check of SaveTweet(validation, blocks, audience, media and moderation) and fills in what the client left out.
+//It returns the author of the tweet being replied to
+func (s *ServiceImpl) prepareTweet(ctx context.Context, tweet *model.Tweet) (string, error) {
 	var replyingToAuthor string
-	if tweet.Author == "" {
-		return nil, errors.New("author is required")
+	now := time.Now()
+	errs := s.validateTweet(tweet, true, now)
+	if errs = append(errs, newPollViolations(tweet.Poll, now)...); len(errs) > 0 {
+		return "", &ValidationError{Violations: errs}
+	}
+	if tweet.Poll != nil {
+		//a new poll starts without votes
+		for i := range tweet.Poll.Options {
+			tweet.Poll.Options[i].Votes = 0
+		}
 	}
 
-	if tweet.ReplyingTo != "" {		
-		tokens := strings.Split(tweet.ReplyingTo, ":")
+	if tweet.ReplyingTo != "" {
+		tweet.ReplyingTo, replyingToAuthor = splitReplyingTo(tweet.ReplyingTo)
+		if err := s.checkNotBlocked(ctx, replyingToAuthor, tweet.Author); err != nil {
+			return "", err
+		}
+	}
+	if err := s.setAudience(ctx, tweet); err != nil {
+		return "", err
+	}
+	if err := s.checkMedia(ctx, tweet); err != nil {
+		return "", err
+	}
 
-		if len(tokens) != 2{
-			return nil, errors.New("invalid format for replyingTo. It should be eg {reply_tweet_id}:{reply_tweet_author}")
+	//only the moderation rules decide whether a new tweet is held for review
+	tweet.ModerationState, tweet.ModerationReasons = "", nil
+	if s.moderator != nil {
+		result := s.moderator.Moderate(ctx, tweet)
+		switch result.Verdict {
+		case tweetsmoderation.Reject:
+			return "", &ValidationError{Violations: []FieldViolation{{"text", "text was rejected by moderation, " + result.Reasons[0]}}}
+		case tweetsmoderation.Flag:
+			tweet.ModerationState = model.ModerationPending
+			tweet.ModerationReasons = result.Reasons
 		}
-		tweet.ReplyingTo = tokens[0]
-		replyingToAuthor = tokens[1]
 	}
 
-	if tweet.Timestamp.IsZero() {
-			tweet.Timestamp =  model.ChirperAppUnixTime(time.Now())
+	if isTimestampUnset(tweet.Timestamp) {
+			tweet.Timestamp =  model.ChirperAppUnixTime(now)
 	}
 
 	if tweet.Id == "" {
 		tweet.Id = uuid.NewString()
 	}
+	return replyingToAuthor, nil
+}
 
-	newTweet, err := s.repo.SaveTweetToDynamoDb(ctx, replyingToAuthor,tweet)
-	if err != nil {
-		return nil, err
+func countCreated(tweet *model.Tweet) {
+	if tweet.ReplyingTo != "" {
+		metrics.TweetsCreated.WithLabelValues("reply").Inc()
+	} else {
+		metrics.TweetsCreated.WithLabelValues("tweet").Inc()
 	}
-	return newTweet, nil
 }
 
+//BulkSaveTweet validates every tweet first and saves them only when all of them are valid. The poll votes, moderation
+//state and audience of the payload go through prepareImport. Any number of tweets can be
+//passed: they are written model.MaxBatchSize at a time. When a write fails it returns a BulkSaveError with how many
+//tweets were written before
 func (s *ServiceImpl) BulkSaveTweet(ctx context.Context, tweets []*model.Tweet) error {
 	if len(tweets) == 0 {
 		return errors.New("cannot perform action on an empty list")
 	}
 
+	now := time.Now()
+	seen := make(map[string]bool, len(tweets))
 	for _, tweet := range tweets {
-		if tweet.Author == "" {
-			return fmt.Errorf("author is required for tweetID: %s", tweet.Id)
+		if errs := s.validateTweet(tweet, false, now); len(errs) > 0 {
+			metrics.ImportRows.WithLabelValues("rejected").Add(float64(len(tweets)))
+			return fmt.Errorf("%s for tweetID: %s", errs[0].Description, tweet.Id)
+		}
+		if tweet.Id != "" && seen[tweet.Id] {
+			metrics.ImportRows.WithLabelValues("rejected").Add(float64(len(tweets)))
+			return fmt.Errorf("duplicate tweetID: %s", tweet.Id)
 		}
+		seen[tweet.Id] = true
+	}
+	_, rejected, err := s.prepareImport(ctx, tweets)
+	if err != nil {
+		return err
+	}
+	if len(rejected) > 0 {
+		metrics.ImportRows.WithLabelValues("rejected").Add(float64(len(tweets)))
+		return fmt.Errorf("%s for tweetID: %s", rejected[0].Message, rejected[0].Id)
 	}
 
 	for i := range tweets {
-		if tweets[i].Timestamp.IsZero() {
-			tweets[i].Timestamp =  model.ChirperAppUnixTime(time.Now())
+		if isTimestampUnset(tweets[i].Timestamp) {
+			tweets[i].Timestamp =  model.ChirperAppUnixTime(now)
 		}
 
 		if tweets[i].Id == "" {
 			tweets[i].Id = uuid.NewString()
 		}
-			
+
+		//the tweets table only keeps the id of the parent tweet
+		tweets[i].ReplyingTo, _ = splitReplyingTo(tweets[i].ReplyingTo)
 	}
 
-	if err := s.repo.BulkSaveTweetToDynamoDb(ctx, tweets); err != nil {
-		return err
+	//a BatchWriteItem takes at most MaxBatchSize items, so the tweets are written a batch at a time whoever calls us
+	saved := 0
+	for _, batch := range model.Batches(tweets, model.MaxBatchSize) {
+		if err := s.repo.BulkSaveTweetToDynamoDb(ctx, batch); err != nil {
+			var unprocessed *repo.UnprocessedTweetsError
+			if errors.As(err, &unprocessed) {
+				saved += len(batch) - len(unprocessed.Ids)
+			}
+			metrics.ImportRows.WithLabelValues("saved").Add(float64(saved))
+			metrics.ImportRows.WithLabelValues("failed").Add(float64(len(tweets) - saved))
+			return &BulkSaveError{Saved: saved, Err: err}
+		}
+		saved += len(batch)
 	}
+	metrics.ImportRows.WithLabelValues("saved").Add(float64(saved))
 	return nil
 }
 
-func (s *ServiceImpl) ListTweets(ctx context.Context, limit int32, nextKey string) ([]*model.Tweet, string, error) {
-	if (limit <= 0){
-		limit = 10
-	} else if limit > 30 {
-		return nil, "", errors.New("limit cannot be more than 30")
+//ValidateBulkTweets runs a bulk payload through the same checks as BulkSaveTweet, plus lookups against the users and tweets tables, without writing anything.
+//Unlike BulkSaveTweet it does not stop at the first bad record; every issue is in the report. What the import would
+//rewrite(see prepareImport) is in the warnings
+func (s *ServiceImpl) ValidateBulkTweets(ctx context.Context, tweets []*model.Tweet) (*model.ValidationReport, error) {
+	if len(tweets) == 0 {
+		return nil, errors.New("cannot perform action on an empty list")
+	}
+	report := &model.ValidationReport{DryRun: true, Total: len(tweets)}
+
+	now := time.Now()
+	invalid := make(map[int]bool)
+	addError := func(i int, field, message string) {
+		report.Errors = append(report.Errors, model.RecordIssue{Index: i, Id: tweets[i].Id, Field: field, Message: message})
+		invalid[i] = true
+	}
+
+	firstIndex := make(map[string]int, len(tweets))
+	badReplyingTo := make(map[int]bool)
+	var authors, ids, parents []string
+	for i, tweet := range tweets {
+		for _, e := range s.validateTweet(tweet, false, now) {
+			addError(i, e.Field, e.Description)
+			badReplyingTo[i] = badReplyingTo[i] || e.Field == "replyingTo"
+		}
+
+		if tweet.Id != "" {
+			if first, ok := firstIndex[tweet.Id]; ok {
+				addError(i, "id", fmt.Sprintf("duplicate id. It is already used by the record at index %d", first))
+			} else {
+				firstIndex[tweet.Id] = i
+				ids = append(ids, tweet.Id)
+			}
+		}
+		if tweet.Author != "" {
+			authors = append(authors, tweet.Author)
+		}
+	}
+
+	//the parent of a reply can be created by the same payload(it may even come later in the list)
+	replyParent := func(i int) string {
+		if badReplyingTo[i] {
+			return ""
+		}
+		parentId, _ := splitReplyingTo(tweets[i].ReplyingTo)
+		if _, inPayload := firstIndex[parentId]; inPayload {
+			return ""
+		}
+		return parentId
+	}
+	for i := range tweets {
+		if parentId := replyParent(i); parentId != "" {
+			parents = append(parents, parentId)
+		}
+	}
+
+	existingUsers, err := s.repo.FindExistingUsersInDynamoDb(ctx, unique(authors))
+	if err != nil {
+		return nil, err
+	}
+	existingTweets, err := s.repo.FindExistingTweetsInDynamoDb(ctx, unique(append(ids, parents...)))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, tweet := range tweets {
+		if tweet.Author != "" && !existingUsers[tweet.Author] {
+			addError(i, "author", fmt.Sprintf("user %s does not exist", tweet.Author))
+		}
+
+		if parentId := replyParent(i); parentId != "" && !existingTweets[parentId] {
+			addError(i, "replyingTo", fmt.Sprintf("tweet %s does not exist", parentId))
+		}
+
+		if tweet.Id != "" && firstIndex[tweet.Id] == i && existingTweets[tweet.Id] {
+			report.Warnings = append(report.Warnings, model.RecordIssue{Index: i, Id: tweet.Id, Field: "id", Message: "an existing tweet with this id will be replaced"})
+		}
+	}
+
+	//what the import would change in the payload, and the tweets the moderation rules reject
+	rewrites, rejected, err := s.prepareImport(ctx, tweets)
+	if err != nil {
+		return nil, err
+	}
+	report.Warnings = append(report.Warnings, rewrites...)
+	for _, issue := range rejected {
+		addError(issue.Index, issue.Field, issue.Message)
+	}
+
+	report.Invalid = len(invalid)
+	report.Valid = report.Total - report.Invalid
+	return report, nil
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
 	}
+	return out
+}
 
-	return s.repo.ScanTweetsFromDynamoDb(ctx, limit, nextKey)
+//ListTweets returns a page of tweets for viewer, leaving out the authors the viewer blocked or muted and the tweets
+//whose audience the viewer is not in. Filtering can leave a page short, so more pages are read(at most maxListRounds) until it is full again or the table ends
+func (s *ServiceImpl) ListTweets(ctx context.Context, viewer string, limit int32, nextKey string) ([]*model.Tweet, string, error) {
+	limit, err := s.pageLimit(limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	hidden, follows, err := s.viewerRelations(ctx, viewer)
+	if err != nil {
+		return nil, "", err
+	}
+	audience := audienceCheck{viewer: viewer, follows: func(ctx context.Context, owner string) (bool, error) {
+		return follows[owner], nil
+	}}
+
+	tweets := []*model.Tweet{}
+	for round := 0; ; round++ {
+		page, next, err := s.repo.ScanTweetsFromDynamoDb(ctx, limit-int32(len(tweets)), nextKey)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, tweet := range page {
+			if hidden[tweet.Author] {
+				continue
+			}
+			if visible, _ := audience.canSee(ctx, tweet); visible {
+				tweets = append(tweets, tweet)
+			}
+		}
+		//the cursor points after the last tweet read, so nothing is skipped or repeated on the next page
+		nextKey = next
+		if int32(len(tweets)) >= limit || nextKey == "" || round+1 >= maxListRounds {
+			if err := s.showPolls(ctx, viewer, tweets, time.Now()); err != nil {
+				return nil, "", err
+			}
+			if err := s.showMedia(ctx, tweets); err != nil {
+				return nil, "", err
+			}
+			return tweets, nextKey, nil
+		}
+	}
+}
+
+//pageLimit applies the ListTweets page size rules to the other lists
+func (s *ServiceImpl) pageLimit(limit int32) (int32, error) {
+	if limit <= 0 {
+		return s.listDefaultLimit, nil
+	}
+	if limit > s.listMaxLimit {
+		return 0, fmt.Errorf("limit cannot be more than %d", s.listMaxLimit)
+	}
+	return limit, nil
+}
+
+//GetTweet returns repo.ErrTweetNotFound when the viewer is not in the audience of the tweet, so restricted tweets can't be probed for
+func (s *ServiceImpl) GetTweet(ctx context.Context, viewer, tweetID string) (*model.Tweet, error) {
+	if tweetID == "" {
+		return nil, errors.New("id is required")
+	}
+	tweet, err := s.repo.GetTweetFromDynamoDb(ctx, tweetID)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := s.audienceFor(viewer).canSee(ctx, tweet)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, repo.ErrTweetNotFound
+	}
+	if err := s.showPolls(ctx, viewer, []*model.Tweet{tweet}, time.Now()); err != nil {
+		return nil, err
+	}
+	return tweet, nil
 }
 
 func (s *ServiceImpl) SaveLikeToggle(ctx context.Context, tweetID, author, authedUserID string, hasLiked bool) error {
@@ -99,5 +388,62 @@ func (s *ServiceImpl) SaveLikeToggle(ctx context.Context, tweetID, author, authe
 	if authedUserID == "" {
 		return errors.New("authedUserID is required")
 	}
-	return s.repo.SaveLikeToggleInDynamoDb(ctx, tweetID, author, authedUserID, hasLiked)
+	//taking a like back is always allowed
+	if !hasLiked {
+		if err := s.checkNotBlocked(ctx, author, authedUserID); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.SaveLikeToggleInDynamoDb(ctx, tweetID, author, authedUserID, hasLiked); err != nil {
+		return err
+	}
+	//hasLiked is the state before the toggle
+	if hasLiked {
+		metrics.LikesToggled.WithLabelValues("unlike").Inc()
+	} else {
+		metrics.LikesToggled.WithLabelValues("like").Inc()
+	}
+	return nil
+}
+
+func (s *ServiceImpl) ExportTweets(ctx context.Context, filter model.TweetFilter, segments int32, fn func(*model.Tweet) error) error {
+	if segments <= 0 {
+		segments = 4
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return errors.New("from cannot be after to")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() //stops the scan workers if fn fails half way
+
+	pages, err := s.ParallelScanTweets(ctx, model.ParallelScanInput{TotalSegments: segments, Filter: filter})
+	if err != nil {
+		return err
+	}
+
+	for page := range pages {
+		if page.Err != nil {
+			return page.Err
+		}
+		for _, tweet := range page.Tweets {
+			if err := fn(tweet); err != nil {
+				return err
+			}
+		}
+	}
+	//the channel is also closed when the parent context is cancelled
+	return ctx.Err()
+}
+
+//ParallelScanTweets streams every page of a parallel scan of the tweets table. It is what the admin jobs(export, reindex, repair-counters) are built on
+func (s *ServiceImpl) ParallelScanTweets(ctx context.Context, input model.ParallelScanInput) (<-chan model.ScanPage, error) {
+	if input.TotalSegments > 32 {
+		return nil, errors.New("segments cannot be more than 32")
+	}
+	if input.TotalSegments <= 0 {
+		input.TotalSegments = 1
+	}
+	return s.repo.ParallelScanTweetsFromDynamoDb(ctx, input)
 }
\ No newline at end of file
@@ -3,7 +3,9 @@ package tweetsservice
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -26,7 +28,7 @@ func Test_SaveTweet(t *testing.T) {
 	}{
 		{
 			name: "should return error with repo error",
-			tweet: &model.Tweet{Id: "SomeID", Author: "some_handle", ReplyingTo: "tweetID:another_author",},
+			tweet: &model.Tweet{Id: "SomeID", Author: "some_handle", Text: "hello", ReplyingTo: "tweetID:another_author",},
 			repoError: errors.New("repo error"),
 
 			expectedError: errors.New("repo error"),
@@ -37,27 +39,27 @@ func Test_SaveTweet(t *testing.T) {
 		},
 		{
 			name: "should return error when replyingToAuthor tweet params format is invalid",
-			tweet: &model.Tweet{Id: "SomeID", Author: "some_handle", ReplyingTo: "tweetID-I-am-ReplyingTo"},
+			tweet: &model.Tweet{Id: "SomeID", Author: "some_handle", Text: "hello", ReplyingTo: "tweetID-I-am-ReplyingTo"},
 			repoError:  errors.New("invalid format for replyingTo. It should be eg {reply_tweet_id}:{reply_tweet_author}"),
 
-			expectedError:  errors.New("invalid format for replyingTo. It should be eg {reply_tweet_id}:{reply_tweet_author}"),
+			expectedError:  &ValidationError{Violations: []FieldViolation{{"replyingTo", "invalid format for replyingTo. It should be eg {reply_tweet_id}:{reply_tweet_author}"}}},
 			expectedRepoCallTimes: 0,
 		},
 		{
 			name: "should return error with author ID not provided",
-			tweet: &model.Tweet{Id: "SomeID"},
+			tweet: &model.Tweet{Id: "SomeID", Text: "hello"},
 
 			repoError: errors.New("author is required"),
-			expectedError: errors.New("author is required"),
+			expectedError: &ValidationError{Violations: []FieldViolation{{"author", "author is required"}}},
 			
 			expectedRepoCallTimes: 0,
 
 		},
 		{
 			name: "should return no error with repo doesn't error",
-			tweet: &model.Tweet{Id: "SomeID", Author: "some_handle", ReplyingTo: "tweetID:another_author"},
+			tweet: &model.Tweet{Id: "SomeID", Author: "some_handle", Text: "hello", ReplyingTo: "tweetID:another_author"},
 			replyingToAuthor: "another_author",
-		 	expectedRepoResp:  &model.Tweet{Id: "SomeID", Author: "some_handle", ReplyingTo: "tweetID"},
+		 	expectedRepoResp:  &model.Tweet{Id: "SomeID", Author: "some_handle", Text: "hello", ReplyingTo: "tweetID"},
 
 			repoError: nil,
 			expectedError: nil,
@@ -75,6 +77,8 @@ func Test_SaveTweet(t *testing.T) {
 
 			repoMock := tweetsrepo.NewMockRepository(ctrl)
 			
+			repoMock.EXPECT().HasRelationInDynamoDb(ctx, tc.replyingToAuthor, model.Block, gomock.Any()).AnyTimes().Return(false, nil)
+			repoMock.EXPECT().GetTweetFromDynamoDb(ctx, "tweetID").AnyTimes().Return(&model.Tweet{Id: "tweetID", Author: tc.replyingToAuthor}, nil)
 			repoMock.EXPECT().SaveTweetToDynamoDb(ctx, tc.replyingToAuthor, tc.tweet).Times(tc.expectedRepoCallTimes).Return(tc.expectedRepoResp, tc.repoError)
 
 			service := New(repoMock)
@@ -85,6 +89,61 @@ func Test_SaveTweet(t *testing.T) {
 	}
 }
 
+func Test_SaveTweet_Text(t *testing.T) {
+	testCases := []struct {
+		name          string
+		text          string
+		maxTextLength int
+
+		expectedText  string
+		expectedError error
+	}{
+		{name: "should save the normalized text", text: "  Cafe\u0301\u200b time\r\n", expectedText: "Caf\u00e9 time"},
+		{
+			name: "should reject a text that is empty once normalized",
+			text: " \u200b\u0007\u3164 ",
+			expectedError: &ValidationError{Violations: []FieldViolation{{"text", "text is required"}}},
+		},
+		{
+			name: "should count a link as 23 characters",
+			text: "https://example.com/" + strings.Repeat("a", 100), maxTextLength: 23,
+			expectedText: "https://example.com/" + strings.Repeat("a", 100),
+		},
+		{
+			name: "should reject a text over the max length",
+			text: "a \U0001F44D\U0001F3FD https://example.com", maxTextLength: 20,
+			expectedError: &ValidationError{Violations: []FieldViolation{{"text", "text cannot be more than 20 characters, it has 27. A link counts as 23"}}},
+		},
+		{
+			name: "should reject a huge text before looking at it",
+			text: strings.Repeat("a", 1<<20),
+			expectedError: &ValidationError{Violations: []FieldViolation{{"text", "text cannot be more than 280 characters"}}},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			repoMock := tweetsrepo.NewMockRepository(gomock.NewController(t))
+			var saved *model.Tweet
+			repoMock.EXPECT().SaveTweetToDynamoDb(gomock.Any(), "", gomock.Any()).AnyTimes().DoAndReturn(func(ctx context.Context, replyingToAuthor string, tweet *model.Tweet) (*model.Tweet, error) {
+				saved = tweet
+				return tweet, nil
+			})
+
+			service := New(repoMock)
+			service.SetMaxTextLength(tc.maxTextLength)
+			_, err := service.SaveTweet(context.Background(), &model.Tweet{Author: "some_handle", Text: tc.text})
+
+			assert.Equal(t, tc.expectedError, err)
+			if tc.expectedError == nil {
+				assert.Equal(t, tc.expectedText, saved.Text)
+			}
+		})
+	}
+}
+
 func Test_BatchSaveTweet(t *testing.T) {
 		testCases := []struct {
 		name          string
@@ -97,30 +156,62 @@ func Test_BatchSaveTweet(t *testing.T) {
 		{
 			name: "should return error with repo error",
 			tweets: []*model.Tweet{
-				{Id: "SomeID1", Author: "some_handle1"},
-				{Id: "SomeID2", Author: "some_handle2"},
+				{Id: "SomeID1", Author: "some_handle1", Text: "hello"},
+				{Id: "SomeID2", Author: "some_handle2", Text: "hello"},
 			},
 			buildStubs: func(ctx context.Context, tweets []*model.Tweet, repoMock *tweetsrepo.MockRepository) {
 				repoMock.EXPECT().BulkSaveTweetToDynamoDb(ctx, tweets).Times(1).Return(errors.New("repo error"))
 			},
-			expectedError: errors.New("repo error"),
+			expectedError: &BulkSaveError{Err: errors.New("repo error")},
+		},
+		{
+			name: "should count only the tweets DynamoDB wrote",
+			tweets: []*model.Tweet{
+				{Id: "SomeID1", Author: "some_handle1", Text: "hello"},
+				{Id: "SomeID2", Author: "some_handle2", Text: "hello"},
+			},
+			buildStubs: func(ctx context.Context, tweets []*model.Tweet, repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().BulkSaveTweetToDynamoDb(ctx, tweets).Times(1).Return(&tweetsrepo.UnprocessedTweetsError{Ids: []string{"SomeID2"}})
+			},
+			expectedError: &BulkSaveError{Saved: 1, Err: &tweetsrepo.UnprocessedTweetsError{Ids: []string{"SomeID2"}}},
 		},
 		{
 			name: "should return error with author ID not provided",
 			tweets: []*model.Tweet{
-				{Id: "SomeID1", Author: "some_handle1"},
-				{Id: "SomeID2"},
+				{Id: "SomeID1", Author: "some_handle1", Text: "hello"},
+				{Id: "SomeID2", Text: "hello"},
 			},
 			buildStubs: func(ctx context.Context, tweets []*model.Tweet, repoMock *tweetsrepo.MockRepository) {
 				repoMock.EXPECT().BulkSaveTweetToDynamoDb(ctx, tweets).Times(0)
 			},
 			expectedError: errors.New("author is required for tweetID: SomeID2"),
 		},
+		{
+			name: "should return error with duplicate ids",
+			tweets: []*model.Tweet{
+				{Id: "SomeID1", Author: "some_handle1", Text: "hello"},
+				{Id: "SomeID1", Author: "some_handle2", Text: "hello"},
+			},
+			buildStubs: func(ctx context.Context, tweets []*model.Tweet, repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().BulkSaveTweetToDynamoDb(ctx, tweets).Times(0)
+			},
+			expectedError: errors.New("duplicate tweetID: SomeID1"),
+		},
+		{
+			name: "should return error with a timestamp in the future",
+			tweets: []*model.Tweet{
+				{Id: "SomeID1", Author: "some_handle1", Text: "hello", Timestamp: model.ChirperAppUnixTime(time.Now().Add(time.Hour))},
+			},
+			buildStubs: func(ctx context.Context, tweets []*model.Tweet, repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().BulkSaveTweetToDynamoDb(ctx, tweets).Times(0)
+			},
+			expectedError: errors.New("timestamp cannot be in the future for tweetID: SomeID1"),
+		},
 		{
 			name: "should return no error with repo doesn't error",
 			tweets: []*model.Tweet{
-				{Id: "SomeID1", Author: "some_handle1"},
-				{Id: "SomeID2", Author: "some_handle2"},
+				{Id: "SomeID1", Author: "some_handle1", Text: "hello"},
+				{Id: "SomeID2", Author: "some_handle2", Text: "hello"},
 			},
 			buildStubs: func(ctx context.Context, tweets []*model.Tweet, repoMock *tweetsrepo.MockRepository) {
 				repoMock.EXPECT().BulkSaveTweetToDynamoDb(ctx, tweets).Times(1).Return(nil)
@@ -146,4 +237,154 @@ func Test_BatchSaveTweet(t *testing.T) {
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func Test_ExportTweets(t *testing.T) {
+	pagesOf := func(pages ...model.ScanPage) <-chan model.ScanPage {
+		ch := make(chan model.ScanPage, len(pages))
+		for _, p := range pages {
+			ch <- p
+		}
+		close(ch)
+		return ch
+	}
+
+	testCases := []struct {
+		name     string
+		filter   model.TweetFilter
+		segments int32
+
+		buildStubs func(repoMock *tweetsrepo.MockRepository)
+
+		expectedIds   []string
+		expectedError error
+	}{
+		{
+			name:     "should default to 4 segments",
+			segments: 0,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().ParallelScanTweetsFromDynamoDb(gomock.Any(), model.ParallelScanInput{TotalSegments: 4}).Times(1).Return(pagesOf(
+					model.ScanPage{Segment: 0, Tweets: []*model.Tweet{{Id: "a"}, {Id: "b"}}},
+					model.ScanPage{Segment: 3, Tweets: []*model.Tweet{{Id: "c"}}},
+				), nil)
+			},
+			expectedIds:   []string{"a", "b", "c"},
+			expectedError: nil,
+		},
+		{
+			name:     "should return error when segments is more than 32",
+			segments: 33,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().ParallelScanTweetsFromDynamoDb(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedIds:   []string{},
+			expectedError: errors.New("segments cannot be more than 32"),
+		},
+		{
+			name:     "should return error when from is after to",
+			segments: 2,
+			filter:   model.TweetFilter{From: time.UnixMilli(1518122597860), To: time.UnixMilli(1510044395650)},
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().ParallelScanTweetsFromDynamoDb(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedIds:   []string{},
+			expectedError: errors.New("from cannot be after to"),
+		},
+		{
+			name:     "should return the scan error",
+			segments: 2,
+			buildStubs: func(repoMock *tweetsrepo.MockRepository) {
+				repoMock.EXPECT().ParallelScanTweetsFromDynamoDb(gomock.Any(), gomock.Any()).Times(1).Return(pagesOf(
+					model.ScanPage{Segment: 0, Tweets: []*model.Tweet{{Id: "a"}}},
+					model.ScanPage{Segment: 1, Err: errors.New("ProvisionedThroughputExceededException")},
+				), nil)
+			},
+			expectedIds:   []string{"a"},
+			expectedError: errors.New("ProvisionedThroughputExceededException"),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+
+			repoMock := tweetsrepo.NewMockRepository(ctrl)
+
+			tc.buildStubs(repoMock)
+
+			ids := []string{}
+			service := New(repoMock)
+			err := service.ExportTweets(ctx, tc.filter, tc.segments, func(tweet *model.Tweet) error {
+				ids = append(ids, tweet.Id)
+				return nil
+			})
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedIds, ids)
+		})
+	}
+}
+func Test_ListTweets(t *testing.T) {
+	testCases := []struct {
+		name         string
+		defaultLimit int32
+		maxLimit     int32
+		limit        int32
+
+		expectedRepoLimit     int32
+		expectedError         error
+		expectedRepoCallTimes int
+	}{
+		{
+			name:                  "should use the default limit when none is given",
+			limit:                 0,
+			expectedRepoLimit:     10,
+			expectedRepoCallTimes: 1,
+		},
+		{
+			name:                  "should return error when limit is more than the max",
+			limit:                 31,
+			expectedError:         errors.New("limit cannot be more than 30"),
+			expectedRepoCallTimes: 0,
+		},
+		{
+			name:                  "should use the configured limits",
+			defaultLimit:          20,
+			maxLimit:              100,
+			limit:                 0,
+			expectedRepoLimit:     20,
+			expectedRepoCallTimes: 1,
+		},
+		{
+			name:                  "should return error when limit is more than the configured max",
+			defaultLimit:          20,
+			maxLimit:              100,
+			limit:                 101,
+			expectedError:         errors.New("limit cannot be more than 100"),
+			expectedRepoCallTimes: 0,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+
+			repoMock := tweetsrepo.NewMockRepository(ctrl)
+			repoMock.EXPECT().ScanTweetsFromDynamoDb(gomock.Any(), tc.expectedRepoLimit, "").Times(tc.expectedRepoCallTimes).Return([]*model.Tweet{}, "", nil)
+
+			service := New(repoMock)
+			if tc.defaultLimit != 0 {
+				service.SetListLimits(tc.defaultLimit, tc.maxLimit)
+			}
+			_, _, err := service.ListTweets(ctx, "", tc.limit, "")
+
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
@@ -0,0 +1,311 @@
+package tweetsservice
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//TracedService wraps a Service so every method is a span, between the span of the request and the DynamoDB ones
+type TracedService struct {
+	next   Service
+	tracer trace.Tracer
+}
+
+func WithTracing(next Service) *TracedService {
+	return &TracedService{next: next, tracer: otel.Tracer("github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic")}
+}
+
+func (s *TracedService) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "TweetsService."+method, trace.WithAttributes(attrs...))
+}
+
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s *TracedService) SaveTweet(ctx context.Context, tweet *model.Tweet) (*model.Tweet, error) {
+	ctx, span := s.start(ctx, "SaveTweet", attribute.Bool("tweet.reply", tweet.ReplyingTo != ""))
+	newTweet, err := s.next.SaveTweet(ctx, tweet)
+	if err == nil {
+		span.SetAttributes(attribute.String("tweet.id", newTweet.Id))
+	}
+	end(span, err)
+	return newTweet, err
+}
+
+func (s *TracedService) BulkSaveTweet(ctx context.Context, tweets []*model.Tweet) error {
+	ctx, span := s.start(ctx, "BulkSaveTweet", attribute.Int("tweets.count", len(tweets)))
+	err := s.next.BulkSaveTweet(ctx, tweets)
+	end(span, err)
+	return err
+}
+
+func (s *TracedService) ValidateBulkTweets(ctx context.Context, tweets []*model.Tweet) (*model.ValidationReport, error) {
+	ctx, span := s.start(ctx, "ValidateBulkTweets", attribute.Int("tweets.count", len(tweets)))
+	report, err := s.next.ValidateBulkTweets(ctx, tweets)
+	end(span, err)
+	return report, err
+}
+
+func (s *TracedService) ListTweets(ctx context.Context, viewer string, limit int32, nextKey string) ([]*model.Tweet, string, error) {
+	ctx, span := s.start(ctx, "ListTweets", attribute.Int("list.limit", int(limit)), attribute.Bool("list.next_page", nextKey != ""))
+	tweets, next, err := s.next.ListTweets(ctx, viewer, limit, nextKey)
+	span.SetAttributes(attribute.Int("tweets.count", len(tweets)))
+	end(span, err)
+	return tweets, next, err
+}
+
+func (s *TracedService) GetTweet(ctx context.Context, viewer, tweetID string) (*model.Tweet, error) {
+	ctx, span := s.start(ctx, "GetTweet", attribute.String("tweet.id", tweetID))
+	tweet, err := s.next.GetTweet(ctx, viewer, tweetID)
+	end(span, err)
+	return tweet, err
+}
+
+func (s *TracedService) SaveLikeToggle(ctx context.Context, tweetID, author, authedUserID string, hasLiked bool) error {
+	ctx, span := s.start(ctx, "SaveLikeToggle", attribute.String("tweet.id", tweetID), attribute.Bool("tweet.has_liked", hasLiked))
+	err := s.next.SaveLikeToggle(ctx, tweetID, author, authedUserID, hasLiked)
+	end(span, err)
+	return err
+}
+
+func (s *TracedService) ExportTweets(ctx context.Context, filter model.TweetFilter, segments int32, fn func(*model.Tweet) error) error {
+	ctx, span := s.start(ctx, "ExportTweets", attribute.Int("scan.segments", int(segments)))
+	err := s.next.ExportTweets(ctx, filter, segments, fn)
+	end(span, err)
+	return err
+}
+
+//ParallelScanTweets only covers starting the scan. The pages are read after it returns
+func (s *TracedService) ParallelScanTweets(ctx context.Context, input model.ParallelScanInput) (<-chan model.ScanPage, error) {
+	ctx, span := s.start(ctx, "ParallelScanTweets", attribute.Int("scan.segments", int(input.TotalSegments)))
+	pages, err := s.next.ParallelScanTweets(ctx, input)
+	end(span, err)
+	return pages, err
+}
+
+func (s *TracedService) ReindexUserTweets(ctx context.Context, segments int32) (*model.ReindexReport, error) {
+	ctx, span := s.start(ctx, "ReindexUserTweets", attribute.Int("scan.segments", int(segments)))
+	report, err := s.next.ReindexUserTweets(ctx, segments)
+	end(span, err)
+	return report, err
+}
+
+func (s *TracedService) RepairReplies(ctx context.Context, segments int32, dryRun bool) (*model.RepairReport, error) {
+	ctx, span := s.start(ctx, "RepairReplies", attribute.Int("scan.segments", int(segments)), attribute.Bool("dry_run", dryRun))
+	report, err := s.next.RepairReplies(ctx, segments, dryRun)
+	end(span, err)
+	return report, err
+}
+
+func (s *TracedService) BulkSaveUsers(ctx context.Context, users []*model.User) error {
+	ctx, span := s.start(ctx, "BulkSaveUsers", attribute.Int("users.count", len(users)))
+	err := s.next.BulkSaveUsers(ctx, users)
+	end(span, err)
+	return err
+}
+
+func (s *TracedService) ListReviewQueue(ctx context.Context, limit int32, nextKey string) ([]*model.Tweet, string, error) {
+	ctx, span := s.start(ctx, "ListReviewQueue", attribute.Int("list.limit", int(limit)), attribute.Bool("list.next_page", nextKey != ""))
+	tweets, next, err := s.next.ListReviewQueue(ctx, limit, nextKey)
+	span.SetAttributes(attribute.Int("tweets.count", len(tweets)))
+	end(span, err)
+	return tweets, next, err
+}
+
+func (s *TracedService) ReviewTweet(ctx context.Context, tweetID string, decision model.ReviewDecision, reviewer string) (*model.Tweet, error) {
+	ctx, span := s.start(ctx, "ReviewTweet", attribute.String("tweet.id", tweetID), attribute.String("review.decision", string(decision)))
+	tweet, err := s.next.ReviewTweet(ctx, tweetID, decision, reviewer)
+	end(span, err)
+	return tweet, err
+}
+
+func (s *TracedService) Block(ctx context.Context, userID, target string) error {
+	ctx, span := s.start(ctx, "Block")
+	err := s.next.Block(ctx, userID, target)
+	end(span, err)
+	return err
+}
+
+func (s *TracedService) Unblock(ctx context.Context, userID, target string) error {
+	ctx, span := s.start(ctx, "Unblock")
+	err := s.next.Unblock(ctx, userID, target)
+	end(span, err)
+	return err
+}
+
+func (s *TracedService) Mute(ctx context.Context, userID, target string) error {
+	ctx, span := s.start(ctx, "Mute")
+	err := s.next.Mute(ctx, userID, target)
+	end(span, err)
+	return err
+}
+
+func (s *TracedService) Unmute(ctx context.Context, userID, target string) error {
+	ctx, span := s.start(ctx, "Unmute")
+	err := s.next.Unmute(ctx, userID, target)
+	end(span, err)
+	return err
+}
+
+func (s *TracedService) ListRelations(ctx context.Context, userID string) ([]*model.Relation, error) {
+	ctx, span := s.start(ctx, "ListRelations")
+	relations, err := s.next.ListRelations(ctx, userID)
+	span.SetAttributes(attribute.Int("relations.count", len(relations)))
+	end(span, err)
+	return relations, err
+}
+
+func (s *TracedService) Follow(ctx context.Context, userID, target string) error {
+	ctx, span := s.start(ctx, "Follow")
+	err := s.next.Follow(ctx, userID, target)
+	end(span, err)
+	return err
+}
+
+func (s *TracedService) Unfollow(ctx context.Context, userID, target string) error {
+	ctx, span := s.start(ctx, "Unfollow")
+	err := s.next.Unfollow(ctx, userID, target)
+	end(span, err)
+	return err
+}
+
+func (s *TracedService) ScheduleTweet(ctx context.Context, tweet *model.Tweet, publishAt time.Time) (*model.ScheduledTweet, error) {
+	ctx, span := s.start(ctx, "ScheduleTweet", attribute.Bool("tweet.reply", tweet.ReplyingTo != ""))
+	scheduled, err := s.next.ScheduleTweet(ctx, tweet, publishAt)
+	if err == nil {
+		span.SetAttributes(attribute.String("scheduled.id", scheduled.Id))
+	}
+	end(span, err)
+	return scheduled, err
+}
+
+func (s *TracedService) ListScheduled(ctx context.Context, author string, limit int32, nextKey string) ([]*model.ScheduledTweet, string, error) {
+	ctx, span := s.start(ctx, "ListScheduled", attribute.Int("list.limit", int(limit)), attribute.Bool("list.next_page", nextKey != ""))
+	scheduled, next, err := s.next.ListScheduled(ctx, author, limit, nextKey)
+	span.SetAttributes(attribute.Int("scheduled.count", len(scheduled)))
+	end(span, err)
+	return scheduled, next, err
+}
+
+func (s *TracedService) CancelScheduled(ctx context.Context, author, id string) error {
+	ctx, span := s.start(ctx, "CancelScheduled", attribute.String("scheduled.id", id))
+	err := s.next.CancelScheduled(ctx, author, id)
+	end(span, err)
+	return err
+}
+
+func (s *TracedService) PublishDueTweets(ctx context.Context, now time.Time) (*model.PublishReport, error) {
+	ctx, span := s.start(ctx, "PublishDueTweets")
+	report, err := s.next.PublishDueTweets(ctx, now)
+	if report != nil {
+		span.SetAttributes(attribute.Int("scheduled.due", report.Due), attribute.Int("scheduled.published", report.Published), attribute.Int("scheduled.failed", report.Failed))
+	}
+	end(span, err)
+	return report, err
+}
+
+func (s *TracedService) SaveDraft(ctx context.Context, draft *model.Draft) (*model.Draft, error) {
+	ctx, span := s.start(ctx, "SaveDraft", attribute.Bool("draft.new", draft.Id == ""), attribute.Int64("draft.read_version", draft.Version))
+	saved, err := s.next.SaveDraft(ctx, draft)
+	if err == nil {
+		span.SetAttributes(attribute.String("draft.id", saved.Id))
+	}
+	end(span, err)
+	return saved, err
+}
+
+func (s *TracedService) ListDrafts(ctx context.Context, userID string, limit int32, nextKey string) ([]*model.Draft, string, error) {
+	ctx, span := s.start(ctx, "ListDrafts", attribute.Int("list.limit", int(limit)), attribute.Bool("list.next_page", nextKey != ""))
+	drafts, next, err := s.next.ListDrafts(ctx, userID, limit, nextKey)
+	span.SetAttributes(attribute.Int("drafts.count", len(drafts)))
+	end(span, err)
+	return drafts, next, err
+}
+
+func (s *TracedService) DeleteDraft(ctx context.Context, userID, id string, version int64) error {
+	ctx, span := s.start(ctx, "DeleteDraft", attribute.String("draft.id", id))
+	err := s.next.DeleteDraft(ctx, userID, id, version)
+	end(span, err)
+	return err
+}
+
+func (s *TracedService) PublishDraft(ctx context.Context, userID, id string, version int64) (*model.Tweet, error) {
+	ctx, span := s.start(ctx, "PublishDraft", attribute.String("draft.id", id))
+	tweet, err := s.next.PublishDraft(ctx, userID, id, version)
+	if err == nil {
+		span.SetAttributes(attribute.String("tweet.id", tweet.Id))
+	}
+	end(span, err)
+	return tweet, err
+}
+
+func (s *TracedService) UploadMedia(ctx context.Context, userID string, body io.Reader, checksum string) (*model.Media, error) {
+	ctx, span := s.start(ctx, "UploadMedia")
+	media, err := s.next.UploadMedia(ctx, userID, body, checksum)
+	if err == nil {
+		span.SetAttributes(attribute.String("media.id", media.Id), attribute.String("media.content_type", media.ContentType), attribute.Int64("media.size", media.Size))
+	}
+	end(span, err)
+	return media, err
+}
+
+func (s *TracedService) OpenMedia(ctx context.Context, viewer, id string) (*model.Media, io.ReadCloser, error) {
+	ctx, span := s.start(ctx, "OpenMedia", attribute.String("media.id", id))
+	media, content, err := s.next.OpenMedia(ctx, viewer, id)
+	end(span, err)
+	return media, content, err
+}
+
+func (s *TracedService) ListTweetMedia(ctx context.Context, viewer, tweetID string) ([]*model.Media, error) {
+	ctx, span := s.start(ctx, "ListTweetMedia", attribute.String("tweet.id", tweetID))
+	media, err := s.next.ListTweetMedia(ctx, viewer, tweetID)
+	end(span, err)
+	return media, err
+}
+
+func (s *TracedService) CollectOrphanMedia(ctx context.Context, now time.Time) (*model.MediaGCReport, error) {
+	ctx, span := s.start(ctx, "CollectOrphanMedia")
+	report, err := s.next.CollectOrphanMedia(ctx, now)
+	if report != nil {
+		span.SetAttributes(attribute.Int("media.orphans", report.Orphans), attribute.Int("media.deleted", report.Deleted))
+	}
+	end(span, err)
+	return report, err
+}
+
+func (s *TracedService) OpenThumbnail(ctx context.Context, viewer, id string, side int) (*model.Media, io.ReadCloser, error) {
+	ctx, span := s.start(ctx, "OpenThumbnail", attribute.String("media.id", id), attribute.Int("media.thumbnail", side))
+	media, content, err := s.next.OpenThumbnail(ctx, viewer, id, side)
+	end(span, err)
+	return media, content, err
+}
+
+func (s *TracedService) ProcessPendingMedia(ctx context.Context) (*model.MediaProcessingReport, error) {
+	ctx, span := s.start(ctx, "ProcessPendingMedia")
+	report, err := s.next.ProcessPendingMedia(ctx)
+	if report != nil {
+		span.SetAttributes(attribute.Int("media.pending", report.Pending), attribute.Int("media.ready", report.Ready), attribute.Int("media.failed", report.Failed))
+	}
+	end(span, err)
+	return report, err
+}
+
+func (s *TracedService) VotePoll(ctx context.Context, userID, tweetID string, option int) (*model.Poll, error) {
+	ctx, span := s.start(ctx, "VotePoll", attribute.String("tweet.id", tweetID), attribute.Int("poll.option", option))
+	poll, err := s.next.VotePoll(ctx, userID, tweetID, option)
+	end(span, err)
+	return poll, err
+}
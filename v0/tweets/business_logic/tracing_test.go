@@ -0,0 +1,70 @@
+package tweetsservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func Test_TracedService(t *testing.T) {
+	testCases := []struct {
+		name    string
+		nextErr error
+
+		expectedStatus codes.Code
+	}{
+		{
+			name:           "should end the span ok",
+			expectedStatus: codes.Unset,
+		},
+		{
+			name:    "should record the error of the service",
+			nextErr: errors.New("id is required"),
+
+			expectedStatus: codes.Error,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			previous := otel.GetTracerProvider()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+			t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			next := NewMockService(ctrl)
+			var serviceCtx context.Context
+			next.EXPECT().GetTweet(gomock.Any(), "sarah_edo", "tweetID").DoAndReturn(func(ctx context.Context, viewer, tweetID string) (*model.Tweet, error) {
+				serviceCtx = ctx
+				return nil, tc.nextErr
+			})
+
+			_, err := WithTracing(next).GetTweet(context.TODO(), "sarah_edo", "tweetID")
+
+			assert.Equal(t, tc.nextErr, err)
+			spans := recorder.Ended()
+			if assert.Len(t, spans, 1) {
+				assert.Equal(t, "TweetsService.GetTweet", spans[0].Name())
+				assert.Equal(t, tc.expectedStatus, spans[0].Status().Code)
+				assert.Contains(t, spans[0].Attributes(), attribute.String("tweet.id", "tweetID"))
+				//the repository is called inside the span
+				assert.Equal(t, spans[0].SpanContext().SpanID(), trace.SpanContextFromContext(serviceCtx).SpanID())
+			}
+		})
+	}
+}
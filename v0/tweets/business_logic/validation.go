@@ -0,0 +1,121 @@
+package tweetsservice
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetstext "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/text"
+)
+
+const (
+	//maxBytesPerCharacter bounds the size of the text before it is normalized and counted, so a client can't make us
+	//segment megabytes. The longest grapheme clusters in real use(flags with tags, family emoji) stay well under it
+	maxBytesPerCharacter = 32
+	//how far in the future a timestamp can be before we assume the client clock is wrong
+	maxClockSkew = 5 * time.Minute
+)
+
+//the chirper sample data starts in 2017. Anything before twitter existed is certainly a bad timestamp
+var minTimestamp = time.Date(2006, time.March, 21, 0, 0, 0, 0, time.UTC)
+
+//FieldViolation is one problem with one field of a tweet
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+//ValidationError is returned when a tweet is invalid. It has every problem of the tweet, not only the first one
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Description
+	}
+	return strings.Join(messages, "; ")
+}
+
+//BulkSaveError is returned when BulkSaveTweet stopped part way. Saved tweets were written, the others were not. Err is
+//what stopped it, eg a repo.UnprocessedTweetsError
+type BulkSaveError struct {
+	Saved int
+	Err   error
+}
+
+func (e *BulkSaveError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BulkSaveError) Unwrap() error {
+	return e.Err
+}
+
+//validateTweet runs the checks every write path(SaveTweet, BulkSaveTweet and the dry run) applies to a record.
+//The text is normalized first(see tweetstext.Normalize), so what is checked is what gets saved.
+//When requireReplyAuthor is true, replyingTo must be in the {reply_tweet_id}:{reply_tweet_author} format SaveTweet needs to update the parent tweet
+func (s *ServiceImpl) validateTweet(tweet *model.Tweet, requireReplyAuthor bool, now time.Time) []FieldViolation {
+	var errs []FieldViolation
+
+	if tweet.Author == "" {
+		errs = append(errs, FieldViolation{"author", "author is required"})
+	}
+
+	if tweet.ReplyingTo != "" {
+		tokens := strings.Split(tweet.ReplyingTo, ":")
+		valid := len(tokens) == 2 || (!requireReplyAuthor && len(tokens) == 1)
+		for _, t := range tokens {
+			valid = valid && t != ""
+		}
+		if !valid {
+			errs = append(errs, FieldViolation{"replyingTo", "invalid format for replyingTo. It should be eg {reply_tweet_id}:{reply_tweet_author}"})
+		}
+	}
+
+	if !isTimestampUnset(tweet.Timestamp) {
+		ts := time.Time(tweet.Timestamp)
+		if ts.Before(minTimestamp) {
+			errs = append(errs, FieldViolation{"timestamp", fmt.Sprintf("timestamp cannot be before %s", minTimestamp.Format(time.RFC3339))})
+		} else if ts.After(now.Add(maxClockSkew)) {
+			errs = append(errs, FieldViolation{"timestamp", "timestamp cannot be in the future"})
+		}
+	}
+
+	if tweet.Audience != nil {
+		if level, err := model.ParseAudienceLevel(string(tweet.Audience.Level)); err != nil {
+			errs = append(errs, FieldViolation{"audience", err.Error()})
+		} else {
+			tweet.Audience.Level = level
+		}
+	}
+
+	errs = append(errs, validatePoll(tweet.Poll)...)
+	errs = append(errs, validateMediaIds(tweet.MediaIds)...)
+
+	if len(tweet.Text) > s.maxTextLength*maxBytesPerCharacter {
+		errs = append(errs, FieldViolation{"text", fmt.Sprintf("text cannot be more than %d characters", s.maxTextLength)})
+		return errs
+	}
+	tweet.Text = tweetstext.Normalize(tweet.Text)
+	if tweet.Text == "" {
+		errs = append(errs, FieldViolation{"text", "text is required"})
+	} else if n := tweetstext.Length(tweet.Text); n > s.maxTextLength {
+		errs = append(errs, FieldViolation{"text", fmt.Sprintf("text cannot be more than %d characters, it has %d. A link counts as %d", s.maxTextLength, n, tweetstext.URLWeight)})
+	}
+
+	return errs
+}
+
+//the gRPC/http requests have no way to tell a missing timestamp from 0, so the unix epoch is treated the same as not set
+func isTimestampUnset(ts model.ChirperAppUnixTime) bool {
+	return ts.IsZero() || time.Time(ts).UnixMilli() == 0
+}
+
+//splitReplyingTo returns the tweet id and(when present) the author of the tweet being replied to
+func splitReplyingTo(replyingTo string) (string, string) {
+	id, author, _ := strings.Cut(replyingTo, ":")
+	return id, author
+}
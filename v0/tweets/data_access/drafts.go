@@ -0,0 +1,244 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//cursor kind of the ListDraftsFromDynamoDb cursors
+const draftsCursorKind = "drafts-by-user"
+
+//draftKey is the LastEvaluatedKey of a query on DraftsUpdatedIndexName
+type draftKey struct {
+	UserId    string `json:"user_id" dynamodbav:"user_id"`
+	Id        string `json:"id" dynamodbav:"id"`
+	UpdatedAt int64  `json:"updated_at" dynamodbav:"updated_at"`
+}
+
+func draftID(userID, id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"user_id": &types.AttributeValueMemberS{Value: userID},
+		"id":      &types.AttributeValueMemberS{Value: id},
+	}
+}
+
+//version goes through a placeholder in the expressions, like #status of the scheduled tweets
+var draftVersionName = map[string]string{"#version": "version"}
+
+//draftCondition is the condition of a write on a draft the caller read at version. version 0 skips the version check
+func draftCondition(version int64) (*string, map[string]string, map[string]types.AttributeValue) {
+	if version == 0 {
+		return aws.String("attribute_exists(id)"), nil, nil
+	}
+	return aws.String("attribute_exists(id) AND #version = :version"), draftVersionName, map[string]types.AttributeValue{
+		":version": &types.AttributeValueMemberN{Value: fmt.Sprint(version)},
+	}
+}
+
+//draftConflict tells why a conditional write on a draft failed: it is gone, or it has another version
+func (r *DynamoDbRepository) draftConflict(ctx context.Context, userID, id string) error {
+	if _, err := r.GetDraftFromDynamoDb(ctx, userID, id); err != nil {
+		return err
+	}
+	return ErrDraftVersionConflict
+}
+
+//SaveDraftToDynamoDb writes a draft at draft.Version and returns it as stored. readVersion is the version the caller read,
+//the save fails with ErrDraftVersionConflict when the draft is not at that version anymore. readVersion 0 creates the draft
+func (r *DynamoDbRepository) SaveDraftToDynamoDb(ctx context.Context, draft *model.Draft, readVersion int64) (*model.Draft, error) {
+	if readVersion == 0 {
+		item, err := attributevalue.MarshalMap(draft)
+		if err != nil {
+			return nil, err
+		}
+		_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:           aws.String(r.tables.Drafts),
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(id)"),
+		})
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return nil, ErrDraftVersionConflict
+		}
+		if err != nil {
+			return nil, err
+		}
+		return draft, nil
+	}
+
+	//an update rather than a put so created_at stays
+	condition, names, values := draftCondition(readVersion)
+	values[":text"] = &types.AttributeValueMemberS{Value: draft.Text}
+	values[":new_version"] = &types.AttributeValueMemberN{Value: fmt.Sprint(draft.Version)}
+	values[":updated_at"] = &types.AttributeValueMemberN{Value: fmt.Sprint(time.Time(draft.UpdatedAt).Unix())}
+	set := []string{"text_blob = :text", "#version = :new_version", "updated_at = :updated_at"}
+	var remove []string
+	if draft.ReplyingTo != "" {
+		set = append(set, "replyingTo = :replyingTo")
+		values[":replyingTo"] = &types.AttributeValueMemberS{Value: draft.ReplyingTo}
+	} else {
+		remove = append(remove, "replyingTo")
+	}
+	if draft.Audience != nil {
+		audience, err := attributevalue.Marshal(draft.Audience)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, "audience = :audience")
+		values[":audience"] = audience
+	} else {
+		remove = append(remove, "audience")
+	}
+	update := "SET " + strings.Join(set, ", ")
+	if len(remove) > 0 {
+		update += " REMOVE " + strings.Join(remove, ", ")
+	}
+
+	out, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tables.Drafts),
+		Key:                       draftID(draft.UserId, draft.Id),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       condition,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil, r.draftConflict(ctx, draft.UserId, draft.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	saved := &model.Draft{}
+	if err := attributevalue.UnmarshalMap(out.Attributes, saved); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+//GetDraftFromDynamoDb returns ErrDraftNotFound when the user has no such draft
+func (r *DynamoDbRepository) GetDraftFromDynamoDb(ctx context.Context, userID, id string) (*model.Draft, error) {
+	out, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tables.Drafts),
+		Key:            draftID(userID, id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Item) == 0 {
+		return nil, ErrDraftNotFound
+	}
+
+	draft := &model.Draft{}
+	if err := attributevalue.UnmarshalMap(out.Item, draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+//ListDraftsFromDynamoDb returns the drafts of a user, the last edited first
+func (r *DynamoDbRepository) ListDraftsFromDynamoDb(ctx context.Context, userID, nextKey string, limit int32) ([]*model.Draft, string, error) {
+	items := []*model.Draft{}
+
+	p := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tables.Drafts),
+		IndexName:              aws.String(DraftsUpdatedIndexName),
+		KeyConditionExpression: aws.String("user_id = :user_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":user_id": &types.AttributeValueMemberS{Value: userID},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(limit),
+	}
+
+	if nextKey != "" {
+		nk := &draftKey{}
+		if err := r.cursors.Decode(draftsCursorKind, nextKey, nk); err != nil {
+			return items, "", err
+		}
+		if nk.UserId != userID {
+			return items, "", fmt.Errorf("%w: issued for another user", cursor.ErrInvalidCursor)
+		}
+		p.ExclusiveStartKey, _ = attributevalue.MarshalMap(nk)
+	}
+
+	out, err := r.client.Query(ctx, p)
+	if err != nil {
+		return items, "", err
+	}
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &items); err != nil {
+		return items, "", err
+	}
+
+	var finalKeyValue string
+	if len(out.LastEvaluatedKey) > 0 {
+		var dNextKey draftKey
+		if err := attributevalue.UnmarshalMap(out.LastEvaluatedKey, &dNextKey); err != nil {
+			return items, "", err
+		}
+		if finalKeyValue, err = r.cursors.Encode(draftsCursorKind, dNextKey); err != nil {
+			return items, "", err
+		}
+	}
+	return items, finalKeyValue, nil
+}
+
+//DeleteDraftFromDynamoDb deletes a draft the caller read at version, or at any version when it is 0
+func (r *DynamoDbRepository) DeleteDraftFromDynamoDb(ctx context.Context, userID, id string, version int64) error {
+	condition, names, values := draftCondition(version)
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(r.tables.Drafts),
+		Key:                       draftID(userID, id),
+		ConditionExpression:       condition,
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return r.draftConflict(ctx, userID, id)
+	}
+	return err
+}
+
+//PublishDraftToDynamoDb saves tweet like SaveTweetToDynamoDb and deletes the draft it was written in, in one transaction.
+//Nothing is saved when the draft is not at version anymore: ErrDraftVersionConflict
+func (r *DynamoDbRepository) PublishDraftToDynamoDb(ctx context.Context, replyingToAuthor string, tweet *model.Tweet, userID, id string, version int64) (*model.Tweet, error) {
+	condition, names, values := draftCondition(version)
+	ti := append(r.saveTweetItems(replyingToAuthor, tweet), types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName:                 aws.String(r.tables.Drafts),
+			Key:                       draftID(userID, id),
+			ConditionExpression:       condition,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		},
+	})
+
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: ti})
+	var cancelled *types.TransactionCanceledException
+	if errors.As(err, &cancelled) {
+		//the reasons are in the order of the items, the draft is the last one
+		reasons := cancelled.CancellationReasons
+		if len(reasons) == len(ti) && aws.ToString(reasons[len(ti)-1].Code) == "ConditionalCheckFailed" {
+			return nil, r.draftConflict(ctx, userID, id)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tweet, nil
+}
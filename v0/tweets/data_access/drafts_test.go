@@ -0,0 +1,154 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//draftsMockClient keeps the version of every draft and checks the conditions of the writes like DynamoDB does
+type draftsMockClient struct {
+	common.DynamoDBAPI
+	versions     map[string]int64
+	updates      []*dynamodb.UpdateItemInput
+	transactions []*dynamodb.TransactWriteItemsInput
+}
+
+func (m *draftsMockClient) matches(id string, condition *string, values map[string]types.AttributeValue) bool {
+	version, ok := m.versions[id]
+	switch aws.ToString(condition) {
+	case "attribute_not_exists(id)":
+		return !ok
+	case "attribute_exists(id)":
+		return ok
+	}
+	var expected int64
+	attributevalue.Unmarshal(values[":version"], &expected)
+	return ok && version == expected
+}
+
+func (m *draftsMockClient) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	id := input.Item["id"].(*types.AttributeValueMemberS).Value
+	if !m.matches(id, input.ConditionExpression, input.ExpressionAttributeValues) {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	var version int64
+	attributevalue.Unmarshal(input.Item["version"], &version)
+	m.versions[id] = version
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *draftsMockClient) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	id := input.Key["id"].(*types.AttributeValueMemberS).Value
+	if !m.matches(id, input.ConditionExpression, input.ExpressionAttributeValues) {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	m.updates = append(m.updates, input)
+	var version int64
+	attributevalue.Unmarshal(input.ExpressionAttributeValues[":new_version"], &version)
+	m.versions[id] = version
+	item, _ := attributevalue.MarshalMap(model.Draft{UserId: "sarah_edo", Id: id, Version: version})
+	return &dynamodb.UpdateItemOutput{Attributes: item}, nil
+}
+
+func (m *draftsMockClient) GetItem(ctx context.Context, input *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	id := input.Key["id"].(*types.AttributeValueMemberS).Value
+	version, ok := m.versions[id]
+	if !ok {
+		return &dynamodb.GetItemOutput{}, nil
+	}
+	item, _ := attributevalue.MarshalMap(model.Draft{UserId: "sarah_edo", Id: id, Version: version})
+	return &dynamodb.GetItemOutput{Item: item}, nil
+}
+
+func (m *draftsMockClient) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	id := input.Key["id"].(*types.AttributeValueMemberS).Value
+	if !m.matches(id, input.ConditionExpression, input.ExpressionAttributeValues) {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	delete(m.versions, id)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (m *draftsMockClient) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	m.transactions = append(m.transactions, input)
+	reasons := make([]types.CancellationReason, len(input.TransactItems))
+	failed := false
+	for i, ti := range input.TransactItems {
+		reasons[i].Code = aws.String("None")
+		if d := ti.Delete; d != nil && !m.matches(d.Key["id"].(*types.AttributeValueMemberS).Value, d.ConditionExpression, d.ExpressionAttributeValues) {
+			reasons[i].Code = aws.String("ConditionalCheckFailed")
+			failed = true
+		}
+	}
+	if failed {
+		return nil, &types.TransactionCanceledException{Message: aws.String("Transaction cancelled"), CancellationReasons: reasons}
+	}
+	for _, ti := range input.TransactItems {
+		if ti.Delete != nil {
+			delete(m.versions, ti.Delete.Key["id"].(*types.AttributeValueMemberS).Value)
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func Test_SaveDraftToDynamoDb(t *testing.T) {
+	ctx := context.Background()
+	client := &draftsMockClient{versions: map[string]int64{}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	_, err := repo.SaveDraftToDynamoDb(ctx, &model.Draft{UserId: "sarah_edo", Id: "draft-1", Version: 1}, 0)
+	assert.NoError(t, err)
+	saved, err := repo.SaveDraftToDynamoDb(ctx, &model.Draft{UserId: "sarah_edo", Id: "draft-1", Version: 2, Text: "from the phone", ReplyingTo: "tweet-1:tylermcginnis"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), saved.Version)
+	_, err = repo.SaveDraftToDynamoDb(ctx, &model.Draft{UserId: "sarah_edo", Id: "draft-1", Version: 2, Text: "from the laptop"}, 1)
+	assert.ErrorIs(t, err, ErrDraftVersionConflict, "the laptop read version 1, the phone saved version 2 since")
+	_, err = repo.SaveDraftToDynamoDb(ctx, &model.Draft{UserId: "sarah_edo", Id: "deleted", Version: 3}, 2)
+	assert.ErrorIs(t, err, ErrDraftNotFound)
+	assert.Equal(t, map[string]int64{"draft-1": 2}, client.versions)
+
+	if assert.Len(t, client.updates, 1) {
+		assert.Equal(t, "SET text_blob = :text, #version = :new_version, updated_at = :updated_at, replyingTo = :replyingTo REMOVE audience",
+			aws.ToString(client.updates[0].UpdateExpression), "an update keeps created_at")
+	}
+}
+
+func Test_DeleteDraftFromDynamoDb(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDynamoDbRepo(&draftsMockClient{versions: map[string]int64{"draft-1": 3, "draft-2": 1}}, fakeTables, fakeCursors)
+
+	assert.ErrorIs(t, repo.DeleteDraftFromDynamoDb(ctx, "sarah_edo", "draft-1", 2), ErrDraftVersionConflict)
+	assert.NoError(t, repo.DeleteDraftFromDynamoDb(ctx, "sarah_edo", "draft-1", 3))
+	assert.NoError(t, repo.DeleteDraftFromDynamoDb(ctx, "sarah_edo", "draft-2", 0), "version 0 deletes any version")
+	assert.ErrorIs(t, repo.DeleteDraftFromDynamoDb(ctx, "sarah_edo", "draft-2", 0), ErrDraftNotFound)
+}
+
+func Test_PublishDraftToDynamoDb(t *testing.T) {
+	ctx := context.Background()
+	client := &draftsMockClient{versions: map[string]int64{"draft-1": 2}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+	tweet := &model.Tweet{Id: "tweet-1", Author: "sarah_edo", Text: "done writing"}
+
+	_, err := repo.PublishDraftToDynamoDb(ctx, "", tweet, "sarah_edo", "draft-1", 1)
+	assert.ErrorIs(t, err, ErrDraftVersionConflict)
+	assert.Equal(t, map[string]int64{"draft-1": 2}, client.versions, "the draft stays when the tweet is not saved")
+
+	saved, err := repo.PublishDraftToDynamoDb(ctx, "", tweet, "sarah_edo", "draft-1", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, tweet, saved)
+	assert.Empty(t, client.versions)
+	if assert.Len(t, client.transactions, 2) {
+		items := client.transactions[1].TransactItems
+		assert.Equal(t, fakeTable, aws.ToString(items[0].Put.TableName), "the tweet is saved like SaveTweetToDynamoDb does")
+		assert.Equal(t, fakeDraftsTable, aws.ToString(items[len(items)-1].Delete.TableName))
+	}
+}
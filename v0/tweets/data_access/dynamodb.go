@@ -2,9 +2,10 @@ package tweetsdataaccess
 
 import (
 	"context"
-	"encoding/json"
-	"log"
-	"net/url"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -12,28 +13,66 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 
 	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
 	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
 )
 
+//Tables are the names of the tables the repository works with. They come from the config so the same code runs against dev and prod
+type Tables struct {
+	Tweets string
+	//the users table is owned by the users service. We only add tweet ids to it and check that users exist
+	Users string
+	//blocks, mutes and follows, see model.Relation
+	Relations string
+	//tweets waiting to be published, see model.ScheduledTweet. The lease of the publisher is kept there too
+	Scheduled string
+	//unfinished tweets, see model.Draft
+	Drafts string
+	//the votes on the polls of the tweets, see model.PollVote
+	Votes string
+	//what we know about the uploads, see model.Media. The lease of the orphan media collector is kept there too
+	Media string
+}
+
 //We can call this an Adapter! It connects to external service
 type DynamoDbRepository struct {
 	client common.DynamoDBAPI
-	tableName string
-}
-
-type NextKey struct {
-	Id string `json:"id"`
-	Author string `json:"author"`
+	tables Tables
+	listScanSegments int32
+	cursors *cursor.Codec
 }
 
-func NewDynamoDbRepo(client common.DynamoDBAPI, tableName string) *DynamoDbRepository{
+//cursors signs the pagination cursors handed to clients. See the cursor package
+func NewDynamoDbRepo(client common.DynamoDBAPI, tables Tables, cursors *cursor.Codec) *DynamoDbRepository{
 	return &DynamoDbRepository{
 		client: client,
-		tableName: tableName,
+		tables: tables,
+		listScanSegments: 1,
+		cursors: cursors,
+	}
+}
+
+//SetListScanSegments splits the Scan behind ListTweets into n parallel segments. Each page then costs n Scan calls but they run at the same time
+func (r *DynamoDbRepository) SetListScanSegments(n int32) {
+	if n > 0 && n <= MaxScanSegments {
+		r.listScanSegments = n
 	}
 }
 
 func (r *DynamoDbRepository) SaveTweetToDynamoDb(ctx context.Context, replyingToAuthor string, tweet *model.Tweet) (*model.Tweet, error) {
+	input := &dynamodb.TransactWriteItemsInput{
+        TransactItems: r.saveTweetItems(replyingToAuthor, tweet),
+    }
+
+	if _, err := r.client.TransactWriteItems(ctx,input); err != nil {
+		return nil,  r.mediaConflict(err, input.TransactItems)
+	}
+	return tweet, nil
+}
+
+//saveTweetItems are the writes of a new tweet: the tweet, the tweets set of its author, the replies set of the tweet it replies to
+//and its media
+func (r *DynamoDbRepository) saveTweetItems(replyingToAuthor string, tweet *model.Tweet) []types.TransactWriteItem {
 	item, _ := attributevalue.MarshalMap(tweet)
 	if len(tweet.Likes) == 0{
 			delete(item, "likes")
@@ -46,12 +85,12 @@ func (r *DynamoDbRepository) SaveTweetToDynamoDb(ctx context.Context, replyingTo
             {
                 Put: &types.Put{
                     Item: item,
-                    TableName: aws.String(r.tableName),
+                    TableName: aws.String(r.tables.Tweets),
                 },
             },
             {
                 Update: &types.Update{
-                    TableName:  aws.String("chirper-app-users-dev"),
+                    TableName:  aws.String(r.tables.Users),
 					Key: map[string]types.AttributeValue{
 						"id": &types.AttributeValueMemberS{Value: tweet.Author},
 					},
@@ -70,7 +109,7 @@ func (r *DynamoDbRepository) SaveTweetToDynamoDb(ctx context.Context, replyingTo
 		
 		ti = append(ti, types.TransactWriteItem{       
 			Update: &types.Update{
-				TableName:  aws.String(r.tableName),
+				TableName:  aws.String(r.tables.Tweets),
 				Key: map[string]types.AttributeValue{
 					"id": &types.AttributeValueMemberS{Value: tweet.ReplyingTo},
 					"author": &types.AttributeValueMemberS{Value: replyingToAuthor},
@@ -83,17 +122,17 @@ func (r *DynamoDbRepository) SaveTweetToDynamoDb(ctx context.Context, replyingTo
 			},
         })
 	}
+	return append(ti, r.attachMediaItems(tweet)...)
+}
 
-	input := &dynamodb.TransactWriteItemsInput{
-        TransactItems: ti,
-    }
+//batchWriteAttempts is how many times BulkSaveTweetToDynamoDb sends the tweets DynamoDB left unprocessed
+const batchWriteAttempts = 5
 
-	if _, err := r.client.TransactWriteItems(ctx,input); err != nil {
-		return nil,  err
-	}
-	return tweet, nil
-}
+//batchWriteBackoff is the wait before the first retry of the unprocessed tweets. It doubles on every retry
+var batchWriteBackoff = 100 * time.Millisecond
 
+//BulkSaveTweetToDynamoDb writes at most model.MaxBatchSize tweets. It returns an UnprocessedTweetsError when DynamoDB
+//still left some of them unwritten after batchWriteAttempts
 func (r *DynamoDbRepository) BulkSaveTweetToDynamoDb(ctx context.Context, tweets []*model.Tweet) error {
  	batch := make(map[string][]types.WriteRequest)
  	var requests []types.WriteRequest
@@ -111,104 +150,71 @@ func (r *DynamoDbRepository) BulkSaveTweetToDynamoDb(ctx context.Context, tweets
 		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
 	}
 
-	batch[r.tableName] = requests
+	batch[r.tables.Tweets] = requests
+
+	//items DynamoDB could not write(eg: throttling) come back as UnprocessedItems. They are sent again, with a growing
+	//wait, until there are none left or we run out of attempts
+	backoff := batchWriteBackoff
+	for attempt := 1; ; attempt++ {
+		out, err := r.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: batch,
+		})
+		if err != nil {
+			return err
+		}
+		batch = out.UnprocessedItems
+		if len(batch[r.tables.Tweets]) == 0 {
+			return nil
+		}
+		if attempt == batchWriteAttempts {
+			break
+		}
 
-	_, err := r.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
-		RequestItems: batch,
-	})
-	if err != nil {
-		return err
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
 	}
 
-	//for the sake of this demo, we don't want to deal with unprocessed items if any. We could let the user know the exact ids of unprocessed items if we wanted :)
-	// if len(op.UnprocessedItems) != 0 {
-	// 	log.Println("there were", len(op.UnprocessedItems), "unprocessed records")
-	// }
-
-	return nil
-	//for more of a migration type algorithm :)
-	// @see https://towardsdatascience.com/dynamodb-go-sdk-how-to-use-the-scan-and-batch-operations-efficiently-5b41988b4988
+	unprocessed := &UnprocessedTweetsError{}
+	for _, request := range batch[r.tables.Tweets] {
+		var id string
+		attributevalue.Unmarshal(request.PutRequest.Item["id"], &id)
+		unprocessed.Ids = append(unprocessed.Ids, id)
+	}
+	return unprocessed
 }
 
-func (r *DynamoDbRepository) ListTweetsFromDynamoDb(ctx context.Context, authedUserID, nextKey string, limit int32) (results []*model.Tweet, nextCursor string, err error) {
+func (r *DynamoDbRepository) GetTweetFromDynamoDb(ctx context.Context, tweetID string) (*model.Tweet, error){
 	items := []*model.Tweet{}
 
+	//the table key is id + author. Ids are unique on their own, so we query the hash key instead of asking callers for the author
 	p := &dynamodb.QueryInput{
-		TableName: aws.String(r.tableName),
-		KeyConditionExpression: aws.String("author = :author"),
+		TableName: aws.String(r.tables.Tweets),
+		KeyConditionExpression: aws.String("id = :id"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":author":        &types.AttributeValueMemberS{Value: authedUserID},
+			":id": &types.AttributeValueMemberS{Value: tweetID},
 		},
-		ScanIndexForward: aws.Bool(false), //it reverses the order of the list. The latest images will be first
-	}
-
-	if nextKey != "" {
-		nk := &NextKey{}
-
-		//We decode the key
-		k, _ := url.QueryUnescape(nextKey)
-
-		//parse the key
-		json.Unmarshal([]byte(k), nk)
-
-		st, _ := attributevalue.MarshalMap(nk)
-
-		p.ExclusiveStartKey = st
+		Limit: aws.Int32(1),
 	}
 
 	out, err := r.client.Query(ctx,p)
 
 	if err != nil {
-		return items, "", err
+		return nil, err
 	}
 
 	err = attributevalue.UnmarshalListOfMaps(out.Items, &items)
 	if err != nil {
-		return items, "", err
+		return nil, err
 	}
-
-	var mNextKey NextKey
-	if err := attributevalue.UnmarshalMap(out.LastEvaluatedKey, &mNextKey); err != nil {
-		return items, "", err
-	}
-
-	
-	log.Printf("%+v\n", nextKey)
-
-	var finalKeyValue string
-	if mNextKey.Id == "" {
-		//when the next key is null it means there is no more items ot return
-		finalKeyValue = string("null")
-	} else {
-		out, _ := json.Marshal(mNextKey.Id)
-		finalKeyValue = string(out)
+	if len(items) == 0 {
+		return nil, ErrTweetNotFound
 	}
 
-	return items, finalKeyValue, nil
-}
-
-func (r *DynamoDbRepository) GetTweetFromDynamoDb(ctx context.Context, tweetID string) (*model.Tweet, error){
-	item := model.Tweet{}
-
-	p := &dynamodb.GetItemInput{
-		TableName: aws.String(r.tableName),
-		Key: map[string]types.AttributeValue{
-            "id": &types.AttributeValueMemberS{Value: tweetID},
-        },
-	}
-
-	out, err := r.client.GetItem(ctx,p)
-
-	if err != nil {
-		return &item, err
-	}
-
-	err = attributevalue.UnmarshalMap(out.Item, &item)
-	if err != nil {
-		return &item, err
-	}
-
-	return &item, nil
+	return items[0], nil
 }
 
 func (r *DynamoDbRepository) SaveLikeToggleInDynamoDb(ctx context.Context, tweetID, author, authedUserID string, hasLiked bool) error {
@@ -220,7 +226,7 @@ func (r *DynamoDbRepository) SaveLikeToggleInDynamoDb(ctx context.Context, tweet
 	}
 
 	input := &dynamodb.UpdateItemInput{
-        TableName:  aws.String(r.tableName),
+        TableName:  aws.String(r.tables.Tweets),
         Key: map[string]types.AttributeValue{
             "id": &types.AttributeValueMemberS{Value: tweetID},
 			"author": &types.AttributeValueMemberS{Value: author},
@@ -243,55 +249,193 @@ func (r *DynamoDbRepository) SaveLikeToggleInDynamoDb(ctx context.Context, tweet
 
 func (r *DynamoDbRepository) ScanTweetsFromDynamoDb(ctx context.Context, limit int32, nextKey string) ([]*model.Tweet, string, error) {
 	/*
-	we expect the next key to be an object like { "id": "", "<range_key>": "" } . 
-	
-	Range key will be required for pagination only if the table has a range key was set during the configuration of the table. 
-	For the Tweets table, the range key is `authors` that is of type string
+	A page is made of one Scan call per segment that is not done yet, each asking for its share of the limit. The shares
+	add up to the limit, so a page never has more tweets than asked for and does not shrink as segments finish. With a
+	single segment(the default) this is a plain Scan. The cursor carries the LastEvaluatedKey of every segment. See
+	ParallelScanTweetsFromDynamoDb
 	*/
-	var mNextKey map[string]string
 	pItems := []*model.Tweet{}
 
-	input := &dynamodb.ScanInput{
-		TableName:  aws.String(r.tableName),
-		Limit:      aws.Int32(limit),
-	}
-
-	if nextKey != "" {
-		//We decode the key
-		k, _ := url.QueryUnescape(nextKey)
-
-		//parse the key
-		json.Unmarshal([]byte(k), &mNextKey)
-
-		st, _ := attributevalue.MarshalMap(mNextKey)
-
-		input.ExclusiveStartKey = st
-	}
-
-	out, err := r.client.Scan(ctx, input)
+	segments := r.listScanSegments
+	state, err := decodeScanCursor(r.cursors, nextKey, segments)
 	if err != nil {
 		return pItems, "", err
 	}
+	var pending []int32
+	for segment := int32(0); segment < segments; segment++ {
+		if !state.isDone(segment) {
+			pending = append(pending, segment)
+		}
+	}
+	//when the limit is less than the segments, the first ones get a tweet each and the others wait for the next page
+	limits := make(map[int32]int32, len(pending))
+	for i, segment := range pending {
+		share := limit / int32(len(pending))
+		if int32(i) < limit%int32(len(pending)) {
+			share++
+		}
+		if share > 0 {
+			limits[segment] = share
+		}
+	}
 
-	err = attributevalue.UnmarshalListOfMaps(out.Items, &pItems)
+	pages, err := r.ParallelScanTweetsFromDynamoDb(ctx, model.ParallelScanInput{
+		TotalSegments:      segments,
+		SegmentLimits:      limits,
+		MaxPagesPerSegment: 1,
+		Filter:             model.TweetFilter{VisibleOnly: true},
+		Cursor:             nextKey,
+	})
 	if err != nil {
 		return pItems, "", err
 	}
 
-	var finalKeyValue string
-	//if the an empty key is returned we know we have reached the end of the page
-	if out.LastEvaluatedKey == nil {
-		finalKeyValue = ""
-	} else {
-		if err := attributevalue.UnmarshalMap(out.LastEvaluatedKey, &mNextKey); err != nil {
-			return pItems, "", err
+	//nextKey stays when no segment was read(a limit of 0)
+	finalKeyValue := nextKey
+	for page := range pages {
+		if page.Err != nil {
+			return pItems, "", page.Err
 		}
-		out, _ := json.Marshal(mNextKey)
-		finalKeyValue = url.QueryEscape(string(out))
+		pItems = append(pItems, page.Tweets...)
+		//pages are sent one at a time, so the cursor of the last page covers every segment
+		finalKeyValue = page.Cursor
+	}
+	if err := ctx.Err(); err != nil {
+		return pItems, "", err
 	}
-	// log.Printf("%+v\n", out.LastEvaluatedKey)
 	return pItems, finalKeyValue, nil
 
 	//for more on how to improve your scanning speed see the link below. Ideally you may want to use dynamoDB Query operation which is faster
 	// @see https://towardsdatascience.com/dynamodb-go-sdk-how-to-use-the-scan-and-batch-operations-efficiently-5b41988b4988
+}
+
+//applyTweetFilter pushes the filter down to DynamoDB so we don't pay to transfer tweets we will throw away
+func applyTweetFilter(input *dynamodb.ScanInput, filter model.TweetFilter) {
+	var conditions []string
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	if len(filter.Authors) > 0 {
+		keys := make([]string, 0, len(filter.Authors))
+		for i, author := range filter.Authors {
+			k := fmt.Sprintf(":author%d", i)
+			keys = append(keys, k)
+			values[k] = &types.AttributeValueMemberS{Value: author}
+		}
+		names["#author"] = "author"
+		conditions = append(conditions, fmt.Sprintf("#author IN (%s)", strings.Join(keys, ", ")))
+	}
+
+	//created_at is stored as unix time in seconds. See the `unixtime` tag on model.Tweet
+	if !filter.From.IsZero() {
+		names["#created_at"] = "created_at"
+		values[":from"] = &types.AttributeValueMemberN{Value: fmt.Sprint(filter.From.Unix())}
+		conditions = append(conditions, "#created_at >= :from")
+	}
+	if !filter.To.IsZero() {
+		names["#created_at"] = "created_at"
+		values[":to"] = &types.AttributeValueMemberN{Value: fmt.Sprint(filter.To.Unix())}
+		conditions = append(conditions, "#created_at <= :to")
+	}
+
+	if filter.VisibleOnly {
+		names["#moderation_state"] = "moderation_state"
+		conditions = append(conditions, "attribute_not_exists(#moderation_state)")
+	}
+
+	if len(conditions) == 0 {
+		return
+	}
+	if len(values) == 0 {
+		values = nil //DynamoDB rejects an empty ExpressionAttributeValues
+	}
+	input.FilterExpression = aws.String(strings.Join(conditions, " AND "))
+	input.ExpressionAttributeNames = names
+	input.ExpressionAttributeValues = values
+}
+
+
+func (r *DynamoDbRepository) FindExistingUsersInDynamoDb(ctx context.Context, userIDs []string) (map[string]bool, error) {
+	existing := make(map[string]bool, len(userIDs))
+
+	//BatchGetItem accepts at most 100 keys per call
+	for start := 0; start < len(userIDs); start += 100 {
+		end := start + 100
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+
+		keys := make([]map[string]types.AttributeValue, 0, end-start)
+		for _, id := range userIDs[start:end] {
+			keys = append(keys, map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: id},
+			})
+		}
+
+		requestItems := map[string]types.KeysAndAttributes{
+			r.tables.Users: {Keys: keys, ProjectionExpression: aws.String("id")},
+		}
+		//keys DynamoDB could not get to(eg: throttling) come back as UnprocessedKeys. We keep asking until there are none left
+		for len(requestItems) > 0 {
+			out, err := r.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: requestItems})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, item := range out.Responses[r.tables.Users] {
+				if v, ok := item["id"].(*types.AttributeValueMemberS); ok {
+					existing[v.Value] = true
+				}
+			}
+			requestItems = out.UnprocessedKeys
+		}
+	}
+	return existing, nil
+}
+
+func (r *DynamoDbRepository) FindExistingTweetsInDynamoDb(ctx context.Context, tweetIDs []string) (map[string]bool, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		existing = make(map[string]bool, len(tweetIDs))
+		sem      = make(chan struct{}, 8) //we don't want to eat all the read capacity of the table
+	)
+
+	//we may only know the id of a tweet(eg: replyingTo), so we query the hash key instead of using BatchGetItem which needs the full key
+	for _, id := range tweetIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer func() { <-sem; wg.Done() }()
+
+			out, err := r.client.Query(ctx, &dynamodb.QueryInput{
+				TableName:              aws.String(r.tables.Tweets),
+				KeyConditionExpression: aws.String("id = :id"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":id": &types.AttributeValueMemberS{Value: id},
+				},
+				Limit:  aws.Int32(1),
+				Select: types.SelectCount,
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			if out.Count > 0 {
+				existing[id] = true
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return existing, nil
 }
\ No newline at end of file
@@ -3,27 +3,41 @@ package tweetsdataaccess
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/google/uuid"
 
 	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
 	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
 	"github.com/stretchr/testify/assert"
 )
 
 const fakeTable = "fake-table-name"
+const fakeUsersTable = "fake-users-table-name"
+const fakeRelationsTable = "fake-relations-table-name"
+const fakeScheduledTable = "fake-scheduled-table-name"
+const fakeDraftsTable = "fake-drafts-table-name"
+const fakeVotesTable = "fake-votes-table-name"
+const fakeMediaTable = "fake-media-table-name"
+
+var fakeTables = Tables{Tweets: fakeTable, Users: fakeUsersTable, Relations: fakeRelationsTable, Scheduled: fakeScheduledTable, Drafts: fakeDraftsTable, Votes: fakeVotesTable, Media: fakeMediaTable}
 
 type DynamodbMockClient struct {
 	common.DynamoDBAPI
 }
 
+var fakeCursors = cursor.NewCodec([]byte("fake-secret"), time.Hour)
+
 func initializeFakeDynamoDBRepository() (Repository, error) {
-	return NewDynamoDbRepo(&DynamodbMockClient{}, fakeTable), nil
+	return NewDynamoDbRepo(&DynamodbMockClient{}, fakeTables, fakeCursors), nil
 }
 
 func (m *DynamodbMockClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
@@ -66,8 +80,12 @@ func (m *DynamodbMockClient) Query(ctx context.Context, input *dynamodb.QueryInp
     if input.TableName == nil || *input.TableName == "" {
         return &dynamodb.QueryOutput{}, errors.New("Missing required field UpdateItemInput.TableName")
     }
+	if strings.HasPrefix(aws.ToString(input.KeyConditionExpression), "author") && aws.ToString(input.IndexName) != AuthorIndexName {
+		return &dynamodb.QueryOutput{}, errors.New("Query condition missed key schema element: id")
+	}
 
 	return &dynamodb.QueryOutput{
+		Count: 1,
 		Items:[]map[string]types.AttributeValue{
 			{
 				"id":        &types.AttributeValueMemberS{Value: "r0xu2v1qrxa6ygtvf2rkjw"},
@@ -90,8 +108,11 @@ func (m *DynamodbMockClient) BatchWriteItem(ctx context.Context, params *dynamod
 	// 	keys = append(keys, k)
 	// }
 
-	//Check Map Contains a key "fake-table-name"
+	//Check Map Contains a key "fake-table-name"(or the users table when seeding users)
 	requests, ok := params.RequestItems[fakeTable]
+	if !ok {
+		requests, ok = params.RequestItems[fakeUsersTable]
+	}
 	if !ok {
 		return &result, errors.New("table or tables specified in the BatchWriteItem request does not exist")
 	}
@@ -109,19 +130,61 @@ func (m *DynamodbMockClient) BatchWriteItem(ctx context.Context, params *dynamod
 	return &result, nil
 }
 
-func Test_ListTweetsFromDynamoDb_ReturnsWithNoError(t *testing.T) {
-	ctx := context.Background()
-	repo, err := initializeFakeDynamoDBRepository()
-	if err != nil {
-		t.Fatalf("error initializing repository: %s", err.Error())
+//BatchGetItem finds every user except "unknown_user". The first call leaves the last key unprocessed to exercise the retry
+func (m *DynamodbMockClient) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	result := dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{}}
+
+	for table, ka := range params.RequestItems {
+		if table != fakeUsersTable {
+			return &result, errors.New("users are looked up in the wrong table")
+		}
+		keys := ka.Keys
+		if len(keys) > 100 {
+			return &result, errors.New("Too many items requested for the BatchGetItem call")
+		}
+		if len(keys) > 1 {
+			result.UnprocessedKeys = map[string]types.KeysAndAttributes{table: {Keys: keys[len(keys)-1:]}}
+			keys = keys[:len(keys)-1]
+		}
+		for _, k := range keys {
+			if k["id"].(*types.AttributeValueMemberS).Value != "unknown_user" {
+				result.Responses[table] = append(result.Responses[table], k)
+			}
+		}
 	}
+	return &result, nil
+}
 
-	tweets, _, err := repo.ListTweetsFromDynamoDb(ctx, "dan_abramov", "", 0)
+//Scan returns two pages per segment. Each item is tagged with the segment that returned it
+func (m *DynamodbMockClient) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	if input.TableName == nil || *input.TableName == "" {
+		return &dynamodb.ScanOutput{}, errors.New("Missing required field ScanInput.TableName")
+	}
+
+	segment := fmt.Sprint(aws.ToInt32(input.Segment))
+	page := "1"
+	var lastKey map[string]types.AttributeValue
+	if input.ExclusiveStartKey == nil {
+		page = "0"
+		lastKey = map[string]types.AttributeValue{
+			"id":     &types.AttributeValueMemberS{Value: segment + "-0"},
+			"author": &types.AttributeValueMemberS{Value: "sarah_edo"},
+		}
+	}
+
+	item, err := attributevalue.MarshalMap(model.Tweet{
+		Id:        segment + "-" + page,
+		Author:    "sarah_edo",
+		Timestamp: model.ChirperAppUnixTime(time.Unix(1518122597, 0)),
+	})
 	if err != nil {
-        t.Fatal(err)
-    }
+		return &dynamodb.ScanOutput{}, err
+	}
 
-	assert.Equal(t, 1, len(tweets))
+	return &dynamodb.ScanOutput{
+		Items:            []map[string]types.AttributeValue{item},
+		LastEvaluatedKey: lastKey,
+	}, nil
 }
 
 func Test_GetTweetFromDynamoDb_ReturnsWithNoError(t *testing.T) {
@@ -176,6 +239,48 @@ func Test_BatchSaveTweetToDynamoDb(t *testing.T) {
 	}
 }
 
+//throttledMockClient leaves the last tweet of every BatchWriteItem unprocessed for the first throttled calls
+type throttledMockClient struct {
+	common.DynamoDBAPI
+	throttled int
+	calls     int
+	written   []string
+}
+
+func (m *throttledMockClient) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	m.calls++
+	requests := params.RequestItems[fakeTable]
+	out := &dynamodb.BatchWriteItemOutput{}
+	if m.calls <= m.throttled {
+		out.UnprocessedItems = map[string][]types.WriteRequest{fakeTable: requests[len(requests)-1:]}
+		requests = requests[:len(requests)-1]
+	}
+	for _, request := range requests {
+		var id string
+		attributevalue.Unmarshal(request.PutRequest.Item["id"], &id)
+		m.written = append(m.written, id)
+	}
+	return out, nil
+}
+
+func Test_BulkSaveTweetToDynamoDb_Unprocessed(t *testing.T) {
+	defer func(backoff time.Duration) { batchWriteBackoff = backoff }(batchWriteBackoff)
+	batchWriteBackoff = time.Millisecond
+	tweets := []*model.Tweet{{Id: "tweet-1", Author: "sarah_edo"}, {Id: "tweet-2", Author: "sarah_edo"}, {Id: "tweet-3", Author: "sarah_edo"}}
+
+	client := &throttledMockClient{throttled: 2}
+	err := NewDynamoDbRepo(client, fakeTables, fakeCursors).BulkSaveTweetToDynamoDb(context.Background(), tweets)
+	assert.NoError(t, err, "the unprocessed tweets are sent again")
+	assert.Equal(t, 3, client.calls)
+	assert.ElementsMatch(t, []string{"tweet-1", "tweet-2", "tweet-3"}, client.written)
+
+	client = &throttledMockClient{throttled: batchWriteAttempts}
+	err = NewDynamoDbRepo(client, fakeTables, fakeCursors).BulkSaveTweetToDynamoDb(context.Background(), tweets)
+	assert.Equal(t, &UnprocessedTweetsError{Ids: []string{"tweet-3"}}, err, "the tweets never written are returned")
+	assert.Equal(t, batchWriteAttempts, client.calls)
+	assert.ElementsMatch(t, []string{"tweet-1", "tweet-2"}, client.written)
+}
+
 // func Test_UpsertTweetFromDynamoDb_ReturnsWithNoError(t *testing.T) {
 // 	ctx := context.Background()
 // 	repo, err := initializeFakeDynamoDBRepository()
@@ -189,6 +294,208 @@ func Test_BatchSaveTweetToDynamoDb(t *testing.T) {
 //     }
 // }
 
+func Test_ParallelScanTweetsFromDynamoDb(t *testing.T) {
+	ctx := context.Background()
+	repo, err := initializeFakeDynamoDBRepository()
+	if err != nil {
+		t.Fatalf("error initializing repository: %s", err.Error())
+	}
+
+	collect := func(pages <-chan model.ScanPage) ([]string, string) {
+		ids, next := []string{}, ""
+		for page := range pages {
+			assert.NoError(t, page.Err)
+			for _, tweet := range page.Tweets {
+				ids = append(ids, tweet.Id)
+			}
+			next = page.Cursor
+		}
+		return ids, next
+	}
+
+	//the whole table
+	pages, err := repo.ParallelScanTweetsFromDynamoDb(ctx, model.ParallelScanInput{TotalSegments: 3})
+	assert.NoError(t, err)
+	ids, next := collect(pages)
+	assert.ElementsMatch(t, []string{"0-0", "0-1", "1-0", "1-1", "2-0", "2-1"}, ids)
+	assert.Equal(t, "", next)
+
+	//one page per segment then resume with the composite cursor
+	pages, err = repo.ParallelScanTweetsFromDynamoDb(ctx, model.ParallelScanInput{TotalSegments: 3, MaxPagesPerSegment: 1})
+	assert.NoError(t, err)
+	ids, next = collect(pages)
+	assert.ElementsMatch(t, []string{"0-0", "1-0", "2-0"}, ids)
+	assert.NotEqual(t, "", next)
+
+	pages, err = repo.ParallelScanTweetsFromDynamoDb(ctx, model.ParallelScanInput{TotalSegments: 3, Cursor: next})
+	assert.NoError(t, err)
+	ids, next = collect(pages)
+	assert.ElementsMatch(t, []string{"0-1", "1-1", "2-1"}, ids)
+	assert.Equal(t, "", next)
+
+	//tweets that slip through the scan filter are dropped
+	pages, err = repo.ParallelScanTweetsFromDynamoDb(ctx, model.ParallelScanInput{TotalSegments: 2, Filter: model.TweetFilter{Authors: []string{"dan_abramov"}}})
+	assert.NoError(t, err)
+	ids, _ = collect(pages)
+	assert.Empty(t, ids)
+
+	_, err = repo.ParallelScanTweetsFromDynamoDb(ctx, model.ParallelScanInput{TotalSegments: 2, Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, cursor.ErrInvalidCursor)
+
+	//a cursor only works with the number of segments it was created for
+	pages, err = repo.ParallelScanTweetsFromDynamoDb(ctx, model.ParallelScanInput{TotalSegments: 3, MaxPagesPerSegment: 1})
+	assert.NoError(t, err)
+	_, cursorFor3 := collect(pages)
+	_, err = repo.ParallelScanTweetsFromDynamoDb(ctx, model.ParallelScanInput{TotalSegments: 2, Cursor: cursorFor3})
+	assert.ErrorIs(t, err, cursor.ErrInvalidCursor)
+
+	_, err = repo.ParallelScanTweetsFromDynamoDb(ctx, model.ParallelScanInput{TotalSegments: 33})
+	assert.Equal(t, errors.New("segments must be between 1 and 32"), err)
+}
+
+func Test_ParallelScanTweetsFromDynamoDb_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	repo, err := initializeFakeDynamoDBRepository()
+	if err != nil {
+		t.Fatalf("error initializing repository: %s", err.Error())
+	}
+
+	pages, err := repo.ParallelScanTweetsFromDynamoDb(ctx, model.ParallelScanInput{TotalSegments: 4})
+	assert.NoError(t, err)
+	<-pages
+	cancel()
+
+	//the workers give up and the channel gets closed even though we stopped reading
+	for range pages {
+	}
+}
+
+func Test_ScanTweetsFromDynamoDb(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDynamoDbRepo(&DynamodbMockClient{}, fakeTables, fakeCursors)
+	repo.SetListScanSegments(2)
+
+	tweets, nextKey, err := repo.ScanTweetsFromDynamoDb(ctx, 10, "")
+	assert.NoError(t, err)
+	assert.Len(t, tweets, 2)
+	assert.NotEqual(t, "", nextKey)
+
+	tweets, nextKey, err = repo.ScanTweetsFromDynamoDb(ctx, 10, nextKey)
+	assert.NoError(t, err)
+	assert.Len(t, tweets, 2)
+	assert.Equal(t, "", nextKey)
+}
+
+//segmentedMockClient has sizes[segment] tweets in each segment and honours the Limit of every Scan call
+type segmentedMockClient struct {
+	common.DynamoDBAPI
+	sizes []int
+}
+
+func (m *segmentedMockClient) Scan(ctx context.Context, input *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	segment := aws.ToInt32(input.Segment)
+	start := 0
+	if input.ExclusiveStartKey != nil {
+		var last string
+		attributevalue.Unmarshal(input.ExclusiveStartKey["id"], &last)
+		fmt.Sscanf(last, "%d-%d", &segment, &start)
+		start++
+	}
+	end := m.sizes[segment]
+	if input.Limit != nil && start+int(aws.ToInt32(input.Limit)) < end {
+		end = start + int(aws.ToInt32(input.Limit))
+	}
+
+	out := &dynamodb.ScanOutput{}
+	for i := start; i < end; i++ {
+		item, err := attributevalue.MarshalMap(model.Tweet{Id: fmt.Sprintf("%d-%d", segment, i), Author: "sarah_edo"})
+		if err != nil {
+			return out, err
+		}
+		out.Items = append(out.Items, item)
+	}
+	if end < m.sizes[segment] {
+		out.LastEvaluatedKey = map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: fmt.Sprintf("%d-%d", segment, end-1)}}
+	}
+	return out, nil
+}
+
+func Test_ScanTweetsFromDynamoDb_Segments(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDynamoDbRepo(&segmentedMockClient{sizes: []int{10, 10, 1, 1}}, fakeTables, fakeCursors)
+	repo.SetListScanSegments(4)
+
+	//the limit is shared 3, 3, 2, 2 and the last two segments finish early
+	tweets, nextKey, err := repo.ScanTweetsFromDynamoDb(ctx, 10, "")
+	assert.NoError(t, err)
+	assert.Len(t, tweets, 8)
+
+	//the two segments left share the whole limit, so the page does not shrink
+	seen := map[string]bool{}
+	for _, tweet := range tweets {
+		seen[tweet.Id] = true
+	}
+	for nextKey != "" {
+		tweets, nextKey, err = repo.ScanTweetsFromDynamoDb(ctx, 10, nextKey)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.LessOrEqual(t, len(tweets), 10)
+		if nextKey != "" {
+			assert.Len(t, tweets, 10, "a page is full while there are tweets left")
+		}
+		for _, tweet := range tweets {
+			seen[tweet.Id] = true
+		}
+	}
+	assert.Len(t, seen, 22, "every tweet is listed once")
+
+	//a limit under the segments reads one tweet from the first ones only
+	tweets, nextKey, err = repo.ScanTweetsFromDynamoDb(ctx, 3, "")
+	assert.NoError(t, err)
+	assert.Len(t, tweets, 3)
+	assert.NotEqual(t, "", nextKey)
+}
+
+func Test_applyTweetFilter(t *testing.T) {
+	input := &dynamodb.ScanInput{}
+	applyTweetFilter(input, model.TweetFilter{})
+	assert.Nil(t, input.FilterExpression)
+
+	applyTweetFilter(input, model.TweetFilter{
+		Authors: []string{"sarah_edo", "tylermcginnis"},
+		From:    time.Unix(1518122597, 0),
+		To:      time.Unix(1518122600, 0),
+	})
+	assert.Equal(t, "#author IN (:author0, :author1) AND #created_at >= :from AND #created_at <= :to", aws.ToString(input.FilterExpression))
+	assert.Equal(t, map[string]string{"#author": "author", "#created_at": "created_at"}, input.ExpressionAttributeNames)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "1518122597"}, input.ExpressionAttributeValues[":from"])
+}
+
+func Test_FindExistingUsersInDynamoDb(t *testing.T) {
+	ctx := context.Background()
+	repo, err := initializeFakeDynamoDBRepository()
+	if err != nil {
+		t.Fatalf("error initializing repository: %s", err.Error())
+	}
+
+	existing, err := repo.FindExistingUsersInDynamoDb(ctx, []string{"sarah_edo", "unknown_user", "tylermcginnis"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"sarah_edo": true, "tylermcginnis": true}, existing)
+}
+
+func Test_FindExistingTweetsInDynamoDb(t *testing.T) {
+	ctx := context.Background()
+	repo, err := initializeFakeDynamoDBRepository()
+	if err != nil {
+		t.Fatalf("error initializing repository: %s", err.Error())
+	}
+
+	existing, err := repo.FindExistingTweetsInDynamoDb(ctx, []string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"a": true, "b": true}, existing)
+}
+
 func randomTweets(tweetsCount int) []*model.Tweet {
 	tweets := make([]*model.Tweet, 0)
 	n := 1
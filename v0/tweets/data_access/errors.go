@@ -0,0 +1,38 @@
+package tweetsdataaccess
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrTweetNotFound = errors.New("tweet not found")
+	ErrUserNotFound  = errors.New("user not found")
+	//returned when a reviewer acts on a tweet that is not waiting for review, eg another reviewer got to it first
+	ErrNotPendingReview       = errors.New("tweet is not waiting for review")
+	ErrScheduledTweetNotFound = errors.New("scheduled tweet not found")
+	//returned when a scheduled tweet is already being published, or was published, eg when it is cancelled too late
+	ErrScheduledTweetNotWaiting = errors.New("scheduled tweet is not waiting to be published")
+	ErrDraftNotFound            = errors.New("draft not found")
+	//returned when a draft was saved by someone else(eg another device of the user) since the version the caller read
+	ErrDraftVersionConflict = errors.New("draft was changed since it was read")
+	ErrAlreadyVoted         = errors.New("you already voted on this poll")
+	//returned when a vote comes after the poll closed
+	ErrPollClosed    = errors.New("poll is closed")
+	ErrMediaNotFound = errors.New("media not found")
+	//returned when a tweet references media that is gone, attached to another tweet or was uploaded by someone else
+	ErrMediaUnavailable = errors.New("media is not available to attach")
+	//returned when orphan media got attached to a tweet while it was being deleted
+	ErrMediaAttached = errors.New("media is attached to a tweet")
+)
+
+//UnprocessedTweetsError is returned by BulkSaveTweetToDynamoDb when DynamoDB kept leaving some tweets of a batch
+//unwritten, eg because the table is throttled. The other tweets of the batch were written
+type UnprocessedTweetsError struct {
+	Ids []string
+}
+
+func (e *UnprocessedTweetsError) Error() string {
+	return fmt.Sprintf("%d tweets were not written: %s", len(e.Ids), strings.Join(e.Ids, ", "))
+}
@@ -2,6 +2,7 @@ package tweetsdataaccess
 
 import (
 	"context"
+	"time"
 
 	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
 )
@@ -11,8 +12,7 @@ type Repository interface {
 	//Creates a new tweet or replaces a an old tweet with a new tweet(if the tweet id exists) in the in tweets table.
 	SaveTweetToDynamoDb(ctx context.Context, replyingToAuthor string, tweet *model.Tweet) (*model.Tweet, error)
 	//returns the list of tweets
-	ListTweetsFromDynamoDb(ctx context.Context, authedUserID, nextKey string, limit int32) (results []*model.Tweet, nextCursor string, err error)
-	//get a tweet by ID
+	//get a tweet by ID. Returns ErrTweetNotFound when there is no such tweet
 	GetTweetFromDynamoDb(ctx context.Context, tweetID string) (*model.Tweet, error)
 	//Update
 	// UpsertTweetFromDynamoDb(ctx context.Context, tweet *model.Tweet) error
@@ -22,4 +22,74 @@ type Repository interface {
 	ScanTweetsFromDynamoDb(ctx context.Context, limit int32, nextKey string) ([]*model.Tweet, string, error)
 	//Multi Create or replace
 	BulkSaveTweetToDynamoDb(ctx context.Context, tweets []*model.Tweet) error
+	//returns the ids from userIDs that exist in the users table
+	FindExistingUsersInDynamoDb(ctx context.Context, userIDs []string) (map[string]bool, error)
+	//returns the ids from tweetIDs that exist in the tweets table
+	FindExistingTweetsInDynamoDb(ctx context.Context, tweetIDs []string) (map[string]bool, error)
+	//scans the table with a worker per segment. The base for admin jobs like export, reindexing and counter repair
+	ParallelScanTweetsFromDynamoDb(ctx context.Context, input model.ParallelScanInput) (<-chan model.ScanPage, error)
+	//adds tweet ids to the tweets set of a user. Used to rebuild the users index
+	AddTweetsToUserInDynamoDb(ctx context.Context, userID string, tweetIDs []string) error
+	//fixes the replies set of a tweet
+	RepairRepliesInDynamoDb(ctx context.Context, tweetID, author string, missing, dangling []string) error
+	//Multi Create or replace users. Only used to seed demo and load test data
+	BulkSaveUsersToDynamoDb(ctx context.Context, users []*model.User) error
+	//returns the latest tweets of an author posted since a given time. Used by the duplicate text moderation rule
+	ListRecentTweetsByAuthorFromDynamoDb(ctx context.Context, author string, since time.Time, limit int32) ([]*model.Tweet, error)
+	//returns the tweets waiting for review, oldest first
+	ListModerationQueueFromDynamoDb(ctx context.Context, nextKey string, limit int32) ([]*model.Tweet, string, error)
+	//approves or removes a tweet waiting for review. Returns ErrNotPendingReview when it is not waiting for review
+	ReviewTweetInDynamoDb(ctx context.Context, tweetID, author string, approve bool, reviewer string, at time.Time) (*model.Tweet, error)
+	//creates or refreshes a block, mute or follow of a user
+	SaveRelationInDynamoDb(ctx context.Context, rel *model.Relation) error
+	//removes a block, mute or follow of a user
+	DeleteRelationFromDynamoDb(ctx context.Context, userID string, kind model.RelationKind, target string) error
+	//returns every block, mute and follow of a user
+	ListRelationsFromDynamoDb(ctx context.Context, userID string) ([]*model.Relation, error)
+	//tells whether userID blocked, muted or follows target
+	HasRelationInDynamoDb(ctx context.Context, userID string, kind model.RelationKind, target string) (bool, error)
+	//adds a tweet to publish later
+	SaveScheduledTweetToDynamoDb(ctx context.Context, scheduled *model.ScheduledTweet) error
+	//get a scheduled tweet by ID. Returns ErrScheduledTweetNotFound when there is no such scheduled tweet
+	GetScheduledTweetFromDynamoDb(ctx context.Context, id string) (*model.ScheduledTweet, error)
+	//returns the scheduled tweets of an author, the next one to go out first
+	ListScheduledTweetsFromDynamoDb(ctx context.Context, author, nextKey string, limit int32) ([]*model.ScheduledTweet, string, error)
+	//returns the scheduled tweets of a day bucket that are due
+	ListDueScheduledTweetsFromDynamoDb(ctx context.Context, bucket string, now time.Time, limit int32) ([]*model.ScheduledTweet, error)
+	//takes a due tweet for one publisher. Returns ErrScheduledTweetNotWaiting when another publisher has it or it was cancelled
+	ClaimScheduledTweetInDynamoDb(ctx context.Context, id, claim string, now, until time.Time) (*model.ScheduledTweet, error)
+	//marks a claimed scheduled tweet as published
+	CompleteScheduledTweetInDynamoDb(ctx context.Context, id, claim, tweetID string, expiresAt time.Time) error
+	//records a failed attempt of a claimed scheduled tweet, to retry at retryAt or for good when it is zero
+	FailScheduledTweetInDynamoDb(ctx context.Context, id, claim, lastError string, retryAt time.Time) error
+	//cancels a scheduled tweet and lets go of its media. Returns ErrScheduledTweetNotWaiting when it is being published or was published
+	DeleteScheduledTweetFromDynamoDb(ctx context.Context, scheduled *model.ScheduledTweet) error
+	//creates(readVersion 0) or replaces a draft. Returns ErrDraftVersionConflict when the draft is not at readVersion anymore
+	SaveDraftToDynamoDb(ctx context.Context, draft *model.Draft, readVersion int64) (*model.Draft, error)
+	//get a draft of a user. Returns ErrDraftNotFound when there is no such draft
+	GetDraftFromDynamoDb(ctx context.Context, userID, id string) (*model.Draft, error)
+	//returns the drafts of a user, the last edited first
+	ListDraftsFromDynamoDb(ctx context.Context, userID, nextKey string, limit int32) ([]*model.Draft, string, error)
+	//deletes a draft at version, or at any version when it is 0
+	DeleteDraftFromDynamoDb(ctx context.Context, userID, id string, version int64) error
+	//saves a tweet and deletes the draft at version it was written in, in one transaction
+	PublishDraftToDynamoDb(ctx context.Context, replyingToAuthor string, tweet *model.Tweet, userID, id string, version int64) (*model.Tweet, error)
+	//records a vote and adds it to the tally of the poll. Returns ErrAlreadyVoted or ErrPollClosed
+	VotePollInDynamoDb(ctx context.Context, vote *model.PollVote, author string, now time.Time) error
+	//returns the option userID voted for on each of tweetIDs they voted on
+	ListPollVotesFromDynamoDb(ctx context.Context, userID string, tweetIDs []string) (map[string]int, error)
+	//records a new upload
+	SaveMediaToDynamoDb(ctx context.Context, media *model.Media) error
+	//get a media by ID. Returns ErrMediaNotFound when there is no such media
+	GetMediaFromDynamoDb(ctx context.Context, id string) (*model.Media, error)
+	//returns the media of ids in the same order, without the missing ones
+	ListMediaFromDynamoDb(ctx context.Context, ids []string) ([]*model.Media, error)
+	//returns up to limit media no tweet references, uploaded before before
+	ListOrphanMediaFromDynamoDb(ctx context.Context, before time.Time, limit int) ([]*model.Media, error)
+	//deletes an orphan media. Returns ErrMediaAttached when a tweet references it
+	DeleteMediaFromDynamoDb(ctx context.Context, id string) error
+	//returns up to limit images waiting to be processed
+	ListPendingMediaFromDynamoDb(ctx context.Context, limit int) ([]*model.Media, error)
+	//records the outcome of the processing of an image. Returns ErrMediaNotFound when the media is gone
+	SaveMediaProcessingToDynamoDb(ctx context.Context, media *model.Media) error
 }
\ No newline at end of file
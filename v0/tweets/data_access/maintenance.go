@@ -0,0 +1,109 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//maxSetUpdate is how many values we put in a single ADD/DELETE. It keeps each request well under the 400KB item limit
+const maxSetUpdate = 100
+
+//AddTweetsToUserInDynamoDb adds tweetIDs to the tweets set of a user. It is what SaveTweetToDynamoDb does for a single tweet, so running it again is harmless.
+//It returns ErrUserNotFound when the user is not in the users table; we never create users here
+func (r *DynamoDbRepository) AddTweetsToUserInDynamoDb(ctx context.Context, userID string, tweetIDs []string) error {
+	for start := 0; start < len(tweetIDs); start += maxSetUpdate {
+		end := start + maxSetUpdate
+		if end > len(tweetIDs) {
+			end = len(tweetIDs)
+		}
+
+		_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(r.tables.Users),
+			Key: map[string]types.AttributeValue{
+				"id": &types.AttributeValueMemberS{Value: userID},
+			},
+			UpdateExpression: aws.String("ADD tweets :tweets"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":tweets": &types.AttributeValueMemberSS{Value: tweetIDs[start:end]},
+			},
+			ConditionExpression: aws.String("attribute_exists(id)"),
+		})
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//RepairRepliesInDynamoDb adds the missing reply ids to the replies set of a tweet and removes the dangling ones.
+//ADD and DELETE can't touch the same attribute in one expression so they are separate calls. Both are idempotent
+func (r *DynamoDbRepository) RepairRepliesInDynamoDb(ctx context.Context, tweetID, author string, missing, dangling []string) error {
+	update := func(expression string, ids []string) error {
+		if len(ids) == 0 {
+			return nil
+		}
+		_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(r.tables.Tweets),
+			Key: map[string]types.AttributeValue{
+				"id":     &types.AttributeValueMemberS{Value: tweetID},
+				"author": &types.AttributeValueMemberS{Value: author},
+			},
+			UpdateExpression: aws.String(expression),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":replies": &types.AttributeValueMemberSS{Value: ids},
+			},
+			ConditionExpression: aws.String("attribute_exists(id)"), //the tweet may have been deleted since we scanned it
+		})
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return ErrTweetNotFound
+		}
+		return err
+	}
+
+	if err := update("ADD replies :replies", missing); err != nil {
+		return err
+	}
+	return update("DELETE replies :replies", dangling)
+}
+
+//BulkSaveUsersToDynamoDb creates or replaces users in the users table, 25 at a time. It is only meant for seeding demo and load test environments
+func (r *DynamoDbRepository) BulkSaveUsersToDynamoDb(ctx context.Context, users []*model.User) error {
+	for start := 0; start < len(users); start += model.MaxBatchSize {
+		end := start + model.MaxBatchSize
+		if end > len(users) {
+			end = len(users)
+		}
+
+		requests := make([]types.WriteRequest, 0, end-start)
+		for _, user := range users[start:end] {
+			item, err := attributevalue.MarshalMap(user)
+			if err != nil {
+				return err
+			}
+			requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+		}
+
+		//items DynamoDB could not write(eg: throttling) come back as UnprocessedItems. We keep sending them until there are none left
+		items := map[string][]types.WriteRequest{r.tables.Users: requests}
+		for len(items) > 0 {
+			out, err := r.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: items})
+			if err != nil {
+				return err
+			}
+			items = out.UnprocessedItems
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//updateRecorder records every UpdateItem. Items whose id is in missing fail the attribute_exists(id) condition
+type updateRecorder struct {
+	common.DynamoDBAPI
+	missing map[string]bool
+	inputs  []*dynamodb.UpdateItemInput
+}
+
+func (m *updateRecorder) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if m.missing[input.Key["id"].(*types.AttributeValueMemberS).Value] {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	m.inputs = append(m.inputs, input)
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func Test_AddTweetsToUserInDynamoDb(t *testing.T) {
+	client := &updateRecorder{missing: map[string]bool{"unknown_user": true}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	ids := make([]string, 150)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("tweet-%d", i)
+	}
+
+	err := repo.AddTweetsToUserInDynamoDb(context.Background(), "sarah_edo", ids)
+	assert.NoError(t, err)
+	if assert.Len(t, client.inputs, 2) { //split in chunks of 100
+		assert.Equal(t, fakeUsersTable, aws.ToString(client.inputs[0].TableName))
+		assert.Len(t, client.inputs[0].ExpressionAttributeValues[":tweets"].(*types.AttributeValueMemberSS).Value, 100)
+		assert.Len(t, client.inputs[1].ExpressionAttributeValues[":tweets"].(*types.AttributeValueMemberSS).Value, 50)
+	}
+
+	err = repo.AddTweetsToUserInDynamoDb(context.Background(), "unknown_user", ids[:1])
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func Test_RepairRepliesInDynamoDb(t *testing.T) {
+	client := &updateRecorder{missing: map[string]bool{"deleted": true}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	err := repo.RepairRepliesInDynamoDb(context.Background(), "root", "sarah_edo", []string{"reply2"}, []string{"gone"})
+	assert.NoError(t, err)
+	if assert.Len(t, client.inputs, 2) {
+		assert.Equal(t, "ADD replies :replies", aws.ToString(client.inputs[0].UpdateExpression))
+		assert.Equal(t, "DELETE replies :replies", aws.ToString(client.inputs[1].UpdateExpression))
+	}
+
+	//nothing to remove means a single call
+	client.inputs = nil
+	err = repo.RepairRepliesInDynamoDb(context.Background(), "root", "sarah_edo", []string{"reply2"}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, client.inputs, 1)
+
+	err = repo.RepairRepliesInDynamoDb(context.Background(), "deleted", "sarah_edo", []string{"reply2"}, nil)
+	assert.ErrorIs(t, err, ErrTweetNotFound)
+}
+
+func Test_BulkSaveUsersToDynamoDb(t *testing.T) {
+	repo := NewDynamoDbRepo(&DynamodbMockClient{}, fakeTables, fakeCursors)
+
+	users := make([]*model.User, 60)
+	for i := range users {
+		users[i] = &model.User{Id: fmt.Sprintf("user-%d", i), Name: "User", Tweets: []string{"a"}}
+	}
+
+	//the mock rejects batches of more than 25
+	err := repo.BulkSaveUsersToDynamoDb(context.Background(), users)
+	assert.NoError(t, err)
+}
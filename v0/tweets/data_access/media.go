@@ -0,0 +1,241 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func mediaID(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}}
+}
+
+//SaveMediaToDynamoDb records a new upload. The id must be new
+func (r *DynamoDbRepository) SaveMediaToDynamoDb(ctx context.Context, media *model.Media) error {
+	item, err := attributevalue.MarshalMap(media)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tables.Media),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(id)"),
+	})
+	return err
+}
+
+func (r *DynamoDbRepository) GetMediaFromDynamoDb(ctx context.Context, id string) (*model.Media, error) {
+	out, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tables.Media),
+		Key:       mediaID(id),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Item) == 0 {
+		return nil, ErrMediaNotFound
+	}
+	media := &model.Media{}
+	if err := attributevalue.UnmarshalMap(out.Item, media); err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+//ListMediaFromDynamoDb returns the media of ids in the same order. Missing ids are left out
+func (r *DynamoDbRepository) ListMediaFromDynamoDb(ctx context.Context, ids []string) ([]*model.Media, error) {
+	found := make(map[string]*model.Media, len(ids))
+
+	//BatchGetItem accepts at most 100 keys per call
+	for start := 0; start < len(ids); start += 100 {
+		end := start + 100
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		keys := make([]map[string]types.AttributeValue, 0, end-start)
+		seen := map[string]bool{}
+		for _, id := range ids[start:end] {
+			//BatchGetItem rejects duplicate keys
+			if !seen[id] {
+				seen[id] = true
+				keys = append(keys, mediaID(id))
+			}
+		}
+
+		requestItems := map[string]types.KeysAndAttributes{
+			r.tables.Media: {Keys: keys},
+		}
+		for len(requestItems) > 0 {
+			out, err := r.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: requestItems})
+			if err != nil {
+				return nil, err
+			}
+
+			var media []*model.Media
+			if err := attributevalue.UnmarshalListOfMaps(out.Responses[r.tables.Media], &media); err != nil {
+				return nil, err
+			}
+			for _, m := range media {
+				found[m.Id] = m
+			}
+			requestItems = out.UnprocessedKeys
+		}
+	}
+
+	list := make([]*model.Media, 0, len(found))
+	for _, id := range ids {
+		if m, ok := found[id]; ok {
+			list = append(list, m)
+		}
+	}
+	return list, nil
+}
+
+//ListOrphanMediaFromDynamoDb returns up to limit media no tweet references that were uploaded before before, oldest
+//first. It queries MediaOrphanIndexName, so it only reads the orphans
+func (r *DynamoDbRepository) ListOrphanMediaFromDynamoDb(ctx context.Context, before time.Time, limit int) ([]*model.Media, error) {
+	return r.queryMedia(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tables.Media),
+		IndexName:              aws.String(MediaOrphanIndexName),
+		KeyConditionExpression: aws.String("pending_gc = :orphan AND created_at < :before"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":orphan": &types.AttributeValueMemberS{Value: model.MediaOrphan},
+			":before": unixValue(before),
+		},
+		ScanIndexForward: aws.Bool(true),
+	}, limit)
+}
+
+//queryMedia reads the pages of a query on an index of the media table until it has limit media
+func (r *DynamoDbRepository) queryMedia(ctx context.Context, input *dynamodb.QueryInput, limit int) ([]*model.Media, error) {
+	var media []*model.Media
+	for {
+		input.Limit = aws.Int32(int32(limit - len(media)))
+		out, err := r.client.Query(ctx, input)
+		if err != nil {
+			return media, err
+		}
+		var page []*model.Media
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return media, err
+		}
+		media = append(media, page...)
+		if len(media) >= limit {
+			return media[:limit], nil
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return media, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+//ListPendingMediaFromDynamoDb returns up to limit images waiting for the processing worker, oldest first. It queries
+//MediaPendingIndexName, so it only reads the pending images
+func (r *DynamoDbRepository) ListPendingMediaFromDynamoDb(ctx context.Context, limit int) ([]*model.Media, error) {
+	return r.queryMedia(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tables.Media),
+		IndexName:              aws.String(MediaPendingIndexName),
+		KeyConditionExpression: aws.String("pending_processing = :pending"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending": &types.AttributeValueMemberS{Value: model.MediaPending},
+		},
+		ScanIndexForward: aws.Bool(true),
+	}, limit)
+}
+
+//SaveMediaProcessingToDynamoDb records what the processing worker found out about an image: its status, size,
+//placeholder, thumbnails and attempts. An image that is no longer pending leaves MediaPendingIndexName. The rest of the
+//media, eg the tweet it is attached to, is left alone. It returns ErrMediaNotFound when the media was deleted in the
+//meantime
+func (r *DynamoDbRepository) SaveMediaProcessingToDynamoDb(ctx context.Context, media *model.Media) error {
+	thumbnails, err := attributevalue.Marshal(media.Thumbnails)
+	if err != nil {
+		return err
+	}
+	update := "SET #status = :status, width = :width, height = :height, placeholder = :placeholder, thumbnails = :thumbnails, attempts = :attempts"
+	if media.Status != model.MediaPending {
+		update += " REMOVE pending_processing"
+	}
+	_, err = r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(r.tables.Media),
+		Key:                      mediaID(media.Id),
+		UpdateExpression:         aws.String(update),
+		ConditionExpression:      aws.String("attribute_exists(id)"),
+		ExpressionAttributeNames: map[string]string{"#status": "status"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status":      &types.AttributeValueMemberS{Value: media.Status},
+			":width":       &types.AttributeValueMemberN{Value: strconv.Itoa(media.Width)},
+			":height":      &types.AttributeValueMemberN{Value: strconv.Itoa(media.Height)},
+			":placeholder": &types.AttributeValueMemberS{Value: media.Placeholder},
+			":thumbnails":  thumbnails,
+			":attempts":    &types.AttributeValueMemberN{Value: strconv.Itoa(media.Attempts)},
+		},
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrMediaNotFound
+	}
+	return err
+}
+
+//DeleteMediaFromDynamoDb deletes an orphan. It returns ErrMediaAttached when a tweet references it by now
+func (r *DynamoDbRepository) DeleteMediaFromDynamoDb(ctx context.Context, id string) error {
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:           aws.String(r.tables.Media),
+		Key:                 mediaID(id),
+		ConditionExpression: aws.String("attribute_not_exists(tweet_id)"),
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrMediaAttached
+	}
+	return err
+}
+
+//attachMediaItems attach the media of a new tweet to it. Each write checks the media exists, is an orphan(or already has
+//the tweet, when it is saved again) and was uploaded by the author, so two tweets can't take the same media
+func (r *DynamoDbRepository) attachMediaItems(tweet *model.Tweet) []types.TransactWriteItem {
+	items := make([]types.TransactWriteItem, 0, len(tweet.MediaIds))
+	for _, id := range tweet.MediaIds {
+		items = append(items, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(r.tables.Media),
+				Key:       mediaID(id),
+				//removing pending_gc takes the media out of MediaOrphanIndexName
+				UpdateExpression:    aws.String("SET tweet_id = :tweet REMOVE pending_gc"),
+				ConditionExpression: aws.String("attribute_exists(id) AND (attribute_not_exists(tweet_id) OR tweet_id = :tweet) AND user_id = :author"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":tweet":  &types.AttributeValueMemberS{Value: tweet.Id},
+					":author": &types.AttributeValueMemberS{Value: tweet.Author},
+				},
+			},
+		})
+	}
+	return items
+}
+
+//mediaConflict turns a transaction cancelled by the condition of attachMediaItems into ErrMediaUnavailable
+func (r *DynamoDbRepository) mediaConflict(err error, items []types.TransactWriteItem) error {
+	var cancelled *types.TransactionCanceledException
+	if !errors.As(err, &cancelled) {
+		return err
+	}
+	//the reasons are in the order of the items
+	for i, reason := range cancelled.CancellationReasons {
+		if i < len(items) && items[i].Update != nil && aws.ToString(items[i].Update.TableName) == r.tables.Media &&
+			aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+			return ErrMediaUnavailable
+		}
+	}
+	return err
+}
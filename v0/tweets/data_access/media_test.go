@@ -0,0 +1,245 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//mediaMockClient keeps the media items. A transaction fails the condition of the media that are already attached
+type mediaMockClient struct {
+	common.DynamoDBAPI
+	media        map[string]*model.Media
+	transactions []*dynamodb.TransactWriteItemsInput
+	queries      int
+}
+
+func (m *mediaMockClient) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	m.transactions = append(m.transactions, input)
+	reasons := make([]types.CancellationReason, len(input.TransactItems))
+	cancelled := false
+	for i, item := range input.TransactItems {
+		reasons[i].Code = aws.String("None")
+		if item.Update == nil || aws.ToString(item.Update.TableName) != fakeMediaTable {
+			continue
+		}
+		var id string
+		attributevalue.Unmarshal(item.Update.Key["id"], &id)
+		if media, ok := m.media[id]; !ok || media.TweetId != "" {
+			reasons[i].Code = aws.String("ConditionalCheckFailed")
+			cancelled = true
+		}
+	}
+	if cancelled {
+		return nil, &types.TransactionCanceledException{Message: aws.String("Transaction cancelled"), CancellationReasons: reasons}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (m *mediaMockClient) BatchGetItem(ctx context.Context, input *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	var items []map[string]types.AttributeValue
+	for _, key := range input.RequestItems[fakeMediaTable].Keys {
+		var id string
+		attributevalue.Unmarshal(key["id"], &id)
+		if media, ok := m.media[id]; ok {
+			item, _ := attributevalue.MarshalMap(media)
+			items = append(items, item)
+		}
+	}
+	return &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{fakeMediaTable: items}}, nil
+}
+
+//Query answers the orphans of MediaOrphanIndexName created before :before, or the images of MediaPendingIndexName,
+//one per page, by id
+func (m *mediaMockClient) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	m.queries++
+	var before model.ChirperAppUnixTime
+	attributevalue.Unmarshal(input.ExpressionAttributeValues[":before"], &before)
+	var start string
+	attributevalue.Unmarshal(input.ExclusiveStartKey["id"], &start)
+
+	var ids []string
+	for id, media := range m.media {
+		indexed := media.PendingGC != "" && time.Time(media.CreatedAt).Before(time.Time(before))
+		if aws.ToString(input.IndexName) == MediaPendingIndexName {
+			indexed = media.PendingProcessing != ""
+		}
+		if indexed && id > start {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	out := &dynamodb.QueryOutput{}
+	if len(ids) > 0 {
+		item, _ := attributevalue.MarshalMap(m.media[ids[0]])
+		out.Items = append(out.Items, item)
+	}
+	if len(ids) > 1 {
+		out.LastEvaluatedKey = mediaID(ids[0])
+	}
+	return out, nil
+}
+
+//UpdateItem applies the processing of SaveMediaProcessingToDynamoDb to the media, if it exists
+func (m *mediaMockClient) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	var id string
+	attributevalue.Unmarshal(input.Key["id"], &id)
+	media, ok := m.media[id]
+	if !ok {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	values := input.ExpressionAttributeValues
+	attributevalue.Unmarshal(values[":status"], &media.Status)
+	attributevalue.Unmarshal(values[":width"], &media.Width)
+	attributevalue.Unmarshal(values[":height"], &media.Height)
+	attributevalue.Unmarshal(values[":placeholder"], &media.Placeholder)
+	attributevalue.Unmarshal(values[":thumbnails"], &media.Thumbnails)
+	attributevalue.Unmarshal(values[":attempts"], &media.Attempts)
+	if strings.Contains(aws.ToString(input.UpdateExpression), "REMOVE pending_processing") {
+		media.PendingProcessing = ""
+	}
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (m *mediaMockClient) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	var id string
+	attributevalue.Unmarshal(input.Key["id"], &id)
+	if media, ok := m.media[id]; ok && media.TweetId != "" {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	delete(m.media, id)
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func Test_SaveTweetToDynamoDb_Media(t *testing.T) {
+	testCases := []struct {
+		name          string
+		mediaIds      []string
+		expectedError error
+	}{
+		{name: "should attach the media", mediaIds: []string{"photo-1", "photo-2"}},
+		{name: "should not attach media of another tweet", mediaIds: []string{"photo-1", "attached"}, expectedError: ErrMediaUnavailable},
+		{name: "should not attach missing media", mediaIds: []string{"missing"}, expectedError: ErrMediaUnavailable},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			client := &mediaMockClient{media: map[string]*model.Media{
+				"photo-1":  {Id: "photo-1", UserId: "sarah_edo"},
+				"photo-2":  {Id: "photo-2", UserId: "sarah_edo"},
+				"attached": {Id: "attached", UserId: "sarah_edo", TweetId: "other-tweet"},
+			}}
+			repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+			_, err := repo.SaveTweetToDynamoDb(context.Background(), "", &model.Tweet{Id: "tweet-1", Author: "sarah_edo", Text: "look", MediaIds: tc.mediaIds})
+			assert.Equal(t, tc.expectedError, err)
+			if assert.Len(t, client.transactions, 1) {
+				items := client.transactions[0].TransactItems
+				//the tweet, the tweets set of the author, then the media
+				assert.Len(t, items, 2+len(tc.mediaIds))
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "tweet-1"}, items[2].Update.ExpressionAttributeValues[":tweet"])
+				assert.Contains(t, aws.ToString(items[2].Update.UpdateExpression), "REMOVE pending_gc", "attached media leave the orphan index")
+			}
+		})
+	}
+}
+
+func Test_ListMediaFromDynamoDb(t *testing.T) {
+	client := &mediaMockClient{media: map[string]*model.Media{
+		"photo-1": {Id: "photo-1"},
+		"photo-2": {Id: "photo-2"},
+	}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	media, err := repo.ListMediaFromDynamoDb(context.Background(), []string{"photo-2", "missing", "photo-1", "photo-2"})
+	assert.NoError(t, err)
+	ids := make([]string, len(media))
+	for i, m := range media {
+		ids[i] = m.Id
+	}
+	assert.Equal(t, []string{"photo-2", "photo-1", "photo-2"}, ids, "in the order asked for")
+}
+
+func Test_ListOrphanMediaFromDynamoDb(t *testing.T) {
+	now := time.Now()
+	old := model.ChirperAppUnixTime(now.Add(-time.Hour))
+	client := &mediaMockClient{media: map[string]*model.Media{
+		"orphan-1": {Id: "orphan-1", PendingGC: model.MediaOrphan, CreatedAt: old},
+		"orphan-2": {Id: "orphan-2", PendingGC: model.MediaOrphan, CreatedAt: old},
+		"orphan-3": {Id: "orphan-3", PendingGC: model.MediaOrphan, CreatedAt: old},
+		"new":      {Id: "new", PendingGC: model.MediaOrphan, CreatedAt: model.ChirperAppUnixTime(now)},
+		"attached": {Id: "attached", TweetId: "tweet-1", CreatedAt: old},
+	}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	orphans, err := repo.ListOrphanMediaFromDynamoDb(context.Background(), now.Add(-time.Minute), 2)
+	assert.NoError(t, err)
+	assert.Len(t, orphans, 2)
+	assert.Equal(t, 2, client.queries, "stops reading once it has limit orphans")
+
+	orphans, err = repo.ListOrphanMediaFromDynamoDb(context.Background(), now.Add(-time.Minute), 10)
+	assert.NoError(t, err)
+	assert.Len(t, orphans, 3, "the new upload and the attached media are left out")
+}
+
+func Test_ListPendingMediaFromDynamoDb(t *testing.T) {
+	client := &mediaMockClient{media: map[string]*model.Media{
+		"pending-1": {Id: "pending-1", Status: model.MediaPending, PendingProcessing: model.MediaPending},
+		"pending-2": {Id: "pending-2", Status: model.MediaPending, PendingProcessing: model.MediaPending},
+		"ready":     {Id: "ready", Status: model.MediaReady},
+		"video":     {Id: "video"},
+	}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	pending, err := repo.ListPendingMediaFromDynamoDb(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2, "only the images waiting for the worker")
+
+	client.queries = 0
+	pending, err = repo.ListPendingMediaFromDynamoDb(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, 1, client.queries, "stops reading once it has limit images")
+}
+
+func Test_DeleteMediaFromDynamoDb(t *testing.T) {
+	client := &mediaMockClient{media: map[string]*model.Media{
+		"orphan":   {Id: "orphan"},
+		"attached": {Id: "attached", TweetId: "tweet-1"},
+	}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	assert.NoError(t, repo.DeleteMediaFromDynamoDb(context.Background(), "orphan"))
+	assert.Equal(t, ErrMediaAttached, repo.DeleteMediaFromDynamoDb(context.Background(), "attached"))
+	assert.Len(t, client.media, 1)
+}
+
+func Test_SaveMediaProcessingToDynamoDb(t *testing.T) {
+	client := &mediaMockClient{media: map[string]*model.Media{
+		"image": {Id: "image", TweetId: "tweet-1", Status: model.MediaPending, PendingProcessing: model.MediaPending},
+		"retry": {Id: "retry", Status: model.MediaPending, PendingProcessing: model.MediaPending},
+	}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	processed := &model.Media{Id: "image", Status: model.MediaReady, Width: 640, Height: 480, Placeholder: "LEHV6nWB2yk8pyo0adR*.7kCMdnj", Thumbnails: []model.MediaThumbnail{{Side: 150, Size: 4096}}, Attempts: 1}
+	assert.NoError(t, repo.SaveMediaProcessingToDynamoDb(context.Background(), processed))
+	assert.Equal(t, &model.Media{Id: "image", TweetId: "tweet-1", Status: model.MediaReady, Width: 640, Height: 480, Placeholder: "LEHV6nWB2yk8pyo0adR*.7kCMdnj", Thumbnails: []model.MediaThumbnail{{Side: 150, Size: 4096}}, Attempts: 1}, client.media["image"], "the tweet of the media is kept")
+
+	assert.NoError(t, repo.SaveMediaProcessingToDynamoDb(context.Background(), &model.Media{Id: "retry", Status: model.MediaPending, Attempts: 1}))
+	assert.Equal(t, model.MediaPending, client.media["retry"].PendingProcessing, "an image to retry stays in the index")
+
+	assert.Equal(t, ErrMediaNotFound, repo.SaveMediaProcessingToDynamoDb(context.Background(), &model.Media{Id: "deleted", Status: model.MediaReady}))
+}
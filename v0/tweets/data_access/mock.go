@@ -7,6 +7,7 @@ package tweetsdataaccess
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	tweetmodel "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
@@ -35,6 +36,20 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddTweetsToUserInDynamoDb mocks base method.
+func (m *MockRepository) AddTweetsToUserInDynamoDb(ctx context.Context, userID string, tweetIDs []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTweetsToUserInDynamoDb", ctx, userID, tweetIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddTweetsToUserInDynamoDb indicates an expected call of AddTweetsToUserInDynamoDb.
+func (mr *MockRepositoryMockRecorder) AddTweetsToUserInDynamoDb(ctx, userID, tweetIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTweetsToUserInDynamoDb", reflect.TypeOf((*MockRepository)(nil).AddTweetsToUserInDynamoDb), ctx, userID, tweetIDs)
+}
+
 // BulkSaveTweetToDynamoDb mocks base method.
 func (m *MockRepository) BulkSaveTweetToDynamoDb(ctx context.Context, tweets []*tweetmodel.Tweet) error {
 	m.ctrl.T.Helper()
@@ -49,6 +64,194 @@ func (mr *MockRepositoryMockRecorder) BulkSaveTweetToDynamoDb(ctx, tweets interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkSaveTweetToDynamoDb", reflect.TypeOf((*MockRepository)(nil).BulkSaveTweetToDynamoDb), ctx, tweets)
 }
 
+// BulkSaveUsersToDynamoDb mocks base method.
+func (m *MockRepository) BulkSaveUsersToDynamoDb(ctx context.Context, users []*tweetmodel.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkSaveUsersToDynamoDb", ctx, users)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BulkSaveUsersToDynamoDb indicates an expected call of BulkSaveUsersToDynamoDb.
+func (mr *MockRepositoryMockRecorder) BulkSaveUsersToDynamoDb(ctx, users interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkSaveUsersToDynamoDb", reflect.TypeOf((*MockRepository)(nil).BulkSaveUsersToDynamoDb), ctx, users)
+}
+
+// ClaimScheduledTweetInDynamoDb mocks base method.
+func (m *MockRepository) ClaimScheduledTweetInDynamoDb(ctx context.Context, id, claim string, now, until time.Time) (*tweetmodel.ScheduledTweet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimScheduledTweetInDynamoDb", ctx, id, claim, now, until)
+	ret0, _ := ret[0].(*tweetmodel.ScheduledTweet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimScheduledTweetInDynamoDb indicates an expected call of ClaimScheduledTweetInDynamoDb.
+func (mr *MockRepositoryMockRecorder) ClaimScheduledTweetInDynamoDb(ctx, id, claim, now, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimScheduledTweetInDynamoDb", reflect.TypeOf((*MockRepository)(nil).ClaimScheduledTweetInDynamoDb), ctx, id, claim, now, until)
+}
+
+// CompleteScheduledTweetInDynamoDb mocks base method.
+func (m *MockRepository) CompleteScheduledTweetInDynamoDb(ctx context.Context, id, claim, tweetID string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteScheduledTweetInDynamoDb", ctx, id, claim, tweetID, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteScheduledTweetInDynamoDb indicates an expected call of CompleteScheduledTweetInDynamoDb.
+func (mr *MockRepositoryMockRecorder) CompleteScheduledTweetInDynamoDb(ctx, id, claim, tweetID, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteScheduledTweetInDynamoDb", reflect.TypeOf((*MockRepository)(nil).CompleteScheduledTweetInDynamoDb), ctx, id, claim, tweetID, expiresAt)
+}
+
+// DeleteDraftFromDynamoDb mocks base method.
+func (m *MockRepository) DeleteDraftFromDynamoDb(ctx context.Context, userID, id string, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDraftFromDynamoDb", ctx, userID, id, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDraftFromDynamoDb indicates an expected call of DeleteDraftFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) DeleteDraftFromDynamoDb(ctx, userID, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDraftFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).DeleteDraftFromDynamoDb), ctx, userID, id, version)
+}
+
+// DeleteMediaFromDynamoDb mocks base method.
+func (m *MockRepository) DeleteMediaFromDynamoDb(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMediaFromDynamoDb", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMediaFromDynamoDb indicates an expected call of DeleteMediaFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) DeleteMediaFromDynamoDb(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMediaFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).DeleteMediaFromDynamoDb), ctx, id)
+}
+
+// DeleteRelationFromDynamoDb mocks base method.
+func (m *MockRepository) DeleteRelationFromDynamoDb(ctx context.Context, userID string, kind tweetmodel.RelationKind, target string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRelationFromDynamoDb", ctx, userID, kind, target)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRelationFromDynamoDb indicates an expected call of DeleteRelationFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) DeleteRelationFromDynamoDb(ctx, userID, kind, target interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRelationFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).DeleteRelationFromDynamoDb), ctx, userID, kind, target)
+}
+
+// DeleteScheduledTweetFromDynamoDb mocks base method.
+func (m *MockRepository) DeleteScheduledTweetFromDynamoDb(ctx context.Context, scheduled *tweetmodel.ScheduledTweet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteScheduledTweetFromDynamoDb", ctx, scheduled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteScheduledTweetFromDynamoDb indicates an expected call of DeleteScheduledTweetFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) DeleteScheduledTweetFromDynamoDb(ctx, scheduled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteScheduledTweetFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).DeleteScheduledTweetFromDynamoDb), ctx, scheduled)
+}
+
+// FailScheduledTweetInDynamoDb mocks base method.
+func (m *MockRepository) FailScheduledTweetInDynamoDb(ctx context.Context, id, claim, lastError string, retryAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailScheduledTweetInDynamoDb", ctx, id, claim, lastError, retryAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailScheduledTweetInDynamoDb indicates an expected call of FailScheduledTweetInDynamoDb.
+func (mr *MockRepositoryMockRecorder) FailScheduledTweetInDynamoDb(ctx, id, claim, lastError, retryAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailScheduledTweetInDynamoDb", reflect.TypeOf((*MockRepository)(nil).FailScheduledTweetInDynamoDb), ctx, id, claim, lastError, retryAt)
+}
+
+// FindExistingTweetsInDynamoDb mocks base method.
+func (m *MockRepository) FindExistingTweetsInDynamoDb(ctx context.Context, tweetIDs []string) (map[string]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindExistingTweetsInDynamoDb", ctx, tweetIDs)
+	ret0, _ := ret[0].(map[string]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindExistingTweetsInDynamoDb indicates an expected call of FindExistingTweetsInDynamoDb.
+func (mr *MockRepositoryMockRecorder) FindExistingTweetsInDynamoDb(ctx, tweetIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindExistingTweetsInDynamoDb", reflect.TypeOf((*MockRepository)(nil).FindExistingTweetsInDynamoDb), ctx, tweetIDs)
+}
+
+// FindExistingUsersInDynamoDb mocks base method.
+func (m *MockRepository) FindExistingUsersInDynamoDb(ctx context.Context, userIDs []string) (map[string]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindExistingUsersInDynamoDb", ctx, userIDs)
+	ret0, _ := ret[0].(map[string]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindExistingUsersInDynamoDb indicates an expected call of FindExistingUsersInDynamoDb.
+func (mr *MockRepositoryMockRecorder) FindExistingUsersInDynamoDb(ctx, userIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindExistingUsersInDynamoDb", reflect.TypeOf((*MockRepository)(nil).FindExistingUsersInDynamoDb), ctx, userIDs)
+}
+
+// GetDraftFromDynamoDb mocks base method.
+func (m *MockRepository) GetDraftFromDynamoDb(ctx context.Context, userID, id string) (*tweetmodel.Draft, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDraftFromDynamoDb", ctx, userID, id)
+	ret0, _ := ret[0].(*tweetmodel.Draft)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDraftFromDynamoDb indicates an expected call of GetDraftFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) GetDraftFromDynamoDb(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDraftFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).GetDraftFromDynamoDb), ctx, userID, id)
+}
+
+// GetMediaFromDynamoDb mocks base method.
+func (m *MockRepository) GetMediaFromDynamoDb(ctx context.Context, id string) (*tweetmodel.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMediaFromDynamoDb", ctx, id)
+	ret0, _ := ret[0].(*tweetmodel.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMediaFromDynamoDb indicates an expected call of GetMediaFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) GetMediaFromDynamoDb(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMediaFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).GetMediaFromDynamoDb), ctx, id)
+}
+
+// GetScheduledTweetFromDynamoDb mocks base method.
+func (m *MockRepository) GetScheduledTweetFromDynamoDb(ctx context.Context, id string) (*tweetmodel.ScheduledTweet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTweetFromDynamoDb", ctx, id)
+	ret0, _ := ret[0].(*tweetmodel.ScheduledTweet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTweetFromDynamoDb indicates an expected call of GetScheduledTweetFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) GetScheduledTweetFromDynamoDb(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTweetFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).GetScheduledTweetFromDynamoDb), ctx, id)
+}
+
 // GetTweetFromDynamoDb mocks base method.
 func (m *MockRepository) GetTweetFromDynamoDb(ctx context.Context, tweetID string) (*tweetmodel.Tweet, error) {
 	m.ctrl.T.Helper()
@@ -64,20 +267,246 @@ func (mr *MockRepositoryMockRecorder) GetTweetFromDynamoDb(ctx, tweetID interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTweetFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).GetTweetFromDynamoDb), ctx, tweetID)
 }
 
-// ListTweetsFromDynamoDb mocks base method.
-func (m *MockRepository) ListTweetsFromDynamoDb(ctx context.Context, authedUserID, nextKey string, limit int32) ([]*tweetmodel.Tweet, string, error) {
+// HasRelationInDynamoDb mocks base method.
+func (m *MockRepository) HasRelationInDynamoDb(ctx context.Context, userID string, kind tweetmodel.RelationKind, target string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasRelationInDynamoDb", ctx, userID, kind, target)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasRelationInDynamoDb indicates an expected call of HasRelationInDynamoDb.
+func (mr *MockRepositoryMockRecorder) HasRelationInDynamoDb(ctx, userID, kind, target interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasRelationInDynamoDb", reflect.TypeOf((*MockRepository)(nil).HasRelationInDynamoDb), ctx, userID, kind, target)
+}
+
+// ListDraftsFromDynamoDb mocks base method.
+func (m *MockRepository) ListDraftsFromDynamoDb(ctx context.Context, userID, nextKey string, limit int32) ([]*tweetmodel.Draft, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDraftsFromDynamoDb", ctx, userID, nextKey, limit)
+	ret0, _ := ret[0].([]*tweetmodel.Draft)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListDraftsFromDynamoDb indicates an expected call of ListDraftsFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) ListDraftsFromDynamoDb(ctx, userID, nextKey, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDraftsFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ListDraftsFromDynamoDb), ctx, userID, nextKey, limit)
+}
+
+// ListDueScheduledTweetsFromDynamoDb mocks base method.
+func (m *MockRepository) ListDueScheduledTweetsFromDynamoDb(ctx context.Context, bucket string, now time.Time, limit int32) ([]*tweetmodel.ScheduledTweet, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListTweetsFromDynamoDb", ctx, authedUserID, nextKey, limit)
+	ret := m.ctrl.Call(m, "ListDueScheduledTweetsFromDynamoDb", ctx, bucket, now, limit)
+	ret0, _ := ret[0].([]*tweetmodel.ScheduledTweet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueScheduledTweetsFromDynamoDb indicates an expected call of ListDueScheduledTweetsFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) ListDueScheduledTweetsFromDynamoDb(ctx, bucket, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueScheduledTweetsFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ListDueScheduledTweetsFromDynamoDb), ctx, bucket, now, limit)
+}
+
+// ListMediaFromDynamoDb mocks base method.
+func (m *MockRepository) ListMediaFromDynamoDb(ctx context.Context, ids []string) ([]*tweetmodel.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMediaFromDynamoDb", ctx, ids)
+	ret0, _ := ret[0].([]*tweetmodel.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMediaFromDynamoDb indicates an expected call of ListMediaFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) ListMediaFromDynamoDb(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMediaFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ListMediaFromDynamoDb), ctx, ids)
+}
+
+// ListModerationQueueFromDynamoDb mocks base method.
+func (m *MockRepository) ListModerationQueueFromDynamoDb(ctx context.Context, nextKey string, limit int32) ([]*tweetmodel.Tweet, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListModerationQueueFromDynamoDb", ctx, nextKey, limit)
 	ret0, _ := ret[0].([]*tweetmodel.Tweet)
 	ret1, _ := ret[1].(string)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
-// ListTweetsFromDynamoDb indicates an expected call of ListTweetsFromDynamoDb.
-func (mr *MockRepositoryMockRecorder) ListTweetsFromDynamoDb(ctx, authedUserID, nextKey, limit interface{}) *gomock.Call {
+// ListModerationQueueFromDynamoDb indicates an expected call of ListModerationQueueFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) ListModerationQueueFromDynamoDb(ctx, nextKey, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListModerationQueueFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ListModerationQueueFromDynamoDb), ctx, nextKey, limit)
+}
+
+// ListOrphanMediaFromDynamoDb mocks base method.
+func (m *MockRepository) ListOrphanMediaFromDynamoDb(ctx context.Context, before time.Time, limit int) ([]*tweetmodel.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrphanMediaFromDynamoDb", ctx, before, limit)
+	ret0, _ := ret[0].([]*tweetmodel.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrphanMediaFromDynamoDb indicates an expected call of ListOrphanMediaFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) ListOrphanMediaFromDynamoDb(ctx, before, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrphanMediaFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ListOrphanMediaFromDynamoDb), ctx, before, limit)
+}
+
+// ListPendingMediaFromDynamoDb mocks base method.
+func (m *MockRepository) ListPendingMediaFromDynamoDb(ctx context.Context, limit int) ([]*tweetmodel.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingMediaFromDynamoDb", ctx, limit)
+	ret0, _ := ret[0].([]*tweetmodel.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingMediaFromDynamoDb indicates an expected call of ListPendingMediaFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) ListPendingMediaFromDynamoDb(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingMediaFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ListPendingMediaFromDynamoDb), ctx, limit)
+}
+
+// ListPollVotesFromDynamoDb mocks base method.
+func (m *MockRepository) ListPollVotesFromDynamoDb(ctx context.Context, userID string, tweetIDs []string) (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPollVotesFromDynamoDb", ctx, userID, tweetIDs)
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPollVotesFromDynamoDb indicates an expected call of ListPollVotesFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) ListPollVotesFromDynamoDb(ctx, userID, tweetIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPollVotesFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ListPollVotesFromDynamoDb), ctx, userID, tweetIDs)
+}
+
+// ListRecentTweetsByAuthorFromDynamoDb mocks base method.
+func (m *MockRepository) ListRecentTweetsByAuthorFromDynamoDb(ctx context.Context, author string, since time.Time, limit int32) ([]*tweetmodel.Tweet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRecentTweetsByAuthorFromDynamoDb", ctx, author, since, limit)
+	ret0, _ := ret[0].([]*tweetmodel.Tweet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRecentTweetsByAuthorFromDynamoDb indicates an expected call of ListRecentTweetsByAuthorFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) ListRecentTweetsByAuthorFromDynamoDb(ctx, author, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRecentTweetsByAuthorFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ListRecentTweetsByAuthorFromDynamoDb), ctx, author, since, limit)
+}
+
+// ListRelationsFromDynamoDb mocks base method.
+func (m *MockRepository) ListRelationsFromDynamoDb(ctx context.Context, userID string) ([]*tweetmodel.Relation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRelationsFromDynamoDb", ctx, userID)
+	ret0, _ := ret[0].([]*tweetmodel.Relation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRelationsFromDynamoDb indicates an expected call of ListRelationsFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) ListRelationsFromDynamoDb(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRelationsFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ListRelationsFromDynamoDb), ctx, userID)
+}
+
+// ListScheduledTweetsFromDynamoDb mocks base method.
+func (m *MockRepository) ListScheduledTweetsFromDynamoDb(ctx context.Context, author, nextKey string, limit int32) ([]*tweetmodel.ScheduledTweet, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListScheduledTweetsFromDynamoDb", ctx, author, nextKey, limit)
+	ret0, _ := ret[0].([]*tweetmodel.ScheduledTweet)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListScheduledTweetsFromDynamoDb indicates an expected call of ListScheduledTweetsFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) ListScheduledTweetsFromDynamoDb(ctx, author, nextKey, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTweetsFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ListScheduledTweetsFromDynamoDb), ctx, author, nextKey, limit)
+}
+
+// ParallelScanTweetsFromDynamoDb mocks base method.
+func (m *MockRepository) ParallelScanTweetsFromDynamoDb(ctx context.Context, input tweetmodel.ParallelScanInput) (<-chan tweetmodel.ScanPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParallelScanTweetsFromDynamoDb", ctx, input)
+	ret0, _ := ret[0].(<-chan tweetmodel.ScanPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParallelScanTweetsFromDynamoDb indicates an expected call of ParallelScanTweetsFromDynamoDb.
+func (mr *MockRepositoryMockRecorder) ParallelScanTweetsFromDynamoDb(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParallelScanTweetsFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ParallelScanTweetsFromDynamoDb), ctx, input)
+}
+
+// PublishDraftToDynamoDb mocks base method.
+func (m *MockRepository) PublishDraftToDynamoDb(ctx context.Context, replyingToAuthor string, tweet *tweetmodel.Tweet, userID, id string, version int64) (*tweetmodel.Tweet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishDraftToDynamoDb", ctx, replyingToAuthor, tweet, userID, id, version)
+	ret0, _ := ret[0].(*tweetmodel.Tweet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishDraftToDynamoDb indicates an expected call of PublishDraftToDynamoDb.
+func (mr *MockRepositoryMockRecorder) PublishDraftToDynamoDb(ctx, replyingToAuthor, tweet, userID, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishDraftToDynamoDb", reflect.TypeOf((*MockRepository)(nil).PublishDraftToDynamoDb), ctx, replyingToAuthor, tweet, userID, id, version)
+}
+
+// RepairRepliesInDynamoDb mocks base method.
+func (m *MockRepository) RepairRepliesInDynamoDb(ctx context.Context, tweetID, author string, missing, dangling []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairRepliesInDynamoDb", ctx, tweetID, author, missing, dangling)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RepairRepliesInDynamoDb indicates an expected call of RepairRepliesInDynamoDb.
+func (mr *MockRepositoryMockRecorder) RepairRepliesInDynamoDb(ctx, tweetID, author, missing, dangling interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTweetsFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ListTweetsFromDynamoDb), ctx, authedUserID, nextKey, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairRepliesInDynamoDb", reflect.TypeOf((*MockRepository)(nil).RepairRepliesInDynamoDb), ctx, tweetID, author, missing, dangling)
+}
+
+// ReviewTweetInDynamoDb mocks base method.
+func (m *MockRepository) ReviewTweetInDynamoDb(ctx context.Context, tweetID, author string, approve bool, reviewer string, at time.Time) (*tweetmodel.Tweet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviewTweetInDynamoDb", ctx, tweetID, author, approve, reviewer, at)
+	ret0, _ := ret[0].(*tweetmodel.Tweet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReviewTweetInDynamoDb indicates an expected call of ReviewTweetInDynamoDb.
+func (mr *MockRepositoryMockRecorder) ReviewTweetInDynamoDb(ctx, tweetID, author, approve, reviewer, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviewTweetInDynamoDb", reflect.TypeOf((*MockRepository)(nil).ReviewTweetInDynamoDb), ctx, tweetID, author, approve, reviewer, at)
+}
+
+// SaveDraftToDynamoDb mocks base method.
+func (m *MockRepository) SaveDraftToDynamoDb(ctx context.Context, draft *tweetmodel.Draft, readVersion int64) (*tweetmodel.Draft, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDraftToDynamoDb", ctx, draft, readVersion)
+	ret0, _ := ret[0].(*tweetmodel.Draft)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveDraftToDynamoDb indicates an expected call of SaveDraftToDynamoDb.
+func (mr *MockRepositoryMockRecorder) SaveDraftToDynamoDb(ctx, draft, readVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDraftToDynamoDb", reflect.TypeOf((*MockRepository)(nil).SaveDraftToDynamoDb), ctx, draft, readVersion)
 }
 
 // SaveLikeToggleInDynamoDb mocks base method.
@@ -94,6 +523,62 @@ func (mr *MockRepositoryMockRecorder) SaveLikeToggleInDynamoDb(ctx, tweetID, aut
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLikeToggleInDynamoDb", reflect.TypeOf((*MockRepository)(nil).SaveLikeToggleInDynamoDb), ctx, tweetID, author, authedUserID, hasLiked)
 }
 
+// SaveMediaProcessingToDynamoDb mocks base method.
+func (m *MockRepository) SaveMediaProcessingToDynamoDb(ctx context.Context, media *tweetmodel.Media) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMediaProcessingToDynamoDb", ctx, media)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveMediaProcessingToDynamoDb indicates an expected call of SaveMediaProcessingToDynamoDb.
+func (mr *MockRepositoryMockRecorder) SaveMediaProcessingToDynamoDb(ctx, media interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMediaProcessingToDynamoDb", reflect.TypeOf((*MockRepository)(nil).SaveMediaProcessingToDynamoDb), ctx, media)
+}
+
+// SaveMediaToDynamoDb mocks base method.
+func (m *MockRepository) SaveMediaToDynamoDb(ctx context.Context, media *tweetmodel.Media) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMediaToDynamoDb", ctx, media)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveMediaToDynamoDb indicates an expected call of SaveMediaToDynamoDb.
+func (mr *MockRepositoryMockRecorder) SaveMediaToDynamoDb(ctx, media interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMediaToDynamoDb", reflect.TypeOf((*MockRepository)(nil).SaveMediaToDynamoDb), ctx, media)
+}
+
+// SaveRelationInDynamoDb mocks base method.
+func (m *MockRepository) SaveRelationInDynamoDb(ctx context.Context, rel *tweetmodel.Relation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveRelationInDynamoDb", ctx, rel)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveRelationInDynamoDb indicates an expected call of SaveRelationInDynamoDb.
+func (mr *MockRepositoryMockRecorder) SaveRelationInDynamoDb(ctx, rel interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveRelationInDynamoDb", reflect.TypeOf((*MockRepository)(nil).SaveRelationInDynamoDb), ctx, rel)
+}
+
+// SaveScheduledTweetToDynamoDb mocks base method.
+func (m *MockRepository) SaveScheduledTweetToDynamoDb(ctx context.Context, scheduled *tweetmodel.ScheduledTweet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveScheduledTweetToDynamoDb", ctx, scheduled)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveScheduledTweetToDynamoDb indicates an expected call of SaveScheduledTweetToDynamoDb.
+func (mr *MockRepositoryMockRecorder) SaveScheduledTweetToDynamoDb(ctx, scheduled interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveScheduledTweetToDynamoDb", reflect.TypeOf((*MockRepository)(nil).SaveScheduledTweetToDynamoDb), ctx, scheduled)
+}
+
 // SaveTweetToDynamoDb mocks base method.
 func (m *MockRepository) SaveTweetToDynamoDb(ctx context.Context, replyingToAuthor string, tweet *tweetmodel.Tweet) (*tweetmodel.Tweet, error) {
 	m.ctrl.T.Helper()
@@ -124,3 +609,17 @@ func (mr *MockRepositoryMockRecorder) ScanTweetsFromDynamoDb(ctx, limit, nextKey
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanTweetsFromDynamoDb", reflect.TypeOf((*MockRepository)(nil).ScanTweetsFromDynamoDb), ctx, limit, nextKey)
 }
+
+// VotePollInDynamoDb mocks base method.
+func (m *MockRepository) VotePollInDynamoDb(ctx context.Context, vote *tweetmodel.PollVote, author string, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VotePollInDynamoDb", ctx, vote, author, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VotePollInDynamoDb indicates an expected call of VotePollInDynamoDb.
+func (mr *MockRepositoryMockRecorder) VotePollInDynamoDb(ctx, vote, author, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VotePollInDynamoDb", reflect.TypeOf((*MockRepository)(nil).VotePollInDynamoDb), ctx, vote, author, now)
+}
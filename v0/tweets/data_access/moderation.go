@@ -0,0 +1,137 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//cursor kind of the review queue cursors
+const moderationCursorKind = "moderation-queue"
+
+//moderationKey is the LastEvaluatedKey of a query on ModerationIndexName
+type moderationKey struct {
+	Id              string `json:"id" dynamodbav:"id"`
+	Author          string `json:"author" dynamodbav:"author"`
+	ModerationState string `json:"moderation_state" dynamodbav:"moderation_state"`
+	CreatedAt       int64  `json:"created_at" dynamodbav:"created_at"`
+}
+
+//ListRecentTweetsByAuthorFromDynamoDb returns up to limit tweets the author posted since `since`, newest first. Moderated tweets are included.
+//Only the fields the moderation rules look at are read
+func (r *DynamoDbRepository) ListRecentTweetsByAuthorFromDynamoDb(ctx context.Context, author string, since time.Time, limit int32) ([]*model.Tweet, error) {
+	items := []*model.Tweet{}
+
+	out, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tables.Tweets),
+		IndexName:              aws.String(AuthorIndexName),
+		KeyConditionExpression: aws.String("author = :author AND created_at >= :since"),
+		ProjectionExpression:   aws.String("id, author, text_blob, created_at"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":author": &types.AttributeValueMemberS{Value: author},
+			//created_at is stored as unix time in seconds. See the `unixtime` tag on model.Tweet
+			":since": &types.AttributeValueMemberN{Value: fmt.Sprint(since.Unix())},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(limit),
+	})
+	if err != nil {
+		return items, err
+	}
+
+	err = attributevalue.UnmarshalListOfMaps(out.Items, &items)
+	return items, err
+}
+
+//ListModerationQueueFromDynamoDb returns the tweets waiting for review, oldest first, so the queue is worked through in order
+func (r *DynamoDbRepository) ListModerationQueueFromDynamoDb(ctx context.Context, nextKey string, limit int32) ([]*model.Tweet, string, error) {
+	items := []*model.Tweet{}
+
+	p := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tables.Tweets),
+		IndexName:              aws.String(ModerationIndexName),
+		KeyConditionExpression: aws.String("moderation_state = :state"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":state": &types.AttributeValueMemberS{Value: model.ModerationPending},
+		},
+		ScanIndexForward: aws.Bool(true),
+		Limit:            aws.Int32(limit),
+	}
+
+	if nextKey != "" {
+		nk := &moderationKey{}
+		if err := r.cursors.Decode(moderationCursorKind, nextKey, nk); err != nil {
+			return items, "", err
+		}
+		p.ExclusiveStartKey, _ = attributevalue.MarshalMap(nk)
+	}
+
+	out, err := r.client.Query(ctx, p)
+	if err != nil {
+		return items, "", err
+	}
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &items); err != nil {
+		return items, "", err
+	}
+
+	var finalKeyValue string
+	if len(out.LastEvaluatedKey) > 0 {
+		var mNextKey moderationKey
+		if err := attributevalue.UnmarshalMap(out.LastEvaluatedKey, &mNextKey); err != nil {
+			return items, "", err
+		}
+		if finalKeyValue, err = r.cursors.Encode(moderationCursorKind, mNextKey); err != nil {
+			return items, "", err
+		}
+	}
+	return items, finalKeyValue, nil
+}
+
+//ReviewTweetInDynamoDb records the decision of a reviewer on a pending tweet. Approving makes the tweet visible, otherwise it is marked removed.
+//It returns ErrNotPendingReview when the tweet is not(or no longer) waiting for review
+func (r *DynamoDbRepository) ReviewTweetInDynamoDb(ctx context.Context, tweetID, author string, approve bool, reviewer string, at time.Time) (*model.Tweet, error) {
+	values := map[string]types.AttributeValue{
+		":pending":  &types.AttributeValueMemberS{Value: model.ModerationPending},
+		":reviewer": &types.AttributeValueMemberS{Value: reviewer},
+		":at":       &types.AttributeValueMemberN{Value: fmt.Sprint(at.Unix())},
+	}
+	//removing the attribute also takes the tweet out of ModerationIndexName
+	update := "REMOVE moderation_state SET reviewed_by = :reviewer, reviewed_at = :at"
+	if !approve {
+		update = "SET moderation_state = :removed, reviewed_by = :reviewer, reviewed_at = :at"
+		values[":removed"] = &types.AttributeValueMemberS{Value: model.ModerationRemoved}
+	}
+
+	out, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.tables.Tweets),
+		Key: map[string]types.AttributeValue{
+			"id":     &types.AttributeValueMemberS{Value: tweetID},
+			"author": &types.AttributeValueMemberS{Value: author},
+		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("moderation_state = :pending"), //two reviewers can't both decide
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil, ErrNotPendingReview
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tweet := &model.Tweet{}
+	if err := attributevalue.UnmarshalMap(out.Attributes, tweet); err != nil {
+		return nil, err
+	}
+	return tweet, nil
+}
@@ -0,0 +1,87 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//queueMockClient returns one flagged tweet per page. The first page has a LastEvaluatedKey
+type queueMockClient struct {
+	common.DynamoDBAPI
+	inputs []*dynamodb.QueryInput
+}
+
+func (m *queueMockClient) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	m.inputs = append(m.inputs, input)
+
+	id := "flagged-1"
+	var lastKey map[string]types.AttributeValue
+	if input.ExclusiveStartKey == nil {
+		id = "flagged-0"
+		lastKey, _ = attributevalue.MarshalMap(moderationKey{Id: id, Author: "sarah_edo", ModerationState: model.ModerationPending, CreatedAt: 1518122597})
+	}
+	item, _ := attributevalue.MarshalMap(model.Tweet{Id: id, Author: "sarah_edo", Text: "spam", ModerationState: model.ModerationPending, ModerationReasons: []string{"repeated_characters: too many"}})
+	return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{item}, LastEvaluatedKey: lastKey}, nil
+}
+
+func Test_ListModerationQueueFromDynamoDb(t *testing.T) {
+	ctx := context.Background()
+	client := &queueMockClient{}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	tweets, next, err := repo.ListModerationQueueFromDynamoDb(ctx, "", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, tweets, 1) {
+		assert.Equal(t, model.ModerationPending, tweets[0].ModerationState)
+		assert.Equal(t, []string{"repeated_characters: too many"}, tweets[0].ModerationReasons)
+	}
+	assert.NotEqual(t, "", next)
+	assert.Equal(t, ModerationIndexName, aws.ToString(client.inputs[0].IndexName))
+
+	tweets, next, err = repo.ListModerationQueueFromDynamoDb(ctx, next, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "flagged-1", tweets[0].Id)
+	assert.Equal(t, "", next)
+
+	_, _, err = repo.ListModerationQueueFromDynamoDb(ctx, "not-a-cursor", 10)
+	assert.ErrorIs(t, err, cursor.ErrInvalidCursor)
+}
+
+func Test_ReviewTweetInDynamoDb(t *testing.T) {
+	client := &updateRecorder{missing: map[string]bool{"reviewed": true}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+	at := time.Unix(1518122597, 0)
+
+	_, err := repo.ReviewTweetInDynamoDb(context.Background(), "flagged", "sarah_edo", true, "admin", at)
+	assert.NoError(t, err)
+	_, err = repo.ReviewTweetInDynamoDb(context.Background(), "flagged", "sarah_edo", false, "admin", at)
+	assert.NoError(t, err)
+	if assert.Len(t, client.inputs, 2) {
+		assert.Equal(t, "REMOVE moderation_state SET reviewed_by = :reviewer, reviewed_at = :at", aws.ToString(client.inputs[0].UpdateExpression))
+		assert.Equal(t, "SET moderation_state = :removed, reviewed_by = :reviewer, reviewed_at = :at", aws.ToString(client.inputs[1].UpdateExpression))
+		assert.Equal(t, "moderation_state = :pending", aws.ToString(client.inputs[1].ConditionExpression))
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "1518122597"}, client.inputs[1].ExpressionAttributeValues[":at"])
+	}
+
+	_, err = repo.ReviewTweetInDynamoDb(context.Background(), "reviewed", "sarah_edo", true, "admin", at)
+	assert.ErrorIs(t, err, ErrNotPendingReview)
+}
+
+func Test_applyTweetFilter_VisibleOnly(t *testing.T) {
+	input := &dynamodb.ScanInput{}
+	applyTweetFilter(input, model.TweetFilter{VisibleOnly: true})
+	assert.Equal(t, "attribute_not_exists(#moderation_state)", aws.ToString(input.FilterExpression))
+	assert.Equal(t, map[string]string{"#moderation_state": "moderation_state"}, input.ExpressionAttributeNames)
+	assert.Nil(t, input.ExpressionAttributeValues)
+}
@@ -0,0 +1,198 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//MaxScanSegments is the most segments we let a single scan split into
+const MaxScanSegments = 32
+
+//cursor kind of the composite scan cursors
+const scanCursorKind = "tweets-scan"
+
+//scanCursor holds the LastEvaluatedKey of every segment so a parallel scan can be resumed where it stopped
+type scanCursor struct {
+	TotalSegments int32                       `json:"n"`
+	Keys          map[int32]map[string]string `json:"k,omitempty"` //segments that have started but not finished
+	Done          []int32                     `json:"d,omitempty"`
+}
+
+func (c *scanCursor) isDone(segment int32) bool {
+	for _, d := range c.Done {
+		if d == segment {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *scanCursor) complete() bool {
+	return int32(len(c.Done)) == c.TotalSegments
+}
+
+func (c *scanCursor) encode(codec *cursor.Codec) (string, error) {
+	if c.complete() {
+		return "", nil
+	}
+	return codec.Encode(scanCursorKind, c)
+}
+
+func decodeScanCursor(codec *cursor.Codec, s string, totalSegments int32) (*scanCursor, error) {
+	c := &scanCursor{TotalSegments: totalSegments, Keys: map[int32]map[string]string{}}
+	if s == "" {
+		return c, nil
+	}
+
+	if err := codec.Decode(scanCursorKind, s, c); err != nil {
+		return nil, err
+	}
+	if c.TotalSegments != totalSegments {
+		return nil, fmt.Errorf("%w: it was created for %d segments not %d", cursor.ErrInvalidCursor, c.TotalSegments, totalSegments)
+	}
+	if c.Keys == nil {
+		c.Keys = map[int32]map[string]string{}
+	}
+	return c, nil
+}
+
+//ParallelScanTweetsFromDynamoDb scans the tweets table with one worker per segment and streams the pages through the returned channel.
+//The channel is closed once every segment is done, a segment fails(the page has Err set) or ctx is cancelled.
+//Callers must either drain the channel or cancel ctx, otherwise the workers stay blocked on the send
+// @see https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Scan.html#Scan.ParallelScan
+func (r *DynamoDbRepository) ParallelScanTweetsFromDynamoDb(ctx context.Context, input model.ParallelScanInput) (<-chan model.ScanPage, error) {
+	if input.TotalSegments <= 0 || input.TotalSegments > MaxScanSegments {
+		return nil, fmt.Errorf("segments must be between 1 and %d", MaxScanSegments)
+	}
+
+	state, err := decodeScanCursor(r.cursors, input.Cursor, input.TotalSegments)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	pages := make(chan model.ScanPage)
+
+	var (
+		wg sync.WaitGroup
+		//held while a page is sent. That way the cursor in a page never includes progress from a page the consumer has not received yet
+		mu sync.Mutex
+	)
+
+	send := func(page model.ScanPage) bool {
+		select {
+		case pages <- page:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	//worked out before any worker starts since the workers update the cursor
+	var pending []int32
+	for segment := int32(0); segment < input.TotalSegments; segment++ {
+		if _, read := input.SegmentLimits[segment]; !state.isDone(segment) && (input.SegmentLimits == nil || read) {
+			pending = append(pending, segment)
+		}
+	}
+
+	for _, segment := range pending {
+		wg.Add(1)
+		go func(segment int32) {
+			defer wg.Done()
+
+			scanInput := &dynamodb.ScanInput{
+				TableName:     aws.String(r.tables.Tweets),
+				Segment:       aws.Int32(segment),
+				TotalSegments: aws.Int32(input.TotalSegments),
+			}
+			if limit, ok := input.SegmentLimits[segment]; ok {
+				scanInput.Limit = aws.Int32(limit)
+			} else if input.PageSize > 0 {
+				scanInput.Limit = aws.Int32(input.PageSize)
+			}
+			applyTweetFilter(scanInput, input.Filter)
+
+			mu.Lock()
+			if key, ok := state.Keys[segment]; ok {
+				scanInput.ExclusiveStartKey, _ = attributevalue.MarshalMap(key)
+			}
+			mu.Unlock()
+
+			for n := 0; input.MaxPagesPerSegment == 0 || n < input.MaxPagesPerSegment; n++ {
+				out, err := r.client.Scan(ctx, scanInput)
+				if err != nil {
+					send(model.ScanPage{Segment: segment, Err: err})
+					cancel()
+					return
+				}
+
+				items := []*model.Tweet{}
+				if err := attributevalue.UnmarshalListOfMaps(out.Items, &items); err != nil {
+					send(model.ScanPage{Segment: segment, Err: err})
+					cancel()
+					return
+				}
+
+				//the scan filter works with seconds. We check again so the millisecond bounds are exact
+				tweets := items[:0]
+				for _, item := range items {
+					if input.Filter.Match(item) {
+						tweets = append(tweets, item)
+					}
+				}
+
+				var lastKey map[string]string
+				if len(out.LastEvaluatedKey) > 0 {
+					if err := attributevalue.UnmarshalMap(out.LastEvaluatedKey, &lastKey); err != nil {
+						send(model.ScanPage{Segment: segment, Err: err})
+						cancel()
+						return
+					}
+				}
+
+				mu.Lock()
+				if lastKey == nil {
+					delete(state.Keys, segment)
+					state.Done = append(state.Done, segment)
+				} else {
+					state.Keys[segment] = lastKey
+				}
+				encoded, err := state.encode(r.cursors)
+				if err == nil {
+					if !send(model.ScanPage{Segment: segment, Tweets: tweets, Cursor: encoded}) {
+						mu.Unlock()
+						return
+					}
+				}
+				mu.Unlock()
+
+				if err != nil {
+					send(model.ScanPage{Segment: segment, Err: err})
+					cancel()
+					return
+				}
+				if lastKey == nil {
+					return
+				}
+				scanInput.ExclusiveStartKey = out.LastEvaluatedKey
+			}
+		}(segment)
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(pages)
+	}()
+
+	return pages, nil
+}
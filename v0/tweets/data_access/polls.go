@@ -0,0 +1,106 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//VotePollInDynamoDb records the vote and adds it to the tally of its option, in one transaction. The vote is only
+//written once per user(ErrAlreadyVoted) and only while the poll is open at now(ErrPollClosed)
+func (r *DynamoDbRepository) VotePollInDynamoDb(ctx context.Context, vote *model.PollVote, author string, now time.Time) error {
+	item, err := attributevalue.MarshalMap(vote)
+	if err != nil {
+		return err
+	}
+
+	//the option is an index the service checked, list indexes can't be expression values
+	votes := fmt.Sprintf("poll.options[%d].votes", vote.Option)
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName:           aws.String(r.tables.Votes),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(user_id)"),
+				},
+			},
+			{
+				Update: &types.Update{
+					TableName: aws.String(r.tables.Tweets),
+					Key: map[string]types.AttributeValue{
+						"id":     &types.AttributeValueMemberS{Value: vote.TweetId},
+						"author": &types.AttributeValueMemberS{Value: author},
+					},
+					UpdateExpression:    aws.String(fmt.Sprintf("SET %s = %s + :one", votes, votes)),
+					ConditionExpression: aws.String(fmt.Sprintf("attribute_exists(%s) AND poll.closes_at > :now", votes)),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":one": &types.AttributeValueMemberN{Value: "1"},
+						":now": unixValue(now),
+					},
+				},
+			},
+		},
+	})
+
+	var cancelled *types.TransactionCanceledException
+	if errors.As(err, &cancelled) && len(cancelled.CancellationReasons) == 2 {
+		//the reasons are in the order of the items
+		switch {
+		case aws.ToString(cancelled.CancellationReasons[0].Code) == "ConditionalCheckFailed":
+			return ErrAlreadyVoted
+		case aws.ToString(cancelled.CancellationReasons[1].Code) == "ConditionalCheckFailed":
+			return ErrPollClosed
+		}
+	}
+	return err
+}
+
+//ListPollVotesFromDynamoDb returns the option userID voted for on each of the tweets they voted on
+func (r *DynamoDbRepository) ListPollVotesFromDynamoDb(ctx context.Context, userID string, tweetIDs []string) (map[string]int, error) {
+	voted := make(map[string]int, len(tweetIDs))
+
+	//BatchGetItem accepts at most 100 keys per call
+	for start := 0; start < len(tweetIDs); start += 100 {
+		end := start + 100
+		if end > len(tweetIDs) {
+			end = len(tweetIDs)
+		}
+
+		keys := make([]map[string]types.AttributeValue, 0, end-start)
+		for _, id := range tweetIDs[start:end] {
+			keys = append(keys, map[string]types.AttributeValue{
+				"tweet_id": &types.AttributeValueMemberS{Value: id},
+				"user_id":  &types.AttributeValueMemberS{Value: userID},
+			})
+		}
+
+		requestItems := map[string]types.KeysAndAttributes{
+			r.tables.Votes: {Keys: keys},
+		}
+		for len(requestItems) > 0 {
+			out, err := r.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: requestItems})
+			if err != nil {
+				return nil, err
+			}
+
+			var votes []*model.PollVote
+			if err := attributevalue.UnmarshalListOfMaps(out.Responses[r.tables.Votes], &votes); err != nil {
+				return nil, err
+			}
+			for _, vote := range votes {
+				voted[vote.TweetId] = vote.Option
+			}
+			requestItems = out.UnprocessedKeys
+		}
+	}
+	return voted, nil
+}
@@ -0,0 +1,94 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//pollsMockClient cancels the transactions with the reasons in reasons, like DynamoDB does when a condition fails
+type pollsMockClient struct {
+	common.DynamoDBAPI
+	reasons      []string
+	transactions []*dynamodb.TransactWriteItemsInput
+	batches      int
+}
+
+func (m *pollsMockClient) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	m.transactions = append(m.transactions, input)
+	if m.reasons == nil {
+		return &dynamodb.TransactWriteItemsOutput{}, nil
+	}
+	reasons := make([]types.CancellationReason, len(m.reasons))
+	for i, code := range m.reasons {
+		reasons[i].Code = aws.String(code)
+	}
+	return nil, &types.TransactionCanceledException{Message: aws.String("Transaction cancelled"), CancellationReasons: reasons}
+}
+
+func (m *pollsMockClient) BatchGetItem(ctx context.Context, input *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	m.batches++
+	keys := input.RequestItems[fakeVotesTable].Keys
+	//the user voted on the first tweet of every batch
+	var tweetID string
+	attributevalue.Unmarshal(keys[0]["tweet_id"], &tweetID)
+	item, _ := attributevalue.MarshalMap(model.PollVote{TweetId: tweetID, UserId: "sarah_edo", Option: 1})
+	return &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{fakeVotesTable: {item}}}, nil
+}
+
+func Test_VotePollInDynamoDb(t *testing.T) {
+	now := time.Unix(1767225600, 0)
+	vote := &model.PollVote{TweetId: "tweet-1", UserId: "sarah_edo", Option: 2}
+
+	testCases := []struct {
+		name          string
+		reasons       []string
+		expectedError error
+	}{
+		{name: "should count the vote"},
+		{name: "should count one vote per user", reasons: []string{"ConditionalCheckFailed", "None"}, expectedError: ErrAlreadyVoted},
+		{name: "should not count a vote on a closed poll", reasons: []string{"None", "ConditionalCheckFailed"}, expectedError: ErrPollClosed},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			client := &pollsMockClient{reasons: tc.reasons}
+			repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+			err := repo.VotePollInDynamoDb(context.Background(), vote, "tylermcginnis", now)
+			assert.Equal(t, tc.expectedError, err)
+			if assert.Len(t, client.transactions, 1) {
+				items := client.transactions[0].TransactItems
+				assert.Equal(t, fakeVotesTable, aws.ToString(items[0].Put.TableName))
+				assert.Equal(t, "SET poll.options[2].votes = poll.options[2].votes + :one", aws.ToString(items[1].Update.UpdateExpression))
+				assert.Equal(t, &types.AttributeValueMemberN{Value: "1767225600"}, items[1].Update.ExpressionAttributeValues[":now"])
+			}
+		})
+	}
+}
+
+func Test_ListPollVotesFromDynamoDb(t *testing.T) {
+	tweetIDs := make([]string, 150)
+	for i := range tweetIDs {
+		tweetIDs[i] = fmt.Sprintf("tweet-%d", i)
+	}
+	client := &pollsMockClient{}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	voted, err := repo.ListPollVotesFromDynamoDb(context.Background(), "sarah_edo", tweetIDs)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, client.batches, "BatchGetItem takes 100 keys at most")
+	assert.Equal(t, map[string]int{tweetIDs[0]: 1, tweetIDs[100]: 1}, voted)
+}
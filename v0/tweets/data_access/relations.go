@@ -0,0 +1,86 @@
+package tweetsdataaccess
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//relationKey is the key of an item in the relations table
+func relationKey(userID string, kind model.RelationKind, target string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"user_id":  &types.AttributeValueMemberS{Value: userID},
+		"relation": &types.AttributeValueMemberS{Value: model.RelationKey(kind, target)},
+	}
+}
+
+//SaveRelationInDynamoDb creates a block, mute or follow. Saving the same relation again only moves its timestamp
+func (r *DynamoDbRepository) SaveRelationInDynamoDb(ctx context.Context, rel *model.Relation) error {
+	item, err := attributevalue.MarshalMap(rel)
+	if err != nil {
+		return err
+	}
+	item["relation"] = &types.AttributeValueMemberS{Value: model.RelationKey(rel.Kind, rel.Target)}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.tables.Relations),
+		Item:      item,
+	})
+	return err
+}
+
+//DeleteRelationFromDynamoDb removes a block, mute or follow. Removing one that does not exist is not an error
+func (r *DynamoDbRepository) DeleteRelationFromDynamoDb(ctx context.Context, userID string, kind model.RelationKind, target string) error {
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tables.Relations),
+		Key:       relationKey(userID, kind, target),
+	})
+	return err
+}
+
+//ListRelationsFromDynamoDb returns every block, mute and follow of a user. Lists read them all once per page
+func (r *DynamoDbRepository) ListRelationsFromDynamoDb(ctx context.Context, userID string) ([]*model.Relation, error) {
+	items := []*model.Relation{}
+
+	p := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tables.Relations),
+		KeyConditionExpression: aws.String("user_id = :user_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":user_id": &types.AttributeValueMemberS{Value: userID},
+		},
+	}
+	for {
+		out, err := r.client.Query(ctx, p)
+		if err != nil {
+			return items, err
+		}
+		var page []*model.Relation
+		if err := attributevalue.UnmarshalListOfMaps(out.Items, &page); err != nil {
+			return items, err
+		}
+		items = append(items, page...)
+
+		if len(out.LastEvaluatedKey) == 0 {
+			return items, nil
+		}
+		p.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+//HasRelationInDynamoDb tells whether userID has the relation of kind with target
+func (r *DynamoDbRepository) HasRelationInDynamoDb(ctx context.Context, userID string, kind model.RelationKind, target string) (bool, error) {
+	out, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:            aws.String(r.tables.Relations),
+		Key:                  relationKey(userID, kind, target),
+		ProjectionExpression: aws.String("user_id"),
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(out.Item) > 0, nil
+}
@@ -0,0 +1,101 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//relationsMockClient keeps the relations table in a map of "user_id/relation" to item. Query returns one item per page
+type relationsMockClient struct {
+	common.DynamoDBAPI
+	items map[string]map[string]types.AttributeValue
+}
+
+func (m *relationsMockClient) key(k map[string]types.AttributeValue) string {
+	return k["user_id"].(*types.AttributeValueMemberS).Value + "/" + k["relation"].(*types.AttributeValueMemberS).Value
+}
+
+func (m *relationsMockClient) PutItem(ctx context.Context, input *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	if aws.ToString(input.TableName) != fakeRelationsTable {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("Requested resource not found")}
+	}
+	m.items[m.key(input.Item)] = input.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (m *relationsMockClient) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	delete(m.items, m.key(input.Key))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (m *relationsMockClient) GetItem(ctx context.Context, input *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: m.items[m.key(input.Key)]}, nil
+}
+
+func (m *relationsMockClient) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	userID := input.ExpressionAttributeValues[":user_id"].(*types.AttributeValueMemberS).Value
+	after := ""
+	if input.ExclusiveStartKey != nil {
+		after = m.key(input.ExclusiveStartKey)
+	}
+
+	var next string
+	for k := range m.items {
+		if strings.HasPrefix(k, userID+"/") && k > after && (next == "" || k < next) {
+			next = k
+		}
+	}
+	if next == "" {
+		return &dynamodb.QueryOutput{}, nil
+	}
+	item := m.items[next]
+	return &dynamodb.QueryOutput{
+		Items:            []map[string]types.AttributeValue{item},
+		LastEvaluatedKey: map[string]types.AttributeValue{"user_id": item["user_id"], "relation": item["relation"]},
+	}, nil
+}
+
+func Test_Relations(t *testing.T) {
+	ctx := context.Background()
+	client := &relationsMockClient{items: map[string]map[string]types.AttributeValue{}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+	ts := model.ChirperAppUnixTime(time.Unix(1518122597, 0))
+
+	assert.NoError(t, repo.SaveRelationInDynamoDb(ctx, &model.Relation{UserId: "sarah_edo", Kind: model.Block, Target: "tylermcginnis", Timestamp: ts}))
+	assert.NoError(t, repo.SaveRelationInDynamoDb(ctx, &model.Relation{UserId: "sarah_edo", Kind: model.Mute, Target: "dan_abramov", Timestamp: ts}))
+	assert.NoError(t, repo.SaveRelationInDynamoDb(ctx, &model.Relation{UserId: "tylermcginnis", Kind: model.Mute, Target: "sarah_edo", Timestamp: ts}))
+	assert.Contains(t, client.items, "sarah_edo/block#tylermcginnis")
+
+	blocked, err := repo.HasRelationInDynamoDb(ctx, "sarah_edo", model.Block, "tylermcginnis")
+	assert.NoError(t, err)
+	assert.True(t, blocked)
+	blocked, err = repo.HasRelationInDynamoDb(ctx, "sarah_edo", model.Block, "dan_abramov")
+	assert.NoError(t, err)
+	assert.False(t, blocked, "a mute is not a block")
+
+	relations, err := repo.ListRelationsFromDynamoDb(ctx, "sarah_edo")
+	assert.NoError(t, err)
+	assert.Equal(t, []*model.Relation{
+		{UserId: "sarah_edo", Kind: model.Block, Target: "tylermcginnis", Timestamp: ts},
+		{UserId: "sarah_edo", Kind: model.Mute, Target: "dan_abramov", Timestamp: ts},
+	}, relations, "every page is read")
+
+	assert.NoError(t, repo.DeleteRelationFromDynamoDb(ctx, "sarah_edo", model.Block, "tylermcginnis"))
+	blocked, _ = repo.HasRelationInDynamoDb(ctx, "sarah_edo", model.Block, "tylermcginnis")
+	assert.False(t, blocked)
+
+	var saved model.Relation
+	assert.NoError(t, attributevalue.UnmarshalMap(client.items["sarah_edo/mute#dan_abramov"], &saved))
+	assert.Equal(t, "dan_abramov", saved.Target)
+}
@@ -0,0 +1,301 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//cursor kind of the ListScheduledTweetsFromDynamoDb cursors
+const scheduledCursorKind = "scheduled-by-author"
+
+//scheduledKey is the LastEvaluatedKey of a query on ScheduledAuthorIndexName
+type scheduledKey struct {
+	Id        string `json:"id" dynamodbav:"id"`
+	Author    string `json:"author" dynamodbav:"author"`
+	PublishAt int64  `json:"publish_at" dynamodbav:"publish_at"`
+}
+
+//status is a reserved word in DynamoDB expressions
+var scheduledStatusName = map[string]string{"#status": "status"}
+
+func scheduledID(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}}
+}
+
+func unixValue(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: fmt.Sprint(t.Unix())}
+}
+
+//SaveScheduledTweetToDynamoDb adds a scheduled tweet. The id must be new. Its media are attached to it in the same
+//transaction, the way SaveTweetToDynamoDb attaches them, and ErrMediaUnavailable is returned when one can't be. The
+//published tweet has the id of the scheduled tweet, so it takes them over
+func (r *DynamoDbRepository) SaveScheduledTweetToDynamoDb(ctx context.Context, scheduled *model.ScheduledTweet) error {
+	item, err := attributevalue.MarshalMap(scheduled)
+	if err != nil {
+		return err
+	}
+	if len(scheduled.MediaIds) == 0 {
+		_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:           aws.String(r.tables.Scheduled),
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(id)"),
+		})
+		return err
+	}
+
+	items := append([]types.TransactWriteItem{{
+		Put: &types.Put{
+			TableName:           aws.String(r.tables.Scheduled),
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(id)"),
+		},
+	}}, r.attachMediaItems(&model.Tweet{Id: scheduled.Id, Author: scheduled.Author, MediaIds: scheduled.MediaIds})...)
+	_, err = r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	return r.mediaConflict(err, items)
+}
+
+//GetScheduledTweetFromDynamoDb returns ErrScheduledTweetNotFound when there is no such scheduled tweet
+func (r *DynamoDbRepository) GetScheduledTweetFromDynamoDb(ctx context.Context, id string) (*model.ScheduledTweet, error) {
+	out, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tables.Scheduled),
+		Key:            scheduledID(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Item) == 0 {
+		return nil, ErrScheduledTweetNotFound
+	}
+
+	scheduled := &model.ScheduledTweet{}
+	if err := attributevalue.UnmarshalMap(out.Item, scheduled); err != nil {
+		return nil, err
+	}
+	return scheduled, nil
+}
+
+//ListScheduledTweetsFromDynamoDb returns the scheduled tweets of an author, the next one to go out first. Published and failed
+//tweets are listed too, until the TTL deletes them
+func (r *DynamoDbRepository) ListScheduledTweetsFromDynamoDb(ctx context.Context, author, nextKey string, limit int32) ([]*model.ScheduledTweet, string, error) {
+	items := []*model.ScheduledTweet{}
+
+	p := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tables.Scheduled),
+		IndexName:              aws.String(ScheduledAuthorIndexName),
+		KeyConditionExpression: aws.String("author = :author"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":author": &types.AttributeValueMemberS{Value: author},
+		},
+		ScanIndexForward: aws.Bool(true),
+		Limit:            aws.Int32(limit),
+	}
+
+	if nextKey != "" {
+		nk := &scheduledKey{}
+		if err := r.cursors.Decode(scheduledCursorKind, nextKey, nk); err != nil {
+			return items, "", err
+		}
+		if nk.Author != author {
+			//a cursor of another author would list their tweets from that point on
+			return items, "", fmt.Errorf("%w: issued for another author", cursor.ErrInvalidCursor)
+		}
+		p.ExclusiveStartKey, _ = attributevalue.MarshalMap(nk)
+	}
+
+	out, err := r.client.Query(ctx, p)
+	if err != nil {
+		return items, "", err
+	}
+	if err := attributevalue.UnmarshalListOfMaps(out.Items, &items); err != nil {
+		return items, "", err
+	}
+
+	var finalKeyValue string
+	if len(out.LastEvaluatedKey) > 0 {
+		var sNextKey scheduledKey
+		if err := attributevalue.UnmarshalMap(out.LastEvaluatedKey, &sNextKey); err != nil {
+			return items, "", err
+		}
+		if finalKeyValue, err = r.cursors.Encode(scheduledCursorKind, sNextKey); err != nil {
+			return items, "", err
+		}
+	}
+	return items, finalKeyValue, nil
+}
+
+//ListDueScheduledTweetsFromDynamoDb returns up to limit tweets of one day bucket that are due at now, oldest first.
+//Tweets claimed by a publisher are left out until their claim runs out
+func (r *DynamoDbRepository) ListDueScheduledTweetsFromDynamoDb(ctx context.Context, bucket string, now time.Time, limit int32) ([]*model.ScheduledTweet, error) {
+	items := []*model.ScheduledTweet{}
+
+	out, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                aws.String(r.tables.Scheduled),
+		IndexName:                aws.String(ScheduledDueIndexName),
+		KeyConditionExpression:   aws.String("due_bucket = :bucket AND due_at <= :now"),
+		FilterExpression:         aws.String("#status = :waiting OR claimed_until < :now"),
+		ExpressionAttributeNames: scheduledStatusName,
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":bucket":  &types.AttributeValueMemberS{Value: bucket},
+			":now":     unixValue(now),
+			":waiting": &types.AttributeValueMemberS{Value: string(model.ScheduledWaiting)},
+		},
+		ScanIndexForward: aws.Bool(true),
+		Limit:            aws.Int32(limit),
+	})
+	if err != nil {
+		return items, err
+	}
+
+	err = attributevalue.UnmarshalListOfMaps(out.Items, &items)
+	return items, err
+}
+
+//ClaimScheduledTweetInDynamoDb marks a due tweet as being published by the holder of claim until `until`. Only one publisher
+//gets a tweet: the others get ErrScheduledTweetNotWaiting. A claim that ran out(the publisher died) can be taken over
+func (r *DynamoDbRepository) ClaimScheduledTweetInDynamoDb(ctx context.Context, id, claim string, now, until time.Time) (*model.ScheduledTweet, error) {
+	out, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                aws.String(r.tables.Scheduled),
+		Key:                      scheduledID(id),
+		UpdateExpression:         aws.String("SET #status = :publishing, claim = :claim, claimed_until = :until"),
+		ConditionExpression:      aws.String("attribute_exists(due_bucket) AND due_at <= :now AND (#status = :waiting OR (#status = :publishing AND claimed_until < :now))"),
+		ExpressionAttributeNames: scheduledStatusName,
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":publishing": &types.AttributeValueMemberS{Value: string(model.ScheduledPublishing)},
+			":waiting":    &types.AttributeValueMemberS{Value: string(model.ScheduledWaiting)},
+			":claim":      &types.AttributeValueMemberS{Value: claim},
+			":until":      unixValue(until),
+			":now":        unixValue(now),
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return nil, ErrScheduledTweetNotWaiting
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scheduled := &model.ScheduledTweet{}
+	if err := attributevalue.UnmarshalMap(out.Attributes, scheduled); err != nil {
+		return nil, err
+	}
+	return scheduled, nil
+}
+
+//releaseScheduledTweet ends a claim with update. It returns ErrScheduledTweetNotWaiting when the claim was taken over in between
+func (r *DynamoDbRepository) releaseScheduledTweet(ctx context.Context, id, claim, update string, values map[string]types.AttributeValue) error {
+	values[":claim"] = &types.AttributeValueMemberS{Value: claim}
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(r.tables.Scheduled),
+		Key:                       scheduledID(id),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("claim = :claim"),
+		ExpressionAttributeNames:  scheduledStatusName,
+		ExpressionAttributeValues: values,
+	})
+	var ccf *types.ConditionalCheckFailedException
+	if errors.As(err, &ccf) {
+		return ErrScheduledTweetNotWaiting
+	}
+	return err
+}
+
+//CompleteScheduledTweetInDynamoDb records the tweet a claimed scheduled tweet was published as. It leaves the due index and
+//is deleted by the TTL at expiresAt
+func (r *DynamoDbRepository) CompleteScheduledTweetInDynamoDb(ctx context.Context, id, claim, tweetID string, expiresAt time.Time) error {
+	return r.releaseScheduledTweet(ctx, id, claim,
+		"SET #status = :published, tweet_id = :tweet_id, expires_at = :expires_at REMOVE due_bucket, claim, claimed_until, last_error",
+		map[string]types.AttributeValue{
+			":published":  &types.AttributeValueMemberS{Value: string(model.ScheduledPublished)},
+			":tweet_id":   &types.AttributeValueMemberS{Value: tweetID},
+			":expires_at": unixValue(expiresAt),
+		})
+}
+
+//FailScheduledTweetInDynamoDb records a failed attempt of a claimed scheduled tweet. It waits in the due index until retryAt,
+//or leaves it for good when retryAt is zero
+func (r *DynamoDbRepository) FailScheduledTweetInDynamoDb(ctx context.Context, id, claim, lastError string, retryAt time.Time) error {
+	values := map[string]types.AttributeValue{
+		":error": &types.AttributeValueMemberS{Value: lastError},
+		":one":   &types.AttributeValueMemberN{Value: "1"},
+	}
+	if retryAt.IsZero() {
+		values[":failed"] = &types.AttributeValueMemberS{Value: string(model.ScheduledFailed)}
+		return r.releaseScheduledTweet(ctx, id, claim,
+			"SET #status = :failed, last_error = :error ADD attempts :one REMOVE due_bucket, claim, claimed_until", values)
+	}
+
+	values[":waiting"] = &types.AttributeValueMemberS{Value: string(model.ScheduledWaiting)}
+	values[":bucket"] = &types.AttributeValueMemberS{Value: model.DueBucket(retryAt)}
+	values[":due_at"] = unixValue(retryAt)
+	return r.releaseScheduledTweet(ctx, id, claim,
+		"SET #status = :waiting, last_error = :error, due_bucket = :bucket, due_at = :due_at ADD attempts :one REMOVE claim, claimed_until", values)
+}
+
+//DeleteScheduledTweetFromDynamoDb cancels a scheduled tweet of its author that is waiting or failed. It returns
+//ErrScheduledTweetNotWaiting when the tweet is being published or was published already. Its media become orphans
+//again in the same transaction, for the collector to delete
+func (r *DynamoDbRepository) DeleteScheduledTweetFromDynamoDb(ctx context.Context, scheduled *model.ScheduledTweet) error {
+	del := &dynamodb.DeleteItemInput{
+		TableName:                aws.String(r.tables.Scheduled),
+		Key:                      scheduledID(scheduled.Id),
+		ConditionExpression:      aws.String("author = :author AND (#status = :waiting OR #status = :failed)"),
+		ExpressionAttributeNames: scheduledStatusName,
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":author":  &types.AttributeValueMemberS{Value: scheduled.Author},
+			":waiting": &types.AttributeValueMemberS{Value: string(model.ScheduledWaiting)},
+			":failed":  &types.AttributeValueMemberS{Value: string(model.ScheduledFailed)},
+		},
+	}
+	if len(scheduled.MediaIds) == 0 {
+		_, err := r.client.DeleteItem(ctx, del)
+		var ccf *types.ConditionalCheckFailedException
+		if errors.As(err, &ccf) {
+			return ErrScheduledTweetNotWaiting
+		}
+		return err
+	}
+
+	items := []types.TransactWriteItem{{
+		Delete: &types.Delete{
+			TableName:                 del.TableName,
+			Key:                       del.Key,
+			ConditionExpression:       del.ConditionExpression,
+			ExpressionAttributeNames:  del.ExpressionAttributeNames,
+			ExpressionAttributeValues: del.ExpressionAttributeValues,
+		},
+	}}
+	for _, id := range scheduled.MediaIds {
+		items = append(items, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(r.tables.Media),
+				Key:       mediaID(id),
+				//pending_gc puts the media back in MediaOrphanIndexName
+				UpdateExpression:    aws.String("SET pending_gc = :orphan REMOVE tweet_id"),
+				ConditionExpression: aws.String("tweet_id = :tweet"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":orphan": &types.AttributeValueMemberS{Value: model.MediaOrphan},
+					":tweet":  &types.AttributeValueMemberS{Value: scheduled.Id},
+				},
+			},
+		})
+	}
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	var cancelled *types.TransactionCanceledException
+	if errors.As(err, &cancelled) && len(cancelled.CancellationReasons) > 0 && aws.ToString(cancelled.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+		return ErrScheduledTweetNotWaiting
+	}
+	return err
+}
@@ -0,0 +1,172 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/cursor"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//scheduledMockClient fails the conditional writes on the ids in taken, like DynamoDB does when another publisher has them
+type scheduledMockClient struct {
+	common.DynamoDBAPI
+	taken        map[string]bool
+	updates      []*dynamodb.UpdateItemInput
+	queries      []*dynamodb.QueryInput
+	transactions []*dynamodb.TransactWriteItemsInput
+}
+
+//TransactWriteItems fails the delete of the ids in taken, which comes first in the transactions of the scheduled tweets
+func (m *scheduledMockClient) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if del := input.TransactItems[0].Delete; del != nil && m.taken[del.Key["id"].(*types.AttributeValueMemberS).Value] {
+		reasons := make([]types.CancellationReason, len(input.TransactItems))
+		for i := range reasons {
+			reasons[i].Code = aws.String("None")
+		}
+		reasons[0].Code = aws.String("ConditionalCheckFailed")
+		return nil, &types.TransactionCanceledException{Message: aws.String("Transaction cancelled"), CancellationReasons: reasons}
+	}
+	m.transactions = append(m.transactions, input)
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (m *scheduledMockClient) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	if m.taken[input.Key["id"].(*types.AttributeValueMemberS).Value] {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	m.updates = append(m.updates, input)
+	item, _ := attributevalue.MarshalMap(model.ScheduledTweet{Id: "scheduled-1", Author: "sarah_edo", Status: model.ScheduledPublishing})
+	return &dynamodb.UpdateItemOutput{Attributes: item}, nil
+}
+
+func (m *scheduledMockClient) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	if m.taken[input.Key["id"].(*types.AttributeValueMemberS).Value] {
+		return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func (m *scheduledMockClient) Query(ctx context.Context, input *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	m.queries = append(m.queries, input)
+	lastKey, _ := attributevalue.MarshalMap(scheduledKey{Id: "scheduled-1", Author: "sarah_edo", PublishAt: 1767225600})
+	item, _ := attributevalue.MarshalMap(model.ScheduledTweet{Id: "scheduled-1", Author: "sarah_edo", Status: model.ScheduledWaiting})
+	return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{item}, LastEvaluatedKey: lastKey}, nil
+}
+
+func Test_ClaimScheduledTweetInDynamoDb(t *testing.T) {
+	client := &scheduledMockClient{taken: map[string]bool{"scheduled-2": true}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+	now := time.Unix(1767225600, 0)
+
+	scheduled, err := repo.ClaimScheduledTweetInDynamoDb(context.Background(), "scheduled-1", "claim-a", now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, model.ScheduledPublishing, scheduled.Status)
+	if assert.Len(t, client.updates, 1) {
+		assert.Equal(t, fakeScheduledTable, aws.ToString(client.updates[0].TableName))
+		assert.Equal(t, &types.AttributeValueMemberN{Value: "1767225660"}, client.updates[0].ExpressionAttributeValues[":until"])
+	}
+
+	_, err = repo.ClaimScheduledTweetInDynamoDb(context.Background(), "scheduled-2", "claim-b", now, now.Add(time.Minute))
+	assert.ErrorIs(t, err, ErrScheduledTweetNotWaiting)
+}
+
+func Test_FailScheduledTweetInDynamoDb(t *testing.T) {
+	client := &scheduledMockClient{taken: map[string]bool{"taken-over": true}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+	retryAt := time.Date(2026, time.January, 1, 0, 5, 0, 0, time.UTC)
+
+	assert.NoError(t, repo.FailScheduledTweetInDynamoDb(context.Background(), "scheduled-1", "claim-a", "throttled", retryAt))
+	assert.NoError(t, repo.FailScheduledTweetInDynamoDb(context.Background(), "scheduled-1", "claim-a", "text is required", time.Time{}))
+	if assert.Len(t, client.updates, 2) {
+		retry := client.updates[0]
+		assert.Equal(t, "SET #status = :waiting, last_error = :error, due_bucket = :bucket, due_at = :due_at ADD attempts :one REMOVE claim, claimed_until", aws.ToString(retry.UpdateExpression))
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "2026-01-01"}, retry.ExpressionAttributeValues[":bucket"])
+		assert.Equal(t, "claim = :claim", aws.ToString(retry.ConditionExpression))
+		assert.Equal(t, "SET #status = :failed, last_error = :error ADD attempts :one REMOVE due_bucket, claim, claimed_until", aws.ToString(client.updates[1].UpdateExpression), "a tweet that failed for good leaves the due index")
+	}
+
+	err := repo.FailScheduledTweetInDynamoDb(context.Background(), "taken-over", "claim-a", "throttled", retryAt)
+	assert.ErrorIs(t, err, ErrScheduledTweetNotWaiting)
+}
+
+func Test_DeleteScheduledTweetFromDynamoDb(t *testing.T) {
+	repo := NewDynamoDbRepo(&scheduledMockClient{taken: map[string]bool{"publishing": true}}, fakeTables, fakeCursors)
+
+	assert.NoError(t, repo.DeleteScheduledTweetFromDynamoDb(context.Background(), &model.ScheduledTweet{Id: "scheduled-1", Author: "sarah_edo"}))
+	assert.ErrorIs(t, repo.DeleteScheduledTweetFromDynamoDb(context.Background(), &model.ScheduledTweet{Id: "publishing", Author: "sarah_edo"}), ErrScheduledTweetNotWaiting)
+}
+
+func Test_DeleteScheduledTweetFromDynamoDb_Media(t *testing.T) {
+	client := &scheduledMockClient{taken: map[string]bool{"publishing": true}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	err := repo.DeleteScheduledTweetFromDynamoDb(context.Background(), &model.ScheduledTweet{Id: "scheduled-1", Author: "sarah_edo", MediaIds: []string{"media-1", "media-2"}})
+	assert.NoError(t, err)
+	if assert.Len(t, client.transactions, 1) {
+		items := client.transactions[0].TransactItems
+		assert.Len(t, items, 3)
+		assert.Equal(t, fakeScheduledTable, aws.ToString(items[0].Delete.TableName))
+		assert.Equal(t, "SET pending_gc = :orphan REMOVE tweet_id", aws.ToString(items[1].Update.UpdateExpression), "the media become orphans again")
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "scheduled-1"}, items[2].Update.ExpressionAttributeValues[":tweet"])
+	}
+
+	err = repo.DeleteScheduledTweetFromDynamoDb(context.Background(), &model.ScheduledTweet{Id: "publishing", Author: "sarah_edo", MediaIds: []string{"media-1"}})
+	assert.ErrorIs(t, err, ErrScheduledTweetNotWaiting)
+}
+
+func Test_SaveScheduledTweetToDynamoDb_Media(t *testing.T) {
+	client := &mediaMockClient{media: map[string]*model.Media{
+		"free":     {Id: "free", UserId: "sarah_edo"},
+		"attached": {Id: "attached", UserId: "sarah_edo", TweetId: "another-tweet"},
+	}}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	err := repo.SaveScheduledTweetToDynamoDb(context.Background(), &model.ScheduledTweet{Id: "scheduled-1", Author: "sarah_edo", MediaIds: []string{"free"}})
+	assert.NoError(t, err)
+	if assert.Len(t, client.transactions, 1) {
+		items := client.transactions[0].TransactItems
+		assert.Equal(t, fakeScheduledTable, aws.ToString(items[0].Put.TableName))
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "scheduled-1"}, items[1].Update.ExpressionAttributeValues[":tweet"], "the media are attached to the id the tweet is published with")
+	}
+
+	err = repo.SaveScheduledTweetToDynamoDb(context.Background(), &model.ScheduledTweet{Id: "scheduled-2", Author: "sarah_edo", MediaIds: []string{"attached"}})
+	assert.ErrorIs(t, err, ErrMediaUnavailable)
+}
+
+func Test_ListScheduledTweetsFromDynamoDb(t *testing.T) {
+	ctx := context.Background()
+	client := &scheduledMockClient{}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	scheduled, next, err := repo.ListScheduledTweetsFromDynamoDb(ctx, "sarah_edo", "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, scheduled, 1)
+	assert.NotEqual(t, "", next)
+	assert.Equal(t, ScheduledAuthorIndexName, aws.ToString(client.queries[0].IndexName))
+
+	_, _, err = repo.ListScheduledTweetsFromDynamoDb(ctx, "sarah_edo", next, 10)
+	assert.NoError(t, err)
+	_, _, err = repo.ListScheduledTweetsFromDynamoDb(ctx, "tylermcginnis", next, 10)
+	assert.ErrorIs(t, err, cursor.ErrInvalidCursor, "a cursor only pages the author it was issued for")
+}
+
+func Test_ListDueScheduledTweetsFromDynamoDb(t *testing.T) {
+	client := &scheduledMockClient{}
+	repo := NewDynamoDbRepo(client, fakeTables, fakeCursors)
+
+	due, err := repo.ListDueScheduledTweetsFromDynamoDb(context.Background(), "2026-01-01", time.Unix(1767225600, 0), 25)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+	assert.Equal(t, ScheduledDueIndexName, aws.ToString(client.queries[0].IndexName))
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2026-01-01"}, client.queries[0].ExpressionAttributeValues[":bucket"])
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "1767225600"}, client.queries[0].ExpressionAttributeValues[":now"])
+}
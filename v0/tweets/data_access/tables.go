@@ -0,0 +1,247 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+)
+
+//AuthorIndexName is the GSI ListRecentTweetsByAuthorFromDynamoDb queries. It lists the tweets of an author by time
+const AuthorIndexName = "author-created_at-index"
+
+//ModerationIndexName is the GSI behind the review queue. Only tweets with a moderation_state are in it, so it stays small
+const ModerationIndexName = "moderation_state-created_at-index"
+
+//ScheduledAuthorIndexName lists the scheduled tweets of an author by the time they go out
+const ScheduledAuthorIndexName = "author-publish_at-index"
+
+//ScheduledDueIndexName is the GSI the publisher polls. It is bucketed by day(see model.DueBucket) and only waiting tweets are in it
+const ScheduledDueIndexName = "due_bucket-due_at-index"
+
+//MediaOrphanIndexName is the GSI the orphan media collector queries. Only the uploads no tweet attached are in it(see
+//model.Media.PendingGC), oldest first
+const MediaOrphanIndexName = "pending_gc-created_at-index"
+
+//MediaPendingIndexName is the GSI the processing worker queries. Only the images waiting for it are in it(see
+//model.Media.PendingProcessing), oldest first
+const MediaPendingIndexName = "pending_processing-created_at-index"
+
+//DraftsUpdatedIndexName is the LSI ListDraftsFromDynamoDb queries. It lists the drafts of a user, last edited first
+const DraftsUpdatedIndexName = "user_id-updated_at-index"
+
+//TableDefinitions describes every table the service needs, with the same keys and indexes as the tables on AWS
+func TableDefinitions(tables Tables) []*dynamodb.CreateTableInput {
+	return []*dynamodb.CreateTableInput{
+		{
+			TableName: aws.String(tables.Tweets),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("author"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("created_at"), AttributeType: types.ScalarAttributeTypeN},
+				{AttributeName: aws.String("moderation_state"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String("author"), KeyType: types.KeyTypeRange},
+			},
+			GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+				{
+					IndexName: aws.String(AuthorIndexName),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("author"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("created_at"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				},
+				{
+					IndexName: aws.String(ModerationIndexName),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("moderation_state"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("created_at"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		},
+		{
+			//owned by the users service. We only create it so the service can run on its own against DynamoDB Local
+			TableName: aws.String(tables.Users),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		},
+		{
+			TableName: aws.String(tables.Relations),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("user_id"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("relation"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("user_id"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String("relation"), KeyType: types.KeyTypeRange},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		},
+		{
+			//turn on TTL on expires_at so the published tweets are deleted after a while. It can't be set with CreateTable
+			TableName: aws.String(tables.Scheduled),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("author"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("publish_at"), AttributeType: types.ScalarAttributeTypeN},
+				{AttributeName: aws.String("due_bucket"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("due_at"), AttributeType: types.ScalarAttributeTypeN},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+			},
+			GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+				{
+					IndexName: aws.String(ScheduledAuthorIndexName),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("author"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("publish_at"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				},
+				{
+					IndexName: aws.String(ScheduledDueIndexName),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("due_bucket"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("due_at"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		},
+		{
+			TableName: aws.String(tables.Drafts),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("user_id"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("updated_at"), AttributeType: types.ScalarAttributeTypeN},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("user_id"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String("id"), KeyType: types.KeyTypeRange},
+			},
+			LocalSecondaryIndexes: []types.LocalSecondaryIndex{
+				{
+					IndexName: aws.String(DraftsUpdatedIndexName),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("user_id"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("updated_at"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		},
+		{
+			TableName: aws.String(tables.Votes),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("tweet_id"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("user_id"), AttributeType: types.ScalarAttributeTypeS},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("tweet_id"), KeyType: types.KeyTypeHash},
+				{AttributeName: aws.String("user_id"), KeyType: types.KeyTypeRange},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		},
+		{
+			TableName: aws.String(tables.Media),
+			AttributeDefinitions: []types.AttributeDefinition{
+				{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("pending_gc"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("pending_processing"), AttributeType: types.ScalarAttributeTypeS},
+				{AttributeName: aws.String("created_at"), AttributeType: types.ScalarAttributeTypeN},
+			},
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+			},
+			GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
+				{
+					IndexName: aws.String(MediaOrphanIndexName),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("pending_gc"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("created_at"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				},
+				{
+					IndexName: aws.String(MediaPendingIndexName),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("pending_processing"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("created_at"), KeyType: types.KeyTypeRange},
+					},
+					Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+				},
+			},
+			BillingMode: types.BillingModePayPerRequest,
+		},
+	}
+}
+
+//CreateTables creates the tables that don't exist yet and waits up to maxWait for all of them to be ACTIVE.
+//It is safe to run more than once; it returns the names of the tables it created. extra are tables other packages own,
+//eg the rate limit buckets
+func CreateTables(ctx context.Context, client common.DynamoDBAPI, tables Tables, maxWait time.Duration, extra ...*dynamodb.CreateTableInput) ([]string, error) {
+	created := []string{}
+	definitions := append(TableDefinitions(tables), extra...)
+
+	for _, def := range definitions {
+		_, err := client.CreateTable(ctx, def)
+		var inUse *types.ResourceInUseException
+		if errors.As(err, &inUse) {
+			continue //the table already exists
+		}
+		if err != nil {
+			return created, fmt.Errorf("unable to create table %s: %w", aws.ToString(def.TableName), err)
+		}
+		created = append(created, aws.ToString(def.TableName))
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(client, func(o *dynamodb.TableExistsWaiterOptions) {
+		//the defaults(20s to 120s between calls) are made for AWS. DynamoDB Local is ready almost straight away
+		o.MinDelay = time.Second
+		o.MaxDelay = 5 * time.Second
+	})
+	for _, def := range definitions {
+		if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: def.TableName}, maxWait); err != nil {
+			return created, fmt.Errorf("table %s is not active: %w", aws.ToString(def.TableName), err)
+		}
+	}
+	return created, nil
+}
+
+//CheckTables returns an error unless every table can be described and takes writes. It backs the readiness probe,
+//so it only needs dynamodb:DescribeTable and costs no read capacity
+func CheckTables(ctx context.Context, client common.DynamoDBAPI, tables Tables) error {
+	var errs []error
+	for _, name := range []string{tables.Tweets, tables.Users, tables.Relations, tables.Scheduled, tables.Drafts, tables.Votes, tables.Media} {
+		out, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("table %s: %w", name, err))
+			continue
+		}
+		//an UPDATING table(eg a new index being built) still serves reads and writes
+		if status := out.Table.TableStatus; status != types.TableStatusActive && status != types.TableStatusUpdating {
+			errs = append(errs, fmt.Errorf("table %s is %s", name, status))
+		}
+	}
+	return errors.Join(errs...)
+}
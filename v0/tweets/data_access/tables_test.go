@@ -0,0 +1,162 @@
+package tweetsdataaccess
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common"
+)
+
+//tablesMockClient acts like DynamoDB Local. Tables in existing are already there; every other table is created and is ACTIVE straight away
+type tablesMockClient struct {
+	common.DynamoDBAPI
+	existing  map[string]bool
+	status    types.TableStatus //ACTIVE when empty
+	createErr error
+	inputs    []*dynamodb.CreateTableInput
+}
+
+func (m *tablesMockClient) CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
+	if m.existing[aws.ToString(params.TableName)] {
+		return nil, &types.ResourceInUseException{Message: aws.String("Cannot create preexisting table")}
+	}
+	m.inputs = append(m.inputs, params)
+	m.existing[aws.ToString(params.TableName)] = true
+	return &dynamodb.CreateTableOutput{}, nil
+}
+
+func (m *tablesMockClient) DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	if !m.existing[aws.ToString(params.TableName)] {
+		return nil, &types.ResourceNotFoundException{Message: aws.String("Cannot do operations on a non-existent table")}
+	}
+	status := m.status
+	if status == "" {
+		status = types.TableStatusActive
+	}
+	return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{TableName: params.TableName, TableStatus: status}}, nil
+}
+
+func Test_CreateTables(t *testing.T) {
+	testCases := []struct {
+		name      string
+		existing  map[string]bool
+		createErr error
+
+		expectedCreated []string
+		expectedError   bool
+	}{
+		{
+			name:            "should create every table",
+			existing:        map[string]bool{},
+			expectedCreated: []string{fakeTable, fakeUsersTable, fakeRelationsTable, fakeScheduledTable, fakeDraftsTable, fakeVotesTable, fakeMediaTable},
+		},
+		{
+			name:            "should skip tables that already exist",
+			existing:        map[string]bool{fakeUsersTable: true, fakeRelationsTable: true, fakeScheduledTable: true, fakeDraftsTable: true, fakeVotesTable: true, fakeMediaTable: true},
+			expectedCreated: []string{fakeTable},
+		},
+		{
+			name:            "should return error when a table can't be created",
+			existing:        map[string]bool{},
+			createErr:       errors.New("UnrecognizedClientException"),
+			expectedCreated: []string{},
+			expectedError:   true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			client := &tablesMockClient{existing: tc.existing, createErr: tc.createErr}
+
+			created, err := CreateTables(context.Background(), client, fakeTables, time.Second)
+
+			assert.Equal(t, tc.expectedError, err != nil)
+			assert.Equal(t, tc.expectedCreated, created)
+		})
+	}
+}
+
+func Test_CheckTables(t *testing.T) {
+	testCases := []struct {
+		name     string
+		existing map[string]bool
+		status   types.TableStatus
+
+		expectedError string
+	}{
+		{
+			name:     "should pass when every table is active",
+			existing: map[string]bool{fakeTable: true, fakeUsersTable: true, fakeRelationsTable: true, fakeScheduledTable: true, fakeDraftsTable: true, fakeVotesTable: true, fakeMediaTable: true},
+		},
+		{
+			name:     "should pass while a table is updating",
+			existing: map[string]bool{fakeTable: true, fakeUsersTable: true, fakeRelationsTable: true, fakeScheduledTable: true, fakeDraftsTable: true, fakeVotesTable: true, fakeMediaTable: true},
+			status:   types.TableStatusUpdating,
+		},
+		{
+			name:          "should list every table that can't be described",
+			existing:      map[string]bool{},
+			expectedError: "table fake-table-name: ResourceNotFoundException: Cannot do operations on a non-existent table\ntable fake-users-table-name: ResourceNotFoundException: Cannot do operations on a non-existent table\ntable fake-relations-table-name: ResourceNotFoundException: Cannot do operations on a non-existent table\ntable fake-scheduled-table-name: ResourceNotFoundException: Cannot do operations on a non-existent table\ntable fake-drafts-table-name: ResourceNotFoundException: Cannot do operations on a non-existent table\ntable fake-votes-table-name: ResourceNotFoundException: Cannot do operations on a non-existent table\ntable fake-media-table-name: ResourceNotFoundException: Cannot do operations on a non-existent table",
+		},
+		{
+			name:          "should fail when the tables are not active",
+			existing:      map[string]bool{fakeTable: true, fakeUsersTable: true, fakeRelationsTable: true, fakeScheduledTable: true, fakeDraftsTable: true, fakeVotesTable: true, fakeMediaTable: true},
+			status:        types.TableStatusDeleting,
+			expectedError: "table fake-table-name is DELETING\ntable fake-users-table-name is DELETING\ntable fake-relations-table-name is DELETING\ntable fake-scheduled-table-name is DELETING\ntable fake-drafts-table-name is DELETING\ntable fake-votes-table-name is DELETING\ntable fake-media-table-name is DELETING",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			client := &tablesMockClient{existing: tc.existing, status: tc.status}
+
+			err := CheckTables(context.Background(), client, fakeTables)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.expectedError)
+		})
+	}
+}
+
+func Test_TableDefinitions(t *testing.T) {
+	defs := TableDefinitions(fakeTables)
+
+	tweets := defs[0]
+	assert.Equal(t, fakeTable, aws.ToString(tweets.TableName))
+	assert.Equal(t, []types.KeySchemaElement{
+		{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+		{AttributeName: aws.String("author"), KeyType: types.KeyTypeRange},
+	}, tweets.KeySchema)
+	if assert.Len(t, tweets.GlobalSecondaryIndexes, 2) {
+		assert.Equal(t, AuthorIndexName, aws.ToString(tweets.GlobalSecondaryIndexes[0].IndexName))
+		assert.Equal(t, ModerationIndexName, aws.ToString(tweets.GlobalSecondaryIndexes[1].IndexName))
+	}
+
+	users := defs[1]
+	assert.Equal(t, fakeUsersTable, aws.ToString(users.TableName))
+	assert.Equal(t, []types.KeySchemaElement{{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash}}, users.KeySchema)
+
+	relations := defs[2]
+	assert.Equal(t, fakeRelationsTable, aws.ToString(relations.TableName))
+	assert.Equal(t, []types.KeySchemaElement{
+		{AttributeName: aws.String("user_id"), KeyType: types.KeyTypeHash},
+		{AttributeName: aws.String("relation"), KeyType: types.KeyTypeRange},
+	}, relations.KeySchema)
+}
@@ -0,0 +1,316 @@
+package tweetsencoding
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//Format is how a list of tweets is laid out on the wire. Every format uses the same field names as the model.Tweet JSON,
+//so whatever we export can be fed straight back into the importer(/migrate-tweet)
+type Format string
+
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatJSON   Format = "json"
+	FormatCSV    Format = "csv"
+)
+
+//csvHeader is the column order for the csv format. likes, replies, mentioned and mediaIds are joined with
+//csvListSeparator. audience, audienceOwner and mentioned are the model.Audience of the tweet, empty for public tweets,
+//and poll is the JSON of the model.Poll
+var csvHeader = []string{"id", "author", "text", "timestamp", "replyingTo", "likes", "replies", "audience", "audienceOwner", "mentioned", "mediaIds", "moderationState", "poll"}
+
+const csvListSeparator = ";"
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return FormatNDJSON, nil
+	case FormatNDJSON, FormatJSON, FormatCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q. It should be one of ndjson, json or csv", s)
+	}
+}
+
+//FormatFromContentType maps a request Content-Type to a Format. Anything we don't recognize is treated as a JSON array
+func FormatFromContentType(contentType string) Format {
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	switch strings.ToLower(mediaType) {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return FormatNDJSON
+	case "text/csv":
+		return FormatCSV
+	default:
+		return FormatJSON
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSON:
+		return "application/json"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+type Encoder interface {
+	Encode(tweet *model.Tweet) error
+	//Close writes any trailing bytes needed by the format(eg the closing bracket of a JSON array). It does not close the underlying writer
+	Close() error
+}
+
+func NewEncoder(w io.Writer, f Format) Encoder {
+	switch f {
+	case FormatJSON:
+		return &jsonArrayEncoder{w: w}
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}
+	default:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}
+	}
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(tweet *model.Tweet) error {
+	return e.enc.Encode(tweet) //json.Encoder already terminates each value with a newline
+}
+
+func (e *ndjsonEncoder) Close() error { return nil }
+
+type jsonArrayEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonArrayEncoder) Encode(tweet *model.Tweet) error {
+	b, err := json.Marshal(tweet)
+	if err != nil {
+		return err
+	}
+
+	prefix := ",\n"
+	if e.count == 0 {
+		prefix = "[\n"
+	}
+	e.count++
+
+	if _, err := io.WriteString(e.w, prefix); err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonArrayEncoder) Close() error {
+	if e.count == 0 {
+		_, err := io.WriteString(e.w, "[]\n")
+		return err
+	}
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
+
+type csvEncoder struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (e *csvEncoder) Encode(tweet *model.Tweet) error {
+	if !e.wroteHeader {
+		if err := e.w.Write(csvHeader); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+
+	var timestamp string
+	if !tweet.Timestamp.IsZero() {
+		timestamp = strconv.FormatInt(time.Time(tweet.Timestamp).UnixMilli(), 10)
+	}
+
+	var level, owner string
+	var mentioned []string
+	if tweet.Audience != nil {
+		level, owner, mentioned = string(tweet.Audience.Level), tweet.Audience.Owner, tweet.Audience.Mentioned
+	}
+	var poll string
+	if tweet.Poll != nil {
+		b, err := json.Marshal(tweet.Poll)
+		if err != nil {
+			return err
+		}
+		poll = string(b)
+	}
+
+	err := e.w.Write([]string{
+		tweet.Id,
+		tweet.Author,
+		tweet.Text,
+		timestamp,
+		tweet.ReplyingTo,
+		strings.Join(tweet.Likes, csvListSeparator),
+		strings.Join(tweet.Replies, csvListSeparator),
+		level,
+		owner,
+		strings.Join(mentioned, csvListSeparator),
+		strings.Join(tweet.MediaIds, csvListSeparator),
+		tweet.ModerationState,
+		poll,
+	})
+	if err != nil {
+		return err
+	}
+	//flush every row so a streamed response does not stall behind the csv buffer
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	if !e.wroteHeader {
+		if err := e.w.Write(csvHeader); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+//Decode reads every tweet in r. It always returns a non nil slice when there is no error
+func Decode(r io.Reader, f Format) ([]*model.Tweet, error) {
+	switch f {
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	case FormatCSV:
+		return decodeCSV(r)
+	default:
+		items := make([]*model.Tweet, 0)
+		if err := json.NewDecoder(r).Decode(&items); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+}
+
+func decodeNDJSON(r io.Reader) ([]*model.Tweet, error) {
+	items := make([]*model.Tweet, 0)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) //a single tweet line should never get close to 1MB
+	line := 0
+	for scanner.Scan() {
+		line++
+		b := strings.TrimSpace(scanner.Text())
+		if b == "" {
+			continue
+		}
+
+		item := &model.Tweet{}
+		if err := json.Unmarshal([]byte(b), item); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func decodeCSV(r io.Reader) ([]*model.Tweet, error) {
+	items := make([]*model.Tweet, 0)
+
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return items, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["author"]; !ok {
+		return nil, errors.New("csv header must contain an author column")
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	list := func(v string) []string {
+		if v == "" {
+			return nil
+		}
+		return strings.Split(v, csvListSeparator)
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		item := &model.Tweet{
+			Id:              field(record, "id"),
+			Author:          field(record, "author"),
+			Text:            field(record, "text"),
+			ReplyingTo:      field(record, "replyingTo"),
+			Likes:           list(field(record, "likes")),
+			Replies:         list(field(record, "replies")),
+			MediaIds:        list(field(record, "mediaIds")),
+			ModerationState: field(record, "moderationState"),
+		}
+		line, _ := cr.FieldPos(0)
+		//files exported before the audience column have none: their tweets are public
+		if level := field(record, "audience"); level != "" {
+			l, err := model.ParseAudienceLevel(level)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if l != model.AudiencePublic {
+				item.Audience = &model.Audience{Level: l, Owner: field(record, "audienceOwner"), Mentioned: list(field(record, "mentioned"))}
+			}
+		}
+		if poll := field(record, "poll"); poll != "" {
+			item.Poll = &model.Poll{}
+			if err := json.Unmarshal([]byte(poll), item.Poll); err != nil {
+				return nil, fmt.Errorf("line %d: invalid poll: %w", line, err)
+			}
+		}
+		if ts := field(record, "timestamp"); ts != "" {
+			t, err := model.ParseTime(ts)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid timestamp %q", line, ts)
+			}
+			item.Timestamp = model.ChirperAppUnixTime(t)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,115 @@
+package tweetsencoding
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EncodeDecode_RoundTrip(t *testing.T) {
+	tweets := []*model.Tweet{
+		{
+			Id:        "8xf0y6ziyjabvozdd253nd",
+			Text:      "Shoutout to all the speakers I know for whom English is not a first language, but can STILL explain a concept well. It's hard enough to give a good talk in your mother tongue!",
+			Author:    "sarah_edo",
+			Timestamp: model.ChirperAppUnixTime(time.UnixMilli(1518122597860)),
+			Likes:     []string{"tylermcginnis"},
+			Replies:   []string{"fap8sdxppna8oabnxljzcv", "3km0v4hf1ps92ajf4z2ytg"},
+		},
+		{
+			Id:         "fap8sdxppna8oabnxljzcv",
+			Text:       "I agree, \"totally\".\nIt's hard",
+			Author:     "tylermcginnis",
+			Timestamp:  model.ChirperAppUnixTime(time.UnixMilli(1518043995650)),
+			ReplyingTo: "8xf0y6ziyjabvozdd253nd",
+		},
+		{
+			Id:              "3km0v4hf1ps92ajf4z2ytg",
+			Text:            "@tylermcginnis only for you",
+			Author:          "sarah_edo",
+			Timestamp:       model.ChirperAppUnixTime(time.UnixMilli(1518044123456)),
+			Audience:        &model.Audience{Level: model.AudienceMentioned, Owner: "sarah_edo", Mentioned: []string{"tylermcginnis"}},
+			MediaIds:        []string{"0b5ba3a6-4f5e-4f7e-9a4e-3f1c3b1f2d44", "5d0c1b1e-8a41-4f0e-b8a3-2a7c9e1d6f3b"},
+			ModerationState: model.ModerationPending,
+			Poll:            &model.Poll{Options: []model.PollOption{{Text: "Tabs", Votes: 2}, {Text: "Spaces"}}, ClosesAt: model.ChirperAppUnixTime(time.UnixMilli(1767225600000))},
+		},
+	}
+
+	for _, format := range []Format{FormatNDJSON, FormatJSON, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := NewEncoder(&buf, format)
+			for _, tweet := range tweets {
+				require.NoError(t, enc.Encode(tweet))
+			}
+			require.NoError(t, enc.Close())
+
+			got, err := Decode(&buf, format)
+			require.NoError(t, err)
+			require.Len(t, got, len(tweets))
+			for i := range tweets {
+				assert.Equal(t, tweets[i].Id, got[i].Id)
+				assert.Equal(t, tweets[i].Author, got[i].Author)
+				assert.Equal(t, tweets[i].Text, got[i].Text)
+				assert.Equal(t, tweets[i].ReplyingTo, got[i].ReplyingTo)
+				assert.Equal(t, tweets[i].Likes, got[i].Likes)
+				assert.Equal(t, tweets[i].Replies, got[i].Replies)
+				assert.Equal(t, tweets[i].Audience, got[i].Audience, "a restricted tweet comes back restricted")
+				assert.Equal(t, tweets[i].MediaIds, got[i].MediaIds)
+				assert.Equal(t, tweets[i].ModerationState, got[i].ModerationState)
+				if tweets[i].Poll != nil && assert.NotNil(t, got[i].Poll) {
+					assert.Equal(t, tweets[i].Poll.Options, got[i].Poll.Options)
+					assert.Equal(t, time.Time(tweets[i].Poll.ClosesAt).UnixMilli(), time.Time(got[i].Poll.ClosesAt).UnixMilli())
+				}
+				assert.Equal(t, time.Time(tweets[i].Timestamp).UnixMilli(), time.Time(got[i].Timestamp).UnixMilli())
+			}
+		})
+	}
+}
+
+func Test_DecodeCSV_WithoutAudience(t *testing.T) {
+	//the columns of the files exported before the audience was added
+	got, err := Decode(bytes.NewBufferString("id,author,text,timestamp,replyingTo,likes,replies\nid-1,sarah_edo,hello,1518122597860,,,\n"), FormatCSV)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Nil(t, got[0].Audience)
+	assert.Nil(t, got[0].Poll)
+
+	_, err = Decode(bytes.NewBufferString("author,audience\nsarah_edo,friends\n"), FormatCSV)
+	assert.Error(t, err)
+}
+
+func Test_Encode_EmptyList(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf, FormatJSON)
+	require.NoError(t, enc.Close())
+	assert.Equal(t, "[]\n", buf.String())
+
+	got, err := Decode(&buf, FormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, []*model.Tweet{}, got)
+}
+
+func Test_ParseFormat(t *testing.T) {
+	f, err := ParseFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatNDJSON, f)
+
+	f, err = ParseFormat("CSV")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, f)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func Test_FormatFromContentType(t *testing.T) {
+	assert.Equal(t, FormatJSON, FormatFromContentType("application/json"))
+	assert.Equal(t, FormatJSON, FormatFromContentType(""))
+	assert.Equal(t, FormatNDJSON, FormatFromContentType("application/x-ndjson; charset=utf-8"))
+	assert.Equal(t, FormatCSV, FormatFromContentType("text/csv"))
+}
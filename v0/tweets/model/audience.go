@@ -0,0 +1,52 @@
+package tweetmodel
+
+import (
+	"fmt"
+	"strings"
+)
+
+//AudienceLevel is who can see a tweet
+type AudienceLevel string
+
+const (
+	//AudiencePublic tweets are seen by everybody, signed in or not. Tweets without an audience are public
+	AudiencePublic AudienceLevel = "public"
+	//AudienceFollowers tweets are seen by the followers of the owner
+	AudienceFollowers AudienceLevel = "followers"
+	//AudienceMentioned tweets are seen by the users @mentioned in the text of the owner's tweet
+	AudienceMentioned AudienceLevel = "mentioned"
+)
+
+//ParseAudienceLevel accepts the levels case insensitively. An empty string is public
+func ParseAudienceLevel(s string) (AudienceLevel, error) {
+	switch l := AudienceLevel(strings.ToLower(strings.TrimSpace(s))); l {
+	case "", AudiencePublic:
+		return AudiencePublic, nil
+	case AudienceFollowers, AudienceMentioned:
+		return l, nil
+	}
+	return "", fmt.Errorf("invalid audience %q. It should be %s, %s or %s", s, AudiencePublic, AudienceFollowers, AudienceMentioned)
+}
+
+//Audience restricts who can see a tweet. Replies copy the audience of the root of their conversation, so Owner and
+//Mentioned are the ones of the root tweet, not of the reply
+type Audience struct {
+	Level AudienceLevel `json:"level" dynamodbav:"level"`
+	//Owner is the author of the tweet that picked the audience. Defaults to the author of the tweet
+	Owner string `json:"owner,omitempty" dynamodbav:"owner,omitempty"`
+	//Mentioned are the users an AudienceMentioned tweet is for
+	Mentioned []string `json:"mentioned,omitempty" dynamodbav:"mentioned,omitempty,omitemptyelem,stringset"`
+}
+
+//Restricted is false for public tweets
+func (t *Tweet) Restricted() bool {
+	return t.Audience != nil && t.Audience.Level != "" && t.Audience.Level != AudiencePublic
+}
+
+//AudienceOwner is the user whose followers or mentions decide who sees the tweet
+func (t *Tweet) AudienceOwner() string {
+	if t.Audience != nil && t.Audience.Owner != "" {
+		return t.Audience.Owner
+	}
+	return t.Author
+}
@@ -0,0 +1,13 @@
+package tweetmodel
+
+//MaxBatchSize is the most tweets BulkSaveTweet writes at a time. DynamoDB BatchWriteItem accepts at most 25 items
+const MaxBatchSize = 25
+
+//Batches splits tweets into chunks of at most size tweets
+func Batches(tweets []*Tweet, size int) [][]*Tweet {
+	var batches [][]*Tweet
+	for size < len(tweets) {
+		tweets, batches = tweets[size:], append(batches, tweets[0:size:size])
+	}
+	return append(batches, tweets)
+}
@@ -0,0 +1,21 @@
+package tweetmodel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Batches(t *testing.T) {
+	tweets := make([]*Tweet, 0)
+	for i := 0; i < 51; i++ {
+		tweets = append(tweets, &Tweet{})
+	}
+
+	batches := Batches(tweets, MaxBatchSize)
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[0], 25)
+	assert.Len(t, batches[1], 25)
+	assert.Len(t, batches[2], 1)
+}
@@ -0,0 +1,18 @@
+package tweetmodel
+
+//Draft is an unfinished tweet or reply of UserId. Clients autosave it while the user types and publish it through SaveTweet
+//once it is done. The drafts table is keyed by user_id and id so the drafts of a user are one Query
+type Draft struct {
+	UserId string `json:"userId" dynamodbav:"user_id"`
+	Id     string `json:"id" dynamodbav:"id"`
+	//Text can be empty or too long while the user is still writing. It is only checked when the draft is published
+	Text string `json:"text" dynamodbav:"text_blob"`
+	//ReplyingTo is in the {reply_tweet_id}:{reply_tweet_author} format SaveTweet takes
+	ReplyingTo string    `json:"replyingTo,omitempty" dynamodbav:"replyingTo,omitempty"`
+	Audience   *Audience `json:"audience,omitempty" dynamodbav:"audience,omitempty"`
+	//Version goes up by one on every save. A save or publish carries the version the client last read, and fails when
+	//another device saved the draft in between, instead of overwriting it
+	Version   int64              `json:"version" dynamodbav:"version"`
+	CreatedAt ChirperAppUnixTime `json:"createdAt" dynamodbav:"created_at,unixtime"`
+	UpdatedAt ChirperAppUnixTime `json:"updatedAt" dynamodbav:"updated_at,unixtime"`
+}
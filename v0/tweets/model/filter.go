@@ -0,0 +1,51 @@
+package tweetmodel
+
+import (
+	"strconv"
+	"time"
+)
+
+//TweetFilter narrows down the tweets returned by bulk reads like export. The zero value matches every tweet
+type TweetFilter struct {
+	Authors []string
+	From    time.Time //inclusive; zero means no lower bound
+	To      time.Time //inclusive; zero means no upper bound
+	//VisibleOnly leaves out the tweets held for review or removed by moderation. The public lists set it; exports don't
+	VisibleOnly bool
+}
+
+func (f TweetFilter) Match(t *Tweet) bool {
+	if len(f.Authors) > 0 {
+		found := false
+		for _, a := range f.Authors {
+			if a == t.Author {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.VisibleOnly && !t.Visible() {
+		return false
+	}
+
+	ts := time.Time(t.Timestamp)
+	if !f.From.IsZero() && ts.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && ts.After(f.To) {
+		return false
+	}
+	return true
+}
+
+//ParseTime accepts either a unix time in milliseconds (the same shape as ChirperAppUnixTime in JSON) or an RFC3339 date
+func ParseTime(s string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,97 @@
+package tweetmodel
+
+import "fmt"
+
+//MaxMediaPerTweet is how many uploads a tweet can reference
+const MaxMediaPerTweet = 4
+
+//the processing of an image. Videos and WebP images are not processed, their Status is empty
+const (
+	//MediaPending images wait for the processing worker. They have no size, placeholder or thumbnails yet
+	MediaPending = "pending"
+	MediaReady   = "ready"
+	//MediaFailed images could not be decoded, or the worker gave up on them. They are still served as uploaded
+	MediaFailed = "failed"
+)
+
+//MediaOrphan is the PendingGC of the uploads no tweet attached yet
+const MediaOrphan = "orphan"
+
+//ThumbnailSizes are the sides of the square thumbnails made of every image, in pixels
+var ThumbnailSizes = []int{150, 480}
+
+//Media is an uploaded file. The content is in the blob store at MediaKey(Id), this is what we know about it. A media
+//is an orphan until a tweet of its uploader references it; orphans are deleted after a while
+type Media struct {
+	Id     string `json:"id" dynamodbav:"id"`
+	UserId string `json:"userId" dynamodbav:"user_id"`
+	//ContentType is sniffed from the content, what the client claims is ignored
+	ContentType string `json:"contentType" dynamodbav:"content_type"`
+	Size        int64  `json:"size" dynamodbav:"size"`
+	//Checksum is the hex SHA-256 of the content
+	Checksum string `json:"checksum" dynamodbav:"checksum"`
+	//TweetId is the tweet the media is attached to, empty for an orphan
+	TweetId   string             `json:"tweetId,omitempty" dynamodbav:"tweet_id,omitempty"`
+	CreatedAt ChirperAppUnixTime `json:"createdAt" dynamodbav:"created_at,unixtime"`
+	//PendingGC puts an orphan in the index of the orphan media collector. It is removed when a tweet attaches the media,
+	//which takes it out of the index
+	PendingGC string `json:"-" dynamodbav:"pending_gc,omitempty"`
+	//Status is where the processing of an image is at, see MediaPending
+	Status string `json:"status,omitempty" dynamodbav:"status,omitempty"`
+	//Width and Height are the size the image is shown with, after its EXIF orientation
+	Width  int `json:"width,omitempty" dynamodbav:"width,omitempty"`
+	Height int `json:"height,omitempty" dynamodbav:"height,omitempty"`
+	//Placeholder is the BlurHash of the image. Clients show it at Width x Height until the image loads
+	Placeholder string           `json:"placeholder,omitempty" dynamodbav:"placeholder,omitempty"`
+	Thumbnails  []MediaThumbnail `json:"thumbnails,omitempty" dynamodbav:"thumbnails,omitempty"`
+	//PendingProcessing is MediaPending while the image waits for the worker. It puts the image in the index the worker
+	//queries; it is removed once the image is ready or failed, which takes it out of the index
+	PendingProcessing string `json:"-" dynamodbav:"pending_processing,omitempty"`
+	//Attempts is how many times the worker tried to process the image
+	Attempts int `json:"-" dynamodbav:"attempts,omitempty"`
+}
+
+//MediaThumbnail is a square JPEG of an image, in the blob store at MediaThumbnailKey
+type MediaThumbnail struct {
+	//Side is the width and height in pixels, one of ThumbnailSizes
+	Side int   `json:"side" dynamodbav:"side"`
+	Size int64 `json:"size" dynamodbav:"size"`
+}
+
+//MediaKey is where the content of a media is in the blob store
+func MediaKey(id string) string {
+	return "media/" + id
+}
+
+//MediaThumbnailKey is where the thumbnail of side pixels of a media is in the blob store
+func MediaThumbnailKey(id string, side int) string {
+	return fmt.Sprintf("thumbnails/%s/%d", id, side)
+}
+
+//Thumbnail is the thumbnail of side pixels, nil when the media has none
+func (m *Media) Thumbnail(side int) *MediaThumbnail {
+	for i := range m.Thumbnails {
+		if m.Thumbnails[i].Side == side {
+			return &m.Thumbnails[i]
+		}
+	}
+	return nil
+}
+
+//MediaGCReport is what one round of the orphan media collector did
+type MediaGCReport struct {
+	Orphans int `json:"orphans"`
+	Deleted int `json:"deleted"`
+	//Attached were attached to a tweet while they were collected, they are kept
+	Attached int `json:"attached"`
+	Failed   int `json:"failed"`
+}
+
+//MediaProcessingReport is what one round of the image processing worker did
+type MediaProcessingReport struct {
+	Pending int `json:"pending"`
+	Ready   int `json:"ready"`
+	//Retried failed for a reason that may go away, eg the blob store was down. They are tried again next round
+	Retried int `json:"retried"`
+	Failed  int `json:"failed"`
+}
@@ -0,0 +1,49 @@
+package tweetmodel
+
+import "time"
+
+const (
+	//MinPollOptions and MaxPollOptions bound how many options a poll has
+	MinPollOptions = 2
+	MaxPollOptions = 4
+	//MaxPollOptionLength is how long the text of an option can be, in characters
+	MaxPollOptionLength = 25
+)
+
+//Poll is a question attached to a tweet. The tallies are kept on the tweet and the votes in the votes table, one per user
+type Poll struct {
+	Options  []PollOption       `json:"options" dynamodbav:"options"`
+	ClosesAt ChirperAppUnixTime `json:"closesAt" dynamodbav:"closes_at,unixtime"`
+	//ResultsHidden is true when the votes were left out because the viewer did not vote and the poll is still open
+	ResultsHidden bool `json:"resultsHidden,omitempty" dynamodbav:"-"`
+	//VotedOption is the index of the option the viewer voted for, nil when they did not vote
+	VotedOption *int `json:"votedOption,omitempty" dynamodbav:"-"`
+}
+
+//PollOption is one answer of a poll and how many users voted for it
+type PollOption struct {
+	Text  string `json:"text" dynamodbav:"text"`
+	Votes int64  `json:"votes" dynamodbav:"votes"`
+}
+
+//Closed is true once nobody can vote anymore
+func (p *Poll) Closed(now time.Time) bool {
+	return !now.Before(time.Time(p.ClosesAt))
+}
+
+//HideResults leaves the tallies out, for viewers who did not vote on an open poll
+func (p *Poll) HideResults() {
+	for i := range p.Options {
+		p.Options[i].Votes = 0
+	}
+	p.ResultsHidden = true
+}
+
+//PollVote is the vote of UserId on the poll of a tweet. The votes table is keyed by tweet_id and user_id, so a user
+//has one vote per poll and a conditional write is enough to enforce it
+type PollVote struct {
+	TweetId   string             `json:"tweetId" dynamodbav:"tweet_id"`
+	UserId    string             `json:"userId" dynamodbav:"user_id"`
+	Option    int                `json:"option" dynamodbav:"option"`
+	Timestamp ChirperAppUnixTime `json:"timestamp" dynamodbav:"created_at,unixtime"`
+}
@@ -0,0 +1,27 @@
+package tweetmodel
+
+//RelationKind is how a user relates to another user
+type RelationKind string
+
+const (
+	//Block stops the target from replying to or liking the tweets of the user, and hides the target from the lists of the user
+	Block RelationKind = "block"
+	//Mute only hides the target from the lists of the user
+	Mute RelationKind = "mute"
+	//Follow lets the user see the followers only tweets of the target
+	Follow RelationKind = "follow"
+)
+
+//Relation is one block, mute or follow of UserId. The relations table is keyed by user_id and relation("block#tylermcginnis") so every
+//relation of a user is one Query, and checking a single one is one GetItem
+type Relation struct {
+	UserId    string             `json:"userId" dynamodbav:"user_id"`
+	Kind      RelationKind       `json:"kind" dynamodbav:"kind"`
+	Target    string             `json:"target" dynamodbav:"target"`
+	Timestamp ChirperAppUnixTime `json:"timestamp,omitempty" dynamodbav:"created_at,unixtime"`
+}
+
+//RelationKey is the range key of a relation
+func RelationKey(kind RelationKind, target string) string {
+	return string(kind) + "#" + target
+}
@@ -0,0 +1,41 @@
+package tweetmodel
+
+//ValidationReport is the result of checking a bulk payload without writing it. See `/migrate-tweet?dryRun=true`
+type ValidationReport struct {
+	DryRun   bool          `json:"dryRun"`
+	Total    int           `json:"total"`
+	Valid    int           `json:"valid"`
+	Invalid  int           `json:"invalid"`
+	Errors   []RecordIssue `json:"errors,omitempty"`
+	Warnings []RecordIssue `json:"warnings,omitempty"` //things that will not fail the import but may be surprising. eg: replacing an existing tweet
+}
+
+//RecordIssue points at a single field of a single record in the payload
+type RecordIssue struct {
+	Index   int    `json:"index"`
+	Id      string `json:"id,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+//ReindexReport is the result of rebuilding the tweets set of every user from the tweets table
+type ReindexReport struct {
+	Tweets       int      `json:"tweets"`
+	Users        int      `json:"users"` //users whose tweets set was updated
+	MissingUsers []string `json:"missingUsers,omitempty"` //authors that are not in the users table. Their tweets are left out of the index
+}
+
+//RepairReport is the result of checking the replies set of every tweet against the tweets that reply to it
+type RepairReport struct {
+	DryRun   bool        `json:"dryRun"`
+	Tweets   int         `json:"tweets"`
+	Repaired int         `json:"repaired"`
+	Fixes    []ReplyFix  `json:"fixes,omitempty"`
+}
+
+type ReplyFix struct {
+	Id       string   `json:"id"`
+	Author   string   `json:"author"`
+	Missing  []string `json:"missing,omitempty"`  //replies that exist but are not in the set
+	Dangling []string `json:"dangling,omitempty"` //ids in the set that are not a reply to this tweet(anymore)
+}
@@ -0,0 +1,24 @@
+package tweetmodel
+
+type ParallelScanInput struct {
+	TotalSegments int32
+	//the Limit of every Scan call. 0 lets DynamoDB fill pages up to 1MB
+	PageSize int32
+	//SegmentLimits, when set, is the Limit of each segment in place of PageSize. Only the segments in it are read, the
+	//others keep their place in the cursor
+	SegmentLimits map[int32]int32
+	//the number of pages to read from each segment before stopping. 0 reads the segments to the end
+	MaxPagesPerSegment int
+	Filter             TweetFilter
+	//resume from the Cursor of a previous ScanPage. TotalSegments must match the scan that produced it
+	Cursor string
+}
+
+//ScanPage is one Scan response from one segment of a parallel scan
+type ScanPage struct {
+	Segment int32
+	Tweets  []*Tweet
+	//Cursor resumes the whole scan right after this page. Every page sent before this one is accounted for; "" means the scan is complete
+	Cursor string
+	Err    error
+}
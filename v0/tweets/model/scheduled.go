@@ -0,0 +1,62 @@
+package tweetmodel
+
+import "time"
+
+//ScheduledStatus is where a scheduled tweet is in its life
+type ScheduledStatus string
+
+const (
+	//ScheduledWaiting tweets wait for their time, or for their next attempt after a failure
+	ScheduledWaiting ScheduledStatus = "scheduled"
+	//ScheduledPublishing tweets were claimed by the publisher and are being saved
+	ScheduledPublishing ScheduledStatus = "publishing"
+	//ScheduledPublished tweets are out. TweetId is the tweet that was saved
+	ScheduledPublished ScheduledStatus = "published"
+	//ScheduledFailed tweets could not be published. LastError says why
+	ScheduledFailed ScheduledStatus = "failed"
+)
+
+//dueBucketLayout buckets the due index by day. The publisher reads one partition per day it looks back
+const dueBucketLayout = "2006-01-02"
+
+//DueBucket is the partition of the due index a tweet due at t goes in
+func DueBucket(t time.Time) string {
+	return t.UTC().Format(dueBucketLayout)
+}
+
+//ScheduledTweet is a tweet written now and published later through SaveTweet, with the same checks as any other tweet
+type ScheduledTweet struct {
+	//Id is also the id of the published tweet, so publishing it twice saves the same tweet
+	Id     string `json:"id" dynamodbav:"id"`
+	Author string `json:"author" dynamodbav:"author"`
+	Text   string `json:"text" dynamodbav:"text_blob"`
+	//ReplyingTo is in the {reply_tweet_id}:{reply_tweet_author} format SaveTweet takes
+	ReplyingTo string    `json:"replyingTo,omitempty" dynamodbav:"replyingTo,omitempty"`
+	Audience   *Audience `json:"audience,omitempty" dynamodbav:"audience,omitempty"`
+	//Poll is published with the tweet. Its votes start when it is published
+	Poll *Poll `json:"poll,omitempty" dynamodbav:"poll,omitempty"`
+	//MediaIds are attached to the scheduled tweet until it is published, so the orphan collector leaves them alone
+	MediaIds  []string           `json:"mediaIds,omitempty" dynamodbav:"media_ids,omitempty"`
+	PublishAt ChirperAppUnixTime `json:"publishAt" dynamodbav:"publish_at,unixtime"`
+	Status    ScheduledStatus    `json:"status" dynamodbav:"status"`
+	//DueBucket and DueAt put a waiting tweet in the due index. DueAt is PublishAt, or the next attempt after a failure.
+	//DueBucket is removed once the tweet is published or failed for good, which takes it out of the index
+	DueBucket string             `json:"-" dynamodbav:"due_bucket,omitempty"`
+	DueAt     ChirperAppUnixTime `json:"-" dynamodbav:"due_at,unixtime"`
+	Attempts  int                `json:"attempts,omitempty" dynamodbav:"attempts,omitempty"`
+	LastError string             `json:"lastError,omitempty" dynamodbav:"last_error,omitempty"`
+	TweetId   string             `json:"tweetId,omitempty" dynamodbav:"tweet_id,omitempty"`
+	CreatedAt ChirperAppUnixTime `json:"createdAt" dynamodbav:"created_at,unixtime"`
+}
+
+//PublishReport sums up one round of the scheduled tweets publisher
+type PublishReport struct {
+	Due       int `json:"due"`
+	Published int `json:"published"`
+	//Retried failed and wait for another attempt
+	Retried int `json:"retried"`
+	//Failed failed for good, eg moderation rejected the text
+	Failed int `json:"failed"`
+	//Skipped were claimed by another publisher first, or cancelled in between
+	Skipped int `json:"skipped"`
+}
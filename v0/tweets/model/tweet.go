@@ -1,6 +1,7 @@
 package tweetmodel
 
 import (
+	"log/slog"
 	"strconv"
 	"strings"
 	"time"
@@ -14,8 +15,54 @@ type Tweet struct {
   	Text string  `json:"text" dynamodbav:"text_blob"`
 	Timestamp ChirperAppUnixTime `json:"timestamp,omitempty" dynamodbav:"created_at,unixtime"`
   	ReplyingTo string  `json:"replyingTo" dynamodbav:"replyingTo"` //if empty then we know its a new tweet
+	//ModerationState is empty for tweets everybody can see. See the ModerationPending and ModerationRemoved states
+	ModerationState string `json:"moderationState,omitempty" dynamodbav:"moderation_state,omitempty"`
+	//why the moderation rules flagged the tweet. Only shown to reviewers
+	ModerationReasons []string `json:"moderationReasons,omitempty" dynamodbav:"moderation_reasons,omitempty,omitemptyelem,stringset"`
+	//Audience is who can see the tweet. nil is public. See Audience
+	Audience *Audience `json:"audience,omitempty" dynamodbav:"audience,omitempty"`
+	//Poll is the poll attached to the tweet, if any
+	Poll *Poll `json:"poll,omitempty" dynamodbav:"poll,omitempty"`
+	//MediaIds are the uploads attached to the tweet, in the order they are shown. See Media
+	MediaIds []string `json:"mediaIds,omitempty" dynamodbav:"media_ids,omitempty"`
+	//Media are the media of MediaIds, with the size and placeholder of the images. Only ListTweets fills it
+	Media []*Media `json:"media,omitempty" dynamodbav:"-"`
 }
 
+const (
+	//ModerationPending tweets were flagged by a moderation rule. They are saved but hidden from the public lists until a reviewer looks at them
+	ModerationPending = "pending"
+	//ModerationRemoved tweets were taken down by a reviewer. They stay in the table for the record
+	ModerationRemoved = "removed"
+)
+
+//ReviewDecision is what a reviewer decides about a tweet held for review
+type ReviewDecision string
+
+const (
+	//ReviewApprove makes the tweet visible
+	ReviewApprove ReviewDecision = "approve"
+	//ReviewRemove takes the tweet down
+	ReviewRemove ReviewDecision = "remove"
+)
+
+//Visible is false for tweets held for review or removed by a reviewer
+func (t *Tweet) Visible() bool {
+	return t.ModerationState == ""
+}
+
+//LogValue is what the logs show of a tweet. The text is under the "text" key so LOG_REDACT_TEXT can hide it
+func (t *Tweet) LogValue() slog.Value {
+	if t == nil {
+		return slog.Value{}
+	}
+	return slog.GroupValue(
+		slog.String("id", t.Id),
+		slog.String("author", t.Author),
+		slog.String("replyingTo", t.ReplyingTo),
+		slog.String("text", t.Text),
+	)
+}
 
 type ChirperAppUnixTime time.Time
 
@@ -0,0 +1,8 @@
+package tweetmodel
+
+//User is the part of a users table item this service knows about. The table belongs to the users service; we only write users when seeding demo data
+type User struct {
+	Id     string   `json:"id" dynamodbav:"id"`
+	Name   string   `json:"name" dynamodbav:"name"`
+	Tweets []string `json:"tweets,omitempty" dynamodbav:"tweets,omitempty,omitemptyelem,stringset"`
+}
@@ -0,0 +1,99 @@
+package tweetsmoderation
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/okpalaChidiebere/chirper-app-api-tweet/v0/common/metrics"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//Verdict is what a rule thinks should happen to a tweet. The values are ordered: a higher verdict wins
+type Verdict int
+
+const (
+	//Allow saves the tweet as usual
+	Allow Verdict = iota
+	//Flag saves the tweet but holds it for review. It is hidden from the public lists until a reviewer approves it
+	Flag
+	//Reject refuses the tweet. The author gets the reason back
+	Reject
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Flag:
+		return "flag"
+	case Reject:
+		return "reject"
+	}
+	return "allow"
+}
+
+//ParseVerdict reads the verdict names used in the config
+func ParseVerdict(s string) (Verdict, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "allow":
+		return Allow, nil
+	case "flag":
+		return Flag, nil
+	case "reject":
+		return Reject, nil
+	}
+	return Allow, fmt.Errorf("unknown verdict %q. It should be allow, flag or reject", s)
+}
+
+//Decision is what one rule decided about a tweet. Reason says why, for the reviewers(flag) or the author(reject)
+type Decision struct {
+	Verdict Verdict
+	Reason  string
+}
+
+//Rule is one check of the moderation chain
+type Rule interface {
+	//Name is used in the reasons, the metrics and the MODERATION_RULES config
+	Name() string
+	Check(ctx context.Context, tweet *model.Tweet) (Decision, error)
+}
+
+//Result is the outcome of the whole chain. Reasons has one entry per rule that did not allow the tweet, as "rule: reason"
+type Result struct {
+	Verdict Verdict
+	Reasons []string
+}
+
+//Chain runs rules in order. The first Reject stops the chain; flags are collected so a reviewer sees every reason at once
+type Chain struct {
+	rules []Rule
+}
+
+func NewChain(rules ...Rule) *Chain {
+	return &Chain{rules: rules}
+}
+
+//Moderate runs the tweet through every rule. A rule that fails(eg DynamoDB is unavailable) is logged and skipped:
+//we would rather let a tweet through than stop everybody from tweeting
+func (c *Chain) Moderate(ctx context.Context, tweet *model.Tweet) Result {
+	result := Result{Verdict: Allow}
+	for _, rule := range c.rules {
+		d, err := rule.Check(ctx, tweet)
+		if err != nil {
+			slog.WarnContext(ctx, "moderation rule failed, skipping it", "rule", rule.Name(), "err", err)
+			continue
+		}
+		if d.Verdict == Allow {
+			continue
+		}
+
+		metrics.ModerationDecisions.WithLabelValues(rule.Name(), d.Verdict.String()).Inc()
+		reason := rule.Name() + ": " + d.Reason
+		if d.Verdict == Reject {
+			return Result{Verdict: Reject, Reasons: []string{reason}}
+		}
+		result.Verdict = Flag
+		result.Reasons = append(result.Reasons, reason)
+	}
+	return result
+}
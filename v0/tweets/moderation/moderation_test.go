@@ -0,0 +1,160 @@
+package tweetsmoderation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func Test_BannedWords(t *testing.T) {
+	rule := BannedWords([]string{"darn", "Heck", "ass"}, Reject)
+
+	testCases := []struct {
+		name     string
+		text     string
+		expected Verdict
+	}{
+		{name: "should allow a clean text", text: "what a lovely day", expected: Allow},
+		{name: "should match a banned word", text: "well darn it", expected: Reject},
+		{name: "should ignore case", text: "DARN", expected: Reject},
+		{name: "should ignore the punctuation around a word", text: "oh, heck!", expected: Reject},
+		{name: "should fold leet speak", text: "h3ck that", expected: Reject},
+		{name: "should fold leet symbols", text: "d@rn", expected: Reject},
+		{name: "should strip accents", text: "héck", expected: Reject},
+		{name: "should squeeze repeated letters", text: "daaaaarn", expected: Reject},
+		{name: "should squeeze a word with a double letter", text: "asssss", expected: Reject},
+		{name: "should join words spelled one letter at a time", text: "d a r n you", expected: Reject},
+		{name: "should not match part of a word", text: "passage", expected: Allow},
+		{name: "should not squeeze a word into a banned one", text: "as", expected: Allow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := rule.Check(context.TODO(), &model.Tweet{Text: tc.text})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, d.Verdict)
+		})
+	}
+}
+
+func Test_BlockedDomains(t *testing.T) {
+	rule := BlockedDomains([]string{"spam.example", "*.bad.test"}, Reject)
+
+	testCases := []struct {
+		name     string
+		text     string
+		expected Verdict
+	}{
+		{name: "should allow a text without links", text: "spam.example is not a link", expected: Allow},
+		{name: "should allow other domains", text: "see https://example.com/spam.example", expected: Allow},
+		{name: "should block the domain", text: "see https://spam.example/offer", expected: Reject},
+		{name: "should block a subdomain", text: "see http://www.SPAM.example", expected: Reject},
+		{name: "should block a www link", text: "www.bad.test.", expected: Reject},
+		{name: "should not block a domain that only ends the same", text: "https://notspam.example", expected: Allow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := rule.Check(context.TODO(), &model.Tweet{Text: tc.text})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, d.Verdict)
+		})
+	}
+}
+
+func Test_RepeatedCharacters(t *testing.T) {
+	rule := RepeatedCharacters(5, Flag)
+
+	testCases := []struct {
+		name     string
+		text     string
+		expected Verdict
+	}{
+		{name: "should allow a short run", text: "sooooo good", expected: Allow},
+		{name: "should flag a long run", text: "soooooo good", expected: Flag},
+		{name: "should count an emoji as one character", text: strings.Repeat("\U0001F44D\U0001F3FD", 6), expected: Flag},
+		{name: "should not count across spaces", text: "a a a a a a a a", expected: Allow},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := rule.Check(context.TODO(), &model.Tweet{Text: tc.text})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, d.Verdict)
+		})
+	}
+}
+
+func Test_DuplicateText(t *testing.T) {
+	now := time.Unix(1518122597, 0)
+	var since time.Time
+	rule := DuplicateText(func(ctx context.Context, author string, s time.Time) ([]*model.Tweet, error) {
+		since = s
+		return []*model.Tweet{{Id: "old", Author: author, Text: "Buy my  course"}}, nil
+	}, 24*time.Hour, Reject).(*duplicateText)
+	rule.now = func() time.Time { return now }
+
+	d, err := rule.Check(context.TODO(), &model.Tweet{Author: "sarah_edo", Text: "buy my course"})
+	assert.NoError(t, err)
+	assert.Equal(t, Reject, d.Verdict)
+	assert.Equal(t, "the same text was already posted in the last 24h", d.Reason)
+	assert.Equal(t, now.Add(-24*time.Hour), since)
+
+	d, _ = rule.Check(context.TODO(), &model.Tweet{Id: "old", Author: "sarah_edo", Text: "buy my course"})
+	assert.Equal(t, Allow, d.Verdict, "saving the same tweet again is not a duplicate")
+
+	d, _ = rule.Check(context.TODO(), &model.Tweet{Author: "sarah_edo", Text: "something else"})
+	assert.Equal(t, Allow, d.Verdict)
+}
+
+//fixedRule always decides the same
+type fixedRule struct {
+	name string
+	d    Decision
+	err  error
+}
+
+func (r fixedRule) Name() string { return r.name }
+func (r fixedRule) Check(ctx context.Context, tweet *model.Tweet) (Decision, error) {
+	return r.d, r.err
+}
+
+func Test_Chain(t *testing.T) {
+	allow := fixedRule{name: "allow"}
+	flagA := fixedRule{name: "a", d: Decision{Verdict: Flag, Reason: "looks odd"}}
+	flagB := fixedRule{name: "b", d: Decision{Verdict: Flag, Reason: "also odd"}}
+	reject := fixedRule{name: "r", d: Decision{Verdict: Reject, Reason: "no"}}
+	broken := fixedRule{name: "broken", d: Decision{Verdict: Reject}, err: errors.New("boom")}
+
+	testCases := []struct {
+		name     string
+		rules    []Rule
+		expected Result
+	}{
+		{name: "should allow when no rule objects", rules: []Rule{allow}, expected: Result{Verdict: Allow}},
+		{name: "should collect every flag", rules: []Rule{flagA, allow, flagB}, expected: Result{Verdict: Flag, Reasons: []string{"a: looks odd", "b: also odd"}}},
+		{name: "should stop at a reject", rules: []Rule{flagA, reject, flagB}, expected: Result{Verdict: Reject, Reasons: []string{"r: no"}}},
+		{name: "should skip a rule that fails", rules: []Rule{broken, allow}, expected: Result{Verdict: Allow}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NewChain(tc.rules...).Moderate(context.TODO(), &model.Tweet{Text: "hello"}))
+		})
+	}
+}
+
+func Test_ParseVerdict(t *testing.T) {
+	v, err := ParseVerdict(" Flag ")
+	assert.NoError(t, err)
+	assert.Equal(t, Flag, v)
+
+	_, err = ParseVerdict("ban")
+	assert.Error(t, err)
+}
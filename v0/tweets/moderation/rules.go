@@ -0,0 +1,246 @@
+package tweetsmoderation
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+	tweetstext "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/text"
+)
+
+//the names of the rules, as used in the config
+const (
+	BannedWordsRule        = "banned_words"
+	BlockedDomainsRule     = "blocked_domains"
+	RepeatedCharactersRule = "repeated_characters"
+	DuplicateTextRule      = "duplicate_text"
+)
+
+//RuleNames lists every rule in the order they run when the config does not pick them. The cheap ones go first
+var RuleNames = []string{BannedWordsRule, BlockedDomainsRule, RepeatedCharactersRule, DuplicateTextRule}
+
+//leet maps the digits and symbols people use in place of letters back to the letters
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l', '+': 't',
+}
+
+func isPunctOrSymbol(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+//foldWord lowercases a word, strips its accents, turns leet speak into letters and drops everything else, so
+//"H3LL0", "héllo" and "h.e.l.l.o" all fold to "hello". The punctuation around the word(eg the ! of "hello!") is not leet speak and is trimmed first
+func foldWord(w string) string {
+	w = strings.TrimRightFunc(w, isPunctOrSymbol)
+	w = strings.TrimLeftFunc(w, func(r rune) bool { return r != '$' && isPunctOrSymbol(r) })
+
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(w)) {
+		if l, ok := leet[r]; ok {
+			r = l
+		}
+		//the accents are separate marks after NFD, so they are dropped here too
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+//squeeze shortens every run of the same letter to at most n letters. "fuuuck" needs 1 and "asss" needs 2
+func squeeze(s string, n int) string {
+	var b strings.Builder
+	var prev rune
+	run := 0
+	for _, r := range s {
+		if r == prev {
+			run++
+		} else {
+			prev, run = r, 1
+		}
+		if run <= n {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+type bannedWords struct {
+	words   map[string]bool
+	verdict Verdict
+}
+
+//BannedWords matches whole words against the list, after folding case, accents and leet speak and squeezing repeated letters.
+//Words spelled out one letter at a time("f u c k") are joined back together
+func BannedWords(words []string, verdict Verdict) Rule {
+	r := &bannedWords{words: map[string]bool{}, verdict: verdict}
+	for _, w := range words {
+		if w = foldWord(w); w != "" {
+			r.words[w] = true
+		}
+	}
+	return r
+}
+
+func (r *bannedWords) Name() string { return BannedWordsRule }
+
+func (r *bannedWords) Check(ctx context.Context, tweet *model.Tweet) (Decision, error) {
+	var tokens, letters []string
+	flush := func() {
+		if len(letters) > 1 {
+			tokens = append(tokens, strings.Join(letters, ""))
+		}
+		letters = nil
+	}
+	for _, w := range strings.Fields(tweet.Text) {
+		token := foldWord(w)
+		if token == "" {
+			continue
+		}
+		tokens = append(tokens, token)
+		if len([]rune(token)) == 1 {
+			letters = append(letters, token)
+		} else {
+			flush()
+		}
+	}
+	flush()
+
+	for _, token := range tokens {
+		for _, candidate := range []string{token, squeeze(token, 1), squeeze(token, 2)} {
+			if r.words[candidate] {
+				return Decision{Verdict: r.verdict, Reason: fmt.Sprintf("contains the banned word %q", candidate)}, nil
+			}
+		}
+	}
+	return Decision{Verdict: Allow}, nil
+}
+
+type blockedDomains struct {
+	domains []string
+	verdict Verdict
+}
+
+//BlockedDomains matches the links of the tweet against the domains. A domain also blocks its subdomains
+func BlockedDomains(domains []string, verdict Verdict) Rule {
+	r := &blockedDomains{verdict: verdict}
+	for _, d := range domains {
+		d = strings.Trim(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "*."), ".")
+		if d != "" {
+			r.domains = append(r.domains, d)
+		}
+	}
+	return r
+}
+
+func (r *blockedDomains) Name() string { return BlockedDomainsRule }
+
+func (r *blockedDomains) Check(ctx context.Context, tweet *model.Tweet) (Decision, error) {
+	for _, link := range tweetstext.Links(tweet.Text) {
+		if !strings.Contains(link, "://") {
+			link = "http://" + link //the www. links
+		}
+		u, err := url.Parse(link)
+		if err != nil {
+			continue
+		}
+		host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+		for _, d := range r.domains {
+			if host == d || strings.HasSuffix(host, "."+d) {
+				return Decision{Verdict: r.verdict, Reason: fmt.Sprintf("links to the blocked domain %s", d)}, nil
+			}
+		}
+	}
+	return Decision{Verdict: Allow}, nil
+}
+
+type repeatedCharacters struct {
+	maxRun  int
+	verdict Verdict
+}
+
+//RepeatedCharacters catches the same character(a grapheme cluster, so an emoji counts once) repeated more than maxRun times in a row.
+//Spaces break a run
+func RepeatedCharacters(maxRun int, verdict Verdict) Rule {
+	return &repeatedCharacters{maxRun: maxRun, verdict: verdict}
+}
+
+func (r *repeatedCharacters) Name() string { return RepeatedCharactersRule }
+
+func (r *repeatedCharacters) Check(ctx context.Context, tweet *model.Tweet) (Decision, error) {
+	var prev string
+	run := 0
+	g := uniseg.NewGraphemes(tweet.Text)
+	for g.Next() {
+		c := g.Str()
+		if strings.TrimSpace(c) == "" {
+			prev, run = "", 0
+			continue
+		}
+		if c == prev {
+			run++
+		} else {
+			prev, run = c, 1
+		}
+		if run > r.maxRun {
+			return Decision{Verdict: r.verdict, Reason: fmt.Sprintf("repeats %q more than %d times in a row", c, r.maxRun)}, nil
+		}
+	}
+	return Decision{Verdict: Allow}, nil
+}
+
+//RecentTweetsFunc returns the tweets an author posted since a given time
+type RecentTweetsFunc func(ctx context.Context, author string, since time.Time) ([]*model.Tweet, error)
+
+type duplicateText struct {
+	recent  RecentTweetsFunc
+	window  time.Duration
+	verdict Verdict
+	now     func() time.Time
+}
+
+//DuplicateText catches an author posting the same text again within window. Case and spacing are ignored
+func DuplicateText(recent RecentTweetsFunc, window time.Duration, verdict Verdict) Rule {
+	return &duplicateText{recent: recent, window: window, verdict: verdict, now: time.Now}
+}
+
+func (r *duplicateText) Name() string { return DuplicateTextRule }
+
+//shortDuration prints 24h instead of the 24h0m0s of time.Duration
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+func sameText(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+func (r *duplicateText) Check(ctx context.Context, tweet *model.Tweet) (Decision, error) {
+	tweets, err := r.recent(ctx, tweet.Author, r.now().Add(-r.window))
+	if err != nil {
+		return Decision{}, err
+	}
+	text := sameText(tweet.Text)
+	for _, t := range tweets {
+		//saving a tweet again with the same id replaces it, it is not a duplicate
+		if t.Id != tweet.Id && sameText(t.Text) == text {
+			return Decision{Verdict: r.verdict, Reason: fmt.Sprintf("the same text was already posted in the last %s", shortDuration(r.window))}, nil
+		}
+	}
+	return Decision{Verdict: Allow}, nil
+}
@@ -0,0 +1,207 @@
+package tweetsseed
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//namespace of the generated ids. Together with the seed value it makes the ids the same on every run
+var idNamespace = uuid.MustParse("2b0f8a52-5d0e-4a1f-9a57-6c1e3e0f4d2a")
+
+//the default range is fixed(not relative to now) so the same seed always gives the same data
+var (
+	DefaultFrom = time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	DefaultTo   = time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type Options struct {
+	Users  int
+	Tweets int
+	Seed   int64
+	//timestamps are spread between From and To
+	From time.Time
+	To   time.Time
+	//ReplyRatio is the share of tweets that reply to an earlier tweet. Defaults to 0.35
+	ReplyRatio float64
+}
+
+//Dataset is what Generate produces. Every tweet is in the Tweets set of its author, and the Replies of a tweet match the tweets replying to it
+type Dataset struct {
+	Users  []*model.User  `json:"users"`
+	Tweets []*model.Tweet `json:"tweets"`
+}
+
+func (o *Options) applyDefaults() error {
+	if o.From.IsZero() {
+		o.From = DefaultFrom
+	}
+	if o.To.IsZero() {
+		o.To = DefaultTo
+	}
+	if o.ReplyRatio == 0 {
+		o.ReplyRatio = 0.35
+	}
+
+	if o.Users < 2 {
+		return errors.New("users must be at least 2")
+	}
+	if o.Tweets < 0 {
+		return errors.New("tweets cannot be negative")
+	}
+	if !o.From.Before(o.To) {
+		return errors.New("from must be before to")
+	}
+	if o.ReplyRatio < 0 || o.ReplyRatio >= 1 {
+		return errors.New("reply ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+//Generate builds a dataset from opts. The same options always give the same users, tweets, ids and timestamps
+func Generate(opts Options) (*Dataset, error) {
+	if err := opts.applyDefaults(); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	g := &generator{rng: rng, opts: opts, byId: make(map[string]*model.Tweet, opts.Tweets)}
+
+	users := g.users()
+	tweets := make([]*model.Tweet, 0, opts.Tweets)
+
+	//a few users write most of the tweets and get most of the likes, like on a real network
+	activity := rand.NewZipf(rng, 1.3, 2, uint64(len(users)-1))
+
+	for i, ts := range g.timestamps() {
+		author := users[activity.Uint64()]
+		tweet := &model.Tweet{
+			Id:        g.id("tweet", i),
+			Author:    author.Id,
+			Timestamp: model.ChirperAppUnixTime(ts),
+		}
+
+		var parent *model.Tweet
+		if len(tweets) > 0 && rng.Float64() < opts.ReplyRatio {
+			parent = g.parent(tweets)
+			tweet.ReplyingTo = parent.Id
+			parent.Replies = append(parent.Replies, tweet.Id)
+		}
+		tweet.Text = g.text(parent)
+		tweet.Likes = g.likes(users, author.Id, activity)
+
+		author.Tweets = append(author.Tweets, tweet.Id)
+		tweets = append(tweets, tweet)
+		g.byId[tweet.Id] = tweet
+	}
+
+	for _, t := range tweets {
+		sort.Strings(t.Replies)
+	}
+	for _, u := range users {
+		sort.Strings(u.Tweets)
+	}
+	return &Dataset{Users: users, Tweets: tweets}, nil
+}
+
+type generator struct {
+	rng  *rand.Rand
+	opts Options
+	byId map[string]*model.Tweet
+}
+
+func (g *generator) id(kind string, i int) string {
+	return uuid.NewSHA1(idNamespace, []byte(fmt.Sprintf("%d:%s:%d", g.opts.Seed, kind, i))).String()
+}
+
+//users get handles like quiet_otter_42. The index is appended so handles never collide
+func (g *generator) users() []*model.User {
+	users := make([]*model.User, g.opts.Users)
+	for i := range users {
+		adjective := adjectives[g.rng.Intn(len(adjectives))]
+		animal := animals[g.rng.Intn(len(animals))]
+		users[i] = &model.User{
+			Id:   fmt.Sprintf("%s_%s_%d", adjective, animal, i),
+			Name: capitalize(adjective) + " " + capitalize(animal),
+		}
+	}
+	return users
+}
+
+//timestamps are uniformly spread over the range and sorted, so a reply always comes after its parent
+func (g *generator) timestamps() []time.Time {
+	span := g.opts.To.Sub(g.opts.From).Milliseconds()
+	ts := make([]time.Time, g.opts.Tweets)
+	for i := range ts {
+		ts[i] = g.opts.From.Add(time.Duration(g.rng.Int63n(span)) * time.Millisecond)
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Before(ts[j]) })
+	return ts
+}
+
+//parent favours recent tweets and tweets that already have replies, which gives threads a few levels deep instead of a flat list
+func (g *generator) parent(tweets []*model.Tweet) *model.Tweet {
+	//a tweet from the last quarter of the timeline most of the time
+	window := len(tweets)/4 + 1
+	candidate := tweets[len(tweets)-1-g.rng.Intn(window)]
+	if g.rng.Float64() < 0.3 {
+		candidate = tweets[g.rng.Intn(len(tweets))]
+	}
+	if len(candidate.Replies) > 0 && g.rng.Float64() < 0.5 {
+		//join the conversation under one of the replies
+		return g.byId[candidate.Replies[g.rng.Intn(len(candidate.Replies))]]
+	}
+	return candidate
+}
+
+func (g *generator) text(parent *model.Tweet) string {
+	var b strings.Builder
+	if parent != nil {
+		b.WriteString("@" + parent.Author + " ")
+		b.WriteString(replies[g.rng.Intn(len(replies))])
+	} else {
+		b.WriteString(openers[g.rng.Intn(len(openers))])
+		b.WriteString(" ")
+		b.WriteString(topics[g.rng.Intn(len(topics))])
+		b.WriteString(endings[g.rng.Intn(len(endings))])
+	}
+
+	//about half the tweets carry one or two hashtags
+	for n := g.rng.Intn(4) - 1; n > 0; n-- {
+		b.WriteString(" #" + hashtags[g.rng.Intn(len(hashtags))])
+	}
+	return b.String()
+}
+
+//likes follow the same popularity curve as authors. Most tweets get a handful, a few get a lot. Authors don't like their own tweets
+func (g *generator) likes(users []*model.User, author string, activity *rand.Zipf) []string {
+	n := g.rng.Intn(3)
+	if g.rng.Float64() < 0.1 {
+		n += g.rng.Intn(len(users))
+	}
+
+	liked := make(map[string]bool, n)
+	for attempts := 0; len(liked) < n && attempts < n*4; attempts++ {
+		u := users[activity.Uint64()].Id
+		if u != author {
+			liked[u] = true
+		}
+	}
+
+	likes := make([]string, 0, len(liked))
+	for u := range liked {
+		likes = append(likes, u)
+	}
+	sort.Strings(likes)
+	return likes
+}
+
+func capitalize(word string) string {
+	return strings.ToUpper(word[:1]) + word[1:]
+}
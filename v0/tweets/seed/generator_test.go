@@ -0,0 +1,107 @@
+package tweetsseed
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+func Test_Generate_IsDeterministic(t *testing.T) {
+	opts := Options{Users: 20, Tweets: 300, Seed: 42}
+
+	a, err := Generate(opts)
+	require.NoError(t, err)
+	b, err := Generate(opts)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	c, err := Generate(Options{Users: 20, Tweets: 300, Seed: 43})
+	require.NoError(t, err)
+	assert.NotEqual(t, a.Tweets[0].Id, c.Tweets[0].Id)
+}
+
+func Test_Generate_IsConsistent(t *testing.T) {
+	from := time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(7 * 24 * time.Hour)
+	dataset, err := Generate(Options{Users: 10, Tweets: 500, Seed: 7, From: from, To: to})
+	require.NoError(t, err)
+	require.Len(t, dataset.Users, 10)
+	require.Len(t, dataset.Tweets, 500)
+
+	users := map[string]*model.User{}
+	for _, u := range dataset.Users {
+		users[u.Id] = u
+	}
+	tweets := map[string]*model.Tweet{}
+	for _, tweet := range dataset.Tweets {
+		tweets[tweet.Id] = tweet
+	}
+
+	var replies, liked, hashtags, deep int
+	for _, tweet := range dataset.Tweets {
+		require.Contains(t, users, tweet.Author)
+		assert.Contains(t, users[tweet.Author].Tweets, tweet.Id)
+		assert.NotContains(t, tweet.Likes, tweet.Author)
+		assert.LessOrEqual(t, utf8.RuneCountInString(tweet.Text), 280)
+
+		ts := time.Time(tweet.Timestamp)
+		assert.False(t, ts.Before(from) || ts.After(to), "timestamp out of range")
+
+		if tweet.ReplyingTo != "" {
+			replies++
+			parent := tweets[tweet.ReplyingTo]
+			require.NotNil(t, parent)
+			assert.Contains(t, parent.Replies, tweet.Id)
+			assert.False(t, ts.Before(time.Time(parent.Timestamp)), "reply before its parent")
+			if parent.ReplyingTo != "" {
+				deep++
+			}
+		}
+		if len(tweet.Likes) > 0 {
+			liked++
+		}
+		for _, r := range []rune(tweet.Text) {
+			if r == '#' {
+				hashtags++
+				break
+			}
+		}
+	}
+
+	assert.Greater(t, replies, 0)
+	assert.Greater(t, deep, 0, "expected threads deeper than one level")
+	assert.Greater(t, liked, 0)
+	assert.Greater(t, hashtags, 0)
+}
+
+func Test_Generate_ValidatesOptions(t *testing.T) {
+	_, err := Generate(Options{Users: 1, Tweets: 10})
+	assert.Equal(t, errors.New("users must be at least 2"), err)
+
+	_, err = Generate(Options{Users: 5, Tweets: 10, From: DefaultTo, To: DefaultFrom})
+	assert.Equal(t, errors.New("from must be before to"), err)
+}
+
+func Test_Write(t *testing.T) {
+	dataset, err := Generate(Options{Users: 5, Tweets: 60, Seed: 1})
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	tweetsServiceMock := tweetsservice.NewMockService(ctrl)
+	gomock.InOrder(
+		tweetsServiceMock.EXPECT().BulkSaveUsers(gomock.Any(), dataset.Users).Times(1).Return(nil),
+		tweetsServiceMock.EXPECT().BulkSaveTweet(gomock.Any(), gomock.Len(25)).Times(2).Return(nil),
+		tweetsServiceMock.EXPECT().BulkSaveTweet(gomock.Any(), gomock.Len(10)).Times(1).Return(nil),
+	)
+
+	assert.NoError(t, Write(context.Background(), tweetsServiceMock, dataset))
+}
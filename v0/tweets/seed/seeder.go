@@ -0,0 +1,26 @@
+package tweetsseed
+
+import (
+	"context"
+
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//Write saves the users and then the tweets of a dataset. Tweets go through BulkSaveTweet, so they get the same validation as an import.
+//Ids are deterministic, so writing the same dataset again replaces it instead of adding to it
+func Write(ctx context.Context, tweetsService tweetsservice.Service, dataset *Dataset) error {
+	if err := tweetsService.BulkSaveUsers(ctx, dataset.Users); err != nil {
+		return err
+	}
+
+	for _, batch := range model.Batches(dataset.Tweets, model.MaxBatchSize) {
+		if len(batch) == 0 {
+			continue
+		}
+		if err := tweetsService.BulkSaveTweet(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
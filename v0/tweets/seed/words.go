@@ -0,0 +1,37 @@
+package tweetsseed
+
+var adjectives = []string{
+	"quiet", "brave", "curious", "sleepy", "happy", "clever", "gentle", "rapid", "lucky", "witty",
+	"calm", "bold", "sunny", "fuzzy", "mighty", "humble", "eager", "jolly", "nimble", "cosmic",
+}
+
+var animals = []string{
+	"otter", "falcon", "panda", "koala", "fox", "badger", "heron", "lynx", "moose", "gecko",
+	"walrus", "tapir", "ibis", "yak", "lemur", "quokka", "narwhal", "puffin", "bison", "okapi",
+}
+
+var openers = []string{
+	"Just shipped", "Hot take:", "Spent the whole day on", "Can't stop thinking about", "Finally understood",
+	"Writing a thread about", "Today I learned about", "Unpopular opinion:", "Loving", "Struggling with",
+}
+
+var topics = []string{
+	"server components", "DynamoDB single table design", "gRPC streaming", "CSS grid", "Go generics",
+	"the new React docs", "TypeScript enums", "Kubernetes operators", "WebAssembly", "edge functions",
+	"accessibility audits", "property based testing", "feature flags", "database migrations", "code reviews",
+}
+
+var endings = []string{
+	".", "!", " and it was worth it.", " and I have questions.", ". Thoughts?", " 🚀", ". More soon.", " 😅",
+}
+
+var replies = []string{
+	"Totally agree with this.", "I had the exact same experience!", "Could you share more details?",
+	"This is a great idea.", "Not sure I agree, but I see your point.", "Bookmarking this.",
+	"Ha, this made my day.", "We tried this at work and it went really well.", "Any links to read more?",
+	"Congrats! 🎉",
+}
+
+var hashtags = []string{
+	"golang", "reactjs", "javascript", "dynamodb", "aws", "webdev", "100DaysOfCode", "opensource", "devops", "css",
+}
@@ -0,0 +1,99 @@
+package tweetstext
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	DefaultMaxLength = 280
+	//URLWeight is what a link counts for whatever its length, like the t.co links of Twitter
+	URLWeight = 23
+)
+
+//urlPattern finds the links that count as URLWeight. Trailing punctuation is not part of the link, see Length
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"]+`)
+
+//mentionPattern finds @handles. The @ can't follow a letter or digit so emails are not mentions
+var mentionPattern = regexp.MustCompile(`(?:^|[^\pL\pN_@])@([A-Za-z0-9_]{1,30})`)
+
+//Normalize is applied to the text of every tweet before it is checked and saved: Unicode NFC(so é is always the same
+//code point), \r\n and the unicode line separators become \n, control characters and invisible characters(zero width
+//spaces, bidi overrides, fillers...) are dropped, and the spaces around the text are trimmed
+func Normalize(s string) string {
+	s = norm.NFC.String(strings.ReplaceAll(s, "\r\n", "\n"))
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r == '\u2028' || r == '\u2029':
+			b.WriteRune('\n')
+		case keep(r):
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func keep(r rune) bool {
+	switch {
+	case r == '\n' || r == '\t':
+		return true
+	case r == unicode.ReplacementChar:
+		//what ranging over invalid UTF-8 gives
+		return false
+	case r == '\u200c' || r == '\u200d':
+		//the joiners are invisible but build emoji sequences(👩‍💻) and are needed by scripts like Persian
+		return true
+	case r >= '\U000e0020' && r <= '\U000e007f':
+		//tags spell the subdivision flags, eg 🏴󠁧󠁢󠁳󠁣󠁴󠁿
+		return true
+	case r == '\u115f' || r == '\u1160' || r == '\u3164' || r == '\uffa0':
+		//Hangul fillers render as blanks. They are the usual way to post a tweet that looks empty
+		return false
+	}
+	return !unicode.In(r, unicode.Cc, unicode.Cf, unicode.Co, unicode.Cs)
+}
+
+//Links returns the links in s, the same ones Length counts as URLWeight
+func Links(s string) []string {
+	var links []string
+	for _, link := range urlPattern.FindAllString(s, -1) {
+		links = append(links, trimLink(link))
+	}
+	return links
+}
+
+//Mentions returns the handles @mentioned in s, without the @ and without duplicates, in the order they first appear
+func Mentions(s string) []string {
+	var handles []string
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(s, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			handles = append(handles, m[1])
+		}
+	}
+	return handles
+}
+
+func trimLink(link string) string {
+	return strings.TrimRight(link, ".,;:!?)]}'\"")
+}
+
+//Length is the length of s the way users count it: a grapheme cluster(eg 👍🏽 or é written as e + ◌́) is one character
+//and every link is URLWeight characters
+func Length(s string) int {
+	n, last := 0, 0
+	for _, loc := range urlPattern.FindAllStringIndex(s, -1) {
+		end := loc[0] + len(trimLink(s[loc[0]:loc[1]]))
+		n += uniseg.GraphemeClusterCount(s[last:loc[0]]) + URLWeight
+		last = end
+	}
+	return n + uniseg.GraphemeClusterCount(s[last:])
+}
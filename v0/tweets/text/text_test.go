@@ -0,0 +1,68 @@
+package tweetstext
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Normalize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "should compose accents(NFC)", text: "Cafe\u0301", expected: "Caf\u00e9"},
+		{name: "should trim the spaces around the text", text: " \n hello \t", expected: "hello"},
+		{name: "should keep the new lines and turn the other line breaks into them", text: "one\r\ntwo\u2028three", expected: "one\ntwo\nthree"},
+		{name: "should drop control characters", text: "bell\u0007 and\u0000 null", expected: "bell and null"},
+		{name: "should drop invisible characters", text: "zero\u200bwidth \u202eoverride\u2066 \ufeffbom\u3164", expected: "zerowidth override bom"},
+		{name: "should keep the joiners of emoji sequences", text: "\U0001F469\u200d\U0001F4BB", expected: "\U0001F469\u200d\U0001F4BB"},
+		{name: "should keep the tags of subdivision flags", text: "\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F", expected: "\U0001F3F4\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F"},
+		{name: "should drop invalid UTF-8", text: "bad\xff byte", expected: "bad byte"},
+		{name: "should be empty when nothing is visible", text: "\u200b\u3164 \u2060", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Normalize(tc.text))
+		})
+	}
+}
+
+func Test_Length(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{name: "should count ascii characters", text: "hello world", expected: 11},
+		{name: "should count an emoji with a skin tone as one", text: "\U0001F44D\U0001F3FD", expected: 1},
+		{name: "should count a family emoji as one", text: "\U0001F468\u200d\U0001F469\u200d\U0001F467", expected: 1},
+		{name: "should count a flag as one", text: "\U0001F1F3\U0001F1EC", expected: 1},
+		{name: "should count a letter and its combining accent as one", text: "e\u0301", expected: 1},
+		{name: "should count a link as URLWeight", text: "read https://example.com/a/very/long/path?with=query", expected: 5 + URLWeight},
+		{name: "should count a short link as URLWeight too", text: "http://a.co", expected: URLWeight},
+		{name: "should count a www link", text: "www.example.com", expected: URLWeight},
+		{name: "should not count the punctuation after a link as part of it", text: "see https://example.com.", expected: 4 + URLWeight + 1},
+		{name: "should count every link", text: "https://a.com https://b.com", expected: 2*URLWeight + 1},
+		{name: "should count a long text", text: strings.Repeat("a", 300), expected: 300},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Length(tc.text))
+		})
+	}
+}
+
+func Test_Links(t *testing.T) {
+	assert.Equal(t, []string{"https://example.com/a", "www.b.co"}, Links("see https://example.com/a, and (www.b.co)"))
+	assert.Nil(t, Links("no links here"))
+}
+
+func Test_Mentions(t *testing.T) {
+	assert.Equal(t, []string{"sarah_edo", "tylermcginnis"}, Mentions("@sarah_edo thoughts? cc @tylermcginnis, @sarah_edo"))
+	assert.Nil(t, Mentions("mail me at dan@example.com"))
+}
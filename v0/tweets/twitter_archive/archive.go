@@ -0,0 +1,174 @@
+package twitterarchive
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+//twitterDateLayout is the layout of `created_at` in the archive. eg: "Wed Oct 10 20:19:24 +0000 2018"
+const twitterDateLayout = time.RubyDate
+
+//namespace used to derive chirper ids from twitter ids. The same archive always maps to the same ids, so importing it twice replaces the tweets instead of duplicating them
+var idNamespace = uuid.MustParse("6f1c7a4e-3a0e-4c55-9f53-2a3b6d0b9c11")
+
+//ArchiveTweet is the part of a tweet in the official Twitter data archive(data/tweets.js) that we care about
+type ArchiveTweet struct {
+	IdStr                string   `json:"id_str"`
+	FullText             string   `json:"full_text"`
+	CreatedAt            string   `json:"created_at"`
+	InReplyToStatusIdStr string   `json:"in_reply_to_status_id_str"`
+	Entities             Entities `json:"entities"`
+}
+
+//Entities are the parts of the text twitter annotated. Hashtags and mentions are already plain text in full_text so we only need the links
+type Entities struct {
+	Urls []struct {
+		Url         string `json:"url"`
+		ExpandedUrl string `json:"expanded_url"`
+	} `json:"urls"`
+	Media []struct {
+		Url string `json:"url"`
+	} `json:"media"`
+}
+
+//archiveEntry covers both archive layouts. Newer archives wrap every tweet in {"tweet": {...}} while older ones don't
+type archiveEntry struct {
+	Tweet *ArchiveTweet `json:"tweet"`
+	ArchiveTweet
+}
+
+//Parse reads a tweets.js file. The file is JavaScript not JSON; the array is assigned to a global like `window.YTD.tweets.part0 = [...]`
+func Parse(r io.Reader) ([]*ArchiveTweet, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	b = bytes.TrimSpace(b)
+	if start := bytes.IndexByte(b, '['); start > 0 {
+		//anything before the array must be the `window.YTD.tweets.partN =` wrapper
+		if !bytes.Contains(b[:start], []byte("=")) {
+			return nil, errors.New("unrecognized twitter archive format. Expected `window.YTD.tweets.part0 = [...]`")
+		}
+		b = b[start:]
+	}
+	b = bytes.TrimSuffix(b, []byte(";"))
+
+	var entries []archiveEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("unable to parse twitter archive: %w", err)
+	}
+
+	tweets := make([]*ArchiveTweet, 0, len(entries))
+	for i := range entries {
+		if entries[i].Tweet != nil {
+			tweets = append(tweets, entries[i].Tweet)
+		} else {
+			tweets = append(tweets, &entries[i].ArchiveTweet)
+		}
+	}
+	return tweets, nil
+}
+
+type MapOptions struct {
+	//the chirper user the tweets will belong to
+	Author string
+	//retweets are someone else's content, so we leave them out unless asked to
+	IncludeRetweets bool
+}
+
+//Map converts archive tweets into chirper tweets. Replies to tweets that are also in the archive are linked using the remapped ids on both sides
+func Map(archive []*ArchiveTweet, opts MapOptions) ([]*model.Tweet, *Report) {
+	report := &Report{Total: len(archive), IdMap: map[string]string{}}
+
+	tweets := make([]*model.Tweet, 0, len(archive))
+	byTwitterId := make(map[string]*model.Tweet, len(archive))
+	replyTo := make(map[*model.Tweet]string)
+
+	for _, at := range archive {
+		if at.IdStr == "" {
+			report.skip(at.IdStr, "missing id_str")
+			continue
+		}
+		if !opts.IncludeRetweets && strings.HasPrefix(at.FullText, "RT @") {
+			report.skip(at.IdStr, "retweet")
+			continue
+		}
+
+		createdAt, err := time.Parse(twitterDateLayout, at.CreatedAt)
+		if err != nil {
+			report.skip(at.IdStr, fmt.Sprintf("invalid created_at %q", at.CreatedAt))
+			continue
+		}
+
+		t := &model.Tweet{
+			Id:        ChirperId(at.IdStr),
+			Author:    opts.Author,
+			Text:      expandText(at),
+			Timestamp: model.ChirperAppUnixTime(createdAt),
+		}
+		tweets = append(tweets, t)
+		byTwitterId[at.IdStr] = t
+		report.IdMap[at.IdStr] = t.Id
+
+		if at.InReplyToStatusIdStr != "" {
+			replyTo[t] = at.InReplyToStatusIdStr
+		}
+	}
+
+	for _, t := range tweets {
+		parentId, ok := replyTo[t]
+		if !ok {
+			continue
+		}
+
+		parent, found := byTwitterId[parentId]
+		if !found {
+			//a reply to someone else's tweet(or a tweet that was deleted). It becomes a top level tweet
+			report.UnresolvedReplies++
+			continue
+		}
+		t.ReplyingTo = parent.Id
+		parent.Replies = append(parent.Replies, t.Id)
+		report.RepliesLinked++
+	}
+
+	for _, t := range tweets {
+		sort.Strings(t.Replies)
+	}
+
+	report.Mapped = len(tweets)
+	return tweets, report
+}
+
+//ChirperId is the id a twitter status id is remapped to
+func ChirperId(twitterId string) string {
+	return uuid.NewSHA1(idNamespace, []byte("twitter:"+twitterId)).String()
+}
+
+//expandText replaces the t.co links with the urls they point to. Media links are dropped since we don't import media
+func expandText(at *ArchiveTweet) string {
+	text := html.UnescapeString(at.FullText) //the archive keeps the html escaping of the twitter api. eg: &amp;
+
+	for _, u := range at.Entities.Urls {
+		if u.Url != "" && u.ExpandedUrl != "" {
+			text = strings.ReplaceAll(text, u.Url, u.ExpandedUrl)
+		}
+	}
+	for _, m := range at.Entities.Media {
+		if m.Url != "" {
+			text = strings.ReplaceAll(text, m.Url, "")
+		}
+	}
+	return strings.TrimSpace(text)
+}
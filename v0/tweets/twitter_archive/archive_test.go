@@ -0,0 +1,178 @@
+package twitterarchive
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeArchive = `window.YTD.tweets.part0 = [
+  {
+    "tweet" : {
+      "id_str" : "1050118621198921728",
+      "full_text" : "Shoutout to all the speakers &amp; organizers https://t.co/abc https://t.co/media",
+      "created_at" : "Wed Oct 10 20:19:24 +0000 2018",
+      "entities" : {
+        "hashtags" : [ ],
+        "urls" : [ { "url" : "https://t.co/abc", "expanded_url" : "https://jsconf.com" } ],
+        "media" : [ { "url" : "https://t.co/media" } ]
+      }
+    }
+  },
+  {
+    "tweet" : {
+      "id_str" : "1050118621198921729",
+      "full_text" : "@sarah_edo agreed!",
+      "created_at" : "Wed Oct 10 20:25:00 +0000 2018",
+      "in_reply_to_status_id_str" : "1050118621198921728",
+      "entities" : { }
+    }
+  },
+  {
+    "tweet" : {
+      "id_str" : "1050118621198921730",
+      "full_text" : "@dan_abramov this is a great idea",
+      "created_at" : "Thu Oct 11 08:00:00 +0000 2018",
+      "in_reply_to_status_id_str" : "999",
+      "entities" : { }
+    }
+  },
+  {
+    "tweet" : {
+      "id_str" : "1050118621198921731",
+      "full_text" : "RT @tylermcginnis: new course out",
+      "created_at" : "Thu Oct 11 09:00:00 +0000 2018"
+    }
+  },
+  {
+    "tweet" : {
+      "id_str" : "1050118621198921732",
+      "full_text" : "bad date",
+      "created_at" : "2018-10-11"
+    }
+  }
+]`
+
+func Test_Parse(t *testing.T) {
+	archive, err := Parse(strings.NewReader(fakeArchive))
+	require.NoError(t, err)
+	require.Len(t, archive, 5)
+	assert.Equal(t, "1050118621198921728", archive[0].IdStr)
+
+	//older archives don't wrap each tweet
+	archive, err = Parse(strings.NewReader(`window.YTD.tweet.part0 = [{"id_str": "1", "full_text": "hi", "created_at": "Wed Oct 10 20:19:24 +0000 2018"}];`))
+	require.NoError(t, err)
+	require.Len(t, archive, 1)
+	assert.Equal(t, "1", archive[0].IdStr)
+
+	_, err = Parse(strings.NewReader(`{"not": "an archive"}`))
+	assert.Error(t, err)
+}
+
+func Test_Map(t *testing.T) {
+	archive, err := Parse(strings.NewReader(fakeArchive))
+	require.NoError(t, err)
+
+	tweets, report := Map(archive, MapOptions{Author: "sarah_edo"})
+	require.Len(t, tweets, 3)
+
+	root, reply, orphan := tweets[0], tweets[1], tweets[2]
+
+	assert.Equal(t, ChirperId("1050118621198921728"), root.Id)
+	assert.Equal(t, "sarah_edo", root.Author)
+	assert.Equal(t, "Shoutout to all the speakers & organizers https://jsconf.com", root.Text)
+	assert.Equal(t, time.Date(2018, 10, 10, 20, 19, 24, 0, time.UTC).Unix(), time.Time(root.Timestamp).Unix())
+	assert.Equal(t, []string{reply.Id}, root.Replies)
+
+	assert.Equal(t, root.Id, reply.ReplyingTo)
+	assert.Equal(t, "", orphan.ReplyingTo)
+
+	assert.Equal(t, 5, report.Total)
+	assert.Equal(t, 3, report.Mapped)
+	assert.Equal(t, 1, report.RepliesLinked)
+	assert.Equal(t, 1, report.UnresolvedReplies)
+	assert.Equal(t, []SkippedTweet{
+		{TwitterId: "1050118621198921731", Reason: "retweet"},
+		{TwitterId: "1050118621198921732", Reason: `invalid created_at "2018-10-11"`},
+	}, report.Skipped)
+
+	//ids are stable between imports
+	again, _ := Map(archive, MapOptions{Author: "sarah_edo"})
+	assert.Equal(t, root.Id, again[0].Id)
+}
+
+func Test_Import(t *testing.T) {
+	testCases := []struct {
+		name       string
+		opts       ImportOptions
+		buildStubs func(tweetsService *tweetsservice.MockService)
+
+		expectedSaved int
+		expectedError error
+	}{
+		{
+			name: "dry run does not save",
+			opts: ImportOptions{MapOptions: MapOptions{Author: "sarah_edo"}, DryRun: true},
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().BulkSaveTweet(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedSaved: 0,
+		},
+		{
+			name: "saves through the bulk path",
+			opts: ImportOptions{MapOptions: MapOptions{Author: "sarah_edo"}},
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().BulkSaveTweet(gomock.Any(), gomock.Len(3)).Times(1).Return(nil)
+			},
+			expectedSaved: 3,
+		},
+		{
+			name: "returns the service error",
+			opts: ImportOptions{MapOptions: MapOptions{Author: "sarah_edo"}},
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().BulkSaveTweet(gomock.Any(), gomock.Any()).Times(1).Return(errors.New("repo error"))
+			},
+			expectedError: errors.New("repo error"),
+		},
+		{
+			name: "counts only the tweets written",
+			opts: ImportOptions{MapOptions: MapOptions{Author: "sarah_edo"}},
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().BulkSaveTweet(gomock.Any(), gomock.Len(3)).Times(1).Return(&tweetsservice.BulkSaveError{Saved: 2, Err: errors.New("1 tweets were not written")})
+			},
+			expectedSaved: 2,
+			expectedError: &tweetsservice.BulkSaveError{Saved: 2, Err: errors.New("1 tweets were not written")},
+		},
+		{
+			name: "author is required",
+			opts: ImportOptions{},
+			buildStubs: func(tweetsService *tweetsservice.MockService) {
+				tweetsService.EXPECT().BulkSaveTweet(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expectedError: errors.New("author is required"),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			tweetsServiceMock := tweetsservice.NewMockService(ctrl)
+			tc.buildStubs(tweetsServiceMock)
+
+			report, err := NewImporter(tweetsServiceMock).Import(context.Background(), strings.NewReader(fakeArchive), tc.opts)
+			assert.Equal(t, tc.expectedError, err)
+			if report != nil {
+				assert.Equal(t, tc.expectedSaved, report.Saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package twitterarchive
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	tweetsservice "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/business_logic"
+	model "github.com/okpalaChidiebere/chirper-app-api-tweet/v0/tweets/model"
+)
+
+type Report struct {
+	DryRun            bool              `json:"dryRun"`
+	Total             int               `json:"total"`
+	Mapped            int               `json:"mapped"` //tweets that made it through the mapping. On a dry run this is what would be saved
+	Saved             int               `json:"saved"`
+	RepliesLinked     int               `json:"repliesLinked"`
+	UnresolvedReplies int               `json:"unresolvedReplies"`
+	Skipped           []SkippedTweet    `json:"skipped,omitempty"`
+	IdMap             map[string]string `json:"idMap,omitempty"` //twitter id -> chirper id
+}
+
+type SkippedTweet struct {
+	TwitterId string `json:"twitterId"`
+	Reason    string `json:"reason"`
+}
+
+func (r *Report) skip(twitterId, reason string) {
+	r.Skipped = append(r.Skipped, SkippedTweet{TwitterId: twitterId, Reason: reason})
+}
+
+type ImportOptions struct {
+	MapOptions
+	//when true, the archive is parsed and mapped but nothing is written
+	DryRun bool
+}
+
+type Importer struct {
+	tweetsService tweetsservice.Service
+}
+
+func NewImporter(tweetsService tweetsservice.Service) *Importer {
+	return &Importer{tweetsService: tweetsService}
+}
+
+//Import reads a tweets.js archive and saves the tweets through the bulk save path
+func (i *Importer) Import(ctx context.Context, r io.Reader, opts ImportOptions) (*Report, error) {
+	if opts.Author == "" {
+		return nil, errors.New("author is required")
+	}
+
+	archive, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	tweets, report := Map(archive, opts.MapOptions)
+	report.DryRun = opts.DryRun
+	if opts.DryRun || len(tweets) == 0 {
+		return report, nil
+	}
+
+	for _, batch := range model.Batches(tweets, model.MaxBatchSize) {
+		if err := i.tweetsService.BulkSaveTweet(ctx, batch); err != nil {
+			//only what was written counts as saved, eg not the tweets DynamoDB kept throttling
+			var partial *tweetsservice.BulkSaveError
+			if errors.As(err, &partial) {
+				report.Saved += partial.Saved
+			}
+			return report, err
+		}
+		report.Saved += len(batch)
+	}
+	return report, nil
+}